@@ -364,31 +364,32 @@ func init() {
 func init() { proto.RegisterFile("authn.proto", fileDescriptor_b40bfba985381dd1) }
 
 var fileDescriptor_b40bfba985381dd1 = []byte{
-	// 381 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x51, 0xcd, 0x4e, 0xb3, 0x40,
-	0x14, 0x85, 0x7e, 0x5f, 0x7f, 0xbe, 0xfb, 0x95, 0xaa, 0x37, 0xa6, 0x12, 0x8c, 0xd8, 0xcc, 0xaa,
-	0x2b, 0x6a, 0xaa, 0x6e, 0x35, 0xad, 0xb8, 0x20, 0x26, 0x2e, 0x6a, 0x7d, 0x00, 0x4a, 0xa7, 0x85,
-	0x48, 0xa1, 0x96, 0xa1, 0x91, 0x37, 0xf1, 0x89, 0x8c, 0x4b, 0x1f, 0xc1, 0xd4, 0x17, 0x31, 0x0c,
-	0x4c, 0x8a, 0x5a, 0x8d, 0x3b, 0xce, 0xe5, 0x9e, 0x73, 0xe6, 0x9e, 0x03, 0xff, 0xed, 0x98, 0xb9,
-	0x81, 0x31, 0x5f, 0x84, 0x2c, 0xc4, 0xda, 0xcc, 0xf6, 0x82, 0x89, 0x1f, 0x3f, 0x68, 0xfb, 0xd3,
-	0x30, 0x9c, 0xfa, 0xb4, 0xc3, 0xe7, 0xa3, 0x78, 0xd2, 0xa1, 0xb3, 0x39, 0x4b, 0xb2, 0x35, 0x72,
-	0x06, 0x8d, 0x9e, 0xe3, 0xd0, 0x28, 0xea, 0x27, 0x57, 0x34, 0x19, 0xd0, 0x7b, 0xdc, 0x85, 0x32,
-	0x0b, 0xef, 0x68, 0xa0, 0xca, 0x2d, 0xb9, 0xfd, 0x6f, 0x90, 0x01, 0x6c, 0x42, 0xc5, 0x71, 0xed,
-	0xc0, 0x32, 0xd5, 0x12, 0x1f, 0xe7, 0x88, 0x1c, 0x42, 0x75, 0xe8, 0x7a, 0xc1, 0xd4, 0x32, 0x53,
-	0xe2, 0xd2, 0xf6, 0x63, 0x2a, 0x88, 0x1c, 0x90, 0x1e, 0x28, 0xc2, 0xc0, 0x32, 0x53, 0x7d, 0x15,
-	0xaa, 0x2c, 0x63, 0xe4, 0x8b, 0x02, 0x7e, 0xeb, 0x71, 0x00, 0xe5, 0x21, 0x7f, 0xc4, 0x66, 0x87,
-	0x13, 0xa8, 0xdf, 0x46, 0x74, 0x61, 0x8d, 0x69, 0xc0, 0x3c, 0x96, 0x60, 0x03, 0x4a, 0xde, 0x38,
-	0x5f, 0x29, 0x79, 0xe3, 0x94, 0x45, 0x67, 0xb6, 0xe7, 0xe7, 0xaa, 0x19, 0x20, 0x26, 0xd4, 0xac,
-	0x28, 0x8a, 0x69, 0xfa, 0xa4, 0x5f, 0x31, 0x10, 0xe1, 0x2f, 0x4b, 0xe6, 0x54, 0xfd, 0xd3, 0x92,
-	0xdb, 0xca, 0x80, 0x7f, 0x77, 0x9f, 0x64, 0x50, 0xf8, 0xfd, 0xd1, 0x0d, 0x5d, 0x2c, 0x3d, 0x87,
-	0xe2, 0x39, 0x34, 0x2e, 0xec, 0xa0, 0x90, 0x29, 0xaa, 0x86, 0xa8, 0xc2, 0xf8, 0x18, 0xb5, 0xb6,
-	0xb3, 0xfe, 0x93, 0x87, 0x48, 0x24, 0xec, 0x83, 0x52, 0x10, 0xb0, 0x4c, 0xdc, 0xfb, 0xca, 0xe7,
-	0x49, 0x6a, 0x4d, 0x23, 0x6b, 0xd6, 0x10, 0xcd, 0x1a, 0x97, 0x69, 0xb3, 0x44, 0xc2, 0x23, 0xa8,
-	0x65, 0x71, 0x4c, 0x12, 0xdc, 0x2a, 0x98, 0xa4, 0x29, 0x6e, 0x74, 0xed, 0xc6, 0x50, 0xef, 0xc5,
-	0xcc, 0xbd, 0x16, 0x67, 0x18, 0x50, 0xe6, 0xf1, 0x20, 0xae, 0xb7, 0x45, 0x5e, 0xda, 0x67, 0x49,
-	0x22, 0xe1, 0xe9, 0x4f, 0x8e, 0xcd, 0xf5, 0xa0, 0xd8, 0x14, 0x91, 0xfa, 0xdb, 0xcf, 0x2b, 0x5d,
-	0x7e, 0x59, 0xe9, 0xf2, 0xeb, 0x4a, 0x97, 0x1f, 0xdf, 0x74, 0x69, 0x54, 0xe1, 0xc7, 0x1c, 0xbf,
-	0x07, 0x00, 0x00, 0xff, 0xff, 0x76, 0x2f, 0x38, 0x1d, 0xcd, 0x02, 0x00, 0x00,
+	// 388 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x51, 0x5d, 0x4e, 0xf2, 0x50,
+	0x10, 0x6d, 0xe1, 0xe3, 0xe7, 0x1b, 0x29, 0xea, 0xc4, 0x60, 0x53, 0x63, 0x25, 0xf7, 0x89, 0xc4,
+	0xa4, 0x18, 0xd4, 0x57, 0x0d, 0x58, 0x1f, 0x1a, 0x13, 0x1f, 0x10, 0x17, 0x50, 0xca, 0x85, 0x36,
+	0x96, 0x16, 0xe9, 0x2d, 0xb1, 0x3b, 0x71, 0x49, 0x3e, 0xba, 0x04, 0x83, 0x4b, 0x70, 0x03, 0xa6,
+	0xb7, 0x6d, 0xa8, 0x82, 0xc6, 0xb7, 0x7b, 0xe6, 0xce, 0x99, 0x33, 0x73, 0x0e, 0x6c, 0x99, 0x21,
+	0xb3, 0x3d, 0x6d, 0x36, 0xf7, 0x99, 0x8f, 0xd5, 0xa9, 0xe9, 0x78, 0x63, 0x37, 0x7c, 0x52, 0x0e,
+	0x26, 0xbe, 0x3f, 0x71, 0x69, 0x9b, 0xd7, 0x87, 0xe1, 0xb8, 0x4d, 0xa7, 0x33, 0x16, 0x25, 0x6d,
+	0xe4, 0x02, 0xea, 0x5d, 0xcb, 0xa2, 0x41, 0xd0, 0x8b, 0x6e, 0x68, 0xd4, 0xa7, 0x8f, 0xb8, 0x07,
+	0x25, 0xe6, 0x3f, 0x50, 0x4f, 0x16, 0x9b, 0x62, 0xeb, 0x7f, 0x3f, 0x01, 0xd8, 0x80, 0xb2, 0x65,
+	0x9b, 0x9e, 0xa1, 0xcb, 0x05, 0x5e, 0x4e, 0x11, 0x39, 0x82, 0xca, 0xc0, 0x76, 0xbc, 0x89, 0xa1,
+	0xc7, 0xc4, 0x85, 0xe9, 0x86, 0x34, 0x23, 0x72, 0x40, 0xba, 0x20, 0x65, 0x02, 0x86, 0x1e, 0xcf,
+	0x97, 0xa1, 0xc2, 0x12, 0x46, 0xda, 0x98, 0xc1, 0x1f, 0x35, 0x0e, 0xa1, 0x34, 0xe0, 0x4b, 0x6c,
+	0x56, 0x38, 0x83, 0xda, 0x7d, 0x40, 0xe7, 0xc6, 0x88, 0x7a, 0xcc, 0x61, 0x11, 0xd6, 0xa1, 0xe0,
+	0x8c, 0xd2, 0x96, 0x82, 0x33, 0x8a, 0x59, 0x74, 0x6a, 0x3a, 0x6e, 0x3a, 0x35, 0x01, 0x44, 0x87,
+	0xaa, 0x11, 0x04, 0x21, 0x8d, 0x57, 0xfa, 0x13, 0x03, 0x11, 0xfe, 0xb1, 0x68, 0x46, 0xe5, 0x62,
+	0x53, 0x6c, 0x49, 0x7d, 0xfe, 0xee, 0x7c, 0x88, 0x20, 0xf1, 0xfb, 0x83, 0x3b, 0x3a, 0x5f, 0x38,
+	0x16, 0xc5, 0x4b, 0xa8, 0x5f, 0x99, 0x5e, 0xce, 0x53, 0x94, 0xb5, 0x2c, 0x0a, 0xed, 0xab, 0xd5,
+	0xca, 0xee, 0xea, 0x27, 0x35, 0x91, 0x08, 0xd8, 0x03, 0x29, 0x37, 0xc0, 0xd0, 0x71, 0x7f, 0x9d,
+	0xcf, 0x9d, 0x54, 0x1a, 0x5a, 0x92, 0xac, 0x96, 0x25, 0xab, 0x5d, 0xc7, 0xc9, 0x12, 0x01, 0x4f,
+	0xa0, 0x9a, 0xd8, 0x31, 0x8e, 0x70, 0x3b, 0x27, 0x12, 0xbb, 0xb8, 0x59, 0xf5, 0x18, 0x8a, 0xf1,
+	0xae, 0xeb, 0x7f, 0xca, 0x77, 0x3e, 0x11, 0x3a, 0x21, 0xd4, 0xba, 0x21, 0xb3, 0x6f, 0xb3, 0x9b,
+	0x35, 0x28, 0x71, 0x2f, 0x11, 0x57, 0xbd, 0x99, 0xb9, 0x1b, 0xf8, 0x78, 0xfe, 0xdb, 0x7a, 0x8d,
+	0x55, 0x21, 0x1f, 0x2b, 0x11, 0x7a, 0x3b, 0x2f, 0x4b, 0x55, 0x7c, 0x5d, 0xaa, 0xe2, 0xdb, 0x52,
+	0x15, 0x9f, 0xdf, 0x55, 0x61, 0x58, 0xe6, 0x97, 0x9f, 0x7e, 0x0e, 0x00, 0x14, 0x7d, 0x4e, 0x17,
+	0xfa, 0x02, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -406,6 +407,7 @@ type ThingsServiceClient interface {
 	CanAccessByKey(ctx context.Context, in *AccessByKeyReq, opts ...grpc.CallOption) (*ThingID, error)
 	CanAccessByID(ctx context.Context, in *AccessByIDReq, opts ...grpc.CallOption) (*empty.Empty, error)
 	Identify(ctx context.Context, in *Token, opts ...grpc.CallOption) (*ThingID, error)
+	Key(ctx context.Context, in *ThingID, opts ...grpc.CallOption) (*Token, error)
 }
 
 type thingsServiceClient struct {
@@ -443,11 +445,21 @@ func (c *thingsServiceClient) Identify(ctx context.Context, in *Token, opts ...g
 	return out, nil
 }
 
+func (c *thingsServiceClient) Key(ctx context.Context, in *ThingID, opts ...grpc.CallOption) (*Token, error) {
+	out := new(Token)
+	err := c.cc.Invoke(ctx, "/mainflux.ThingsService/Key", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ThingsServiceServer is the server API for ThingsService service.
 type ThingsServiceServer interface {
 	CanAccessByKey(context.Context, *AccessByKeyReq) (*ThingID, error)
 	CanAccessByID(context.Context, *AccessByIDReq) (*empty.Empty, error)
 	Identify(context.Context, *Token) (*ThingID, error)
+	Key(context.Context, *ThingID) (*Token, error)
 }
 
 // UnimplementedThingsServiceServer can be embedded to have forward compatible implementations.
@@ -463,6 +475,9 @@ func (*UnimplementedThingsServiceServer) CanAccessByID(ctx context.Context, req
 func (*UnimplementedThingsServiceServer) Identify(ctx context.Context, req *Token) (*ThingID, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Identify not implemented")
 }
+func (*UnimplementedThingsServiceServer) Key(ctx context.Context, req *ThingID) (*Token, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Key not implemented")
+}
 
 func RegisterThingsServiceServer(s *grpc.Server, srv ThingsServiceServer) {
 	s.RegisterService(&_ThingsService_serviceDesc, srv)
@@ -522,6 +537,24 @@ func _ThingsService_Identify_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ThingsService_Key_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ThingID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThingsServiceServer).Key(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/mainflux.ThingsService/Key",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThingsServiceServer).Key(ctx, req.(*ThingID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _ThingsService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "mainflux.ThingsService",
 	HandlerType: (*ThingsServiceServer)(nil),
@@ -538,6 +571,10 @@ var _ThingsService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Identify",
 			Handler:    _ThingsService_Identify_Handler,
 		},
+		{
+			MethodName: "Key",
+			Handler:    _ThingsService_Key_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "authn.proto",
@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/mainflux/mainflux/bootstrap"
+	jose "gopkg.in/square/go-jose.v2"
 )
 
 func addEndpoint(svc bootstrap.Service) endpoint.Endpoint {
@@ -96,6 +97,7 @@ func viewEndpoint(svc bootstrap.Service) endpoint.Endpoint {
 			Name:        config.Name,
 			Content:     config.Content,
 			State:       config.State,
+			Control:     config.ControlChannel,
 		}
 
 		return res, nil
@@ -129,6 +131,64 @@ func updateEndpoint(svc bootstrap.Service) endpoint.Endpoint {
 	}
 }
 
+func publicKeyEndpoint(signer bootstrap.Signer) endpoint.Endpoint {
+	return func(_ context.Context, _ interface{}) (interface{}, error) {
+		res := publicKeyRes{
+			Keys: []jose.JSONWebKey{signer.PublicKey()},
+		}
+
+		return res, nil
+	}
+}
+
+func versionsEndpoint(svc bootstrap.Service) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(entityReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		versions, err := svc.Versions(req.key, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		res := versionsRes{Versions: []versionRes{}}
+		for _, v := range versions {
+			res.Versions = append(res.Versions, versionRes{
+				Version:   v.Version,
+				Name:      v.Name,
+				Content:   v.Content,
+				CreatedAt: v.CreatedAt,
+			})
+		}
+
+		return res, nil
+	}
+}
+
+func rollbackEndpoint(svc bootstrap.Service) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(rollbackReq)
+
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		if err := svc.Rollback(req.key, req.id, req.Version); err != nil {
+			return nil, err
+		}
+
+		res := configRes{
+			id:      req.id,
+			created: false,
+		}
+
+		return res, nil
+	}
+}
+
 func updateConnEndpoint(svc bootstrap.Service) endpoint.Endpoint {
 	return func(_ context.Context, request interface{}) (interface{}, error) {
 		req := request.(updateConnReq)
@@ -188,6 +248,7 @@ func listEndpoint(svc bootstrap.Service) endpoint.Endpoint {
 				Name:        cfg.Name,
 				Content:     cfg.Content,
 				State:       cfg.State,
+				Control:     cfg.ControlChannel,
 			}
 			res.Configs = append(res.Configs, view)
 		}
@@ -243,3 +304,175 @@ func stateEndpoint(svc bootstrap.Service) endpoint.Endpoint {
 		return stateRes{}, nil
 	}
 }
+
+func addTemplateEndpoint(svc bootstrap.Service) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(addTemplateReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		tpl := bootstrap.Template{
+			Name:       req.Name,
+			Content:    req.Content,
+			Channels:   req.Channels,
+			CertPolicy: req.CertPolicy,
+			ClientCert: req.ClientCert,
+			ClientKey:  req.ClientKey,
+			CACert:     req.CACert,
+		}
+		if tpl.CertPolicy == "" {
+			tpl.CertPolicy = bootstrap.NoCerts
+		}
+
+		saved, err := svc.AddTemplate(req.token, tpl)
+		if err != nil {
+			return nil, err
+		}
+
+		res := templateRes{
+			id:      saved.ID,
+			created: true,
+		}
+
+		return res, nil
+	}
+}
+
+func viewTemplateEndpoint(svc bootstrap.Service) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(entityReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		tpl, err := svc.ViewTemplate(req.key, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return toViewTemplateRes(tpl), nil
+	}
+}
+
+func updateTemplateEndpoint(svc bootstrap.Service) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(updateTemplateReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		tpl := bootstrap.Template{
+			ID:         req.id,
+			Name:       req.Name,
+			Content:    req.Content,
+			Channels:   req.Channels,
+			CertPolicy: req.CertPolicy,
+			ClientCert: req.ClientCert,
+			ClientKey:  req.ClientKey,
+			CACert:     req.CACert,
+		}
+		if tpl.CertPolicy == "" {
+			tpl.CertPolicy = bootstrap.NoCerts
+		}
+
+		if err := svc.UpdateTemplate(req.key, tpl); err != nil {
+			return nil, err
+		}
+
+		res := templateRes{
+			id:      tpl.ID,
+			created: false,
+		}
+
+		return res, nil
+	}
+}
+
+func listTemplatesEndpoint(svc bootstrap.Service) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(listTemplatesReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		page, err := svc.ListTemplates(req.key, req.offset, req.limit)
+		if err != nil {
+			return nil, err
+		}
+
+		res := listTemplatesRes{
+			Total:     page.Total,
+			Offset:    page.Offset,
+			Limit:     page.Limit,
+			Templates: []viewTemplateRes{},
+		}
+		for _, tpl := range page.Templates {
+			res.Templates = append(res.Templates, toViewTemplateRes(tpl))
+		}
+
+		return res, nil
+	}
+}
+
+func removeTemplateEndpoint(svc bootstrap.Service) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(entityReq)
+		if err := req.validate(); err != nil {
+			return removeRes{}, err
+		}
+
+		if err := svc.RemoveTemplate(req.key, req.id); err != nil {
+			return nil, err
+		}
+
+		return removeRes{}, nil
+	}
+}
+
+func bulkAddEndpoint(svc bootstrap.Service) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(bulkAddReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		var entries []bootstrap.BulkConfig
+		for _, c := range req.configs {
+			entries = append(entries, bootstrap.BulkConfig{
+				MFThing:     c.ThingID,
+				ExternalID:  c.ExternalID,
+				ExternalKey: c.ExternalKey,
+				Name:        c.Name,
+				ClientCert:  c.ClientCert,
+				ClientKey:   c.ClientKey,
+			})
+		}
+
+		saved, err := svc.BulkAdd(req.key, req.id, entries)
+		if err != nil {
+			return nil, err
+		}
+
+		res := bulkAddRes{Configs: []bulkConfigRes{}}
+		for _, cfg := range saved {
+			res.Configs = append(res.Configs, bulkConfigRes{
+				MFThing:    cfg.MFThing,
+				MFKey:      cfg.MFKey,
+				ExternalID: cfg.ExternalID,
+			})
+		}
+
+		return res, nil
+	}
+}
+
+func toViewTemplateRes(tpl bootstrap.Template) viewTemplateRes {
+	return viewTemplateRes{
+		ID:         tpl.ID,
+		Name:       tpl.Name,
+		Content:    tpl.Content,
+		Channels:   tpl.Channels,
+		CertPolicy: tpl.CertPolicy,
+	}
+}
@@ -4,16 +4,22 @@
 package api_test
 
 import (
+	"crypto"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/x509"
 	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strconv"
 	"strings"
 	"testing"
@@ -22,12 +28,16 @@ import (
 	"github.com/mainflux/mainflux/bootstrap"
 	bsapi "github.com/mainflux/mainflux/bootstrap/api"
 	"github.com/mainflux/mainflux/bootstrap/mocks"
+	log "github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/errors"
 	mfsdk "github.com/mainflux/mainflux/pkg/sdk/go"
+	uuidProvider "github.com/mainflux/mainflux/pkg/uuid"
 	"github.com/mainflux/mainflux/things"
 	thingsapi "github.com/mainflux/mainflux/things/api/things/http"
 	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	jose "gopkg.in/square/go-jose.v2"
 )
 
 const (
@@ -163,7 +173,8 @@ func newService(authn mainflux.AuthNServiceClient, url string) bootstrap.Service
 	}
 
 	sdk := mfsdk.NewSDK(config)
-	return bootstrap.New(authn, things, sdk, encKey)
+	logger, _ := log.New(os.Stdout, log.Info.String())
+	return bootstrap.New(authn, things, mocks.NewTemplatesRepository(), sdk, encKey, uuidProvider.NewMock(), mocks.NewPublisher(), false, logger)
 }
 
 func generateChannels() map[string]things.Channel {
@@ -189,10 +200,26 @@ func newThingsServer(svc things.Service) *httptest.Server {
 }
 
 func newBootstrapServer(svc bootstrap.Service) *httptest.Server {
-	mux := bsapi.MakeHandler(svc, bootstrap.NewConfigReader(encKey))
+	return newSignedBootstrapServer(svc, nil)
+}
+
+func newSignedBootstrapServer(svc bootstrap.Service, signer bootstrap.Signer) *httptest.Server {
+	mux := bsapi.MakeHandler(svc, bootstrap.NewConfigReader(encKey), signer)
 	return httptest.NewServer(mux)
 }
 
+func newSigner(t *testing.T) (bootstrap.Signer, crypto.PublicKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err, fmt.Sprintf("Generating signing key expected to succeed: %s.\n", err))
+	der, err := x509.MarshalECPrivateKey(key)
+	require.Nil(t, err, fmt.Sprintf("Encoding signing key expected to succeed: %s.\n", err))
+
+	signer, err := bootstrap.NewSigner(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+	require.Nil(t, err, fmt.Sprintf("Creating signer expected to succeed: %s.\n", err))
+
+	return signer, key.Public()
+}
+
 func toJSON(data interface{}) string {
 	jsonData, _ := json.Marshal(data)
 	return string(jsonData)
@@ -1290,3 +1317,532 @@ type configPage struct {
 type errorRes struct {
 	Err string `json:"error"`
 }
+
+func TestAddTemplate(t *testing.T) {
+	users := mocks.NewUsersService(map[string]string{validToken: email})
+
+	ts := newThingsServer(newThingsService(users))
+	svc := newService(users, ts.URL)
+	bs := newBootstrapServer(svc)
+
+	tplReq := struct {
+		Name       string   `json:"name"`
+		Content    string   `json:"content"`
+		Channels   []string `json:"channels"`
+		CertPolicy string   `json:"cert_policy,omitempty"`
+	}{
+		Name:     "gateway",
+		Content:  "id: {{external_id}}",
+		Channels: addChannels,
+	}
+	data := toJSON(tplReq)
+
+	invalidPolicy := tplReq
+	invalidPolicy.CertPolicy = "invalid"
+	invalidPolicyData := toJSON(invalidPolicy)
+
+	cases := []struct {
+		desc        string
+		req         string
+		auth        string
+		contentType string
+		status      int
+		location    string
+	}{
+		{
+			desc:        "add a template unauthorized",
+			req:         data,
+			auth:        invalidToken,
+			contentType: contentType,
+			status:      http.StatusForbidden,
+			location:    "",
+		},
+		{
+			desc:        "add a valid template",
+			req:         data,
+			auth:        validToken,
+			contentType: contentType,
+			status:      http.StatusCreated,
+			location:    fmt.Sprintf("/things/templates/%s%012d", uuidProvider.Prefix, 1),
+		},
+		{
+			desc:        "add a template with wrong content type",
+			req:         data,
+			auth:        validToken,
+			contentType: "",
+			status:      http.StatusUnsupportedMediaType,
+			location:    "",
+		},
+		{
+			desc:        "add a template with invalid cert policy",
+			req:         invalidPolicyData,
+			auth:        validToken,
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+			location:    "",
+		},
+		{
+			desc:        "add a template with invalid request format",
+			req:         "}",
+			auth:        validToken,
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+			location:    "",
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client:      bs.Client(),
+			method:      http.MethodPost,
+			url:         fmt.Sprintf("%s/things/templates", bs.URL),
+			contentType: tc.contentType,
+			token:       tc.auth,
+			body:        strings.NewReader(tc.req),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+
+		location := res.Header.Get("Location")
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		assert.Equal(t, tc.location, location, fmt.Sprintf("%s: expected location '%s' got '%s'", tc.desc, tc.location, location))
+	}
+}
+
+func TestBulkAdd(t *testing.T) {
+	users := mocks.NewUsersService(map[string]string{validToken: email})
+
+	ts := newThingsServer(newThingsService(users))
+	svc := newService(users, ts.URL)
+	bs := newBootstrapServer(svc)
+
+	tpl := bootstrap.Template{
+		Name:       "gateway",
+		Content:    "id: {{external_id}}",
+		Channels:   addChannels,
+		CertPolicy: bootstrap.NoCerts,
+	}
+	saved, err := svc.AddTemplate(validToken, tpl)
+	require.Nil(t, err, fmt.Sprintf("Saving template expected to succeed: %s.\n", err))
+
+	jsonData := `[{"external_id": "gw-1", "external_key": "key-1"}, {"external_id": "gw-2", "external_key": "key-2"}]`
+	csvData := "external_id,external_key,name\ngw-3,key-3,gateway 3\ngw-4,key-4,gateway 4\n"
+
+	cases := []struct {
+		desc        string
+		id          string
+		req         string
+		auth        string
+		contentType string
+		status      int
+		configs     int
+	}{
+		{
+			desc:        "bulk add configs unauthorized",
+			id:          saved.ID,
+			req:         jsonData,
+			auth:        invalidToken,
+			contentType: contentType,
+			status:      http.StatusForbidden,
+		},
+		{
+			desc:        "bulk add configs from JSON",
+			id:          saved.ID,
+			req:         jsonData,
+			auth:        validToken,
+			contentType: contentType,
+			status:      http.StatusCreated,
+			configs:     2,
+		},
+		{
+			desc:        "bulk add configs from CSV",
+			id:          saved.ID,
+			req:         csvData,
+			auth:        validToken,
+			contentType: "text/csv",
+			status:      http.StatusCreated,
+			configs:     2,
+		},
+		{
+			desc:        "bulk add existing configs",
+			id:          saved.ID,
+			req:         jsonData,
+			auth:        validToken,
+			contentType: contentType,
+			status:      http.StatusConflict,
+		},
+		{
+			desc:        "bulk add configs from non-existing template",
+			id:          unknown,
+			req:         `[{"external_id": "gw-5", "external_key": "key-5"}]`,
+			auth:        validToken,
+			contentType: contentType,
+			status:      http.StatusNotFound,
+		},
+		{
+			desc:        "bulk add configs with invalid CSV header",
+			id:          saved.ID,
+			req:         "external_id,invalid\ngw-5,key-5\n",
+			auth:        validToken,
+			contentType: "text/csv",
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "bulk add configs without external key",
+			id:          saved.ID,
+			req:         `[{"external_id": "gw-5"}]`,
+			auth:        validToken,
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "bulk add empty list of configs",
+			id:          saved.ID,
+			req:         "[]",
+			auth:        validToken,
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "bulk add configs with wrong content type",
+			id:          saved.ID,
+			req:         jsonData,
+			auth:        validToken,
+			contentType: "",
+			status:      http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client:      bs.Client(),
+			method:      http.MethodPost,
+			url:         fmt.Sprintf("%s/things/templates/%s/configs", bs.URL, tc.id),
+			contentType: tc.contentType,
+			token:       tc.auth,
+			body:        strings.NewReader(tc.req),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		if res.StatusCode != http.StatusCreated {
+			continue
+		}
+
+		var body struct {
+			Configs []struct {
+				MFThing    string `json:"mainflux_id"`
+				ExternalID string `json:"external_id"`
+			} `json:"configs"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&body)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.configs, len(body.Configs), fmt.Sprintf("%s: expected %d configs got %d", tc.desc, tc.configs, len(body.Configs)))
+	}
+}
+
+func TestVersions(t *testing.T) {
+	users := mocks.NewUsersService(map[string]string{validToken: email})
+
+	ts := newThingsServer(newThingsService(users))
+	svc := newService(users, ts.URL)
+	bs := newBootstrapServer(svc)
+
+	c := newConfig([]bootstrap.Channel{bootstrap.Channel{ID: "1"}})
+
+	saved, err := svc.Add(validToken, c)
+	require.Nil(t, err, fmt.Sprintf("Saving config expected to succeed: %s.\n", err))
+
+	cases := []struct {
+		desc   string
+		id     string
+		auth   string
+		status int
+		size   int
+	}{
+		{
+			desc:   "view versions unauthorized",
+			id:     saved.MFThing,
+			auth:   invalidToken,
+			status: http.StatusForbidden,
+			size:   0,
+		},
+		{
+			desc:   "view versions with an empty token",
+			id:     saved.MFThing,
+			auth:   "",
+			status: http.StatusForbidden,
+			size:   0,
+		},
+		{
+			desc:   "view versions of a config",
+			id:     saved.MFThing,
+			auth:   validToken,
+			status: http.StatusOK,
+			size:   1,
+		},
+		{
+			desc:   "view versions of a non-existing config",
+			id:     wrongID,
+			auth:   validToken,
+			status: http.StatusNotFound,
+			size:   0,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client: bs.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/things/configs/versions/%s", bs.URL, tc.id),
+			token:  tc.auth,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+
+		var body struct {
+			Versions []struct {
+				Version uint64 `json:"version"`
+				Content string `json:"content"`
+			} `json:"versions"`
+		}
+		json.NewDecoder(res.Body).Decode(&body)
+		assert.Equal(t, tc.size, len(body.Versions), fmt.Sprintf("%s: expected %d versions got %d", tc.desc, tc.size, len(body.Versions)))
+	}
+}
+
+func TestRollback(t *testing.T) {
+	users := mocks.NewUsersService(map[string]string{validToken: email})
+
+	ts := newThingsServer(newThingsService(users))
+	svc := newService(users, ts.URL)
+	bs := newBootstrapServer(svc)
+
+	c := newConfig([]bootstrap.Channel{bootstrap.Channel{ID: "1"}})
+
+	saved, err := svc.Add(validToken, c)
+	require.Nil(t, err, fmt.Sprintf("Saving config expected to succeed: %s.\n", err))
+
+	modified := saved
+	modified.Content = "new content"
+	err = svc.Update(validToken, modified)
+	require.Nil(t, err, fmt.Sprintf("Updating config expected to succeed: %s.\n", err))
+
+	data := toJSON(map[string]uint64{"version": 1})
+
+	cases := []struct {
+		desc        string
+		req         string
+		id          string
+		auth        string
+		contentType string
+		status      int
+	}{
+		{
+			desc:        "rollback unauthorized",
+			req:         data,
+			id:          saved.MFThing,
+			auth:        invalidToken,
+			contentType: contentType,
+			status:      http.StatusForbidden,
+		},
+		{
+			desc:        "rollback with an empty token",
+			req:         data,
+			id:          saved.MFThing,
+			auth:        "",
+			contentType: contentType,
+			status:      http.StatusForbidden,
+		},
+		{
+			desc:        "rollback a config",
+			req:         data,
+			id:          saved.MFThing,
+			auth:        validToken,
+			contentType: contentType,
+			status:      http.StatusOK,
+		},
+		{
+			desc:        "rollback a config with wrong content type",
+			req:         data,
+			id:          saved.MFThing,
+			auth:        validToken,
+			contentType: "",
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			desc:        "rollback a non-existing config",
+			req:         data,
+			id:          wrongID,
+			auth:        validToken,
+			contentType: contentType,
+			status:      http.StatusNotFound,
+		},
+		{
+			desc:        "rollback a config to a non-existing version",
+			req:         toJSON(map[string]uint64{"version": 100}),
+			id:          saved.MFThing,
+			auth:        validToken,
+			contentType: contentType,
+			status:      http.StatusNotFound,
+		},
+		{
+			desc:        "rollback a config without version",
+			req:         "{}",
+			id:          saved.MFThing,
+			auth:        validToken,
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "rollback a config with invalid request format",
+			req:         "}",
+			id:          saved.MFThing,
+			auth:        validToken,
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client:      bs.Client(),
+			method:      http.MethodPost,
+			url:         fmt.Sprintf("%s/things/configs/rollback/%s", bs.URL, tc.id),
+			contentType: tc.contentType,
+			token:       tc.auth,
+			body:        strings.NewReader(tc.req),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+	}
+}
+
+func TestSignedBootstrap(t *testing.T) {
+	users := mocks.NewUsersService(map[string]string{validToken: email})
+
+	ts := newThingsServer(newThingsService(users))
+	svc := newService(users, ts.URL)
+	signer, pub := newSigner(t)
+	bs := newSignedBootstrapServer(svc, signer)
+	unsigned := newBootstrapServer(svc)
+
+	c := newConfig([]bootstrap.Channel{bootstrap.Channel{ID: "1"}})
+
+	saved, err := svc.Add(validToken, c)
+	require.Nil(t, err, fmt.Sprintf("Saving config expected to succeed: %s.\n", err))
+
+	_, other := newSigner(t)
+
+	cases := []struct {
+		desc string
+		url  string
+		key  crypto.PublicKey
+		err  error
+	}{
+		{
+			desc: "bootstrap with signature verification",
+			url:  bs.URL,
+			key:  pub,
+			err:  nil,
+		},
+		{
+			desc: "bootstrap without signature verification",
+			url:  bs.URL,
+			key:  nil,
+			err:  nil,
+		},
+		{
+			desc: "bootstrap with signature verified using wrong key",
+			url:  bs.URL,
+			key:  other,
+			err:  mfsdk.ErrInvalidSignature,
+		},
+		{
+			desc: "bootstrap unsigned response with signature verification",
+			url:  unsigned.URL,
+			key:  pub,
+			err:  mfsdk.ErrInvalidSignature,
+		},
+	}
+
+	for _, tc := range cases {
+		sdk := mfsdk.NewSDK(mfsdk.Config{
+			BootstrapURL:    tc.url,
+			BootstrapPrefix: "things",
+			BootstrapKey:    tc.key,
+		})
+		cfg, err := sdk.Bootstrap(c.ExternalKey, c.ExternalID)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if err == nil {
+			assert.Equal(t, saved.MFThing, cfg.MFThing, fmt.Sprintf("%s: expected thing %s got %s\n", tc.desc, saved.MFThing, cfg.MFThing))
+		}
+	}
+
+	encExternKey, err := enc([]byte(c.ExternalKey))
+	require.Nil(t, err, fmt.Sprintf("Encrypting external key expected to succeed: %s.\n", err))
+
+	req := testRequest{
+		client: bs.Client(),
+		method: http.MethodGet,
+		url:    fmt.Sprintf("%s/things/bootstrap/secure/%s", bs.URL, c.ExternalID),
+		token:  hex.EncodeToString(encExternKey),
+	}
+	res, err := req.make()
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	body, err := ioutil.ReadAll(res.Body)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+
+	jws, err := jose.ParseDetached(res.Header.Get("X-JWS-Signature"), body)
+	require.Nil(t, err, fmt.Sprintf("secure bootstrap: parsing signature expected to succeed: %s", err))
+	_, err = jws.Verify(pub)
+	assert.Nil(t, err, fmt.Sprintf("secure bootstrap: verifying signature expected to succeed: %s", err))
+}
+
+func TestPublicKey(t *testing.T) {
+	users := mocks.NewUsersService(map[string]string{validToken: email})
+
+	ts := newThingsServer(newThingsService(users))
+	svc := newService(users, ts.URL)
+	signer, _ := newSigner(t)
+
+	cases := []struct {
+		desc   string
+		server *httptest.Server
+		status int
+		keys   int
+	}{
+		{
+			desc:   "retrieve public key of signed bootstrap",
+			server: newSignedBootstrapServer(svc, signer),
+			status: http.StatusOK,
+			keys:   1,
+		},
+		{
+			desc:   "retrieve public key of unsigned bootstrap",
+			server: newBootstrapServer(svc),
+			status: http.StatusNotFound,
+			keys:   0,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client: tc.server.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/.well-known/jwks.json", tc.server.URL),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+
+		var jwks jose.JSONWebKeySet
+		json.NewDecoder(res.Body).Decode(&jwks)
+		assert.Equal(t, tc.keys, len(jwks.Keys), fmt.Sprintf("%s: expected %d keys got %d", tc.desc, tc.keys, len(jwks.Keys)))
+		if len(jwks.Keys) > 0 {
+			assert.Equal(t, signer.PublicKey().KeyID, jwks.Keys[0].KeyID, fmt.Sprintf("%s: expected key ID %s got %s", tc.desc, signer.PublicKey().KeyID, jwks.Keys[0].KeyID))
+		}
+	}
+}
@@ -64,6 +64,32 @@ func (lm *loggingMiddleware) Update(token string, cfg bootstrap.Config) (err err
 	return lm.svc.Update(token, cfg)
 }
 
+func (lm *loggingMiddleware) Versions(token, id string) (versions []bootstrap.ConfigVersion, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method versions for token %s and thing %s took %s to complete", token, id, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.Versions(token, id)
+}
+
+func (lm *loggingMiddleware) Rollback(token, id string, version uint64) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method rollback for token %s, thing %s and version %d took %s to complete", token, id, version, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.Rollback(token, id, version)
+}
+
 func (lm *loggingMiddleware) UpdateCert(token, thingID, clientCert, clientKey, caCert string) (err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method update_cert for thing with id %s took %s to complete", thingID, time.Since(begin))
@@ -142,6 +168,84 @@ func (lm *loggingMiddleware) ChangeState(token, id string, state bootstrap.State
 	return lm.svc.ChangeState(token, id, state)
 }
 
+func (lm *loggingMiddleware) AddTemplate(token string, tpl bootstrap.Template) (saved bootstrap.Template, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method add_template for token %s and template %s took %s to complete", token, saved.ID, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.AddTemplate(token, tpl)
+}
+
+func (lm *loggingMiddleware) ViewTemplate(token, id string) (tpl bootstrap.Template, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method view_template for token %s and template %s took %s to complete", token, id, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.ViewTemplate(token, id)
+}
+
+func (lm *loggingMiddleware) UpdateTemplate(token string, tpl bootstrap.Template) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method update_template for token %s and template %s took %s to complete", token, tpl.ID, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.UpdateTemplate(token, tpl)
+}
+
+func (lm *loggingMiddleware) ListTemplates(token string, offset, limit uint64) (res bootstrap.TemplatesPage, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method list_templates for token %s with offset %d and limit %d took %s to complete", token, offset, limit, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.ListTemplates(token, offset, limit)
+}
+
+func (lm *loggingMiddleware) RemoveTemplate(token, id string) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method remove_template for token %s and template %s took %s to complete", token, id, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.RemoveTemplate(token, id)
+}
+
+func (lm *loggingMiddleware) BulkAdd(token, templateID string, entries []bootstrap.BulkConfig) (saved []bootstrap.Config, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method bulk_add for token %s, template %s and %d configs took %s to complete", token, templateID, len(entries), time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.BulkAdd(token, templateID, entries)
+}
+
 func (lm *loggingMiddleware) UpdateChannelHandler(channel bootstrap.Channel) (err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("Method update_channel_handler for channel %s took %s to complete", channel.ID, time.Since(begin))
@@ -57,6 +57,24 @@ func (mm *metricsMiddleware) Update(token string, cfg bootstrap.Config) (err err
 	return mm.svc.Update(token, cfg)
 }
 
+func (mm *metricsMiddleware) Versions(token, id string) (versions []bootstrap.ConfigVersion, err error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "versions").Add(1)
+		mm.latency.With("method", "versions").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.Versions(token, id)
+}
+
+func (mm *metricsMiddleware) Rollback(token, id string, version uint64) (err error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "rollback").Add(1)
+		mm.latency.With("method", "rollback").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.Rollback(token, id, version)
+}
+
 func (mm *metricsMiddleware) UpdateCert(token, thingKey, clientCert, clientKey, caCert string) (err error) {
 	defer func(begin time.Time) {
 		mm.counter.With("method", "update_cert").Add(1)
@@ -111,6 +129,60 @@ func (mm *metricsMiddleware) ChangeState(token, id string, state bootstrap.State
 	return mm.svc.ChangeState(token, id, state)
 }
 
+func (mm *metricsMiddleware) AddTemplate(token string, tpl bootstrap.Template) (saved bootstrap.Template, err error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "add_template").Add(1)
+		mm.latency.With("method", "add_template").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.AddTemplate(token, tpl)
+}
+
+func (mm *metricsMiddleware) ViewTemplate(token, id string) (tpl bootstrap.Template, err error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "view_template").Add(1)
+		mm.latency.With("method", "view_template").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.ViewTemplate(token, id)
+}
+
+func (mm *metricsMiddleware) UpdateTemplate(token string, tpl bootstrap.Template) (err error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "update_template").Add(1)
+		mm.latency.With("method", "update_template").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.UpdateTemplate(token, tpl)
+}
+
+func (mm *metricsMiddleware) ListTemplates(token string, offset, limit uint64) (res bootstrap.TemplatesPage, err error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "list_templates").Add(1)
+		mm.latency.With("method", "list_templates").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.ListTemplates(token, offset, limit)
+}
+
+func (mm *metricsMiddleware) RemoveTemplate(token, id string) (err error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "remove_template").Add(1)
+		mm.latency.With("method", "remove_template").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.RemoveTemplate(token, id)
+}
+
+func (mm *metricsMiddleware) BulkAdd(token, templateID string, entries []bootstrap.BulkConfig) (saved []bootstrap.Config, err error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "bulk_add").Add(1)
+		mm.latency.With("method", "bulk_add").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.BulkAdd(token, templateID, entries)
+}
+
 func (mm *metricsMiddleware) UpdateChannelHandler(channel bootstrap.Channel) (err error) {
 	defer func(begin time.Time) {
 		mm.counter.With("method", "update_channel").Add(1)
@@ -90,6 +90,24 @@ func (req updateCertReq) validate() error {
 	return nil
 }
 
+type rollbackReq struct {
+	key     string
+	id      string
+	Version uint64 `json:"version"`
+}
+
+func (req rollbackReq) validate() error {
+	if req.key == "" {
+		return bootstrap.ErrUnauthorizedAccess
+	}
+
+	if req.id == "" || req.Version == 0 {
+		return bootstrap.ErrMalformedEntity
+	}
+
+	return nil
+}
+
 type updateConnReq struct {
 	key      string
 	id       string
@@ -166,3 +184,105 @@ func (req changeStateReq) validate() error {
 
 	return nil
 }
+
+type addTemplateReq struct {
+	token      string
+	Name       string               `json:"name"`
+	Content    string               `json:"content"`
+	Channels   []string             `json:"channels"`
+	CertPolicy bootstrap.CertPolicy `json:"cert_policy"`
+	ClientCert string               `json:"client_cert"`
+	ClientKey  string               `json:"client_key"`
+	CACert     string               `json:"ca_cert"`
+}
+
+func (req addTemplateReq) validate() error {
+	if req.token == "" {
+		return bootstrap.ErrUnauthorizedAccess
+	}
+
+	if req.CertPolicy == "" {
+		return nil
+	}
+
+	return req.CertPolicy.Validate()
+}
+
+type updateTemplateReq struct {
+	key        string
+	id         string
+	Name       string               `json:"name"`
+	Content    string               `json:"content"`
+	Channels   []string             `json:"channels"`
+	CertPolicy bootstrap.CertPolicy `json:"cert_policy"`
+	ClientCert string               `json:"client_cert"`
+	ClientKey  string               `json:"client_key"`
+	CACert     string               `json:"ca_cert"`
+}
+
+func (req updateTemplateReq) validate() error {
+	if req.key == "" {
+		return bootstrap.ErrUnauthorizedAccess
+	}
+
+	if req.id == "" {
+		return bootstrap.ErrMalformedEntity
+	}
+
+	if req.CertPolicy == "" {
+		return nil
+	}
+
+	return req.CertPolicy.Validate()
+}
+
+type listTemplatesReq struct {
+	key    string
+	offset uint64
+	limit  uint64
+}
+
+func (req listTemplatesReq) validate() error {
+	if req.key == "" {
+		return bootstrap.ErrUnauthorizedAccess
+	}
+
+	if req.limit == 0 || req.limit > maxLimit {
+		return bootstrap.ErrMalformedEntity
+	}
+
+	return nil
+}
+
+type bulkConfigReq struct {
+	ThingID     string `json:"thing_id"`
+	ExternalID  string `json:"external_id"`
+	ExternalKey string `json:"external_key"`
+	Name        string `json:"name"`
+	ClientCert  string `json:"client_cert"`
+	ClientKey   string `json:"client_key"`
+}
+
+type bulkAddReq struct {
+	key     string
+	id      string
+	configs []bulkConfigReq
+}
+
+func (req bulkAddReq) validate() error {
+	if req.key == "" {
+		return bootstrap.ErrUnauthorizedAccess
+	}
+
+	if req.id == "" || len(req.configs) == 0 || len(req.configs) > maxBulkConfigs {
+		return bootstrap.ErrMalformedEntity
+	}
+
+	for _, c := range req.configs {
+		if c.ExternalID == "" || c.ExternalKey == "" {
+			return bootstrap.ErrMalformedEntity
+		}
+	}
+
+	return nil
+}
@@ -146,6 +146,56 @@ func TestUpdateCertReqValidation(t *testing.T) {
 	}
 }
 
+func TestRollbackReqValidation(t *testing.T) {
+	cases := []struct {
+		desc    string
+		key     string
+		id      string
+		version uint64
+		err     error
+	}{
+		{
+			desc:    "empty key",
+			key:     "",
+			id:      "id",
+			version: 1,
+			err:     bootstrap.ErrUnauthorizedAccess,
+		},
+		{
+			desc:    "empty id",
+			key:     "key",
+			id:      "",
+			version: 1,
+			err:     bootstrap.ErrMalformedEntity,
+		},
+		{
+			desc:    "empty version",
+			key:     "key",
+			id:      "id",
+			version: 0,
+			err:     bootstrap.ErrMalformedEntity,
+		},
+		{
+			desc:    "valid request",
+			key:     "key",
+			id:      "id",
+			version: 1,
+			err:     nil,
+		},
+	}
+
+	for _, tc := range cases {
+		req := rollbackReq{
+			key:     tc.key,
+			id:      tc.id,
+			Version: tc.version,
+		}
+
+		err := req.validate()
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
 func TestUpdateConnReqValidation(t *testing.T) {
 	cases := []struct {
 		desc string
@@ -295,3 +345,62 @@ func TestChangeStateReqValidation(t *testing.T) {
 		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 	}
 }
+
+func TestBulkAddReqValidation(t *testing.T) {
+	valid := []bulkConfigReq{{ExternalID: "external-id", ExternalKey: "external-key"}}
+
+	cases := []struct {
+		desc    string
+		key     string
+		id      string
+		configs []bulkConfigReq
+		err     error
+	}{
+		{
+			desc:    "empty key",
+			key:     "",
+			id:      "id",
+			configs: valid,
+			err:     bootstrap.ErrUnauthorizedAccess,
+		},
+		{
+			desc:    "empty template id",
+			key:     "key",
+			id:      "",
+			configs: valid,
+			err:     bootstrap.ErrMalformedEntity,
+		},
+		{
+			desc:    "empty list of configs",
+			key:     "key",
+			id:      "id",
+			configs: []bulkConfigReq{},
+			err:     bootstrap.ErrMalformedEntity,
+		},
+		{
+			desc:    "too many configs",
+			key:     "key",
+			id:      "id",
+			configs: make([]bulkConfigReq, maxBulkConfigs+1),
+			err:     bootstrap.ErrMalformedEntity,
+		},
+		{
+			desc:    "config without external key",
+			key:     "key",
+			id:      "id",
+			configs: []bulkConfigReq{{ExternalID: "external-id"}},
+			err:     bootstrap.ErrMalformedEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		req := bulkAddReq{
+			key:     tc.key,
+			id:      tc.id,
+			configs: tc.configs,
+		}
+
+		err := req.validate()
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
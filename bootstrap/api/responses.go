@@ -6,9 +6,11 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/bootstrap"
+	jose "gopkg.in/square/go-jose.v2"
 )
 
 var (
@@ -17,6 +19,12 @@ var (
 	_ mainflux.Response = (*stateRes)(nil)
 	_ mainflux.Response = (*viewRes)(nil)
 	_ mainflux.Response = (*listRes)(nil)
+	_ mainflux.Response = (*versionsRes)(nil)
+	_ mainflux.Response = (*publicKeyRes)(nil)
+	_ mainflux.Response = (*templateRes)(nil)
+	_ mainflux.Response = (*viewTemplateRes)(nil)
+	_ mainflux.Response = (*listTemplatesRes)(nil)
+	_ mainflux.Response = (*bulkAddRes)(nil)
 )
 
 type removeRes struct{}
@@ -75,6 +83,7 @@ type viewRes struct {
 	Content     string          `json:"content,omitempty"`
 	Name        string          `json:"name,omitempty"`
 	State       bootstrap.State `json:"state"`
+	Control     string          `json:"control_channel,omitempty"`
 }
 
 func (res viewRes) Code() int {
@@ -108,6 +117,45 @@ func (res listRes) Empty() bool {
 	return false
 }
 
+type versionRes struct {
+	Version   uint64    `json:"version"`
+	Name      string    `json:"name,omitempty"`
+	Content   string    `json:"content,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type versionsRes struct {
+	Versions []versionRes `json:"versions"`
+}
+
+func (res versionsRes) Code() int {
+	return http.StatusOK
+}
+
+func (res versionsRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res versionsRes) Empty() bool {
+	return false
+}
+
+type publicKeyRes struct {
+	Keys []jose.JSONWebKey `json:"keys"`
+}
+
+func (res publicKeyRes) Code() int {
+	return http.StatusOK
+}
+
+func (res publicKeyRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res publicKeyRes) Empty() bool {
+	return false
+}
+
 type stateRes struct{}
 
 func (res stateRes) Code() int {
@@ -125,3 +173,91 @@ func (res stateRes) Empty() bool {
 type errorRes struct {
 	Err string `json:"error"`
 }
+
+type templateRes struct {
+	id      string
+	created bool
+}
+
+func (res templateRes) Code() int {
+	if res.created {
+		return http.StatusCreated
+	}
+
+	return http.StatusOK
+}
+
+func (res templateRes) Headers() map[string]string {
+	if res.created {
+		return map[string]string{
+			"Location": fmt.Sprintf("/things/templates/%s", res.id),
+		}
+	}
+
+	return map[string]string{}
+}
+
+func (res templateRes) Empty() bool {
+	return true
+}
+
+type viewTemplateRes struct {
+	ID         string               `json:"id"`
+	Name       string               `json:"name,omitempty"`
+	Content    string               `json:"content,omitempty"`
+	Channels   []string             `json:"channels,omitempty"`
+	CertPolicy bootstrap.CertPolicy `json:"cert_policy"`
+}
+
+func (res viewTemplateRes) Code() int {
+	return http.StatusOK
+}
+
+func (res viewTemplateRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res viewTemplateRes) Empty() bool {
+	return false
+}
+
+type listTemplatesRes struct {
+	Total     uint64            `json:"total"`
+	Offset    uint64            `json:"offset"`
+	Limit     uint64            `json:"limit"`
+	Templates []viewTemplateRes `json:"templates"`
+}
+
+func (res listTemplatesRes) Code() int {
+	return http.StatusOK
+}
+
+func (res listTemplatesRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res listTemplatesRes) Empty() bool {
+	return false
+}
+
+type bulkConfigRes struct {
+	MFThing    string `json:"mainflux_id"`
+	MFKey      string `json:"mainflux_key"`
+	ExternalID string `json:"external_id"`
+}
+
+type bulkAddRes struct {
+	Configs []bulkConfigRes `json:"configs"`
+}
+
+func (res bulkAddRes) Code() int {
+	return http.StatusCreated
+}
+
+func (res bulkAddRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res bulkAddRes) Empty() bool {
+	return false
+}
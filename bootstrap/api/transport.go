@@ -5,6 +5,7 @@ package api
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -21,9 +22,15 @@ import (
 )
 
 const (
-	contentType  = "application/json"
-	maxLimit     = 100
-	defaultLimit = 10
+	contentType    = "application/json"
+	csvContentType = "text/csv"
+	maxLimit       = 100
+	defaultLimit   = 10
+	maxBulkConfigs = 1000
+
+	// signatureHeader carries detached JWS of the bootstrap response body.
+	signatureHeader = "X-JWS-Signature"
+	jwksPath        = "/.well-known/jwks.json"
 )
 
 var (
@@ -31,12 +38,16 @@ var (
 	errInvalidQueryParams     = errors.New("invalid query params")
 	errInvalidLimitParam      = errors.New("invalid limit query param")
 	errInvalidOffsetParam     = errors.New("invalid offset query param")
+	errInvalidCSVHeader       = errors.New("invalid CSV header")
 	fullMatch                 = []string{"state", "external_id", "mainflux_id", "mainflux_key"}
 	partialMatch              = []string{"name"}
+	csvColumns                = []string{"thing_id", "external_id", "external_key", "name", "client_cert", "client_key"}
 )
 
-// MakeHandler returns a HTTP handler for API endpoints.
-func MakeHandler(svc bootstrap.Service, reader bootstrap.ConfigReader) http.Handler {
+// MakeHandler returns a HTTP handler for API endpoints. If the signer is
+// provided, bootstrap responses are signed and the public key is exposed
+// at the well-known endpoint.
+func MakeHandler(svc bootstrap.Service, reader bootstrap.ConfigReader, signer bootstrap.Signer) http.Handler {
 	opts := []kithttp.ServerOption{
 		kithttp.ServerErrorEncoder(encodeError),
 	}
@@ -66,6 +77,18 @@ func MakeHandler(svc bootstrap.Service, reader bootstrap.ConfigReader) http.Hand
 		encodeResponse,
 		opts...))
 
+	r.Get("/things/configs/versions/:id", kithttp.NewServer(
+		versionsEndpoint(svc),
+		decodeEntityRequest,
+		encodeResponse,
+		opts...))
+
+	r.Post("/things/configs/rollback/:id", kithttp.NewServer(
+		rollbackEndpoint(svc),
+		decodeRollbackRequest,
+		encodeResponse,
+		opts...))
+
 	r.Put("/things/configs/connections/:id", kithttp.NewServer(
 		updateConnEndpoint(svc),
 		decodeUpdateConnRequest,
@@ -81,15 +104,23 @@ func MakeHandler(svc bootstrap.Service, reader bootstrap.ConfigReader) http.Hand
 	r.Get("/things/bootstrap/:external_id", kithttp.NewServer(
 		bootstrapEndpoint(svc, reader, false),
 		decodeBootstrapRequest,
-		encodeResponse,
+		encodeBootstrapRes(signer),
 		opts...))
 
 	r.Get("/things/bootstrap/secure/:external_id", kithttp.NewServer(
 		bootstrapEndpoint(svc, reader, true),
 		decodeBootstrapRequest,
-		encodeSecureRes,
+		encodeBootstrapRes(signer),
 		opts...))
 
+	if signer != nil {
+		r.Get(jwksPath, kithttp.NewServer(
+			publicKeyEndpoint(signer),
+			decodePublicKeyRequest,
+			encodeResponse,
+			opts...))
+	}
+
 	r.Put("/things/state/:id", kithttp.NewServer(
 		stateEndpoint(svc),
 		decodeStateRequest,
@@ -102,6 +133,42 @@ func MakeHandler(svc bootstrap.Service, reader bootstrap.ConfigReader) http.Hand
 		encodeResponse,
 		opts...))
 
+	r.Post("/things/templates", kithttp.NewServer(
+		addTemplateEndpoint(svc),
+		decodeAddTemplateRequest,
+		encodeResponse,
+		opts...))
+
+	r.Get("/things/templates/:id", kithttp.NewServer(
+		viewTemplateEndpoint(svc),
+		decodeEntityRequest,
+		encodeResponse,
+		opts...))
+
+	r.Put("/things/templates/:id", kithttp.NewServer(
+		updateTemplateEndpoint(svc),
+		decodeUpdateTemplateRequest,
+		encodeResponse,
+		opts...))
+
+	r.Get("/things/templates", kithttp.NewServer(
+		listTemplatesEndpoint(svc),
+		decodeListTemplatesRequest,
+		encodeResponse,
+		opts...))
+
+	r.Delete("/things/templates/:id", kithttp.NewServer(
+		removeTemplateEndpoint(svc),
+		decodeEntityRequest,
+		encodeResponse,
+		opts...))
+
+	r.Post("/things/templates/:id/configs", kithttp.NewServer(
+		bulkAddEndpoint(svc),
+		decodeBulkAddRequest,
+		encodeResponse,
+		opts...))
+
 	r.GetFunc("/version", mainflux.Version("bootstrap"))
 	r.Handle("/metrics", promhttp.Handler())
 
@@ -152,6 +219,22 @@ func decodeUpdateCertRequest(_ context.Context, r *http.Request) (interface{}, e
 	return req, nil
 }
 
+func decodeRollbackRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+		return nil, errUnsupportedContentType
+	}
+
+	req := rollbackReq{
+		key: r.Header.Get("Authorization"),
+		id:  bone.GetValue(r, "id"),
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(bootstrap.ErrMalformedEntity, err)
+	}
+
+	return req, nil
+}
+
 func decodeUpdateConnRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
 		return nil, errUnsupportedContentType
@@ -189,6 +272,10 @@ func decodeListRequest(_ context.Context, r *http.Request) (interface{}, error)
 	return req, nil
 }
 
+func decodePublicKeyRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
 func decodeBootstrapRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	req := bootstrapReq{
 		id:  bone.GetValue(r, "external_id"),
@@ -221,6 +308,124 @@ func decodeEntityRequest(_ context.Context, r *http.Request) (interface{}, error
 	return req, nil
 }
 
+func decodeAddTemplateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+		return nil, errUnsupportedContentType
+	}
+
+	req := addTemplateReq{token: r.Header.Get("Authorization")}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(bootstrap.ErrMalformedEntity, err)
+	}
+
+	return req, nil
+}
+
+func decodeUpdateTemplateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+		return nil, errUnsupportedContentType
+	}
+
+	req := updateTemplateReq{
+		key: r.Header.Get("Authorization"),
+		id:  bone.GetValue(r, "id"),
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(bootstrap.ErrMalformedEntity, err)
+	}
+
+	return req, nil
+}
+
+func decodeListTemplatesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		return nil, errInvalidQueryParams
+	}
+
+	offset, limit, err := parsePagePrams(q)
+	if err != nil {
+		return nil, err
+	}
+
+	req := listTemplatesReq{
+		key:    r.Header.Get("Authorization"),
+		offset: offset,
+		limit:  limit,
+	}
+
+	return req, nil
+}
+
+func decodeBulkAddRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	req := bulkAddReq{
+		key: r.Header.Get("Authorization"),
+		id:  bone.GetValue(r, "id"),
+	}
+
+	ct := r.Header.Get("Content-Type")
+	switch {
+	case strings.Contains(ct, contentType):
+		if err := json.NewDecoder(r.Body).Decode(&req.configs); err != nil {
+			return nil, errors.Wrap(bootstrap.ErrMalformedEntity, err)
+		}
+	case strings.Contains(ct, csvContentType):
+		configs, err := decodeCSVConfigs(r.Body)
+		if err != nil {
+			return nil, errors.Wrap(bootstrap.ErrMalformedEntity, err)
+		}
+		req.configs = configs
+	default:
+		return nil, errUnsupportedContentType
+	}
+
+	return req, nil
+}
+
+// decodeCSVConfigs reads bulk Configs from CSV. The first record is a header
+// naming the columns, using the same names as JSON bulk request fields.
+func decodeCSVConfigs(body io.Reader) ([]bulkConfigReq, error) {
+	records, err := csv.NewReader(body).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errInvalidCSVHeader
+	}
+
+	header := records[0]
+	for _, col := range header {
+		if !contains(csvColumns, strings.TrimSpace(col)) {
+			return nil, errInvalidCSVHeader
+		}
+	}
+
+	var configs []bulkConfigReq
+	for _, rec := range records[1:] {
+		var c bulkConfigReq
+		for i, col := range header {
+			v := strings.TrimSpace(rec[i])
+			switch strings.TrimSpace(col) {
+			case "thing_id":
+				c.ThingID = v
+			case "external_id":
+				c.ExternalID = v
+			case "external_key":
+				c.ExternalKey = v
+			case "name":
+				c.Name = v
+			case "client_cert":
+				c.ClientCert = v
+			case "client_key":
+				c.ClientKey = v
+			}
+		}
+		configs = append(configs, c)
+	}
+
+	return configs, nil
+}
+
 func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
 	w.Header().Set("Content-Type", contentType)
 	if ar, ok := response.(mainflux.Response); ok {
@@ -238,15 +443,31 @@ func encodeResponse(_ context.Context, w http.ResponseWriter, response interface
 	return json.NewEncoder(w).Encode(response)
 }
 
-func encodeSecureRes(_ context.Context, w http.ResponseWriter, response interface{}) error {
-	w.Header().Set("Content-Type", contentType)
-	w.WriteHeader(http.StatusOK)
-	if b, ok := response.([]byte); ok {
-		if _, err := w.Write(b); err != nil {
-			return err
+func encodeBootstrapRes(signer bootstrap.Signer) kithttp.EncodeResponseFunc {
+	return func(_ context.Context, w http.ResponseWriter, response interface{}) error {
+		// Secure bootstrap response is already encrypted.
+		body, ok := response.([]byte)
+		if !ok {
+			b, err := json.Marshal(response)
+			if err != nil {
+				return err
+			}
+			body = b
 		}
+
+		if signer != nil {
+			sig, err := signer.Sign(body)
+			if err != nil {
+				return err
+			}
+			w.Header().Set(signatureHeader, sig)
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write(body)
+		return err
 	}
-	return nil
 }
 
 func encodeError(_ context.Context, err error, w http.ResponseWriter) {
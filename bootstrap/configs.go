@@ -3,24 +3,38 @@
 
 package bootstrap
 
+import "time"
+
 // Config represents Configuration entity. It wraps information about external entity
 // as well as info about corresponding Mainflux entities.
 // MFThing represents corresponding Mainflux Thing ID.
 // MFKey is key of corresponding Mainflux Thing.
 // MFChannels is a list of Mainflux Channels corresponding Mainflux Thing connects to.
+// ControlChannel is ID of the Mainflux Channel connected only to the corresponding
+// Mainflux Thing, used to notify it about the Config changes.
 type Config struct {
-	MFThing     string
-	Owner       string
-	Name        string
-	ClientCert  string
-	ClientKey   string
-	CACert      string
-	MFKey       string
-	MFChannels  []Channel
-	ExternalID  string
-	ExternalKey string
-	Content     string
-	State       State
+	MFThing        string
+	Owner          string
+	Name           string
+	ClientCert     string
+	ClientKey      string
+	CACert         string
+	MFKey          string
+	MFChannels     []Channel
+	ExternalID     string
+	ExternalKey    string
+	Content        string
+	State          State
+	ControlChannel string
+}
+
+// ConfigVersion represents a version of the Config name and content. New
+// version is created each time Config name or content is changed.
+type ConfigVersion struct {
+	Version   uint64
+	Name      string
+	Content   string
+	CreatedAt time.Time
 }
 
 // Channel represents Mainflux channel corresponding Mainflux Thing is connected to.
@@ -66,6 +80,14 @@ type ConfigRepository interface {
 	// to indicate operation failure.
 	Update(cfg Config) error
 
+	// RetrieveVersions retrieves versions of the Config having the provided
+	// identifier, that is owned by the specified user, starting from the oldest one.
+	RetrieveVersions(owner, id string) ([]ConfigVersion, error)
+
+	// Rollback restores name and content of the Config to the given version.
+	// Restored name and content are saved as the new version.
+	Rollback(owner, id string, version uint64) error
+
 	// UpdateCerts updates an existing Config certificate and owner.
 	// A non-nil error is returned to indicate operation failure.
 	UpdateCert(owner, thingID, clientCert, clientKey, caCert string) error
@@ -84,8 +106,13 @@ type ConfigRepository interface {
 	// ListExisting retrieves those channels from the given list that exist in DB.
 	ListExisting(owner string, ids []string) ([]Channel, error)
 
-	// Methods RemoveThing, UpdateChannel, and RemoveChannel are related to
-	// event sourcing. That's why these methods surpass ownership check.
+	// Methods RetrieveByThing, RemoveThing, UpdateChannel, and RemoveChannel
+	// are related to event sourcing. That's why these methods surpass
+	// ownership check.
+
+	// RetrieveByThing retrieves Config of the Thing with the given ID.
+	// Channels the Config is connected to are not retrieved.
+	RetrieveByThing(id string) (Config, error)
 
 	// RemoveThing removes Config of the Thing with the given ID.
 	RemoveThing(id string) error
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/mainflux/mainflux/bootstrap"
 )
@@ -24,6 +25,7 @@ type configRepositoryMock struct {
 	counter  uint64
 	configs  map[string]bootstrap.Config
 	channels map[string]bootstrap.Channel
+	versions map[string][]bootstrap.ConfigVersion
 }
 
 // NewConfigsRepository creates in-memory config repository.
@@ -31,6 +33,7 @@ func NewConfigsRepository() bootstrap.ConfigRepository {
 	return &configRepositoryMock{
 		configs:  make(map[string]bootstrap.Config),
 		channels: make(map[string]bootstrap.Channel),
+		versions: make(map[string][]bootstrap.ConfigVersion),
 	}
 }
 
@@ -44,8 +47,10 @@ func (crm *configRepositoryMock) Save(config bootstrap.Config, connections []str
 		}
 	}
 
-	crm.counter++
-	config.MFThing = strconv.FormatUint(crm.counter, 10)
+	if config.MFThing == "" {
+		crm.counter++
+		config.MFThing = strconv.FormatUint(crm.counter, 10)
+	}
 	crm.configs[config.MFThing] = config
 
 	for _, ch := range config.MFChannels {
@@ -59,6 +64,7 @@ func (crm *configRepositoryMock) Save(config bootstrap.Config, connections []str
 	}
 
 	crm.configs[config.MFThing] = config
+	crm.addVersion(config)
 
 	return config.MFThing, nil
 }
@@ -152,10 +158,56 @@ func (crm *configRepositoryMock) Update(config bootstrap.Config) error {
 	cfg.Name = config.Name
 	cfg.Content = config.Content
 	crm.configs[config.MFThing] = cfg
+	crm.addVersion(cfg)
 
 	return nil
 }
 
+func (crm *configRepositoryMock) RetrieveVersions(owner, id string) ([]bootstrap.ConfigVersion, error) {
+	crm.mu.Lock()
+	defer crm.mu.Unlock()
+
+	cfg, ok := crm.configs[id]
+	if !ok || cfg.Owner != owner {
+		return nil, bootstrap.ErrNotFound
+	}
+
+	return crm.versions[id], nil
+}
+
+func (crm *configRepositoryMock) Rollback(owner, id string, version uint64) error {
+	crm.mu.Lock()
+	defer crm.mu.Unlock()
+
+	cfg, ok := crm.configs[id]
+	if !ok || cfg.Owner != owner {
+		return bootstrap.ErrNotFound
+	}
+
+	for _, v := range crm.versions[id] {
+		if v.Version == version {
+			cfg.Name = v.Name
+			cfg.Content = v.Content
+			crm.configs[id] = cfg
+			crm.addVersion(cfg)
+			return nil
+		}
+	}
+
+	return bootstrap.ErrNotFound
+}
+
+func (crm *configRepositoryMock) addVersion(cfg bootstrap.Config) {
+	versions := crm.versions[cfg.MFThing]
+	v := bootstrap.ConfigVersion{
+		Version:   uint64(len(versions) + 1),
+		Name:      cfg.Name,
+		Content:   cfg.Content,
+		CreatedAt: time.Now(),
+	}
+	crm.versions[cfg.MFThing] = append(versions, v)
+}
+
 func (crm *configRepositoryMock) UpdateCert(owner, thingID, clientCert, clientKey, caCert string) error {
 	crm.mu.Lock()
 	defer crm.mu.Unlock()
@@ -210,6 +262,7 @@ func (crm *configRepositoryMock) Remove(token, id string) error {
 	for k, v := range crm.configs {
 		if v.Owner == token && k == id {
 			delete(crm.configs, k)
+			delete(crm.versions, k)
 			break
 		}
 	}
@@ -252,11 +305,24 @@ func (crm *configRepositoryMock) ListExisting(token string, connections []string
 	return ret, nil
 }
 
+func (crm *configRepositoryMock) RetrieveByThing(id string) (bootstrap.Config, error) {
+	crm.mu.Lock()
+	defer crm.mu.Unlock()
+
+	cfg, ok := crm.configs[id]
+	if !ok {
+		return bootstrap.Config{}, bootstrap.ErrNotFound
+	}
+
+	return cfg, nil
+}
+
 func (crm *configRepositoryMock) RemoveThing(id string) error {
 	crm.mu.Lock()
 	defer crm.mu.Unlock()
 
 	delete(crm.configs, id)
+	delete(crm.versions, id)
 	return nil
 }
 
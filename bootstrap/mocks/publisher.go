@@ -0,0 +1,45 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package mocks
+
+import (
+	"sync"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+)
+
+// Publisher represents message publisher mock which keeps published messages.
+type Publisher interface {
+	messaging.Publisher
+
+	// Messages returns messages published so far.
+	Messages() []messaging.Message
+}
+
+var _ Publisher = (*publisherMock)(nil)
+
+type publisherMock struct {
+	mu       sync.Mutex
+	messages []messaging.Message
+}
+
+// NewPublisher returns mock message publisher.
+func NewPublisher() Publisher {
+	return &publisherMock{}
+}
+
+func (pm *publisherMock) Publish(topic string, msg messaging.Message) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.messages = append(pm.messages, msg)
+	return nil
+}
+
+func (pm *publisherMock) Messages() []messaging.Message {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	return pm.messages
+}
@@ -189,9 +189,12 @@ func (svc *mainfluxThings) CreateChannels(_ context.Context, owner string, chs .
 		return []things.Channel{}, things.ErrUnauthorizedAccess
 	}
 	for i := range chs {
-		svc.counter++
+		// Skip IDs of the predefined channels.
+		for ok := true; ok; _, ok = svc.channels[chs[i].ID] {
+			svc.counter++
+			chs[i].ID = strconv.FormatUint(svc.counter, 10)
+		}
 		chs[i].Owner = userID.Email
-		chs[i].ID = strconv.FormatUint(svc.counter, 10)
 		svc.channels[chs[i].ID] = chs[i]
 	}
 
@@ -206,8 +209,22 @@ func (svc *mainfluxThings) ListChannels(context.Context, string, things.PageMeta
 	panic("not implemented")
 }
 
-func (svc *mainfluxThings) RemoveChannel(context.Context, string, string) error {
-	panic("not implemented")
+func (svc *mainfluxThings) RemoveChannel(_ context.Context, owner, id string) error {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	userID, err := svc.auth.Identify(context.Background(), &mainflux.Token{Value: owner})
+	if err != nil {
+		return things.ErrUnauthorizedAccess
+	}
+
+	if c, ok := svc.channels[id]; !ok || c.Owner != userID.Email {
+		return things.ErrNotFound
+	}
+
+	delete(svc.channels, id)
+	delete(svc.connections, id)
+	return nil
 }
 
 func (svc *mainfluxThings) CanAccessByKey(context.Context, string, string) (string, error) {
@@ -222,6 +239,10 @@ func (svc *mainfluxThings) Identify(context.Context, string) (string, error) {
 	panic("not implemented")
 }
 
+func (svc *mainfluxThings) Key(context.Context, string) (string, error) {
+	panic("not implemented")
+}
+
 func findIndex(list []string, val string) int {
 	for i, v := range list {
 		if v == val {
@@ -40,6 +40,8 @@ var (
 	errUpdateChannels   = errors.New("failed to update channels in bootstrap configuration database")
 	errRemoveChannels   = errors.New("failed to remove channels from bootstrap configuration in database")
 	errDisconnectThing  = errors.New("failed to disconnect thing in bootstrap configuration in database")
+	errSaveVersion      = errors.New("failed to save bootstrap configuration version to database")
+	errRetrieveVersions = errors.New("failed to retrieve bootstrap configuration versions from database")
 )
 
 var _ bootstrap.ConfigRepository = (*configRepository)(nil)
@@ -56,8 +58,8 @@ func NewConfigRepository(db *sqlx.DB, log logger.Logger) bootstrap.ConfigReposit
 }
 
 func (cr configRepository) Save(cfg bootstrap.Config, chsConnIDs []string) (string, error) {
-	q := `INSERT INTO configs (mainflux_thing, owner, name, client_cert, client_key, ca_cert, mainflux_key, external_id, external_key, content, state)
-		  VALUES (:mainflux_thing, :owner, :name, :client_cert, :client_key, :ca_cert, :mainflux_key, :external_id, :external_key, :content, :state)`
+	q := `INSERT INTO configs (mainflux_thing, owner, name, client_cert, client_key, ca_cert, mainflux_key, external_id, external_key, content, state, control_channel)
+		  VALUES (:mainflux_thing, :owner, :name, :client_cert, :client_key, :ca_cert, :mainflux_key, :external_id, :external_key, :content, :state, :control_channel)`
 
 	tx, err := cr.db.Beginx()
 	if err != nil {
@@ -89,6 +91,12 @@ func (cr configRepository) Save(cfg bootstrap.Config, chsConnIDs []string) (stri
 		return "", errors.Wrap(errSaveConnections, err)
 	}
 
+	if err := insertVersion(cfg.Owner, cfg.MFThing, tx); err != nil {
+		cr.rollback("Failed to insert Config version", tx, err)
+
+		return "", errors.Wrap(errSaveVersion, err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		cr.rollback("Failed to commit Config save", tx, err)
 	}
@@ -97,7 +105,7 @@ func (cr configRepository) Save(cfg bootstrap.Config, chsConnIDs []string) (stri
 }
 
 func (cr configRepository) RetrieveByID(owner, id string) (bootstrap.Config, error) {
-	q := `SELECT mainflux_thing, mainflux_key, external_id, external_key, name, content, state
+	q := `SELECT mainflux_thing, mainflux_key, external_id, external_key, name, content, state, control_channel
 		  FROM configs
 		  WHERE mainflux_thing = $1 AND owner = $2`
 
@@ -196,7 +204,7 @@ func (cr configRepository) RetrieveAll(owner string, filter bootstrap.Filter, of
 }
 
 func (cr configRepository) RetrieveByExternalID(externalID string) (bootstrap.Config, error) {
-	q := `SELECT mainflux_thing, mainflux_key, external_key, owner, name, client_cert, client_key, ca_cert, content, state
+	q := `SELECT mainflux_thing, mainflux_key, external_key, owner, name, client_cert, client_key, ca_cert, content, state, control_channel
 		  FROM configs
 		  WHERE external_id = $1`
 	dbcfg := dbConfig{
@@ -252,18 +260,71 @@ func (cr configRepository) Update(cfg bootstrap.Config) error {
 	content := nullString(cfg.Content)
 	name := nullString(cfg.Name)
 
-	res, err := cr.db.Exec(q, name, content, cfg.MFThing, cfg.Owner)
+	tx, err := cr.db.Beginx()
 	if err != nil {
 		return errors.Wrap(errUpdate, err)
 	}
 
-	cnt, err := res.RowsAffected()
+	res, err := tx.Exec(q, name, content, cfg.MFThing, cfg.Owner)
 	if err != nil {
+		cr.rollback("Failed to update Config", tx, err)
 		return errors.Wrap(errUpdate, err)
 	}
 
-	if cnt == 0 {
-		return bootstrap.ErrNotFound
+	if err := cr.commitVersion(cfg.Owner, cfg.MFThing, res, tx); err != nil {
+		return errors.Wrap(errUpdate, err)
+	}
+
+	return nil
+}
+
+func (cr configRepository) RetrieveVersions(owner, id string) ([]bootstrap.ConfigVersion, error) {
+	q := `SELECT version, name, content, created_at FROM config_versions
+		  WHERE config_id = $1 AND config_owner = $2 ORDER BY version`
+
+	rows, err := cr.db.Queryx(q, id, owner)
+	if err != nil {
+		return nil, errors.Wrap(errRetrieveVersions, err)
+	}
+	defer rows.Close()
+
+	var versions []bootstrap.ConfigVersion
+	for rows.Next() {
+		var name, content sql.NullString
+		v := bootstrap.ConfigVersion{}
+		if err := rows.Scan(&v.Version, &name, &content, &v.CreatedAt); err != nil {
+			return nil, errors.Wrap(errRetrieveVersions, err)
+		}
+		v.Name = name.String
+		v.Content = content.String
+		versions = append(versions, v)
+	}
+
+	if len(versions) == 0 {
+		return nil, bootstrap.ErrNotFound
+	}
+
+	return versions, nil
+}
+
+func (cr configRepository) Rollback(owner, id string, version uint64) error {
+	q := `UPDATE configs SET name = v.name, content = v.content FROM config_versions v
+		  WHERE configs.mainflux_thing = $1 AND configs.owner = $2
+		  AND v.config_id = $1 AND v.config_owner = $2 AND v.version = $3`
+
+	tx, err := cr.db.Beginx()
+	if err != nil {
+		return errors.Wrap(errUpdate, err)
+	}
+
+	res, err := tx.Exec(q, id, owner, version)
+	if err != nil {
+		cr.rollback("Failed to rollback Config", tx, err)
+		return errors.Wrap(errUpdate, err)
+	}
+
+	if err := cr.commitVersion(owner, id, res, tx); err != nil {
+		return errors.Wrap(errUpdate, err)
 	}
 
 	return nil
@@ -383,6 +444,23 @@ func (cr configRepository) ListExisting(owner string, ids []string) ([]bootstrap
 	return channels, nil
 }
 
+func (cr configRepository) RetrieveByThing(id string) (bootstrap.Config, error) {
+	q := `SELECT mainflux_thing, mainflux_key, external_id, external_key, owner, name, client_cert, client_key, ca_cert, content, state, control_channel
+		  FROM configs
+		  WHERE mainflux_thing = $1`
+	dbcfg := dbConfig{}
+
+	if err := cr.db.QueryRowx(q, id).StructScan(&dbcfg); err != nil {
+		empty := bootstrap.Config{}
+		if err == sql.ErrNoRows {
+			return empty, errors.Wrap(bootstrap.ErrNotFound, err)
+		}
+		return empty, errors.Wrap(errRetrieve, err)
+	}
+
+	return toConfig(dbcfg), nil
+}
+
 func (cr configRepository) RemoveThing(id string) error {
 	q := `DELETE FROM configs WHERE mainflux_thing = $1`
 	_, err := cr.db.Exec(q, id)
@@ -449,6 +527,30 @@ func (cr configRepository) retrieveAll(owner string, filter bootstrap.Filter) (s
 	return fmt.Sprintf(template, f), params
 }
 
+// commitVersion saves current Config name and content as the new
+// version and commits the transaction used to change them.
+func (cr configRepository) commitVersion(owner, id string, res sql.Result, tx *sqlx.Tx) error {
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		cr.rollback("Failed to update Config", tx, err)
+		return err
+	}
+
+	if cnt == 0 {
+		if err := tx.Rollback(); err != nil {
+			cr.log.Error(fmt.Sprintf("Failed to rollback due to %s", err))
+		}
+		return bootstrap.ErrNotFound
+	}
+
+	if err := insertVersion(owner, id, tx); err != nil {
+		cr.rollback("Failed to insert Config version", tx, err)
+		return errors.Wrap(errSaveVersion, err)
+	}
+
+	return tx.Commit()
+}
+
 func (cr configRepository) rollback(content string, tx *sqlx.Tx, err error) {
 	cr.log.Error(fmt.Sprintf("%s %s", content, err))
 
@@ -506,6 +608,18 @@ func insertConnections(cfg bootstrap.Config, connections []string, tx *sqlx.Tx)
 	return err
 }
 
+// insertVersion saves current name and content of the Config as its next version.
+func insertVersion(owner, id string, tx *sqlx.Tx) error {
+	q := `INSERT INTO config_versions (config_id, config_owner, version, name, content)
+		  SELECT mainflux_thing, owner, COALESCE(
+			  (SELECT MAX(version) FROM config_versions WHERE config_id = $1 AND config_owner = $2), 0) + 1,
+			  name, content
+		  FROM configs WHERE mainflux_thing = $1 AND owner = $2`
+
+	_, err := tx.Exec(q, id, owner)
+	return err
+}
+
 func updateConnections(owner, id string, connections []string, tx *sqlx.Tx) error {
 	if len(connections) == 0 {
 		return nil
@@ -575,6 +689,7 @@ type dbConfig struct {
 	ExternalKey string          `db:"external_key"`
 	Content     sql.NullString  `db:"content"`
 	State       bootstrap.State `db:"state"`
+	Control     sql.NullString  `db:"control_channel"`
 }
 
 func toDBConfig(cfg bootstrap.Config) dbConfig {
@@ -590,6 +705,7 @@ func toDBConfig(cfg bootstrap.Config) dbConfig {
 		ExternalKey: cfg.ExternalKey,
 		Content:     nullString(cfg.Content),
 		State:       cfg.State,
+		Control:     nullString(cfg.ControlChannel),
 	}
 }
 
@@ -622,6 +738,10 @@ func toConfig(dbcfg dbConfig) bootstrap.Config {
 	if dbcfg.CaCert.Valid {
 		cfg.CACert = dbcfg.CaCert.String
 	}
+
+	if dbcfg.Control.Valid {
+		cfg.ControlChannel = dbcfg.Control.String
+	}
 	return cfg
 }
 
@@ -245,6 +245,7 @@ func TestRetrieveByExternalID(t *testing.T) {
 	c.MFThing = uid.String()
 	c.ExternalID = uid.String()
 	c.ExternalKey = uid.String()
+	c.ControlChannel = "control"
 	_, err = repo.Save(c, channels)
 	require.Nil(t, err, fmt.Sprintf("Saving config expected to succeed: %s.\n", err))
 
@@ -265,8 +266,54 @@ func TestRetrieveByExternalID(t *testing.T) {
 		},
 	}
 	for _, tc := range cases {
-		_, err := repo.RetrieveByExternalID(tc.externalID)
+		cfg, err := repo.RetrieveByExternalID(tc.externalID)
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if err == nil {
+			assert.Equal(t, c.ControlChannel, cfg.ControlChannel, fmt.Sprintf("%s: expected control channel %s got %s\n", tc.desc, c.ControlChannel, cfg.ControlChannel))
+		}
+	}
+}
+
+func TestRetrieveByThing(t *testing.T) {
+	repo := postgres.NewConfigRepository(db, testLog)
+	err := deleteChannels(repo)
+	require.Nil(t, err, "Channels cleanup expected to succeed.")
+
+	c := config
+	// Use UUID to prevent conflicts.
+	uid, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Got unexpected error: %s.\n", err))
+	c.MFKey = uid.String()
+	c.MFThing = uid.String()
+	c.ExternalID = uid.String()
+	c.ExternalKey = uid.String()
+	c.ControlChannel = "control"
+	_, err = repo.Save(c, channels)
+	require.Nil(t, err, fmt.Sprintf("Saving config expected to succeed: %s.\n", err))
+
+	cases := []struct {
+		desc string
+		id   string
+		err  error
+	}{
+		{
+			desc: "retrieve config of non-existing thing",
+			id:   wrongID,
+			err:  bootstrap.ErrNotFound,
+		},
+		{
+			desc: "retrieve config of existing thing",
+			id:   c.MFThing,
+			err:  nil,
+		},
+	}
+	for _, tc := range cases {
+		cfg, err := repo.RetrieveByThing(tc.id)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if err == nil {
+			assert.Equal(t, c.Owner, cfg.Owner, fmt.Sprintf("%s: expected owner %s got %s\n", tc.desc, c.Owner, cfg.Owner))
+			assert.Equal(t, c.ControlChannel, cfg.ControlChannel, fmt.Sprintf("%s: expected control channel %s got %s\n", tc.desc, c.ControlChannel, cfg.ControlChannel))
+		}
 	}
 }
 
@@ -315,6 +362,133 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+func TestRetrieveVersions(t *testing.T) {
+	repo := postgres.NewConfigRepository(db, testLog)
+	err := deleteChannels(repo)
+	require.Nil(t, err, "Channels cleanup expected to succeed.")
+
+	c := config
+	// Use UUID to prevent conflicts.
+	uid, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Got unexpected error: %s.\n", err))
+	c.MFKey = uid.String()
+	c.MFThing = uid.String()
+	c.ExternalID = uid.String()
+	c.ExternalKey = uid.String()
+	_, err = repo.Save(c, channels)
+	require.Nil(t, err, fmt.Sprintf("Saving config expected to succeed: %s.\n", err))
+
+	modified := c
+	modified.Content = "new content"
+	err = repo.Update(modified)
+	require.Nil(t, err, fmt.Sprintf("Updating config expected to succeed: %s.\n", err))
+
+	cases := []struct {
+		desc     string
+		owner    string
+		id       string
+		versions []string
+		err      error
+	}{
+		{
+			desc:     "retrieve versions",
+			owner:    c.Owner,
+			id:       c.MFThing,
+			versions: []string{c.Content, modified.Content},
+			err:      nil,
+		},
+		{
+			desc:     "retrieve versions with wrong owner",
+			owner:    "2",
+			id:       c.MFThing,
+			versions: nil,
+			err:      bootstrap.ErrNotFound,
+		},
+		{
+			desc:     "retrieve versions of a non-existing config",
+			owner:    c.Owner,
+			id:       "non-existing",
+			versions: nil,
+			err:      bootstrap.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		versions, err := repo.RetrieveVersions(tc.owner, tc.id)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		var contents []string
+		for _, v := range versions {
+			contents = append(contents, v.Content)
+		}
+		assert.Equal(t, tc.versions, contents, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.versions, contents))
+	}
+}
+
+func TestRollback(t *testing.T) {
+	repo := postgres.NewConfigRepository(db, testLog)
+	err := deleteChannels(repo)
+	require.Nil(t, err, "Channels cleanup expected to succeed.")
+
+	c := config
+	// Use UUID to prevent conflicts.
+	uid, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Got unexpected error: %s.\n", err))
+	c.MFKey = uid.String()
+	c.MFThing = uid.String()
+	c.ExternalID = uid.String()
+	c.ExternalKey = uid.String()
+	_, err = repo.Save(c, channels)
+	require.Nil(t, err, fmt.Sprintf("Saving config expected to succeed: %s.\n", err))
+
+	modified := c
+	modified.Content = "new content"
+	err = repo.Update(modified)
+	require.Nil(t, err, fmt.Sprintf("Updating config expected to succeed: %s.\n", err))
+
+	cases := []struct {
+		desc    string
+		owner   string
+		version uint64
+		content string
+		err     error
+	}{
+		{
+			desc:    "rollback with wrong owner",
+			owner:   "2",
+			version: 1,
+			content: modified.Content,
+			err:     bootstrap.ErrNotFound,
+		},
+		{
+			desc:    "rollback to a non-existing version",
+			owner:   c.Owner,
+			version: 100,
+			content: modified.Content,
+			err:     bootstrap.ErrNotFound,
+		},
+		{
+			desc:    "rollback to the first version",
+			owner:   c.Owner,
+			version: 1,
+			content: c.Content,
+			err:     nil,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Rollback(tc.owner, c.MFThing, tc.version)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+
+		cfg, err := repo.RetrieveByID(c.Owner, c.MFThing)
+		require.Nil(t, err, fmt.Sprintf("%s: retrieving config expected to succeed: %s.\n", tc.desc, err))
+		assert.Equal(t, tc.content, cfg.Content, fmt.Sprintf("%s: expected content %s got %s\n", tc.desc, tc.content, cfg.Content))
+	}
+
+	versions, err := repo.RetrieveVersions(c.Owner, c.MFThing)
+	require.Nil(t, err, fmt.Sprintf("Retrieving versions expected to succeed: %s.\n", err))
+	assert.Equal(t, 3, len(versions), fmt.Sprintf("expected 3 versions got %d\n", len(versions)))
+}
+
 func TestUpdateCert(t *testing.T) {
 	repo := postgres.NewConfigRepository(db, testLog)
 	err := deleteChannels(repo)
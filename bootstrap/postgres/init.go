@@ -100,6 +100,46 @@ func migrateDB(db *sqlx.DB) error {
 					"CREATE TABLE IF NOT EXISTS unknown_configs",
 				},
 			},
+			{
+				Id: "configs_3",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS templates (
+						id          UUID NOT NULL,
+						owner       VARCHAR(254) NOT NULL,
+						name        TEXT,
+						content     TEXT,
+						channels    TEXT[],
+						cert_policy VARCHAR(16) NOT NULL,
+						client_cert TEXT,
+						client_key  TEXT,
+						ca_cert     TEXT,
+						PRIMARY KEY (id, owner)
+					)`,
+				},
+				Down: []string{
+					"DROP TABLE templates",
+				},
+			},
+			{
+				Id: "configs_4",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS config_versions (
+						config_id    TEXT,
+						config_owner VARCHAR(254),
+						version      BIGINT NOT NULL,
+						name         TEXT,
+						content      TEXT,
+						created_at   TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+						FOREIGN KEY (config_id, config_owner) REFERENCES configs (mainflux_thing, owner) ON DELETE CASCADE ON UPDATE CASCADE,
+						PRIMARY KEY (config_id, config_owner, version)
+					)`,
+					`ALTER TABLE configs ADD COLUMN IF NOT EXISTS control_channel TEXT`,
+				},
+				Down: []string{
+					"ALTER TABLE configs DROP COLUMN control_channel",
+					"DROP TABLE config_versions",
+				},
+			},
 		},
 	}
 
@@ -0,0 +1,182 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/mainflux/mainflux/bootstrap"
+	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/errors"
+)
+
+const invalidTextErr = "invalid_text_representation"
+
+var (
+	errSaveTemplate     = errors.New("failed to save bootstrap template to database")
+	errRetrieveTemplate = errors.New("failed to retrieve bootstrap template from database")
+	errUpdateTemplate   = errors.New("failed to update bootstrap template in database")
+	errRemoveTemplate   = errors.New("failed to remove bootstrap template from database")
+)
+
+var _ bootstrap.TemplateRepository = (*templateRepository)(nil)
+
+type templateRepository struct {
+	db  *sqlx.DB
+	log logger.Logger
+}
+
+// NewTemplateRepository instantiates a PostgreSQL implementation of template
+// repository.
+func NewTemplateRepository(db *sqlx.DB, log logger.Logger) bootstrap.TemplateRepository {
+	return &templateRepository{db: db, log: log}
+}
+
+func (tr templateRepository) Save(tpl bootstrap.Template) (string, error) {
+	q := `INSERT INTO templates (id, owner, name, content, channels, cert_policy, client_cert, client_key, ca_cert)
+		  VALUES (:id, :owner, :name, :content, :channels, :cert_policy, :client_cert, :client_key, :ca_cert)`
+
+	if _, err := tr.db.NamedExec(q, toDBTemplate(tpl)); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case duplicateErr:
+				return "", bootstrap.ErrConflict
+			case invalidTextErr:
+				return "", errors.Wrap(bootstrap.ErrMalformedEntity, err)
+			}
+		}
+		return "", errors.Wrap(errSaveTemplate, err)
+	}
+
+	return tpl.ID, nil
+}
+
+func (tr templateRepository) RetrieveByID(owner, id string) (bootstrap.Template, error) {
+	q := `SELECT id, owner, name, content, channels, cert_policy, client_cert, client_key, ca_cert
+		  FROM templates WHERE id = $1 AND owner = $2`
+
+	var dbtpl dbTemplate
+	if err := tr.db.QueryRowx(q, id, owner).StructScan(&dbtpl); err != nil {
+		if err == sql.ErrNoRows {
+			return bootstrap.Template{}, errors.Wrap(bootstrap.ErrNotFound, err)
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == invalidTextErr {
+			return bootstrap.Template{}, errors.Wrap(bootstrap.ErrNotFound, err)
+		}
+		return bootstrap.Template{}, errors.Wrap(errRetrieveTemplate, err)
+	}
+
+	return toTemplate(dbtpl), nil
+}
+
+func (tr templateRepository) RetrieveAll(owner string, offset, limit uint64) (bootstrap.TemplatesPage, error) {
+	q := `SELECT id, owner, name, content, channels, cert_policy, client_cert, client_key, ca_cert
+		  FROM templates WHERE owner = $1 ORDER BY id LIMIT $2 OFFSET $3`
+
+	rows, err := tr.db.Queryx(q, owner, limit, offset)
+	if err != nil {
+		return bootstrap.TemplatesPage{}, errors.Wrap(errRetrieveTemplate, err)
+	}
+	defer rows.Close()
+
+	tpls := []bootstrap.Template{}
+	for rows.Next() {
+		var dbtpl dbTemplate
+		if err := rows.StructScan(&dbtpl); err != nil {
+			return bootstrap.TemplatesPage{}, errors.Wrap(errRetrieveTemplate, err)
+		}
+		tpls = append(tpls, toTemplate(dbtpl))
+	}
+
+	var total uint64
+	if err := tr.db.QueryRow(`SELECT COUNT(*) FROM templates WHERE owner = $1`, owner).Scan(&total); err != nil {
+		return bootstrap.TemplatesPage{}, errors.Wrap(errRetrieveTemplate, err)
+	}
+
+	return bootstrap.TemplatesPage{
+		Total:     total,
+		Offset:    offset,
+		Limit:     limit,
+		Templates: tpls,
+	}, nil
+}
+
+func (tr templateRepository) Update(tpl bootstrap.Template) error {
+	q := `UPDATE templates SET name = :name, content = :content, channels = :channels, cert_policy = :cert_policy,
+		  client_cert = :client_cert, client_key = :client_key, ca_cert = :ca_cert
+		  WHERE id = :id AND owner = :owner`
+
+	res, err := tr.db.NamedExec(q, toDBTemplate(tpl))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == invalidTextErr {
+			return errors.Wrap(bootstrap.ErrNotFound, err)
+		}
+		return errors.Wrap(errUpdateTemplate, err)
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(errUpdateTemplate, err)
+	}
+
+	if cnt == 0 {
+		return bootstrap.ErrNotFound
+	}
+
+	return nil
+}
+
+func (tr templateRepository) Remove(owner, id string) error {
+	q := `DELETE FROM templates WHERE id = $1 AND owner = $2`
+	if _, err := tr.db.Exec(q, id, owner); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == invalidTextErr {
+			return nil
+		}
+		return errors.Wrap(errRemoveTemplate, err)
+	}
+
+	return nil
+}
+
+type dbTemplate struct {
+	ID         string         `db:"id"`
+	Owner      string         `db:"owner"`
+	Name       sql.NullString `db:"name"`
+	Content    sql.NullString `db:"content"`
+	Channels   pq.StringArray `db:"channels"`
+	CertPolicy string         `db:"cert_policy"`
+	ClientCert sql.NullString `db:"client_cert"`
+	ClientKey  sql.NullString `db:"client_key"`
+	CaCert     sql.NullString `db:"ca_cert"`
+}
+
+func toDBTemplate(tpl bootstrap.Template) dbTemplate {
+	return dbTemplate{
+		ID:         tpl.ID,
+		Owner:      tpl.Owner,
+		Name:       nullString(tpl.Name),
+		Content:    nullString(tpl.Content),
+		Channels:   pq.StringArray(tpl.Channels),
+		CertPolicy: string(tpl.CertPolicy),
+		ClientCert: nullString(tpl.ClientCert),
+		ClientKey:  nullString(tpl.ClientKey),
+		CaCert:     nullString(tpl.CACert),
+	}
+}
+
+func toTemplate(dbtpl dbTemplate) bootstrap.Template {
+	return bootstrap.Template{
+		ID:         dbtpl.ID,
+		Owner:      dbtpl.Owner,
+		Name:       dbtpl.Name.String,
+		Content:    dbtpl.Content.String,
+		Channels:   []string(dbtpl.Channels),
+		CertPolicy: bootstrap.CertPolicy(dbtpl.CertPolicy),
+		ClientCert: dbtpl.ClientCert.String,
+		ClientKey:  dbtpl.ClientKey.String,
+		CACert:     dbtpl.CaCert.String,
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package postgres_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/mainflux/mainflux/bootstrap"
+	"github.com/mainflux/mainflux/bootstrap/postgres"
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var template = bootstrap.Template{
+	Owner:      "user@email.com",
+	Name:       "gateway",
+	Content:    "id: {{external_id}}",
+	Channels:   []string{"1", "2"},
+	CertPolicy: bootstrap.SharedCerts,
+	ClientCert: "cert",
+	ClientKey:  "key",
+	CACert:     "ca",
+}
+
+func newTemplate(t *testing.T, repo bootstrap.TemplateRepository) bootstrap.Template {
+	uid, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Got unexpected error: %s.\n", err))
+
+	tpl := template
+	tpl.ID = uid.String()
+	_, err = repo.Save(tpl)
+	require.Nil(t, err, fmt.Sprintf("Saving template expected to succeed: %s.\n", err))
+
+	return tpl
+}
+
+func TestSaveTemplate(t *testing.T) {
+	repo := postgres.NewTemplateRepository(db, testLog)
+
+	uid, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Got unexpected error: %s.\n", err))
+
+	tpl := template
+	tpl.ID = uid.String()
+
+	invalidID := template
+	invalidID.ID = wrongValue
+
+	cases := []struct {
+		desc string
+		tpl  bootstrap.Template
+		err  error
+	}{
+		{
+			desc: "save a new template",
+			tpl:  tpl,
+			err:  nil,
+		},
+		{
+			desc: "save a template with an existing ID",
+			tpl:  tpl,
+			err:  bootstrap.ErrConflict,
+		},
+		{
+			desc: "save a template with an invalid ID",
+			tpl:  invalidID,
+			err:  bootstrap.ErrMalformedEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		id, err := repo.Save(tc.tpl)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if err == nil {
+			assert.Equal(t, tc.tpl.ID, id, fmt.Sprintf("%s: expected id %s got %s\n", tc.desc, tc.tpl.ID, id))
+		}
+	}
+}
+
+func TestRetrieveTemplateByID(t *testing.T) {
+	repo := postgres.NewTemplateRepository(db, testLog)
+	saved := newTemplate(t, repo)
+
+	nonExisting, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Got unexpected error: %s.\n", err))
+
+	cases := []struct {
+		desc  string
+		owner string
+		id    string
+		err   error
+	}{
+		{
+			desc:  "retrieve template",
+			owner: saved.Owner,
+			id:    saved.ID,
+			err:   nil,
+		},
+		{
+			desc:  "retrieve template with wrong owner",
+			owner: "2",
+			id:    saved.ID,
+			err:   bootstrap.ErrNotFound,
+		},
+		{
+			desc:  "retrieve a non-existing template",
+			owner: saved.Owner,
+			id:    nonExisting.String(),
+			err:   bootstrap.ErrNotFound,
+		},
+		{
+			desc:  "retrieve a template with invalid ID",
+			owner: saved.Owner,
+			id:    wrongID,
+			err:   bootstrap.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		tpl, err := repo.RetrieveByID(tc.owner, tc.id)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if err == nil {
+			assert.Equal(t, saved, tpl, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, saved, tpl))
+		}
+	}
+}
+
+func TestRetrieveAllTemplates(t *testing.T) {
+	repo := postgres.NewTemplateRepository(db, testLog)
+
+	owner, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("Got unexpected error: %s.\n", err))
+
+	numTemplates := 5
+	for i := 0; i < numTemplates; i++ {
+		uid, err := uuid.NewV4()
+		require.Nil(t, err, fmt.Sprintf("Got unexpected error: %s.\n", err))
+
+		tpl := template
+		tpl.ID = uid.String()
+		tpl.Owner = owner.String()
+		_, err = repo.Save(tpl)
+		require.Nil(t, err, fmt.Sprintf("Saving template expected to succeed: %s.\n", err))
+	}
+
+	cases := []struct {
+		desc   string
+		owner  string
+		offset uint64
+		limit  uint64
+		size   int
+	}{
+		{
+			desc:   "retrieve all templates",
+			owner:  owner.String(),
+			offset: 0,
+			limit:  uint64(numTemplates),
+			size:   numTemplates,
+		},
+		{
+			desc:   "retrieve a subset of templates",
+			owner:  owner.String(),
+			offset: 3,
+			limit:  uint64(numTemplates),
+			size:   numTemplates - 3,
+		},
+		{
+			desc:   "retrieve templates of a user without templates",
+			owner:  wrongValue,
+			offset: 0,
+			limit:  uint64(numTemplates),
+			size:   0,
+		},
+	}
+
+	for _, tc := range cases {
+		page, err := repo.RetrieveAll(tc.owner, tc.offset, tc.limit)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s\n", tc.desc, err))
+		assert.Equal(t, tc.size, len(page.Templates), fmt.Sprintf("%s: expected %d got %d\n", tc.desc, tc.size, len(page.Templates)))
+	}
+}
+
+func TestUpdateTemplate(t *testing.T) {
+	repo := postgres.NewTemplateRepository(db, testLog)
+	saved := newTemplate(t, repo)
+
+	modified := saved
+	modified.Name = "new name"
+	modified.Content = "new content"
+	modified.Channels = []string{"3"}
+	modified.CertPolicy = bootstrap.NoCerts
+
+	wrongOwner := modified
+	wrongOwner.Owner = "2"
+
+	cases := []struct {
+		desc string
+		tpl  bootstrap.Template
+		err  error
+	}{
+		{
+			desc: "update template",
+			tpl:  modified,
+			err:  nil,
+		},
+		{
+			desc: "update template with wrong owner",
+			tpl:  wrongOwner,
+			err:  bootstrap.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Update(tc.tpl)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+
+	tpl, err := repo.RetrieveByID(saved.Owner, saved.ID)
+	require.Nil(t, err, fmt.Sprintf("Retrieving template expected to succeed: %s.\n", err))
+	assert.Equal(t, modified, tpl, fmt.Sprintf("expected %v got %v\n", modified, tpl))
+}
+
+func TestRemoveTemplate(t *testing.T) {
+	repo := postgres.NewTemplateRepository(db, testLog)
+	saved := newTemplate(t, repo)
+
+	for i := 0; i < 2; i++ {
+		err := repo.Remove(saved.Owner, saved.ID)
+		assert.Nil(t, err, fmt.Sprintf("%d: failed to remove template due to: %s", i, err))
+
+		_, err = repo.RetrieveByID(saved.Owner, saved.ID)
+		assert.True(t, errors.Contains(err, bootstrap.ErrNotFound), fmt.Sprintf("%d: expected %s got %s", i, bootstrap.ErrNotFound, err))
+	}
+}
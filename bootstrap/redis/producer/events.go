@@ -45,6 +45,23 @@ type createConfigEvent struct {
 	timestamp  time.Time
 }
 
+func newCreateConfigEvent(cfg bootstrap.Config) createConfigEvent {
+	var channels []string
+	for _, ch := range cfg.MFChannels {
+		channels = append(channels, ch.ID)
+	}
+
+	return createConfigEvent{
+		mfThing:    cfg.MFThing,
+		owner:      cfg.Owner,
+		name:       cfg.Name,
+		mfChannels: channels,
+		externalID: cfg.ExternalID,
+		content:    cfg.Content,
+		timestamp:  time.Now(),
+	}
+}
+
 func (cce createConfigEvent) encode() map[string]interface{} {
 	return map[string]interface{}{
 		"thing_id":    cce.mfThing,
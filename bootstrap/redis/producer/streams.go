@@ -37,22 +37,7 @@ func (es eventStore) Add(token string, cfg bootstrap.Config) (bootstrap.Config,
 		return saved, err
 	}
 
-	var channels []string
-	for _, ch := range saved.MFChannels {
-		channels = append(channels, ch.ID)
-	}
-
-	ev := createConfigEvent{
-		mfThing:    saved.MFThing,
-		owner:      saved.Owner,
-		name:       saved.Name,
-		mfChannels: channels,
-		externalID: saved.ExternalID,
-		content:    saved.Content,
-		timestamp:  time.Now(),
-	}
-
-	es.add(ev)
+	es.add(newCreateConfigEvent(saved))
 
 	return saved, err
 }
@@ -78,6 +63,14 @@ func (es eventStore) Update(token string, cfg bootstrap.Config) error {
 	return nil
 }
 
+func (es eventStore) Versions(token, id string) ([]bootstrap.ConfigVersion, error) {
+	return es.svc.Versions(token, id)
+}
+
+func (es eventStore) Rollback(token, id string, version uint64) error {
+	return es.svc.Rollback(token, id, version)
+}
+
 func (es eventStore) UpdateCert(token, thingKey, clientCert, clientKey, caCert string) error {
 	return es.svc.UpdateCert(token, thingKey, clientCert, clientKey, caCert)
 }
@@ -151,6 +144,38 @@ func (es eventStore) ChangeState(token, id string, state bootstrap.State) error
 	return nil
 }
 
+func (es eventStore) AddTemplate(token string, tpl bootstrap.Template) (bootstrap.Template, error) {
+	return es.svc.AddTemplate(token, tpl)
+}
+
+func (es eventStore) ViewTemplate(token, id string) (bootstrap.Template, error) {
+	return es.svc.ViewTemplate(token, id)
+}
+
+func (es eventStore) UpdateTemplate(token string, tpl bootstrap.Template) error {
+	return es.svc.UpdateTemplate(token, tpl)
+}
+
+func (es eventStore) ListTemplates(token string, offset, limit uint64) (bootstrap.TemplatesPage, error) {
+	return es.svc.ListTemplates(token, offset, limit)
+}
+
+func (es eventStore) RemoveTemplate(token, id string) error {
+	return es.svc.RemoveTemplate(token, id)
+}
+
+func (es eventStore) BulkAdd(token, templateID string, entries []bootstrap.BulkConfig) ([]bootstrap.Config, error) {
+	// Events are sent once the bulk add completes, so Configs removed on
+	// rollback are never announced. Configs returned along with the error
+	// failed to be removed and are announced since they remain saved.
+	saved, err := es.svc.BulkAdd(token, templateID, entries)
+	for _, cfg := range saved {
+		es.add(newCreateConfigEvent(cfg))
+	}
+
+	return saved, err
+}
+
 func (es eventStore) RemoveConfigHandler(id string) error {
 	return es.svc.RemoveConfigHandler(id)
 }
@@ -6,6 +6,7 @@ package producer_test
 import (
 	"fmt"
 	"net/http/httptest"
+	"os"
 	"strconv"
 	"strings"
 	"testing"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/go-redis/redis"
 	"github.com/mainflux/mainflux"
+	log "github.com/mainflux/mainflux/logger"
 	"github.com/mainflux/mainflux/pkg/errors"
 	"github.com/opentracing/opentracing-go/mocktracer"
 
@@ -20,6 +22,7 @@ import (
 	"github.com/mainflux/mainflux/bootstrap/mocks"
 	"github.com/mainflux/mainflux/bootstrap/redis/producer"
 	mfsdk "github.com/mainflux/mainflux/pkg/sdk/go"
+	uuidProvider "github.com/mainflux/mainflux/pkg/uuid"
 	"github.com/mainflux/mainflux/things"
 	httpapi "github.com/mainflux/mainflux/things/api/things/http"
 	"github.com/stretchr/testify/assert"
@@ -68,7 +71,8 @@ func newService(auth mainflux.AuthNServiceClient, url string) bootstrap.Service
 	}
 
 	sdk := mfsdk.NewSDK(config)
-	return bootstrap.New(auth, configs, sdk, encKey)
+	logger, _ := log.New(os.Stdout, log.Info.String())
+	return bootstrap.New(auth, configs, mocks.NewTemplatesRepository(), sdk, encKey, uuidProvider.NewMock(), mocks.NewPublisher(), false, logger)
 }
 
 func newThingsService(auth mainflux.AuthNServiceClient) things.Service {
@@ -466,6 +470,95 @@ func TestBootstrap(t *testing.T) {
 	}
 }
 
+func TestBulkAdd(t *testing.T) {
+	redisClient.FlushAll().Err()
+
+	users := mocks.NewUsersService(map[string]string{validToken: email})
+	server := newThingsServer(newThingsService(users))
+	svc := newService(users, server.URL)
+	svc = producer.NewEventStoreMiddleware(svc, redisClient)
+
+	tpl, err := svc.AddTemplate(validToken, bootstrap.Template{
+		Name:       "gateway",
+		Content:    "id: {{external_id}}",
+		Channels:   []string{channel.ID},
+		CertPolicy: bootstrap.NoCerts,
+	})
+	require.Nil(t, err, fmt.Sprintf("Saving template expected to succeed: %s.\n", err))
+
+	cases := []struct {
+		desc    string
+		entries []bootstrap.BulkConfig
+		err     error
+		events  []map[string]interface{}
+	}{
+		{
+			desc: "bulk add configs successfully",
+			entries: []bootstrap.BulkConfig{
+				{ExternalID: "gw-1", ExternalKey: "key-1"},
+				{ExternalID: "gw-2", ExternalKey: "key-2"},
+			},
+			err: nil,
+			events: []map[string]interface{}{
+				{
+					"thing_id":    "1",
+					"owner":       email,
+					"name":        "",
+					"channels":    channel.ID,
+					"external_id": "gw-1",
+					"content":     "id: gw-1",
+					"timestamp":   time.Now().Unix(),
+					"operation":   configCreate,
+				},
+				{
+					"thing_id":    "2",
+					"owner":       email,
+					"name":        "",
+					"channels":    channel.ID,
+					"external_id": "gw-2",
+					"content":     "id: gw-2",
+					"timestamp":   time.Now().Unix(),
+					"operation":   configCreate,
+				},
+			},
+		},
+		{
+			desc: "bulk add configs with duplicate external ID",
+			entries: []bootstrap.BulkConfig{
+				{ExternalID: "gw-3", ExternalKey: "key-3"},
+				{ExternalID: "gw-1", ExternalKey: "key-1"},
+			},
+			err:    bootstrap.ErrConflict,
+			events: nil,
+		},
+	}
+
+	lastID := "0"
+	for _, tc := range cases {
+		_, err := svc.BulkAdd(validToken, tpl.ID, tc.entries)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+
+		streams := redisClient.XRead(&redis.XReadArgs{
+			Streams: []string{streamID, lastID},
+			Block:   time.Second,
+		}).Val()
+
+		var events []map[string]interface{}
+		if len(streams) > 0 {
+			for _, msg := range streams[0].Messages {
+				events = append(events, msg.Values)
+				lastID = msg.ID
+			}
+		}
+
+		// Configs removed on rollback must not be announced.
+		require.Len(t, events, len(tc.events), fmt.Sprintf("%s: expected %d events got %d\n", tc.desc, len(tc.events), len(events)))
+		for i := range tc.events {
+			test(t, tc.events[i], events[i], tc.desc)
+		}
+	}
+}
+
 func TestChangeState(t *testing.T) {
 	redisClient.FlushAll().Err()
 
@@ -8,13 +8,28 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/authn"
+	"github.com/mainflux/mainflux/logger"
 	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/mainflux/mainflux/pkg/messaging"
 	mfsdk "github.com/mainflux/mainflux/pkg/sdk/go"
 )
 
+const (
+	publisher = "bootstrap"
+
+	controlChannelName = "bootstrap-control"
+
+	configUpdate      = "config.update"
+	configRollback    = "config.rollback"
+	connectionsUpdate = "config.update_connections"
+)
+
 var (
 	// ErrNotFound indicates a non-existent entity request.
 	ErrNotFound = errors.New("non-existent entity")
@@ -55,6 +70,11 @@ var (
 	errCheckChannels      = errors.New("failed to check if channels exists")
 	errConnectionChannels = errors.New("failed to check channels connections")
 	errUpdateCert         = errors.New("failed to update cert")
+	errAddTemplate        = errors.New("failed to add bootstrap template")
+	errUpdateTemplate     = errors.New("failed to update bootstrap template")
+	errRemoveTemplate     = errors.New("failed to remove bootstrap template")
+	errBulkAdd            = errors.New("failed to add bootstrap configurations from template")
+	errRollback           = errors.New("failed to rollback bootstrap configuration")
 )
 
 var _ Service = (*bootstrapService)(nil)
@@ -71,6 +91,13 @@ type Service interface {
 	// Update updates editable fields of the provided Config.
 	Update(token string, cfg Config) error
 
+	// Versions returns versions of the Config with given ID belonging to the user
+	// identified by the given token, starting from the oldest one.
+	Versions(token, id string) ([]ConfigVersion, error)
+
+	// Rollback restores name and content of the Config with given ID to the given version.
+	Rollback(token, id string, version uint64) error
+
 	// UpdateCert updates an existing Config certificate and token.
 	// A non-nil error is returned to indicate operation failure.
 	UpdateCert(token, thingID, clientCert, clientKey, caCert string) error
@@ -91,6 +118,27 @@ type Service interface {
 	// ChangeState changes state of the Thing with given ID and owner.
 	ChangeState(token, id string, state State) error
 
+	// AddTemplate adds new Config Template to the user identified by the provided token.
+	AddTemplate(token string, tpl Template) (Template, error)
+
+	// ViewTemplate returns Config Template with given ID belonging to the user identified by the given token.
+	ViewTemplate(token, id string) (Template, error)
+
+	// UpdateTemplate updates editable fields of the provided Config Template.
+	UpdateTemplate(token string, tpl Template) error
+
+	// ListTemplates returns subset of Config Templates that belong to the user identified by the given token.
+	ListTemplates(token string, offset, limit uint64) (TemplatesPage, error)
+
+	// RemoveTemplate removes Config Template with given ID that belongs to the user identified by the given token.
+	RemoveTemplate(token, id string) error
+
+	// BulkAdd adds Thing Configs created from the Template with given ID, one for each of
+	// the provided entries. Either all Configs are added or none of them. If adding fails
+	// and some of the already added Configs can't be removed, these Configs are returned
+	// along with the error.
+	BulkAdd(token, templateID string, entries []BulkConfig) ([]Config, error)
+
 	// Methods RemoveConfig, UpdateChannel, and RemoveChannel are used as
 	// handlers for events. That's why these methods surpass ownership check.
 
@@ -116,20 +164,32 @@ type ConfigReader interface {
 }
 
 type bootstrapService struct {
-	auth    mainflux.AuthNServiceClient
-	configs ConfigRepository
-	sdk     mfsdk.SDK
-	encKey  []byte
-	reader  ConfigReader
+	auth      mainflux.AuthNServiceClient
+	configs   ConfigRepository
+	templates TemplateRepository
+	sdk       mfsdk.SDK
+	encKey    []byte
+	reader    ConfigReader
+	idp       mainflux.UUIDProvider
+	publisher messaging.Publisher
+	control   bool
+	logger    logger.Logger
 }
 
-// New returns new Bootstrap service.
-func New(auth mainflux.AuthNServiceClient, configs ConfigRepository, sdk mfsdk.SDK, encKey []byte) Service {
+// New returns new Bootstrap service. If control is true, a control channel
+// connected only to the Thing is created for each Config, and the Thing is
+// notified about the changes of its Config over this channel.
+func New(auth mainflux.AuthNServiceClient, configs ConfigRepository, templates TemplateRepository, sdk mfsdk.SDK, encKey []byte, idp mainflux.UUIDProvider, publisher messaging.Publisher, control bool, logger logger.Logger) Service {
 	return &bootstrapService{
-		configs: configs,
-		sdk:     sdk,
-		auth:    auth,
-		encKey:  encKey,
+		configs:   configs,
+		templates: templates,
+		sdk:       sdk,
+		auth:      auth,
+		encKey:    encKey,
+		idp:       idp,
+		publisher: publisher,
+		control:   control,
+		logger:    logger,
 	}
 }
 
@@ -139,6 +199,12 @@ func (bs bootstrapService) Add(token string, cfg Config) (Config, error) {
 		return Config{}, err
 	}
 
+	return bs.add(token, owner, cfg, false)
+}
+
+// Method add saves the Config creating corresponding Mainflux Thing if needed.
+// If render is true, Template variables in Config content are substituted.
+func (bs bootstrapService) add(token, owner string, cfg Config, render bool) (Config, error) {
 	toConnect := bs.toIDList(cfg.MFChannels)
 
 	// Check if channels exist. This is the way to prevent fetching channels that already exist.
@@ -163,9 +229,23 @@ func (bs bootstrapService) Add(token string, cfg Config) (Config, error) {
 	cfg.Owner = owner
 	cfg.State = Inactive
 	cfg.MFKey = mfThing.Key
+	if render {
+		cfg.Content = renderContent(cfg.Content, cfg)
+	}
 
-	saved, err := bs.configs.Save(cfg, toConnect)
+	if bs.control {
+		cfg.ControlChannel, err = bs.controlChannel(token, cfg.MFThing)
+	}
+	var saved string
+	if err == nil {
+		saved, err = bs.configs.Save(cfg, toConnect)
+	}
 	if err != nil {
+		if cfg.ControlChannel != "" {
+			if errC := bs.sdk.DeleteChannel(cfg.ControlChannel, token); errC != nil {
+				err = errors.Wrap(err, errC)
+			}
+		}
 		if id == "" {
 			if errT := bs.sdk.DeleteThing(cfg.MFThing, token); errT != nil {
 				err = errors.Wrap(err, errT)
@@ -197,7 +277,35 @@ func (bs bootstrapService) Update(token string, cfg Config) error {
 
 	cfg.Owner = owner
 
-	return bs.configs.Update(cfg)
+	if err := bs.configs.Update(cfg); err != nil {
+		return err
+	}
+
+	bs.notify(owner, cfg.MFThing, configUpdate)
+	return nil
+}
+
+func (bs bootstrapService) Versions(token, id string) ([]ConfigVersion, error) {
+	owner, err := bs.identify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return bs.configs.RetrieveVersions(owner, id)
+}
+
+func (bs bootstrapService) Rollback(token, id string, version uint64) error {
+	owner, err := bs.identify(token)
+	if err != nil {
+		return err
+	}
+
+	if err := bs.configs.Rollback(owner, id, version); err != nil {
+		return errors.Wrap(errRollback, err)
+	}
+
+	bs.notify(owner, id, configRollback)
+	return nil
 }
 
 func (bs bootstrapService) UpdateCert(token, thingID, clientCert, clientKey, caCert string) error {
@@ -265,7 +373,12 @@ func (bs bootstrapService) UpdateConnections(token, id string, connections []str
 		}
 	}
 
-	return bs.configs.UpdateConnections(owner, id, channels, connections)
+	if err := bs.configs.UpdateConnections(owner, id, channels, connections); err != nil {
+		return err
+	}
+
+	bs.notify(owner, id, connectionsUpdate)
+	return nil
 }
 
 func (bs bootstrapService) List(token string, filter Filter, offset, limit uint64) (ConfigsPage, error) {
@@ -282,9 +395,23 @@ func (bs bootstrapService) Remove(token, id string) error {
 	if err != nil {
 		return err
 	}
+	cfg, err := bs.configs.RetrieveByID(owner, id)
+	if err != nil {
+		if errors.Contains(err, ErrNotFound) {
+			return nil
+		}
+		return errors.Wrap(errRemoveBootstrap, err)
+	}
+
 	if err := bs.configs.Remove(owner, id); err != nil {
 		return errors.Wrap(errRemoveBootstrap, err)
 	}
+
+	if cfg.ControlChannel != "" {
+		if err := bs.sdk.DeleteChannel(cfg.ControlChannel, token); err != nil {
+			return errors.Wrap(ErrThings, err)
+		}
+	}
 	return nil
 }
 
@@ -351,6 +478,124 @@ func (bs bootstrapService) ChangeState(token, id string, state State) error {
 	return nil
 }
 
+func (bs bootstrapService) AddTemplate(token string, tpl Template) (Template, error) {
+	owner, err := bs.identify(token)
+	if err != nil {
+		return Template{}, err
+	}
+
+	if err := tpl.CertPolicy.Validate(); err != nil {
+		return Template{}, errors.Wrap(errAddTemplate, err)
+	}
+
+	// Check if channels exist, so that invalid Template is rejected before any Config is created from it.
+	for _, id := range tpl.Channels {
+		if _, err := bs.sdk.Channel(id, token); err != nil {
+			return Template{}, errors.Wrap(errAddTemplate, errors.Wrap(ErrMalformedEntity, err))
+		}
+	}
+
+	tpl.ID, err = bs.idp.ID()
+	if err != nil {
+		return Template{}, errors.Wrap(errAddTemplate, err)
+	}
+	tpl.Owner = owner
+
+	if _, err := bs.templates.Save(tpl); err != nil {
+		return Template{}, errors.Wrap(errAddTemplate, err)
+	}
+
+	return tpl, nil
+}
+
+func (bs bootstrapService) ViewTemplate(token, id string) (Template, error) {
+	owner, err := bs.identify(token)
+	if err != nil {
+		return Template{}, err
+	}
+
+	return bs.templates.RetrieveByID(owner, id)
+}
+
+func (bs bootstrapService) UpdateTemplate(token string, tpl Template) error {
+	owner, err := bs.identify(token)
+	if err != nil {
+		return err
+	}
+
+	if err := tpl.CertPolicy.Validate(); err != nil {
+		return errors.Wrap(errUpdateTemplate, err)
+	}
+
+	for _, id := range tpl.Channels {
+		if _, err := bs.sdk.Channel(id, token); err != nil {
+			return errors.Wrap(errUpdateTemplate, errors.Wrap(ErrMalformedEntity, err))
+		}
+	}
+
+	tpl.Owner = owner
+	if err := bs.templates.Update(tpl); err != nil {
+		return errors.Wrap(errUpdateTemplate, err)
+	}
+	return nil
+}
+
+func (bs bootstrapService) ListTemplates(token string, offset, limit uint64) (TemplatesPage, error) {
+	owner, err := bs.identify(token)
+	if err != nil {
+		return TemplatesPage{}, err
+	}
+
+	return bs.templates.RetrieveAll(owner, offset, limit)
+}
+
+func (bs bootstrapService) RemoveTemplate(token, id string) error {
+	owner, err := bs.identify(token)
+	if err != nil {
+		return err
+	}
+	if err := bs.templates.Remove(owner, id); err != nil {
+		return errors.Wrap(errRemoveTemplate, err)
+	}
+	return nil
+}
+
+func (bs bootstrapService) BulkAdd(token, templateID string, entries []BulkConfig) ([]Config, error) {
+	owner, err := bs.identify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	tpl, err := bs.templates.RetrieveByID(owner, templateID)
+	if err != nil {
+		return nil, errors.Wrap(errBulkAdd, err)
+	}
+
+	var saved []Config
+	// IDs of Mainflux Things created during the bulk add, removed on rollback.
+	var created []string
+	for _, e := range entries {
+		cfg, err := tpl.config(e)
+		if err == nil {
+			cfg, err = bs.add(token, owner, cfg, true)
+		}
+		if err != nil {
+			remaining, errR := bs.rollback(token, owner, saved, created)
+			if errR != nil {
+				err = errors.Wrap(err, errR)
+			}
+			return remaining, errors.Wrap(errBulkAdd, err)
+		}
+
+		saved = append(saved, cfg)
+		if e.MFThing == "" {
+			created = append(created, cfg.MFThing)
+		}
+	}
+
+	return saved, nil
+}
+
 func (bs bootstrapService) UpdateChannelHandler(channel Channel) error {
 	if err := bs.configs.UpdateChannel(channel); err != nil {
 		return errors.Wrap(errUpdateChannel, err)
@@ -359,9 +604,30 @@ func (bs bootstrapService) UpdateChannelHandler(channel Channel) error {
 }
 
 func (bs bootstrapService) RemoveConfigHandler(id string) error {
+	cfg, err := bs.configs.RetrieveByThing(id)
+	if err != nil {
+		if errors.Contains(err, ErrNotFound) {
+			return nil
+		}
+		return errors.Wrap(errRemoveConfig, err)
+	}
+
 	if err := bs.configs.RemoveThing(id); err != nil {
 		return errors.Wrap(errRemoveConfig, err)
 	}
+
+	if cfg.ControlChannel == "" {
+		return nil
+	}
+	// Removal event carries no user token, so the Config owner's
+	// key is issued to remove the control channel of the Config.
+	token, err := bs.issue(cfg.Owner)
+	if err != nil {
+		return errors.Wrap(errRemoveConfig, err)
+	}
+	if err := bs.sdk.DeleteChannel(cfg.ControlChannel, token); err != nil {
+		return errors.Wrap(ErrThings, err)
+	}
 	return nil
 }
 
@@ -379,6 +645,45 @@ func (bs bootstrapService) DisconnectThingHandler(channelID, thingID string) err
 	return nil
 }
 
+// Method notify publishes notification about the Config change to the control
+// channel of the Config, so that Thing can fetch the new Config. Since the
+// control channel is connected only to the Thing, other Things can neither
+// read nor forge its notifications.
+func (bs bootstrapService) notify(owner, thingID, operation string) {
+	if !bs.control {
+		return
+	}
+
+	cfg, err := bs.configs.RetrieveByID(owner, thingID)
+	if err != nil {
+		bs.logger.Warn(fmt.Sprintf("Failed to retrieve config for change notification: %s", err))
+		return
+	}
+	if cfg.ControlChannel == "" {
+		return
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"thing_id":  thingID,
+		"operation": operation,
+	})
+	if err != nil {
+		bs.logger.Warn(fmt.Sprintf("Failed to encode config change notification: %s", err))
+		return
+	}
+
+	msg := messaging.Message{
+		Channel:   cfg.ControlChannel,
+		Publisher: publisher,
+		Payload:   payload,
+		Created:   time.Now().UnixNano(),
+	}
+
+	if err := bs.publisher.Publish(msg.Channel, msg); err != nil {
+		bs.logger.Warn(fmt.Sprintf("Failed to publish config change notification: %s", err))
+	}
+}
+
 func (bs bootstrapService) identify(token string) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
@@ -391,6 +696,43 @@ func (bs bootstrapService) identify(token string) (string, error) {
 	return res.GetEmail(), nil
 }
 
+func (bs bootstrapService) issue(owner string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	res, err := bs.auth.Issue(ctx, &mainflux.IssueReq{Email: owner, Type: authn.UserKey})
+	if err != nil {
+		return "", errors.Wrap(ErrUnauthorizedAccess, err)
+	}
+
+	return res.GetValue(), nil
+}
+
+// Method controlChannel creates the control channel and connects the Thing
+// with the given ID to it.
+func (bs bootstrapService) controlChannel(token, thingID string) (string, error) {
+	id, err := bs.sdk.CreateChannel(mfsdk.Channel{
+		Name:     controlChannelName,
+		Metadata: map[string]interface{}{"thing_id": thingID},
+	}, token)
+	if err != nil {
+		return "", errors.Wrap(ErrThings, err)
+	}
+
+	conIDs := mfsdk.ConnectionIDs{
+		ChannelIDs: []string{id},
+		ThingIDs:   []string{thingID},
+	}
+	if err := bs.sdk.Connect(conIDs, token); err != nil {
+		if errC := bs.sdk.DeleteChannel(id, token); errC != nil {
+			err = errors.Wrap(err, errC)
+		}
+		return "", errors.Wrap(ErrThings, err)
+	}
+
+	return id, nil
+}
+
 // Method thing retrieves Mainflux Thing creating one if an empty ID is passed.
 func (bs bootstrapService) thing(token, id string) (mfsdk.Thing, error) {
 	thingID := id
@@ -421,6 +763,32 @@ func (bs bootstrapService) thing(token, id string) (mfsdk.Thing, error) {
 	return thing, nil
 }
 
+// Method rollback removes Configs saved and Things created during the failed
+// bulk add. It returns the Configs which failed to be removed.
+func (bs bootstrapService) rollback(token, owner string, saved []Config, created []string) ([]Config, error) {
+	var err error
+	var remaining []Config
+	for _, cfg := range saved {
+		if errR := bs.configs.Remove(owner, cfg.MFThing); errR != nil {
+			err = errors.Wrap(errRemoveBootstrap, errR)
+			remaining = append(remaining, cfg)
+			continue
+		}
+		if cfg.ControlChannel == "" {
+			continue
+		}
+		if errC := bs.sdk.DeleteChannel(cfg.ControlChannel, token); errC != nil {
+			err = errors.Wrap(ErrThings, errC)
+		}
+	}
+	for _, id := range created {
+		if errT := bs.sdk.DeleteThing(id, token); errT != nil {
+			err = errors.Wrap(ErrThings, errT)
+		}
+	}
+	return remaining, err
+}
+
 func (bs bootstrapService) connectionChannels(channels, existing []string, token string) ([]Channel, error) {
 	add := make(map[string]bool, len(channels))
 	for _, ch := range channels {
@@ -4,6 +4,7 @@
 package bootstrap_test
 
 import (
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
@@ -11,6 +12,7 @@ import (
 	"fmt"
 	"io"
 	"net/http/httptest"
+	"os"
 	"strconv"
 	"testing"
 
@@ -20,8 +22,11 @@ import (
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/bootstrap"
 	"github.com/mainflux/mainflux/bootstrap/mocks"
+	log "github.com/mainflux/mainflux/logger"
 	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/mainflux/mainflux/pkg/messaging"
 	mfsdk "github.com/mainflux/mainflux/pkg/sdk/go"
+	uuidProvider "github.com/mainflux/mainflux/pkg/uuid"
 	"github.com/mainflux/mainflux/things"
 	httpapi "github.com/mainflux/mainflux/things/api/things/http"
 	"github.com/stretchr/testify/assert"
@@ -51,16 +56,28 @@ var (
 		MFChannels:  []bootstrap.Channel{channel},
 		Content:     "config",
 	}
+
+	tpl = bootstrap.Template{
+		Name:       "gateway",
+		Content:    "id: {{external_id}}, thing: {{thing_id}}, key: {{thing_key}}",
+		Channels:   []string{channel.ID},
+		CertPolicy: bootstrap.NoCerts,
+	}
 )
 
 func newService(auth mainflux.AuthNServiceClient, url string) bootstrap.Service {
+	return newServiceWithPublisher(auth, url, mocks.NewPublisher(), false)
+}
+
+func newServiceWithPublisher(auth mainflux.AuthNServiceClient, url string, pub messaging.Publisher, control bool) bootstrap.Service {
 	things := mocks.NewConfigsRepository()
 	config := mfsdk.Config{
 		BaseURL: url,
 	}
 
 	sdk := mfsdk.NewSDK(config)
-	return bootstrap.New(auth, things, sdk, encKey)
+	logger, _ := log.New(os.Stdout, log.Info.String())
+	return bootstrap.New(auth, things, mocks.NewTemplatesRepository(), sdk, encKey, uuidProvider.NewMock(), pub, control, logger)
 }
 
 func newThingsService(auth mainflux.AuthNServiceClient) things.Service {
@@ -242,6 +259,191 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+func TestVersions(t *testing.T) {
+	users := mocks.NewUsersService(map[string]string{validToken: email})
+
+	server := newThingsServer(newThingsService(users))
+	svc := newService(users, server.URL)
+
+	saved, err := svc.Add(validToken, config)
+	require.Nil(t, err, fmt.Sprintf("Saving config expected to succeed: %s.\n", err))
+
+	modified := saved
+	modified.Content = "new-config"
+	err = svc.Update(validToken, modified)
+	require.Nil(t, err, fmt.Sprintf("Updating config expected to succeed: %s.\n", err))
+
+	cases := []struct {
+		desc     string
+		id       string
+		token    string
+		versions []string
+		err      error
+	}{
+		{
+			desc:     "view versions of an existing config",
+			id:       saved.MFThing,
+			token:    validToken,
+			versions: []string{saved.Content, modified.Content},
+			err:      nil,
+		},
+		{
+			desc:     "view versions of a non-existing config",
+			id:       unknown,
+			token:    validToken,
+			versions: nil,
+			err:      bootstrap.ErrNotFound,
+		},
+		{
+			desc:     "view versions with wrong credentials",
+			id:       saved.MFThing,
+			token:    invalidToken,
+			versions: nil,
+			err:      bootstrap.ErrUnauthorizedAccess,
+		},
+	}
+
+	for _, tc := range cases {
+		versions, err := svc.Versions(tc.token, tc.id)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		var contents []string
+		for i, v := range versions {
+			assert.Equal(t, uint64(i+1), v.Version, fmt.Sprintf("%s: expected version %d got %d\n", tc.desc, i+1, v.Version))
+			contents = append(contents, v.Content)
+		}
+		assert.Equal(t, tc.versions, contents, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.versions, contents))
+	}
+}
+
+func TestRollback(t *testing.T) {
+	users := mocks.NewUsersService(map[string]string{validToken: email})
+
+	server := newThingsServer(newThingsService(users))
+	pub := mocks.NewPublisher()
+	svc := newServiceWithPublisher(users, server.URL, pub, true)
+
+	saved, err := svc.Add(validToken, config)
+	require.Nil(t, err, fmt.Sprintf("Saving config expected to succeed: %s.\n", err))
+
+	modified := saved
+	modified.Content = "new-config"
+	err = svc.Update(validToken, modified)
+	require.Nil(t, err, fmt.Sprintf("Updating config expected to succeed: %s.\n", err))
+
+	cases := []struct {
+		desc    string
+		id      string
+		token   string
+		version uint64
+		content string
+		err     error
+	}{
+		{
+			desc:    "rollback config to the first version",
+			id:      saved.MFThing,
+			token:   validToken,
+			version: 1,
+			content: saved.Content,
+			err:     nil,
+		},
+		{
+			desc:    "rollback config to the second version",
+			id:      saved.MFThing,
+			token:   validToken,
+			version: 2,
+			content: modified.Content,
+			err:     nil,
+		},
+		{
+			desc:    "rollback config to a non-existing version",
+			id:      saved.MFThing,
+			token:   validToken,
+			version: 100,
+			content: modified.Content,
+			err:     bootstrap.ErrNotFound,
+		},
+		{
+			desc:    "rollback a non-existing config",
+			id:      unknown,
+			token:   validToken,
+			version: 1,
+			content: modified.Content,
+			err:     bootstrap.ErrNotFound,
+		},
+		{
+			desc:    "rollback config with wrong credentials",
+			id:      saved.MFThing,
+			token:   invalidToken,
+			version: 1,
+			content: modified.Content,
+			err:     bootstrap.ErrUnauthorizedAccess,
+		},
+	}
+
+	for _, tc := range cases {
+		sent := len(pub.Messages())
+		err := svc.Rollback(tc.token, tc.id, tc.version)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+
+		cfg, err := svc.View(validToken, saved.MFThing)
+		require.Nil(t, err, fmt.Sprintf("%s: viewing config expected to succeed: %s.\n", tc.desc, err))
+		assert.Equal(t, tc.content, cfg.Content, fmt.Sprintf("%s: expected content %s got %s\n", tc.desc, tc.content, cfg.Content))
+
+		msgs := pub.Messages()
+		if tc.err != nil {
+			assert.Equal(t, sent, len(msgs), fmt.Sprintf("%s: expected no notification to be published\n", tc.desc))
+			continue
+		}
+		require.Equal(t, sent+1, len(msgs), fmt.Sprintf("%s: expected notification to be published\n", tc.desc))
+		msg := msgs[len(msgs)-1]
+		assert.Equal(t, saved.ControlChannel, msg.Channel, fmt.Sprintf("%s: expected channel %s got %s\n", tc.desc, saved.ControlChannel, msg.Channel))
+	}
+}
+
+func TestControlChannel(t *testing.T) {
+	// Email is mapped to the token so that the owner's key can be issued.
+	users := mocks.NewUsersService(map[string]string{validToken: email, email: validToken})
+
+	ths := newThingsService(users)
+	server := newThingsServer(ths)
+	pub := mocks.NewPublisher()
+	svc := newServiceWithPublisher(users, server.URL, pub, true)
+
+	saved, err := svc.Add(validToken, config)
+	require.Nil(t, err, fmt.Sprintf("Saving config expected to succeed: %s.\n", err))
+	require.NotEmpty(t, saved.ControlChannel, "Saving config expected to create control channel")
+
+	other := config
+	other.ExternalID = "other_external_id"
+	otherSaved, err := svc.Add(validToken, other)
+	require.Nil(t, err, fmt.Sprintf("Saving config expected to succeed: %s.\n", err))
+	assert.NotEqual(t, saved.ControlChannel, otherSaved.ControlChannel, "expected configs to have different control channels")
+
+	ch, err := ths.ViewChannel(context.Background(), validToken, saved.ControlChannel)
+	require.Nil(t, err, fmt.Sprintf("Viewing control channel expected to succeed: %s.\n", err))
+	assert.Equal(t, saved.MFThing, ch.Metadata["thing_id"], fmt.Sprintf("expected control channel of thing %s got %v\n", saved.MFThing, ch.Metadata["thing_id"]))
+
+	modified := saved
+	modified.Content = "new-config"
+	err = svc.Update(validToken, modified)
+	require.Nil(t, err, fmt.Sprintf("Updating config expected to succeed: %s.\n", err))
+
+	msgs := pub.Messages()
+	require.Len(t, msgs, 1, "expected notification to be published")
+	assert.Equal(t, saved.ControlChannel, msgs[0].Channel, fmt.Sprintf("expected channel %s got %s\n", saved.ControlChannel, msgs[0].Channel))
+	assert.Empty(t, msgs[0].Subtopic, fmt.Sprintf("expected no subtopic got %s\n", msgs[0].Subtopic))
+
+	err = svc.Remove(validToken, saved.MFThing)
+	require.Nil(t, err, fmt.Sprintf("Removing config expected to succeed: %s.\n", err))
+	_, err = ths.ViewChannel(context.Background(), validToken, saved.ControlChannel)
+	assert.True(t, errors.Contains(err, things.ErrNotFound), fmt.Sprintf("expected control channel to be removed, got %s\n", err))
+
+	err = svc.RemoveConfigHandler(otherSaved.MFThing)
+	require.Nil(t, err, fmt.Sprintf("Removing config of removed thing expected to succeed: %s.\n", err))
+	_, err = ths.ViewChannel(context.Background(), validToken, otherSaved.ControlChannel)
+	assert.True(t, errors.Contains(err, things.ErrNotFound), fmt.Sprintf("expected control channel of removed thing to be removed, got %s\n", err))
+}
+
 func TestUpdateCert(t *testing.T) {
 	users := mocks.NewUsersService(map[string]string{validToken: email})
 
@@ -784,3 +986,354 @@ func TestDisconnectThingsHandler(t *testing.T) {
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 	}
 }
+
+func TestAddTemplate(t *testing.T) {
+	users := mocks.NewUsersService(map[string]string{validToken: email})
+
+	server := newThingsServer(newThingsService(users))
+	svc := newService(users, server.URL)
+
+	wrongChannels := tpl
+	wrongChannels.Channels = []string{"invalid"}
+
+	wrongPolicy := tpl
+	wrongPolicy.CertPolicy = "invalid"
+
+	cases := []struct {
+		desc  string
+		tpl   bootstrap.Template
+		token string
+		err   error
+	}{
+		{
+			desc:  "add a new template",
+			tpl:   tpl,
+			token: validToken,
+			err:   nil,
+		},
+		{
+			desc:  "add a template with wrong credentials",
+			tpl:   tpl,
+			token: invalidToken,
+			err:   bootstrap.ErrUnauthorizedAccess,
+		},
+		{
+			desc:  "add a template with invalid list of channels",
+			tpl:   wrongChannels,
+			token: validToken,
+			err:   bootstrap.ErrMalformedEntity,
+		},
+		{
+			desc:  "add a template with invalid cert policy",
+			tpl:   wrongPolicy,
+			token: validToken,
+			err:   bootstrap.ErrMalformedEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := svc.AddTemplate(tc.token, tc.tpl)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestViewTemplate(t *testing.T) {
+	users := mocks.NewUsersService(map[string]string{validToken: email})
+
+	server := newThingsServer(newThingsService(users))
+	svc := newService(users, server.URL)
+
+	saved, err := svc.AddTemplate(validToken, tpl)
+	require.Nil(t, err, fmt.Sprintf("Saving template expected to succeed: %s.\n", err))
+
+	cases := []struct {
+		desc  string
+		id    string
+		token string
+		err   error
+	}{
+		{
+			desc:  "view an existing template",
+			id:    saved.ID,
+			token: validToken,
+			err:   nil,
+		},
+		{
+			desc:  "view a non-existing template",
+			id:    unknown,
+			token: validToken,
+			err:   bootstrap.ErrNotFound,
+		},
+		{
+			desc:  "view a template with wrong credentials",
+			id:    saved.ID,
+			token: invalidToken,
+			err:   bootstrap.ErrUnauthorizedAccess,
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := svc.ViewTemplate(tc.token, tc.id)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestUpdateTemplate(t *testing.T) {
+	users := mocks.NewUsersService(map[string]string{validToken: email})
+
+	server := newThingsServer(newThingsService(users))
+	svc := newService(users, server.URL)
+
+	saved, err := svc.AddTemplate(validToken, tpl)
+	require.Nil(t, err, fmt.Sprintf("Saving template expected to succeed: %s.\n", err))
+
+	modified := saved
+	modified.Name = "new name"
+	modified.Content = "new content"
+	modified.Channels = []string{"1", "2"}
+
+	nonExisting := saved
+	nonExisting.ID = unknown
+
+	wrongChannels := saved
+	wrongChannels.Channels = []string{"invalid"}
+
+	cases := []struct {
+		desc  string
+		tpl   bootstrap.Template
+		token string
+		err   error
+	}{
+		{
+			desc:  "update an existing template",
+			tpl:   modified,
+			token: validToken,
+			err:   nil,
+		},
+		{
+			desc:  "update a non-existing template",
+			tpl:   nonExisting,
+			token: validToken,
+			err:   bootstrap.ErrNotFound,
+		},
+		{
+			desc:  "update a template with invalid list of channels",
+			tpl:   wrongChannels,
+			token: validToken,
+			err:   bootstrap.ErrMalformedEntity,
+		},
+		{
+			desc:  "update a template with wrong credentials",
+			tpl:   modified,
+			token: invalidToken,
+			err:   bootstrap.ErrUnauthorizedAccess,
+		},
+	}
+
+	for _, tc := range cases {
+		err := svc.UpdateTemplate(tc.token, tc.tpl)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestListTemplates(t *testing.T) {
+	users := mocks.NewUsersService(map[string]string{validToken: email})
+
+	server := newThingsServer(newThingsService(users))
+	svc := newService(users, server.URL)
+
+	numTemplates := 15
+	var saved []bootstrap.Template
+	for i := 0; i < numTemplates; i++ {
+		t1 := tpl
+		t1.Name = fmt.Sprintf("%s-%d", tpl.Name, i)
+		s, err := svc.AddTemplate(validToken, t1)
+		require.Nil(t, err, fmt.Sprintf("Saving template expected to succeed: %s.\n", err))
+		saved = append(saved, s)
+	}
+
+	cases := []struct {
+		desc   string
+		page   bootstrap.TemplatesPage
+		offset uint64
+		limit  uint64
+		token  string
+		err    error
+	}{
+		{
+			desc: "list templates",
+			page: bootstrap.TemplatesPage{
+				Total:     uint64(numTemplates),
+				Offset:    0,
+				Limit:     10,
+				Templates: saved[0:10],
+			},
+			offset: 0,
+			limit:  10,
+			token:  validToken,
+			err:    nil,
+		},
+		{
+			desc: "list last page",
+			page: bootstrap.TemplatesPage{
+				Total:     uint64(numTemplates),
+				Offset:    10,
+				Limit:     10,
+				Templates: saved[10:],
+			},
+			offset: 10,
+			limit:  10,
+			token:  validToken,
+			err:    nil,
+		},
+		{
+			desc:   "list templates unauthorized",
+			page:   bootstrap.TemplatesPage{},
+			offset: 0,
+			limit:  10,
+			token:  invalidToken,
+			err:    bootstrap.ErrUnauthorizedAccess,
+		},
+	}
+
+	for _, tc := range cases {
+		result, err := svc.ListTemplates(tc.token, tc.offset, tc.limit)
+		assert.ElementsMatch(t, tc.page.Templates, result.Templates, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.page.Templates, result.Templates))
+		assert.Equal(t, tc.page.Total, result.Total, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.page.Total, result.Total))
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestRemoveTemplate(t *testing.T) {
+	users := mocks.NewUsersService(map[string]string{validToken: email})
+
+	server := newThingsServer(newThingsService(users))
+	svc := newService(users, server.URL)
+
+	saved, err := svc.AddTemplate(validToken, tpl)
+	require.Nil(t, err, fmt.Sprintf("Saving template expected to succeed: %s.\n", err))
+
+	cases := []struct {
+		desc  string
+		id    string
+		token string
+		err   error
+	}{
+		{
+			desc:  "remove a template with wrong credentials",
+			id:    saved.ID,
+			token: invalidToken,
+			err:   bootstrap.ErrUnauthorizedAccess,
+		},
+		{
+			desc:  "remove an existing template",
+			id:    saved.ID,
+			token: validToken,
+			err:   nil,
+		},
+		{
+			desc:  "remove removed template",
+			id:    saved.ID,
+			token: validToken,
+			err:   nil,
+		},
+	}
+
+	for _, tc := range cases {
+		err := svc.RemoveTemplate(tc.token, tc.id)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestBulkAdd(t *testing.T) {
+	users := mocks.NewUsersService(map[string]string{validToken: email})
+
+	server := newThingsServer(newThingsService(users))
+	svc := newService(users, server.URL)
+
+	saved, err := svc.AddTemplate(validToken, tpl)
+	require.Nil(t, err, fmt.Sprintf("Saving template expected to succeed: %s.\n", err))
+
+	deviceCerts := tpl
+	deviceCerts.CertPolicy = bootstrap.DeviceCerts
+	deviceCerts.CACert = "ca"
+	savedDevice, err := svc.AddTemplate(validToken, deviceCerts)
+	require.Nil(t, err, fmt.Sprintf("Saving template expected to succeed: %s.\n", err))
+
+	entries := []bootstrap.BulkConfig{
+		{ExternalID: "gw-1", ExternalKey: "key-1"},
+		{ExternalID: "gw-2", ExternalKey: "key-2"},
+	}
+
+	cases := []struct {
+		desc    string
+		id      string
+		entries []bootstrap.BulkConfig
+		token   string
+		total   uint64
+		err     error
+	}{
+		{
+			desc:    "bulk add configs",
+			id:      saved.ID,
+			entries: entries,
+			token:   validToken,
+			total:   2,
+			err:     nil,
+		},
+		{
+			desc: "bulk add configs with duplicate external ID",
+			id:   saved.ID,
+			entries: []bootstrap.BulkConfig{
+				{ExternalID: "gw-3", ExternalKey: "key-3"},
+				{ExternalID: "gw-1", ExternalKey: "key-1"},
+			},
+			token: validToken,
+			total: 2,
+			err:   bootstrap.ErrConflict,
+		},
+		{
+			desc: "bulk add configs without required client certificates",
+			id:   savedDevice.ID,
+			entries: []bootstrap.BulkConfig{
+				{ExternalID: "gw-4", ExternalKey: "key-4", ClientCert: "cert", ClientKey: "key"},
+				{ExternalID: "gw-5", ExternalKey: "key-5"},
+			},
+			token: validToken,
+			total: 2,
+			err:   bootstrap.ErrMalformedEntity,
+		},
+		{
+			desc:    "bulk add configs from non-existing template",
+			id:      unknown,
+			entries: entries,
+			token:   validToken,
+			total:   2,
+			err:     bootstrap.ErrNotFound,
+		},
+		{
+			desc:    "bulk add configs with wrong credentials",
+			id:      saved.ID,
+			entries: entries,
+			token:   invalidToken,
+			total:   2,
+			err:     bootstrap.ErrUnauthorizedAccess,
+		},
+	}
+
+	for _, tc := range cases {
+		cfgs, err := svc.BulkAdd(tc.token, tc.id, tc.entries)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		for _, cfg := range cfgs {
+			content := fmt.Sprintf("id: %s, thing: %s, key: %s", cfg.ExternalID, cfg.MFThing, cfg.MFKey)
+			assert.Equal(t, content, cfg.Content, fmt.Sprintf("%s: expected content %s got %s\n", tc.desc, content, cfg.Content))
+		}
+		if tc.err != nil {
+			assert.Empty(t, cfgs, fmt.Sprintf("%s: expected no configs got %d\n", tc.desc, len(cfgs)))
+		}
+		// Unsuccessful bulk add must not leave any of the configs behind.
+		page, err := svc.List(validToken, bootstrap.Filter{}, 0, 10)
+		require.Nil(t, err, fmt.Sprintf("Listing configs expected to succeed: %s.\n", err))
+		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected %d configs got %d\n", tc.desc, tc.total, page.Total))
+	}
+}
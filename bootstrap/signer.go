@@ -0,0 +1,129 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+
+	"github.com/mainflux/mainflux/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// ErrSigningKey indicates that the signing key is malformed or of unsupported type.
+var ErrSigningKey = errors.New("malformed or unsupported signing key")
+
+// Signer signs bootstrap responses using the service key, so that devices
+// can verify that the response is produced by the Bootstrap service.
+type Signer interface {
+	// Sign returns detached JWS of the payload in compact serialization.
+	Sign(payload []byte) (string, error)
+
+	// PublicKey returns JSON Web Key used to verify signatures.
+	PublicKey() jose.JSONWebKey
+}
+
+type signer struct {
+	signer jose.Signer
+	key    jose.JSONWebKey
+}
+
+// NewSigner returns new response signer using the PEM encoded private key.
+// RSA, ECDSA and Ed25519 keys in PKCS #8 format are supported, as well as
+// RSA keys in PKCS #1 and ECDSA keys in SEC 1 format.
+func NewSigner(keyPEM []byte) (Signer, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, ErrSigningKey
+	}
+
+	key, err := parsePrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(ErrSigningKey, err)
+	}
+
+	alg, err := signatureAlgorithm(key)
+	if err != nil {
+		return nil, err
+	}
+
+	pub := jose.JSONWebKey{
+		Key:       key.Public(),
+		Algorithm: string(alg),
+		Use:       "sig",
+	}
+	thumb, err := pub.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, errors.Wrap(ErrSigningKey, err)
+	}
+	pub.KeyID = base64.RawURLEncoding.EncodeToString(thumb)
+
+	sk := jose.SigningKey{
+		Algorithm: alg,
+		Key: jose.JSONWebKey{
+			Key:   key,
+			KeyID: pub.KeyID,
+		},
+	}
+	s, err := jose.NewSigner(sk, nil)
+	if err != nil {
+		return nil, errors.Wrap(ErrSigningKey, err)
+	}
+
+	return signer{signer: s, key: pub}, nil
+}
+
+func (s signer) Sign(payload []byte) (string, error) {
+	jws, err := s.signer.Sign(payload)
+	if err != nil {
+		return "", err
+	}
+
+	return jws.DetachedCompactSerialize()
+}
+
+func (s signer) PublicKey() jose.JSONWebKey {
+	return s.key
+}
+
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		if s, ok := key.(crypto.Signer); ok {
+			return s, nil
+		}
+		return nil, ErrSigningKey
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+
+	return x509.ParseECPrivateKey(der)
+}
+
+func signatureAlgorithm(key crypto.Signer) (jose.SignatureAlgorithm, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return jose.RS256, nil
+	case ed25519.PrivateKey:
+		return jose.EdDSA, nil
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jose.ES256, nil
+		case elliptic.P384():
+			return jose.ES384, nil
+		case elliptic.P521():
+			return jose.ES512, nil
+		}
+	}
+
+	return "", ErrSigningKey
+}
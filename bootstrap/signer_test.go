@@ -0,0 +1,107 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/bootstrap"
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func TestSign(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err, fmt.Sprintf("Generating ECDSA key expected to succeed: %s.\n", err))
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	require.Nil(t, err, fmt.Sprintf("Encoding ECDSA key expected to succeed: %s.\n", err))
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err, fmt.Sprintf("Generating RSA key expected to succeed: %s.\n", err))
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.Nil(t, err, fmt.Sprintf("Generating Ed25519 key expected to succeed: %s.\n", err))
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.Nil(t, err, fmt.Sprintf("Encoding Ed25519 key expected to succeed: %s.\n", err))
+
+	payload := []byte(`{"mainflux_id":"id","mainflux_key":"key"}`)
+
+	cases := []struct {
+		desc string
+		key  []byte
+		pub  crypto.PublicKey
+		alg  jose.SignatureAlgorithm
+		err  error
+	}{
+		{
+			desc: "sign using ECDSA key",
+			key:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}),
+			pub:  ecKey.Public(),
+			alg:  jose.ES256,
+			err:  nil,
+		},
+		{
+			desc: "sign using RSA key",
+			key:  pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}),
+			pub:  rsaKey.Public(),
+			alg:  jose.RS256,
+			err:  nil,
+		},
+		{
+			desc: "sign using Ed25519 key",
+			key:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: edDER}),
+			pub:  edKey.Public(),
+			alg:  jose.EdDSA,
+			err:  nil,
+		},
+		{
+			desc: "sign using malformed key",
+			key:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("key")}),
+			err:  bootstrap.ErrSigningKey,
+		},
+		{
+			desc: "sign using non-PEM key",
+			key:  []byte("key"),
+			err:  bootstrap.ErrSigningKey,
+		},
+	}
+
+	for _, tc := range cases {
+		signer, err := bootstrap.NewSigner(tc.key)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if err != nil {
+			continue
+		}
+
+		jwk := signer.PublicKey()
+		assert.Equal(t, string(tc.alg), jwk.Algorithm, fmt.Sprintf("%s: expected algorithm %s got %s\n", tc.desc, tc.alg, jwk.Algorithm))
+		assert.NotEmpty(t, jwk.KeyID, fmt.Sprintf("%s: expected key ID to be set\n", tc.desc))
+
+		sig, err := signer.Sign(payload)
+		require.Nil(t, err, fmt.Sprintf("%s: signing expected to succeed: %s.\n", tc.desc, err))
+
+		jws, err := jose.ParseDetached(sig, payload)
+		require.Nil(t, err, fmt.Sprintf("%s: parsing signature expected to succeed: %s.\n", tc.desc, err))
+		_, err = jws.Verify(tc.pub)
+		assert.Nil(t, err, fmt.Sprintf("%s: verifying signature expected to succeed: %s.\n", tc.desc, err))
+		_, err = jws.Verify(&jwk)
+		assert.Nil(t, err, fmt.Sprintf("%s: verifying signature using JWK expected to succeed: %s.\n", tc.desc, err))
+
+		tampered, err := jose.ParseDetached(sig, []byte(`{"mainflux_id":"other"}`))
+		require.Nil(t, err, fmt.Sprintf("%s: parsing signature expected to succeed: %s.\n", tc.desc, err))
+		_, err = tampered.Verify(tc.pub)
+		assert.NotNil(t, err, fmt.Sprintf("%s: verifying tampered payload expected to fail\n", tc.desc))
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import "strings"
+
+// Variables that can be used in Template content. They are substituted with
+// values of the Config created from the Template.
+const (
+	ExternalIDVar = "{{external_id}}"
+	ThingIDVar    = "{{thing_id}}"
+	ThingKeyVar   = "{{thing_key}}"
+)
+
+// CertPolicy specifies how certificates are assigned to Configs created
+// from the Template.
+type CertPolicy string
+
+const (
+	// NoCerts policy creates Configs without certificates.
+	NoCerts CertPolicy = "none"
+	// SharedCerts policy copies Template certificates to every Config.
+	SharedCerts CertPolicy = "shared"
+	// DeviceCerts policy copies Template CA certificate to every Config and
+	// requires client certificate and key to be provided for each Config.
+	DeviceCerts CertPolicy = "device"
+)
+
+// Validate checks if the CertPolicy is one of the supported policies.
+func (cp CertPolicy) Validate() error {
+	switch cp {
+	case NoCerts, SharedCerts, DeviceCerts:
+		return nil
+	default:
+		return ErrMalformedEntity
+	}
+}
+
+// Template represents reusable Config specification used to provision
+// fleets of identical Things. Content may contain ExternalIDVar, ThingIDVar
+// and ThingKeyVar variables which are substituted for each created Config.
+// Channels is a list of Mainflux Channel IDs created Configs connect to.
+type Template struct {
+	ID         string
+	Owner      string
+	Name       string
+	Content    string
+	Channels   []string
+	CertPolicy CertPolicy
+	ClientCert string
+	ClientKey  string
+	CACert     string
+}
+
+// BulkConfig represents per-Thing data used to create a Config from the
+// Template. If MFThing is empty, a new Mainflux Thing is created.
+type BulkConfig struct {
+	MFThing     string
+	ExternalID  string
+	ExternalKey string
+	Name        string
+	ClientCert  string
+	ClientKey   string
+}
+
+// TemplatesPage contains page related metadata as well as list of Templates
+// that belong to this page.
+type TemplatesPage struct {
+	Total     uint64
+	Offset    uint64
+	Limit     uint64
+	Templates []Template
+}
+
+// TemplateRepository specifies a Template persistence API.
+type TemplateRepository interface {
+	// Save persists the Template. Successful operation is indicated by non-nil
+	// error response.
+	Save(tpl Template) (string, error)
+
+	// RetrieveByID retrieves the Template having the provided identifier, that
+	// is owned by the specified user.
+	RetrieveByID(owner, id string) (Template, error)
+
+	// RetrieveAll retrieves a subset of Templates that are owned by the
+	// specific user.
+	RetrieveAll(owner string, offset, limit uint64) (TemplatesPage, error)
+
+	// Update updates an existing Template. A non-nil error is returned
+	// to indicate operation failure.
+	Update(tpl Template) error
+
+	// Remove removes the Template having the provided identifier, that is
+	// owned by the specified user.
+	Remove(owner, id string) error
+}
+
+// config returns Config created from the Template for the given Thing data.
+// Content is rendered once the Mainflux Thing is known.
+func (tpl Template) config(bc BulkConfig) (Config, error) {
+	if bc.ExternalID == "" || bc.ExternalKey == "" {
+		return Config{}, ErrMalformedEntity
+	}
+
+	var chs []Channel
+	for _, id := range tpl.Channels {
+		chs = append(chs, Channel{ID: id})
+	}
+
+	cfg := Config{
+		MFThing:     bc.MFThing,
+		Name:        bc.Name,
+		ExternalID:  bc.ExternalID,
+		ExternalKey: bc.ExternalKey,
+		MFChannels:  chs,
+		Content:     tpl.Content,
+	}
+
+	switch tpl.CertPolicy {
+	case SharedCerts:
+		cfg.ClientCert = tpl.ClientCert
+		cfg.ClientKey = tpl.ClientKey
+		cfg.CACert = tpl.CACert
+	case DeviceCerts:
+		if bc.ClientCert == "" || bc.ClientKey == "" {
+			return Config{}, ErrMalformedEntity
+		}
+		cfg.ClientCert = bc.ClientCert
+		cfg.ClientKey = bc.ClientKey
+		cfg.CACert = tpl.CACert
+	}
+
+	return cfg, nil
+}
+
+// renderContent substitutes Template variables in the given content.
+func renderContent(content string, cfg Config) string {
+	r := strings.NewReplacer(
+		ExternalIDVar, cfg.ExternalID,
+		ThingIDVar, cfg.MFThing,
+		ThingKeyVar, cfg.MFKey,
+	)
+	return r.Replace(content)
+}
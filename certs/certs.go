@@ -26,4 +26,7 @@ type Repository interface {
 
 	// RetrieveByThing certificate by given thing
 	RetrieveByThing(ctx context.Context, thingID string) (Cert, error)
+
+	// RetrieveValid retrieves all issued certificates which are not expired
+	RetrieveValid(ctx context.Context) ([]Cert, error)
 }
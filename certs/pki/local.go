@@ -0,0 +1,420 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mainflux/mainflux/pkg/errors"
+)
+
+const (
+	keyTypeRSA = "rsa"
+	keyTypeEC  = "ec"
+
+	defRSABits = 2048
+	defECBits  = 256
+
+	intermediateCertFile = "intermediate.crt"
+	intermediateKeyFile  = "intermediate.key"
+	serialsFile          = "serials.json"
+)
+
+var (
+	// ErrNotFound indicates that certificate with given serial is not issued by the PKI.
+	ErrNotFound = errors.New("certificate not found")
+
+	errMissingCA            = errors.New("missing CA certificate or key")
+	errUnsupportedKeyType   = errors.New("unsupported private key type")
+	errUnsupportedKeyBits   = errors.New("unsupported private key bits")
+	errInvalidTTL           = errors.New("invalid certificate TTL")
+	errFailedKeyCreation    = errors.New("failed to create private key")
+	errFailedCertCreation   = errors.New("failed to create certificate")
+	errFailedCARotation     = errors.New("failed to rotate intermediate CA")
+	errFailedStateLoad      = errors.New("failed to load PKI state")
+	errFailedStateSave      = errors.New("failed to save PKI state")
+	errFailedPemEncode      = errors.New("failed to encode PEM data")
+	errFailedSerialCreation = errors.New("failed to generate certificate serial")
+)
+
+var _ Agent = (*localAgent)(nil)
+
+// record describes certificate issued by the local PKI.
+type record struct {
+	CommonName string    `json:"common_name"`
+	Expire     time.Time `json:"expire"`
+	RevokedAt  time.Time `json:"revoked_at,omitempty"`
+}
+
+type localAgent struct {
+	mu       sync.Mutex
+	dir      string
+	caTTL    time.Duration
+	root     *x509.Certificate
+	rootKey  crypto.Signer
+	rootPEM  string
+	ca       *x509.Certificate
+	caKey    crypto.Signer
+	caPEM    string
+	serials  map[string]record
+	timeFunc func() time.Time
+}
+
+// NewLocalAgent returns PKI agent that issues certificates without 3rd party
+// PKI service. Certificates are signed by the intermediate CA which is issued
+// by the given root CA and rotated when it is close to its expiration. The
+// intermediate CA as well as issued and revoked serials are kept in dir.
+func NewLocalAgent(root tls.Certificate, dir string, caTTL time.Duration) (Agent, error) {
+	if len(root.Certificate) == 0 || root.PrivateKey == nil {
+		return nil, errMissingCA
+	}
+	rootCert, err := x509.ParseCertificate(root.Certificate[0])
+	if err != nil {
+		return nil, errors.Wrap(errMissingCA, err)
+	}
+	rootKey, ok := root.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errUnsupportedKeyType
+	}
+	rootPEM, err := encodeCert(rootCert.Raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(errFailedStateLoad, err)
+	}
+
+	a := &localAgent{
+		dir:      dir,
+		caTTL:    caTTL,
+		root:     rootCert,
+		rootKey:  rootKey,
+		rootPEM:  rootPEM,
+		serials:  make(map[string]record),
+		timeFunc: time.Now,
+	}
+	if err := a.load(); err != nil {
+		return nil, errors.Wrap(errFailedStateLoad, err)
+	}
+
+	return a, nil
+}
+
+func (a *localAgent) IssueCert(cn string, ttl, keyType string, keyBits int) (Cert, error) {
+	validFor, err := time.ParseDuration(ttl)
+	if err != nil || validFor <= 0 {
+		return Cert{}, errInvalidTTL
+	}
+
+	priv, err := generateKey(keyType, keyBits)
+	if err != nil {
+		return Cert{}, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	notBefore := a.timeFunc()
+	notAfter := notBefore.Add(validFor)
+	// Rotate intermediate CA if it would expire before the issued certificate.
+	if a.ca == nil || a.ca.NotAfter.Before(notAfter) {
+		if err := a.rotate(notBefore); err != nil {
+			return Cert{}, errors.Wrap(errFailedCARotation, err)
+		}
+	}
+	if a.ca.NotAfter.Before(notAfter) {
+		notAfter = a.ca.NotAfter
+	}
+
+	serial, err := serialNumber()
+	if err != nil {
+		return Cert{}, err
+	}
+
+	tmpl := x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization:       []string{"Mainflux"},
+			CommonName:         cn,
+			OrganizationalUnit: []string{"mainflux"},
+		},
+		NotBefore:   notBefore,
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, a.ca, priv.Public(), a.caKey)
+	if err != nil {
+		return Cert{}, errors.Wrap(errFailedCertCreation, err)
+	}
+
+	certPEM, err := encodeCert(der)
+	if err != nil {
+		return Cert{}, err
+	}
+	keyPEM, err := encodeKey(priv)
+	if err != nil {
+		return Cert{}, err
+	}
+
+	sn := FormatSerial(serial)
+	a.serials[sn] = record{
+		CommonName: cn,
+		Expire:     notAfter,
+	}
+	if err := a.saveSerials(); err != nil {
+		delete(a.serials, sn)
+		return Cert{}, errors.Wrap(errFailedStateSave, err)
+	}
+
+	return Cert{
+		ClientCert:     certPEM,
+		IssuingCA:      a.caPEM,
+		CAChain:        []string{a.caPEM, a.rootPEM},
+		ClientKey:      keyPEM,
+		PrivateKeyType: keyType,
+		Serial:         sn,
+		Expire:         notAfter,
+	}, nil
+}
+
+func (a *localAgent) Revoke(serial string) (Revoke, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	rec, ok := a.serials[serial]
+	if !ok {
+		return Revoke{}, ErrNotFound
+	}
+	if !rec.RevokedAt.IsZero() {
+		return Revoke{RevocationTime: rec.RevokedAt}, nil
+	}
+
+	rec.RevokedAt = a.timeFunc()
+	a.serials[serial] = rec
+	if err := a.saveSerials(); err != nil {
+		rec.RevokedAt = time.Time{}
+		a.serials[serial] = rec
+		return Revoke{}, errors.Wrap(errFailedStateSave, err)
+	}
+
+	return Revoke{RevocationTime: rec.RevokedAt}, nil
+}
+
+// rotate issues new intermediate CA signed by the root CA.
+func (a *localAgent) rotate(now time.Time) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return errors.Wrap(errFailedKeyCreation, err)
+	}
+
+	serial, err := serialNumber()
+	if err != nil {
+		return err
+	}
+
+	notAfter := now.Add(a.caTTL)
+	if a.root.NotAfter.Before(notAfter) {
+		notAfter = a.root.NotAfter
+	}
+
+	tmpl := x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization:       []string{"Mainflux"},
+			CommonName:         fmt.Sprintf("Mainflux Intermediate CA %d", now.Unix()),
+			OrganizationalUnit: []string{"mainflux"},
+		},
+		NotBefore:             now,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, a.root, key.Public(), a.rootKey)
+	if err != nil {
+		return errors.Wrap(errFailedCertCreation, err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		return errors.Wrap(errFailedCertCreation, err)
+	}
+
+	caPEM, err := encodeCert(der)
+	if err != nil {
+		return err
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(a.dir, intermediateKeyFile), []byte(keyPEM), 0600); err != nil {
+		return errors.Wrap(errFailedStateSave, err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(a.dir, intermediateCertFile), []byte(caPEM), 0600); err != nil {
+		return errors.Wrap(errFailedStateSave, err)
+	}
+
+	a.ca = ca
+	a.caKey = key
+	a.caPEM = caPEM
+	return nil
+}
+
+// load reads intermediate CA and issued serials from the state directory.
+// Missing files are not treated as an error.
+func (a *localAgent) load() error {
+	b, err := ioutil.ReadFile(filepath.Join(a.dir, serialsFile))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, &a.serials); err != nil {
+			return err
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	certPath := filepath.Join(a.dir, intermediateCertFile)
+	if _, err := os.Stat(certPath); os.IsNotExist(err) {
+		return nil
+	}
+	ca, err := tls.LoadX509KeyPair(certPath, filepath.Join(a.dir, intermediateKeyFile))
+	if err != nil {
+		return err
+	}
+	cert, err := x509.ParseCertificate(ca.Certificate[0])
+	if err != nil {
+		return err
+	}
+	key, ok := ca.PrivateKey.(crypto.Signer)
+	if !ok {
+		return errUnsupportedKeyType
+	}
+	caPEM, err := encodeCert(cert.Raw)
+	if err != nil {
+		return err
+	}
+
+	a.ca = cert
+	a.caKey = key
+	a.caPEM = caPEM
+	return nil
+}
+
+func (a *localAgent) saveSerials() error {
+	b, err := json.Marshal(a.serials)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(a.dir, serialsFile+".tmp")
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(a.dir, serialsFile))
+}
+
+// FormatSerial formats certificate serial number the same way Vault does,
+// as colon separated hex encoded bytes.
+func FormatSerial(serial *big.Int) string {
+	b := serial.Bytes()
+	parts := make([]string, len(b))
+	for i, v := range b {
+		parts[i] = fmt.Sprintf("%02x", v)
+	}
+	return strings.Join(parts, ":")
+}
+
+func generateKey(keyType string, keyBits int) (crypto.Signer, error) {
+	switch keyType {
+	case "", keyTypeRSA:
+		if keyBits == 0 {
+			keyBits = defRSABits
+		}
+		if keyBits < defRSABits {
+			return nil, errUnsupportedKeyBits
+		}
+		k, err := rsa.GenerateKey(rand.Reader, keyBits)
+		if err != nil {
+			return nil, errors.Wrap(errFailedKeyCreation, err)
+		}
+		return k, nil
+	case keyTypeEC:
+		var curve elliptic.Curve
+		switch keyBits {
+		case 224:
+			curve = elliptic.P224()
+		case 0, defECBits:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, errUnsupportedKeyBits
+		}
+		k, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return nil, errors.Wrap(errFailedKeyCreation, err)
+		}
+		return k, nil
+	default:
+		return nil, errUnsupportedKeyType
+	}
+}
+
+func serialNumber() (*big.Int, error) {
+	limit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serial, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return nil, errors.Wrap(errFailedSerialCreation, err)
+	}
+	return serial, nil
+}
+
+func encodeCert(der []byte) (string, error) {
+	b := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if b == nil {
+		return "", errFailedPemEncode
+	}
+	return string(b), nil
+}
+
+func encodeKey(key crypto.Signer) (string, error) {
+	var block *pem.Block
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}
+	case *ecdsa.PrivateKey:
+		b, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return "", errors.Wrap(errFailedPemEncode, err)
+		}
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: b}
+	default:
+		return "", errUnsupportedKeyType
+	}
+	return string(pem.EncodeToMemory(block)), nil
+}
@@ -0,0 +1,190 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package pki_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux/certs/pki"
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cn = "thing-id"
+
+func rootCA(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err, fmt.Sprintf("generating root key expected to succeed: %s", err))
+
+	tmpl := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "root"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, key.Public(), key)
+	require.Nil(t, err, fmt.Sprintf("creating root CA expected to succeed: %s", err))
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func newAgent(t *testing.T, root tls.Certificate, dir string) pki.Agent {
+	agent, err := pki.NewLocalAgent(root, dir, time.Hour)
+	require.Nil(t, err, fmt.Sprintf("creating local agent expected to succeed: %s", err))
+	return agent
+}
+
+func parseCert(t *testing.T, data string) *x509.Certificate {
+	block, _ := pem.Decode([]byte(data))
+	require.NotNil(t, block, "decoding PEM certificate expected to succeed")
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.Nil(t, err, fmt.Sprintf("parsing certificate expected to succeed: %s", err))
+	return cert
+}
+
+func TestIssueCert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pki")
+	require.Nil(t, err, fmt.Sprintf("creating temp dir expected to succeed: %s", err))
+	defer os.RemoveAll(dir)
+
+	root := rootCA(t)
+	agent := newAgent(t, root, dir)
+	rootCert, err := x509.ParseCertificate(root.Certificate[0])
+	require.Nil(t, err, fmt.Sprintf("parsing root CA expected to succeed: %s", err))
+
+	cases := []struct {
+		desc    string
+		ttl     string
+		keyType string
+		keyBits int
+		err     error
+	}{
+		{
+			desc:    "issue RSA certificate",
+			ttl:     "10m",
+			keyType: "rsa",
+			keyBits: 2048,
+			err:     nil,
+		},
+		{
+			desc:    "issue ECDSA certificate",
+			ttl:     "10m",
+			keyType: "ec",
+			keyBits: 384,
+			err:     nil,
+		},
+		{
+			desc:    "issue certificate outliving intermediate CA",
+			ttl:     "2h",
+			keyType: "ec",
+			keyBits: 256,
+			err:     nil,
+		},
+		{
+			desc:    "issue certificate with invalid TTL",
+			ttl:     "invalid",
+			keyType: "rsa",
+			keyBits: 2048,
+			err:     errors.New("invalid certificate TTL"),
+		},
+		{
+			desc:    "issue certificate with unsupported key type",
+			ttl:     "10m",
+			keyType: "dsa",
+			keyBits: 2048,
+			err:     errors.New("unsupported private key type"),
+		},
+		{
+			desc:    "issue ECDSA certificate with unsupported key bits",
+			ttl:     "10m",
+			keyType: "ec",
+			keyBits: 2048,
+			err:     errors.New("unsupported private key bits"),
+		},
+	}
+
+	for _, tc := range cases {
+		c, err := agent.IssueCert(cn, tc.ttl, tc.keyType, tc.keyBits)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		if err != nil {
+			continue
+		}
+
+		cert := parseCert(t, c.ClientCert)
+		assert.Equal(t, cn, cert.Subject.CommonName, fmt.Sprintf("%s: expected CN %s got %s", tc.desc, cn, cert.Subject.CommonName))
+		assert.Equal(t, pki.FormatSerial(cert.SerialNumber), c.Serial, fmt.Sprintf("%s: expected serial %s got %s", tc.desc, pki.FormatSerial(cert.SerialNumber), c.Serial))
+
+		roots := x509.NewCertPool()
+		roots.AddCert(rootCert)
+		inter := x509.NewCertPool()
+		inter.AddCert(parseCert(t, c.IssuingCA))
+		_, err = cert.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: inter,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		})
+		assert.Nil(t, err, fmt.Sprintf("%s: expected certificate to be verified by the root CA: %s", tc.desc, err))
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pki")
+	require.Nil(t, err, fmt.Sprintf("creating temp dir expected to succeed: %s", err))
+	defer os.RemoveAll(dir)
+
+	root := rootCA(t)
+	agent := newAgent(t, root, dir)
+
+	c, err := agent.IssueCert(cn, "10m", "ec", 256)
+	require.Nil(t, err, fmt.Sprintf("issuing certificate expected to succeed: %s", err))
+
+	// Reload agent state from disk to make sure issued serials are persisted.
+	agent = newAgent(t, root, dir)
+
+	cases := []struct {
+		desc   string
+		serial string
+		err    error
+	}{
+		{
+			desc:   "revoke issued certificate",
+			serial: c.Serial,
+			err:    nil,
+		},
+		{
+			desc:   "revoke already revoked certificate",
+			serial: c.Serial,
+			err:    nil,
+		},
+		{
+			desc:   "revoke non-existing certificate",
+			serial: "00:01",
+			err:    pki.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		r, err := agent.Revoke(tc.serial)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		if err == nil {
+			assert.False(t, r.RevocationTime.IsZero(), fmt.Sprintf("%s: expected revocation time to be set", tc.desc))
+		}
+	}
+}
@@ -1,7 +1,8 @@
 // Copyright (c) Mainflux
 // SPDX-License-Identifier: Apache-2.0
 
-// Package pki wraps vault client
+// Package pki contains agents that issue and revoke certificates, either
+// through Vault or using the local CA.
 package pki
 
 import "time"
@@ -21,7 +22,8 @@ type Cert struct {
 }
 
 type Agent interface {
-	// IssueCert issues certificate on PKI
+	// IssueCert issues certificate on PKI with the given common name,
+	// which is the ID of the thing the certificate is issued for.
 	IssueCert(cn string, ttl, keyType string, keyBits int) (Cert, error)
 	// Revoke revokes certificate from PKI
 	Revoke(serial string) (Revoke, error)
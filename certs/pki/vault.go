@@ -1,7 +1,6 @@
 // Copyright (c) Mainflux
 // SPDX-License-Identifier: Apache-2.0
 
-// Package pki wraps vault client
 package pki
 
 import (
@@ -142,6 +142,26 @@ func (cr certsRepository) RetrieveByThing(ctx context.Context, thingID string) (
 	return c, nil
 }
 
+func (cr certsRepository) RetrieveValid(ctx context.Context) ([]certs.Cert, error) {
+	q := `SELECT thing_id, owner_id, serial, expire FROM certs WHERE expire > $1`
+	rows, err := cr.db.QueryxContext(ctx, q, time.Now())
+	if err != nil {
+		return nil, errors.Wrap(errRetrieveDB, err)
+	}
+	defer rows.Close()
+
+	var certificates []certs.Cert
+	for rows.Next() {
+		var dbcrt dbCert
+		if err := rows.StructScan(&dbcrt); err != nil {
+			return nil, errors.Wrap(errRetrieveDB, err)
+		}
+		certificates = append(certificates, toCert(dbcrt))
+	}
+
+	return certificates, nil
+}
+
 func (cr certsRepository) retrieveBySerial(ctx context.Context, serial string) (certs.Cert, error) {
 	q := `SELECT thing_id, owner_id, serial, expire FROM certs WHERE serial = $1`
 	var dbcrt dbCert
@@ -4,25 +4,20 @@
 package certs
 
 import (
-	"bufio"
-	"bytes"
 	"context"
-	"crypto/ecdsa"
-	"crypto/rand"
-	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
-	"crypto/x509/pkix"
-	"encoding/pem"
-	"math/big"
 	"time"
 
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/certs/pki"
+	mfauth "github.com/mainflux/mainflux/pkg/auth"
 	"github.com/mainflux/mainflux/pkg/errors"
 	mfsdk "github.com/mainflux/mainflux/pkg/sdk/go"
 )
 
+const keyTypeEC = "ec"
+
 var (
 	// ErrNotFound indicates a non-existent entity request.
 	ErrNotFound = errors.New("non-existent entity")
@@ -34,18 +29,9 @@ var (
 	// when accessing a protected resource.
 	ErrUnauthorizedAccess = errors.New("missing or invalid credentials provided")
 
-	errFailedKeyCreation         = errors.New("failed to create client private key")
-	errFailedDateSetting         = errors.New("failed to set date for certificate")
-	errKeyBitsValueWrong         = errors.New("missing RSA bits for certificate creation")
-	errMissingCACertificate      = errors.New("missing CA certificate for certificate signing")
-	errFailedSerialGeneration    = errors.New("failed to generate certificate serial")
-	errFailedPemKeyWrite         = errors.New("failed to write PEM key")
-	errFailedPemDataWrite        = errors.New("failed to write pem data for certificate")
-	errPrivateKeyUnsupportedType = errors.New("private key type is unsupported")
-	errPrivateKeyEmpty           = errors.New("private key is empty")
-	errFailedToRemoveCertFromDB  = errors.New("failed to remove cert serial from db")
-	errFailedCertCreation        = errors.New("failed to create client certificate")
-	errFailedCertRevocation      = errors.New("failed to revoke certificate")
+	errFailedToRemoveCertFromDB = errors.New("failed to remove cert serial from db")
+	errFailedCertCreation       = errors.New("failed to create client certificate")
+	errFailedCertRevocation     = errors.New("failed to revoke certificate")
 )
 
 var _ Service = (*certsService)(nil)
@@ -91,17 +77,37 @@ type certsService struct {
 	sdk       mfsdk.SDK
 	conf      Config
 	pki       pki.Agent
+	cache     mfauth.CertsCache
 }
 
-// New returns new Certs service.
-func New(auth mainflux.AuthNServiceClient, certs Repository, sdk mfsdk.SDK, config Config, pki pki.Agent) Service {
+// New returns new Certs service. Issued certificates are added to the
+// certificates cache used by protocol adapters, and revoked ones are
+// removed from it.
+func New(auth mainflux.AuthNServiceClient, certs Repository, sdk mfsdk.SDK, config Config, pki pki.Agent, cache mfauth.CertsCache) Service {
 	return &certsService{
 		certsRepo: certs,
 		sdk:       sdk,
 		auth:      auth,
 		conf:      config,
 		pki:       pki,
+		cache:     cache,
+	}
+}
+
+// SeedCache saves the valid certificates from the repository to the
+// certificates cache, so that the certificates issued before the cache
+// was set up are accepted by the protocol adapters.
+func SeedCache(ctx context.Context, repo Repository, cache mfauth.CertsCache) error {
+	certs, err := repo.RetrieveValid(ctx)
+	if err != nil {
+		return err
+	}
+	for _, c := range certs {
+		if err := cache.Save(c.Serial, c.ThingID); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 type Revoke struct {
@@ -132,16 +138,16 @@ func (cs *certsService) IssueCert(ctx context.Context, token, thingID string, da
 		return c, errors.Wrap(errFailedCertCreation, err)
 	}
 
-	// If PKIHost is not set we don't use 3rd party PKI service.
-	if cs.conf.PKIHost == "" {
-		c.ClientCert, c.ClientKey, err = cs.certs(thing.Key, daysValid, keyBits)
-		if err != nil {
-			return c, errors.Wrap(errFailedCertCreation, err)
-		}
-		return c, err
+	if daysValid == "" {
+		daysValid = cs.conf.SignHoursValid
+	}
+	if keyBits == 0 && keyType != keyTypeEC {
+		keyBits = cs.conf.SignRSABits
 	}
 
-	cert, err := cs.pki.IssueCert(thingID, daysValid, keyType, keyBits)
+	// Certificate subject is visible to every TLS peer, so the thing is
+	// identified by its ID rather than by its key.
+	cert, err := cs.pki.IssueCert(thing.ID, daysValid, keyType, keyBits)
 	if err != nil {
 		return c, errors.Wrap(errFailedCertCreation, err)
 	}
@@ -156,8 +162,10 @@ func (cs *certsService) IssueCert(ctx context.Context, token, thingID string, da
 	c.Serial = cert.Serial
 	c.Expire = cert.Expire
 
-	_, err = cs.certsRepo.Save(context.Background(), c)
-	return c, err
+	if _, err := cs.certsRepo.Save(context.Background(), c); err != nil {
+		return c, err
+	}
+	return c, cs.cache.Save(c.Serial, c.ThingID)
 }
 
 func (cs *certsService) RevokeCert(ctx context.Context, token, thingID string) (Revoke, error) {
@@ -180,6 +188,9 @@ func (cs *certsService) RevokeCert(ctx context.Context, token, thingID string) (
 	if err != nil {
 		return revoke, errors.Wrap(errFailedCertRevocation, err)
 	}
+	if err := cs.cache.Remove(cert.Serial); err != nil {
+		return revoke, errors.Wrap(errFailedCertRevocation, err)
+	}
 	revoke.RevocationTime = r.RevocationTime
 	if err = cs.certsRepo.Remove(context.Background(), cert.Serial); err != nil {
 		return revoke, errors.Wrap(errFailedToRemoveCertFromDB, err)
@@ -195,109 +206,3 @@ func (cs *certsService) ListCerts(ctx context.Context, token string, offset, lim
 
 	return cs.certsRepo.RetrieveAll(ctx, u.GetEmail(), offset, limit)
 }
-
-func (cs *certsService) certs(thingKey, daysValid string, keyBits int) (string, string, error) {
-	if cs.conf.SignX509Cert == nil {
-		return "", "", errors.Wrap(errFailedCertCreation, errMissingCACertificate)
-	}
-	if keyBits == 0 {
-		return "", "", errors.Wrap(errFailedCertCreation, errKeyBitsValueWrong)
-	}
-	var priv interface{}
-	priv, err := rsa.GenerateKey(rand.Reader, keyBits)
-	if err != nil {
-		return "", "", errors.Wrap(errFailedKeyCreation, err)
-	}
-
-	if daysValid == "" {
-		daysValid = cs.conf.SignHoursValid
-	}
-
-	notBefore := time.Now()
-	validFor, err := time.ParseDuration(daysValid)
-	if err != nil {
-		return "", "", errors.Wrap(errFailedDateSetting, err)
-	}
-	notAfter := notBefore.Add(validFor)
-
-	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
-	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
-	if err != nil {
-		return "", "", errors.Wrap(errFailedSerialGeneration, err)
-	}
-
-	tmpl := x509.Certificate{
-		SerialNumber: serialNumber,
-		Subject: pkix.Name{
-			Organization:       []string{"Mainflux"},
-			CommonName:         thingKey,
-			OrganizationalUnit: []string{"mainflux"},
-		},
-		NotBefore: notBefore,
-		NotAfter:  notAfter,
-
-		KeyUsage:     x509.KeyUsageDigitalSignature,
-		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
-		SubjectKeyId: []byte{1, 2, 3, 4, 6},
-	}
-
-	pubKey, err := publicKey(priv)
-	if err != nil {
-		return "", "", errors.Wrap(errFailedCertCreation, err)
-	}
-	derBytes, err := x509.CreateCertificate(rand.Reader, &tmpl, cs.conf.SignX509Cert, pubKey, cs.conf.SignTLSCert.PrivateKey)
-	if err != nil {
-		return "", "", errors.Wrap(errFailedCertCreation, err)
-	}
-
-	var bw, keyOut bytes.Buffer
-	buffWriter := bufio.NewWriter(&bw)
-	buffKeyOut := bufio.NewWriter(&keyOut)
-
-	if err := pem.Encode(buffWriter, &pem.Block{Type: "CERTIFICATE", Bytes: derBytes}); err != nil {
-		return "", "", errors.Wrap(errFailedPemDataWrite, err)
-	}
-	buffWriter.Flush()
-	cert := bw.String()
-
-	block, err := pemBlockForKey(priv)
-	if err != nil {
-		return "", "", errors.Wrap(errFailedPemKeyWrite, err)
-	}
-	if err := pem.Encode(buffKeyOut, block); err != nil {
-		return "", "", errors.Wrap(errFailedPemKeyWrite, err)
-	}
-	buffKeyOut.Flush()
-	key := keyOut.String()
-
-	return cert, key, nil
-}
-
-func publicKey(priv interface{}) (interface{}, error) {
-	if priv == nil {
-		return nil, errPrivateKeyEmpty
-	}
-	switch k := priv.(type) {
-	case *rsa.PrivateKey:
-		return &k.PublicKey, nil
-	case *ecdsa.PrivateKey:
-		return &k.PublicKey, nil
-	default:
-		return nil, errPrivateKeyUnsupportedType
-	}
-}
-
-func pemBlockForKey(priv interface{}) (*pem.Block, error) {
-	switch k := priv.(type) {
-	case *rsa.PrivateKey:
-		return &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}, nil
-	case *ecdsa.PrivateKey:
-		b, err := x509.MarshalECPrivateKey(k)
-		if err != nil {
-			return nil, err
-		}
-		return &pem.Block{Type: "EC PRIVATE KEY", Bytes: b}, nil
-	default:
-		return nil, nil
-	}
-}
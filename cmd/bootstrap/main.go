@@ -30,7 +30,10 @@ import (
 	api "github.com/mainflux/mainflux/bootstrap/api"
 	"github.com/mainflux/mainflux/bootstrap/postgres"
 	mflog "github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/nats"
 	mfsdk "github.com/mainflux/mainflux/pkg/sdk/go"
+	uuidProvider "github.com/mainflux/mainflux/pkg/uuid"
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
 	jconfig "github.com/uber/jaeger-client-go/config"
 	"google.golang.org/grpc"
@@ -66,6 +69,9 @@ const (
 	defJaegerURL      = ""
 	defAuthnURL       = "localhost:8181"
 	defAuthnTimeout   = "1s"
+	defNatsURL        = "nats://localhost:4222"
+	defControl        = "false"
+	defSignKey        = ""
 
 	envLogLevel       = "MF_BOOTSTRAP_LOG_LEVEL"
 	envDBHost         = "MF_BOOTSTRAP_DB_HOST"
@@ -95,6 +101,9 @@ const (
 	envJaegerURL      = "MF_JAEGER_URL"
 	envAuthnURL       = "MF_AUTHN_GRPC_URL"
 	envAuthnTimeout   = "MF_AUTHN_GRPC_TIMEOUT"
+	envNatsURL        = "MF_NATS_URL"
+	envControl        = "MF_BOOTSTRAP_CONTROL_CHANNELS"
+	envSignKey        = "MF_BOOTSTRAP_SIGN_KEY"
 )
 
 type config struct {
@@ -118,6 +127,9 @@ type config struct {
 	jaegerURL      string
 	authnURL       string
 	authnTimeout   time.Duration
+	natsURL        string
+	control        bool
+	signKey        string
 }
 
 func main() {
@@ -145,10 +157,18 @@ func main() {
 
 	auth := authapi.NewClient(authTracer, authConn, cfg.authnTimeout)
 
-	svc := newService(auth, db, logger, esClient, cfg)
+	pub, err := nats.NewPublisher(cfg.natsURL)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to connect to NATS: %s", err))
+		os.Exit(1)
+	}
+	defer pub.Close()
+
+	svc := newService(auth, db, logger, esClient, pub, cfg)
+	signer := newSigner(cfg.signKey, logger)
 	errs := make(chan error, 2)
 
-	go startHTTPServer(svc, cfg, logger, errs)
+	go startHTTPServer(svc, signer, cfg, logger, errs)
 	go subscribeToThingsES(svc, thingsESConn, cfg.esConsumerName, logger)
 
 	go func() {
@@ -166,6 +186,10 @@ func loadConfig() config {
 	if err != nil {
 		tls = false
 	}
+	control, err := strconv.ParseBool(mainflux.Env(envControl, defControl))
+	if err != nil {
+		control = false
+	}
 	dbConfig := postgres.Config{
 		Host:        mainflux.Env(envDBHost, defDBHost),
 		Port:        mainflux.Env(envDBPort, defDBPort),
@@ -214,6 +238,9 @@ func loadConfig() config {
 		jaegerURL:      mainflux.Env(envJaegerURL, defJaegerURL),
 		authnURL:       mainflux.Env(envAuthnURL, defAuthnURL),
 		authnTimeout:   authnTimeout,
+		natsURL:        mainflux.Env(envNatsURL, defNatsURL),
+		control:        control,
+		signKey:        mainflux.Env(envSignKey, defSignKey),
 	}
 }
 
@@ -264,8 +291,9 @@ func initJaeger(svcName, url string, logger logger.Logger) (opentracing.Tracer,
 	return tracer, closer
 }
 
-func newService(auth mainflux.AuthNServiceClient, db *sqlx.DB, logger mflog.Logger, esClient *r.Client, cfg config) bootstrap.Service {
+func newService(auth mainflux.AuthNServiceClient, db *sqlx.DB, logger mflog.Logger, esClient *r.Client, pub messaging.Publisher, cfg config) bootstrap.Service {
 	thingsRepo := postgres.NewConfigRepository(db, logger)
+	templatesRepo := postgres.NewTemplateRepository(db, logger)
 
 	config := mfsdk.Config{
 		BaseURL:      cfg.baseURL,
@@ -274,7 +302,7 @@ func newService(auth mainflux.AuthNServiceClient, db *sqlx.DB, logger mflog.Logg
 
 	sdk := mfsdk.NewSDK(config)
 
-	svc := bootstrap.New(auth, thingsRepo, sdk, cfg.encKey)
+	svc := bootstrap.New(auth, thingsRepo, templatesRepo, sdk, cfg.encKey, uuidProvider.New(), pub, cfg.control, logger)
 	svc = redisprod.NewEventStoreMiddleware(svc, esClient)
 	svc = api.NewLoggingMiddleware(svc, logger)
 	svc = api.MetricsMiddleware(
@@ -320,16 +348,36 @@ func connectToAuth(cfg config, logger logger.Logger) *grpc.ClientConn {
 	return conn
 }
 
-func startHTTPServer(svc bootstrap.Service, cfg config, logger mflog.Logger, errs chan error) {
+func newSigner(keyPath string, logger mflog.Logger) bootstrap.Signer {
+	if keyPath == "" {
+		return nil
+	}
+
+	key, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to read signing key: %s", err))
+		os.Exit(1)
+	}
+
+	signer, err := bootstrap.NewSigner(key)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to create response signer: %s", err))
+		os.Exit(1)
+	}
+
+	return signer
+}
+
+func startHTTPServer(svc bootstrap.Service, signer bootstrap.Signer, cfg config, logger mflog.Logger, errs chan error) {
 	p := fmt.Sprintf(":%s", cfg.httpPort)
 	if cfg.serverCert != "" || cfg.serverKey != "" {
 		logger.Info(fmt.Sprintf("Bootstrap service started using https on port %s with cert %s key %s",
 			cfg.httpPort, cfg.serverCert, cfg.serverKey))
-		errs <- http.ListenAndServeTLS(p, cfg.serverCert, cfg.serverKey, api.MakeHandler(svc, bootstrap.NewConfigReader(cfg.encKey)))
+		errs <- http.ListenAndServeTLS(p, cfg.serverCert, cfg.serverKey, api.MakeHandler(svc, bootstrap.NewConfigReader(cfg.encKey), signer))
 		return
 	}
 	logger.Info(fmt.Sprintf("Bootstrap service started using http on port %s", cfg.httpPort))
-	errs <- http.ListenAndServe(p, api.MakeHandler(svc, bootstrap.NewConfigReader(cfg.encKey)))
+	errs <- http.ListenAndServe(p, api.MakeHandler(svc, bootstrap.NewConfigReader(cfg.encKey), signer))
 }
 
 func subscribeToThingsES(svc bootstrap.Service, client *r.Client, consumer string, logger mflog.Logger) {
@@ -20,6 +20,7 @@ import (
 	"github.com/gocql/gocql"
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/envelope"
 	"github.com/mainflux/mainflux/readers"
 	"github.com/mainflux/mainflux/readers/api"
 	"github.com/mainflux/mainflux/readers/cassandra"
@@ -48,6 +49,7 @@ const (
 	defJaegerURL         = ""
 	defThingsAuthURL     = "localhost:8181"
 	defThingsAuthTimeout = "1s"
+	defKeysFile          = ""
 
 	envLogLevel          = "MF_CASSANDRA_READER_LOG_LEVEL"
 	envPort              = "MF_CASSANDRA_READER_PORT"
@@ -63,6 +65,7 @@ const (
 	envJaegerURL         = "MF_JAEGER_URL"
 	envThingsAuthURL     = "MF_THINGS_AUTH_GRPC_URL"
 	envThingsAuthTimeout = "MF_THINGS_AUTH_GRPC_TIMEOUT"
+	envKeysFile          = "MF_CASSANDRA_READER_KEYS_FILE"
 )
 
 type config struct {
@@ -76,6 +79,7 @@ type config struct {
 	jaegerURL         string
 	thingsAuthURL     string
 	thingsAuthTimeout time.Duration
+	keysFile          string
 }
 
 func main() {
@@ -97,6 +101,9 @@ func main() {
 
 	tc := thingsapi.NewClient(conn, thingsTracer, cfg.thingsAuthTimeout)
 	repo := newService(session, logger)
+	if cfg.keysFile != "" {
+		repo = api.DecryptionMiddleware(repo, newSealer(cfg.keysFile, logger))
+	}
 
 	errs := make(chan error, 2)
 
@@ -147,6 +154,7 @@ func loadConfig() config {
 		jaegerURL:         mainflux.Env(envJaegerURL, defJaegerURL),
 		thingsAuthURL:     mainflux.Env(envThingsAuthURL, defThingsAuthURL),
 		thingsAuthTimeout: authTimeout,
+		keysFile:          mainflux.Env(envKeysFile, defKeysFile),
 	}
 }
 
@@ -241,3 +249,12 @@ func startHTTPServer(repo readers.MessageRepository, tc mainflux.ThingsServiceCl
 	logger.Info(fmt.Sprintf("Cassandra reader service started, exposed port %s", cfg.port))
 	errs <- http.ListenAndServe(p, api.MakeHandler(repo, tc, "cassandra-reader"))
 }
+
+func newSealer(keysFile string, logger logger.Logger) envelope.Sealer {
+	keys, err := envelope.NewFileProvider(keysFile)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load encryption keys: %s", err))
+		os.Exit(1)
+	}
+	return envelope.New(keys)
+}
@@ -12,12 +12,16 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
 	"github.com/gocql/gocql"
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/logger"
-	"github.com/mainflux/mainflux/pkg/messaging/nats"
+	"github.com/mainflux/mainflux/pkg/envelope"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/brokers"
+	"github.com/mainflux/mainflux/pkg/messaging/jetstream"
 	"github.com/mainflux/mainflux/pkg/transformers/senml"
 	"github.com/mainflux/mainflux/writers"
 	"github.com/mainflux/mainflux/writers/api"
@@ -30,6 +34,10 @@ const (
 	sep     = ","
 
 	defNatsURL         = "nats://localhost:4222"
+	defBrokerType      = "nats"
+	defJSStream        = "mainflux"
+	defJSMaxDeliver    = "5"
+	defJSAckWait       = "30s"
 	defLogLevel        = "error"
 	defPort            = "8180"
 	defCluster         = "127.0.0.1"
@@ -39,8 +47,19 @@ const (
 	defDBPort          = "9042"
 	defSubjectsCfgPath = "/config/subjects.toml"
 	defContentType     = "application/senml+json"
+	defPartition       = "0"
+	defPartitions      = "1"
+	defHeaders         = ""
+	defTimePolicy      = "device"
+	defMaxTimeSkew     = "5m"
+	defKeysFile        = ""
 
 	envNatsURL         = "MF_NATS_URL"
+	envBrokerType      = "MF_BROKER_TYPE"
+	envBrokerURL       = "MF_BROKER_URL"
+	envJSStream        = "MF_JETSTREAM_STREAM"
+	envJSMaxDeliver    = "MF_JETSTREAM_MAX_DELIVER"
+	envJSAckWait       = "MF_JETSTREAM_ACK_WAIT"
 	envLogLevel        = "MF_CASSANDRA_WRITER_LOG_LEVEL"
 	envPort            = "MF_CASSANDRA_WRITER_PORT"
 	envCluster         = "MF_CASSANDRA_WRITER_DB_CLUSTER"
@@ -50,15 +69,25 @@ const (
 	envDBPort          = "MF_CASSANDRA_WRITER_DB_PORT"
 	envSubjectsCfgPath = "MF_CASSANDRA_WRITER_SUBJECTS_CONFIG"
 	envContentType     = "MF_CASSANDRA_WRITER_CONTENT_TYPE"
+	envPartition       = "MF_CASSANDRA_WRITER_PARTITION"
+	envPartitions      = "MF_CASSANDRA_WRITER_PARTITIONS"
+	envHeaders         = "MF_CASSANDRA_WRITER_HEADERS"
+	envTimePolicy      = "MF_CASSANDRA_WRITER_TIME_POLICY"
+	envMaxTimeSkew     = "MF_CASSANDRA_WRITER_MAX_TIME_SKEW"
+	envKeysFile        = "MF_CASSANDRA_WRITER_KEYS_FILE"
 )
 
 type config struct {
-	natsURL         string
+	broker          brokers.Config
 	logLevel        string
 	port            string
 	subjectsCfgPath string
 	contentType     string
+	partition       writers.Partition
+	headers         []string
+	timestamping    senml.Timestamping
 	dbCfg           cassandra.DBConfig
+	keysFile        string
 }
 
 func main() {
@@ -69,19 +98,40 @@ func main() {
 		log.Fatalf(err.Error())
 	}
 
-	pubSub, err := nats.NewPubSub(cfg.natsURL, "", logger)
+	queue := cfg.partition.Queue(svcName)
+	pubSub, err := brokers.NewPubSub(cfg.broker, queue, logger)
 	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to connect to NATS: %s", err))
+		logger.Error(fmt.Sprintf("Failed to connect to message broker: %s", err))
 		os.Exit(1)
 	}
 	defer pubSub.Close()
 
+	if cfg.partition.Count > 1 {
+		lease, err := brokers.NewLease(cfg.broker, queue)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to create partition lease: %s", err))
+			os.Exit(1)
+		}
+		defer lease.Close()
+		cfg.partition.Lease = lease
+	}
+
+	api.PartitionMetrics(kitprometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Namespace: "cassandra",
+		Subsystem: "message_writer",
+		Name:      "partition",
+		Help:      "Queue group and partition assignment of the writer replica.",
+	}, []string{"queue", "partition", "partitions"}), queue, cfg.partition)
+
 	session := connectToCassandra(cfg.dbCfg, logger)
 	defer session.Close()
 
 	repo := newService(session, logger)
-	st := senml.New(cfg.contentType)
-	if err := writers.Start(pubSub, repo, st, svcName, cfg.subjectsCfgPath, logger); err != nil {
+	if cfg.keysFile != "" {
+		repo = api.EncryptionMiddleware(repo, newSealer(cfg.keysFile, logger))
+	}
+	st := senml.NewWithTimestamping(cfg.contentType, cfg.timestamping)
+	if err := writers.Start(pubSub, repo, st, cfg.partition, cfg.headers, cfg.subjectsCfgPath, logger); err != nil {
 		logger.Error(fmt.Sprintf("Failed to create Cassandra writer: %s", err))
 	}
 
@@ -113,13 +163,76 @@ func loadConfig() config {
 		Port:     dbPort,
 	}
 
+	partition := loadPartition()
+
 	return config{
-		natsURL:         mainflux.Env(envNatsURL, defNatsURL),
+		broker:          loadBrokerConfig(partition.Queue(svcName)),
+		partition:       partition,
+		headers:         messaging.ParseHeaderNames(mainflux.Env(envHeaders, defHeaders)),
+		timestamping:    loadTimestamping(),
 		logLevel:        mainflux.Env(envLogLevel, defLogLevel),
 		port:            mainflux.Env(envPort, defPort),
 		subjectsCfgPath: mainflux.Env(envSubjectsCfgPath, defSubjectsCfgPath),
 		contentType:     mainflux.Env(envContentType, defContentType),
 		dbCfg:           dbCfg,
+		keysFile:        mainflux.Env(envKeysFile, defKeysFile),
+	}
+}
+
+func loadPartition() writers.Partition {
+	id, err := strconv.Atoi(mainflux.Env(envPartition, defPartition))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envPartition, err.Error())
+	}
+
+	count, err := strconv.Atoi(mainflux.Env(envPartitions, defPartitions))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envPartitions, err.Error())
+	}
+
+	p := writers.Partition{ID: id, Count: count}
+	if err := p.Validate(); err != nil {
+		log.Fatalf("Invalid partition %d of %d: %s", id, count, err.Error())
+	}
+	return p
+}
+
+func loadTimestamping() senml.Timestamping {
+	maxSkew, err := time.ParseDuration(mainflux.Env(envMaxTimeSkew, defMaxTimeSkew))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envMaxTimeSkew, err.Error())
+	}
+
+	ts := senml.Timestamping{
+		Policy:  senml.TimePolicy(mainflux.Env(envTimePolicy, defTimePolicy)),
+		MaxSkew: maxSkew,
+	}
+	if err := ts.Validate(); err != nil {
+		log.Fatalf("Invalid %s value %s: %s", envTimePolicy, ts.Policy, err.Error())
+	}
+	return ts
+}
+
+func loadBrokerConfig(durable string) brokers.Config {
+	maxDeliver, err := strconv.Atoi(mainflux.Env(envJSMaxDeliver, defJSMaxDeliver))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSMaxDeliver, err.Error())
+	}
+
+	ackWait, err := time.ParseDuration(mainflux.Env(envJSAckWait, defJSAckWait))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSAckWait, err.Error())
+	}
+
+	return brokers.Config{
+		Type: mainflux.Env(envBrokerType, defBrokerType),
+		URL:  mainflux.Env(envBrokerURL, mainflux.Env(envNatsURL, defNatsURL)),
+		JetStream: jetstream.Config{
+			Stream:     mainflux.Env(envJSStream, defJSStream),
+			Durable:    durable,
+			MaxDeliver: maxDeliver,
+			AckWait:    ackWait,
+		},
 	}
 }
 
@@ -150,6 +263,12 @@ func newService(session *gocql.Session, logger logger.Logger) writers.MessageRep
 			Name:      "request_latency_microseconds",
 			Help:      "Total duration of requests in microseconds.",
 		}, []string{"method"}),
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "cassandra",
+			Subsystem: "message_writer",
+			Name:      "corrected_timestamps",
+			Help:      "Number of saved messages with the device time replaced by the time policy.",
+		}, []string{}),
 	)
 
 	return repo
@@ -160,3 +279,12 @@ func startHTTPServer(port string, errs chan error, logger logger.Logger) {
 	logger.Info(fmt.Sprintf("Cassandra writer service started, exposed port %s", port))
 	errs <- http.ListenAndServe(p, api.MakeHandler(svcName))
 }
+
+func newSealer(keysFile string, logger logger.Logger) envelope.Sealer {
+	keys, err := envelope.NewFileProvider(keysFile)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load encryption keys: %s", err))
+		os.Exit(1)
+	}
+	return envelope.New(keys)
+}
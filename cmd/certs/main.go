@@ -2,6 +2,7 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
@@ -22,7 +23,7 @@ import (
 	authapi "github.com/mainflux/mainflux/authn/api/grpc"
 	"github.com/mainflux/mainflux/certs"
 	"github.com/mainflux/mainflux/certs/api"
-	vault "github.com/mainflux/mainflux/certs/pki"
+	"github.com/mainflux/mainflux/certs/pki"
 	"github.com/mainflux/mainflux/certs/postgres"
 	"github.com/mainflux/mainflux/logger"
 	"github.com/opentracing/opentracing-go"
@@ -32,6 +33,7 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	mflog "github.com/mainflux/mainflux/logger"
+	mfauth "github.com/mainflux/mainflux/pkg/auth"
 	"github.com/mainflux/mainflux/pkg/errors"
 	mfsdk "github.com/mainflux/mainflux/pkg/sdk/go"
 	jconfig "github.com/uber/jaeger-client-go/config"
@@ -64,6 +66,13 @@ const (
 	defSignHoursValid = "2048h"
 	defSignRSABits    = ""
 
+	defLocalPKIDir       = "pki"
+	defLocalCAHoursValid = "8760h"
+
+	defAuthCacheURL  = "localhost:6379"
+	defAuthCachePass = ""
+	defAuthCacheDB   = "0"
+
 	defVaultHost    = ""
 	defVaultRole    = "mainflux"
 	defVaultToken   = ""
@@ -96,6 +105,13 @@ const (
 	envSignHoursValid = "MF_CERTS_SIGN_HOURS_VALID"
 	envSignRSABits    = "MF_CERTS_SIGN_RSA_BITS"
 
+	envLocalPKIDir       = "MF_CERTS_LOCAL_PKI_DIR"
+	envLocalCAHoursValid = "MF_CERTS_LOCAL_CA_HOURS_VALID"
+
+	envAuthCacheURL  = "MF_AUTH_CACHE_URL"
+	envAuthCachePass = "MF_AUTH_CACHE_PASS"
+	envAuthCacheDB   = "MF_AUTH_CACHE_DB"
+
 	envVaultHost    = "MF_CERTS_VAULT_HOST"
 	envVaultPKIPath = "MF_CERTS_VAULT_PKI_PATH"
 	envVaultRole    = "MF_CERTS_VAULT_ROLE"
@@ -133,6 +149,15 @@ type config struct {
 	signCAKeyPath  string
 	signRSABits    int
 	signHoursValid string
+	// Local PKI used when 3rd party
+	// PKI is not configured
+	localPKIDir       string
+	localCAHoursValid time.Duration
+	// Cache of issued certificates
+	// shared with protocol adapters
+	authCacheURL  string
+	authCachePass string
+	authCacheDB   string
 	// 3rd party PKI API access settings
 	pkiPath  string
 	pkiToken string
@@ -153,10 +178,7 @@ func main() {
 		logger.Error("Failed to load CA certificates for issuing client certs")
 	}
 
-	pkiClient, err := vault.NewVaultClient(cfg.pkiToken, cfg.pkiHost, cfg.pkiPath, cfg.pkiRole)
-	if err != nil {
-		logger.Error("Failed to init vault client")
-	}
+	pkiClient := newPKIAgent(cfg, tlsCert, logger)
 
 	db := connectToDB(cfg.dbConfig, logger)
 	defer db.Close()
@@ -169,7 +191,10 @@ func main() {
 
 	auth := authapi.NewClient(authTracer, authConn, cfg.authnTimeout)
 
-	svc := newService(auth, db, logger, nil, tlsCert, caCert, cfg, pkiClient)
+	cacheClient := connectToRedis(cfg.authCacheURL, cfg.authCachePass, cfg.authCacheDB, logger)
+	defer cacheClient.Close()
+
+	svc := newService(auth, db, logger, cacheClient, tlsCert, caCert, cfg, pkiClient)
 	errs := make(chan error, 2)
 
 	go startHTTPServer(svc, cfg, logger, errs)
@@ -206,6 +231,11 @@ func loadConfig() config {
 		log.Fatalf("Invalid %s value: %s", envAuthnTimeout, err.Error())
 	}
 
+	localCAHoursValid, err := time.ParseDuration(mainflux.Env(envLocalCAHoursValid, defLocalCAHoursValid))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envLocalCAHoursValid, err.Error())
+	}
+
 	signRSABits, err := strconv.Atoi(mainflux.Env(envSignRSABits, defSignRSABits))
 	if err != nil {
 		log.Fatalf("Invalid %s value: %s", envSignRSABits, err.Error())
@@ -230,6 +260,13 @@ func loadConfig() config {
 		signHoursValid: mainflux.Env(envSignHoursValid, defSignHoursValid),
 		signRSABits:    signRSABits,
 
+		localPKIDir:       mainflux.Env(envLocalPKIDir, defLocalPKIDir),
+		localCAHoursValid: localCAHoursValid,
+
+		authCacheURL:  mainflux.Env(envAuthCacheURL, defAuthCacheURL),
+		authCachePass: mainflux.Env(envAuthCachePass, defAuthCachePass),
+		authCacheDB:   mainflux.Env(envAuthCacheDB, defAuthCacheDB),
+
 		pkiToken: mainflux.Env(envVaultToken, defVaultToken),
 		pkiPath:  mainflux.Env(envVaultPKIPath, defVaultPKIPath),
 		pkiRole:  mainflux.Env(envVaultRole, defVaultRole),
@@ -310,7 +347,7 @@ func initJaeger(svcName, url string, logger logger.Logger) (opentracing.Tracer,
 	return tracer, closer
 }
 
-func newService(auth mainflux.AuthNServiceClient, db *sqlx.DB, logger mflog.Logger, esClient *redis.Client, tlsCert tls.Certificate, x509Cert *x509.Certificate, cfg config, pkiAgent vault.Agent) certs.Service {
+func newService(auth mainflux.AuthNServiceClient, db *sqlx.DB, logger mflog.Logger, cacheClient *redis.Client, tlsCert tls.Certificate, x509Cert *x509.Certificate, cfg config, pkiAgent pki.Agent) certs.Service {
 	certsRepo := postgres.NewRepository(db, logger)
 
 	certsConfig := certs.Config{
@@ -342,7 +379,13 @@ func newService(auth mainflux.AuthNServiceClient, db *sqlx.DB, logger mflog.Logg
 
 	sdk := mfsdk.NewSDK(config)
 
-	svc := certs.New(auth, certsRepo, sdk, certsConfig, pkiAgent)
+	cache := mfauth.NewCertsCache(cacheClient)
+	if err := certs.SeedCache(context.Background(), certsRepo, cache); err != nil {
+		logger.Error(fmt.Sprintf("Failed to seed certificates cache: %s", err))
+		os.Exit(1)
+	}
+
+	svc := certs.New(auth, certsRepo, sdk, certsConfig, pkiAgent, cache)
 	svc = api.NewLoggingMiddleware(svc, logger)
 	svc = api.MetricsMiddleware(
 		svc,
@@ -362,6 +405,26 @@ func newService(auth mainflux.AuthNServiceClient, db *sqlx.DB, logger mflog.Logg
 	return svc
 }
 
+// newPKIAgent returns Vault PKI agent if Vault host is configured, otherwise
+// certificates are issued by the local PKI using the signing CA.
+func newPKIAgent(cfg config, tlsCert tls.Certificate, logger mflog.Logger) pki.Agent {
+	if cfg.pkiHost != "" {
+		agent, err := pki.NewVaultClient(cfg.pkiToken, cfg.pkiHost, cfg.pkiPath, cfg.pkiRole)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to init vault client: %s", err))
+			os.Exit(1)
+		}
+		return agent
+	}
+
+	agent, err := pki.NewLocalAgent(tlsCert, cfg.localPKIDir, cfg.localCAHoursValid)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to init local PKI: %s", err))
+		os.Exit(1)
+	}
+	return agent
+}
+
 func startHTTPServer(svc certs.Service, cfg config, logger mflog.Logger, errs chan error) {
 	p := fmt.Sprintf(":%s", cfg.httpPort)
 	if cfg.serverCert != "" || cfg.serverKey != "" {
@@ -4,6 +4,7 @@
 package main
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -16,14 +17,21 @@ import (
 	"time"
 
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/go-redis/redis"
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/coap"
 	"github.com/mainflux/mainflux/coap/api"
 	logger "github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/auth"
+	"github.com/mainflux/mainflux/pkg/limits"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/brokers"
+	"github.com/mainflux/mainflux/pkg/messaging/jetstream"
+	"github.com/mainflux/mainflux/pkg/messaging/middleware"
 	thingsapi "github.com/mainflux/mainflux/things/api/auth/grpc"
-	broker "github.com/nats-io/nats.go"
 	opentracing "github.com/opentracing/opentracing-go"
-	gocoap "github.com/plgd-dev/go-coap/v2"
+	piondtls "github.com/pion/dtls/v2"
+	"github.com/plgd-dev/go-coap/v2/mux"
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
 	jconfig "github.com/uber/jaeger-client-go/config"
 	"google.golang.org/grpc"
@@ -33,32 +41,96 @@ import (
 const (
 	defPort              = "5683"
 	defNatsURL           = "nats://localhost:4222"
+	defBrokerType        = "nats"
+	defJSStream          = "mainflux"
+	defJSMaxDeliver      = "5"
+	defJSAckWait         = "30s"
+	defMsgMaxSize        = "0"
+	defMsgRateLimit      = "0"
+	defMsgRateBurst      = "1"
+	defPublishLimits     = "false"
 	defLogLevel          = "error"
 	defClientTLS         = "false"
 	defCACerts           = ""
 	defJaegerURL         = ""
 	defThingsAuthURL     = "localhost:8181"
 	defThingsAuthTimeout = "1s"
+	defDTLSPort          = "5684"
+	defServerCert        = ""
+	defServerKey         = ""
+	defClientCACerts     = ""
+	defAuthCacheURL      = "localhost:6379"
+	defAuthCachePass     = ""
+	defAuthCacheDB       = "0"
+	defPingPeriod        = "1m"
+	defBlockSize         = "1024"
+	defBlockTimeout      = "10s"
+	defPSKPort           = ""
+	defPSKMaxHandshakes  = "100"
+	defPSKIdleTimeout    = "5m"
+	defTCPPort           = ""
+	defWSPort            = ""
 
 	envPort              = "MF_COAP_ADAPTER_PORT"
 	envNatsURL           = "MF_NATS_URL"
+	envBrokerType        = "MF_BROKER_TYPE"
+	envBrokerURL         = "MF_BROKER_URL"
+	envJSStream          = "MF_JETSTREAM_STREAM"
+	envJSMaxDeliver      = "MF_JETSTREAM_MAX_DELIVER"
+	envJSAckWait         = "MF_JETSTREAM_ACK_WAIT"
+	envMsgMaxSize        = "MF_MESSAGE_MAX_SIZE"
+	envMsgRateLimit      = "MF_MESSAGE_RATE_LIMIT"
+	envMsgRateBurst      = "MF_MESSAGE_RATE_BURST"
+	envPublishLimits     = "MF_PUBLISH_LIMITS"
 	envLogLevel          = "MF_COAP_ADAPTER_LOG_LEVEL"
 	envClientTLS         = "MF_COAP_ADAPTER_CLIENT_TLS"
 	envCACerts           = "MF_COAP_ADAPTER_CA_CERTS"
 	envJaegerURL         = "MF_JAEGER_URL"
 	envThingsAuthURL     = "MF_THINGS_AUTH_GRPC_URL"
 	envThingsAuthTimeout = "MF_THINGS_AUTH_GRPC_TIMEOUT"
+	envDTLSPort          = "MF_COAP_ADAPTER_DTLS_PORT"
+	envServerCert        = "MF_COAP_ADAPTER_SERVER_CERT"
+	envServerKey         = "MF_COAP_ADAPTER_SERVER_KEY"
+	envClientCACerts     = "MF_COAP_ADAPTER_CLIENT_CA_CERTS"
+	envAuthCacheURL      = "MF_AUTH_CACHE_URL"
+	envAuthCachePass     = "MF_AUTH_CACHE_PASS"
+	envAuthCacheDB       = "MF_AUTH_CACHE_DB"
+	envPingPeriod        = "MF_COAP_ADAPTER_PING_PERIOD"
+	envBlockSize         = "MF_COAP_ADAPTER_BLOCK_SIZE"
+	envBlockTimeout      = "MF_COAP_ADAPTER_BLOCK_TIMEOUT"
+	envPSKPort           = "MF_COAP_ADAPTER_DTLS_PSK_PORT"
+	envPSKMaxHandshakes  = "MF_COAP_ADAPTER_DTLS_PSK_MAX_HANDSHAKES"
+	envPSKIdleTimeout    = "MF_COAP_ADAPTER_DTLS_PSK_IDLE_TIMEOUT"
+	envTCPPort           = "MF_COAP_ADAPTER_TCP_PORT"
+	envWSPort            = "MF_COAP_ADAPTER_WS_PORT"
 )
 
 type config struct {
 	port              string
-	natsURL           string
+	broker            brokers.Config
+	msgMaxSize        int
+	msgRateLimit      float64
+	msgRateBurst      int
+	publishLimits     bool
 	logLevel          string
 	clientTLS         bool
 	caCerts           string
 	jaegerURL         string
 	thingsAuthURL     string
 	thingsAuthTimeout time.Duration
+	dtlsPort          string
+	serverCert        string
+	serverKey         string
+	clientCACerts     string
+	authCacheURL      string
+	authCachePass     string
+	authCacheDB       string
+	pingPeriod        time.Duration
+	server            api.ServerConfig
+	pskPort           string
+	psk               api.PSKConfig
+	tcpPort           string
+	wsPort            string
 }
 
 func main() {
@@ -72,18 +144,29 @@ func main() {
 	conn := connectToThings(cfg, logger)
 	defer conn.Close()
 
+	tracer, closer := initJaeger("coap_adapter", cfg.jaegerURL, logger)
+	defer closer.Close()
+
 	thingsTracer, thingsCloser := initJaeger("things", cfg.jaegerURL, logger)
 	defer thingsCloser.Close()
 
 	tc := thingsapi.NewClient(conn, thingsTracer, cfg.thingsAuthTimeout)
 
-	nc, err := broker.Connect(cfg.natsURL)
+	pubSub, err := brokers.NewPubSub(cfg.broker, "", logger)
 	if err != nil {
-		log.Fatalf(err.Error())
+		logger.Error(fmt.Sprintf("Failed to connect to message broker: %s", err))
+		os.Exit(1)
+	}
+	defer pubSub.Close()
+
+	limiter := limits.NewUnlimited()
+	if cfg.publishLimits {
+		cacheClient := connectToRedis(cfg.authCacheURL, cfg.authCachePass, cfg.authCacheDB, logger)
+		defer cacheClient.Close()
+		limiter = limits.NewLimiter(cacheClient)
 	}
-	defer nc.Close()
 
-	svc := coap.New(tc, nc)
+	svc := coap.New(tc, messaging.ChainPubSub(pubSub, messagingMiddlewares(cfg, tracer)...), limiter, cfg.pingPeriod)
 
 	svc = api.LoggingMiddleware(svc, logger)
 
@@ -103,10 +186,25 @@ func main() {
 		}, []string{"method"}),
 	)
 
-	errs := make(chan error, 2)
+	errs := make(chan error, 6)
+
+	sessions := api.NewSessions()
+	h := api.MakeCoAPHandler(svc, sessions, logger)
 
 	go startHTTPServer(cfg.port, logger, errs)
-	go startCOAPServer(cfg, svc, nil, logger, errs)
+	go startCOAPServer(cfg, h, logger, errs)
+	if cfg.serverCert != "" && cfg.serverKey != "" {
+		go startDTLSServer(cfg, sessions, h, logger, errs)
+	}
+	if cfg.pskPort != "" {
+		go startPSKServer(cfg, tc, sessions, h, logger, errs)
+	}
+	if cfg.tcpPort != "" {
+		go startTCPServer(cfg, h, logger, errs)
+	}
+	if cfg.wsPort != "" {
+		go startWSServer(cfg, h, logger, errs)
+	}
 
 	go func() {
 		c := make(chan os.Signal)
@@ -129,8 +227,59 @@ func loadConfig() config {
 		log.Fatalf("Invalid %s value: %s", envThingsAuthTimeout, err.Error())
 	}
 
+	pingPeriod, err := time.ParseDuration(mainflux.Env(envPingPeriod, defPingPeriod))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envPingPeriod, err.Error())
+	}
+
+	blockSize, err := strconv.Atoi(mainflux.Env(envBlockSize, defBlockSize))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envBlockSize, err.Error())
+	}
+
+	blockTimeout, err := time.ParseDuration(mainflux.Env(envBlockTimeout, defBlockTimeout))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envBlockTimeout, err.Error())
+	}
+
+	msgMaxSize, err := strconv.Atoi(mainflux.Env(envMsgMaxSize, defMsgMaxSize))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envMsgMaxSize, err.Error())
+	}
+
+	msgRateLimit, err := strconv.ParseFloat(mainflux.Env(envMsgRateLimit, defMsgRateLimit), 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envMsgRateLimit, err.Error())
+	}
+
+	msgRateBurst, err := strconv.Atoi(mainflux.Env(envMsgRateBurst, defMsgRateBurst))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envMsgRateBurst, err.Error())
+	}
+
+	publishLimits, err := strconv.ParseBool(mainflux.Env(envPublishLimits, defPublishLimits))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s\n", envPublishLimits)
+	}
+
+	pskPort := mainflux.Env(envPSKPort, defPSKPort)
+
+	pskMaxHandshakes, err := strconv.Atoi(mainflux.Env(envPSKMaxHandshakes, defPSKMaxHandshakes))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envPSKMaxHandshakes, err.Error())
+	}
+
+	pskIdleTimeout, err := time.ParseDuration(mainflux.Env(envPSKIdleTimeout, defPSKIdleTimeout))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envPSKIdleTimeout, err.Error())
+	}
+
 	return config{
-		natsURL:           mainflux.Env(envNatsURL, defNatsURL),
+		broker:            loadBrokerConfig("coap-adapter"),
+		msgMaxSize:        msgMaxSize,
+		msgRateLimit:      msgRateLimit,
+		msgRateBurst:      msgRateBurst,
+		publishLimits:     publishLimits,
 		port:              mainflux.Env(envPort, defPort),
 		logLevel:          mainflux.Env(envLogLevel, defLogLevel),
 		clientTLS:         tls,
@@ -138,6 +287,59 @@ func loadConfig() config {
 		jaegerURL:         mainflux.Env(envJaegerURL, defJaegerURL),
 		thingsAuthURL:     mainflux.Env(envThingsAuthURL, defThingsAuthURL),
 		thingsAuthTimeout: authTimeout,
+		dtlsPort:          mainflux.Env(envDTLSPort, defDTLSPort),
+		serverCert:        mainflux.Env(envServerCert, defServerCert),
+		serverKey:         mainflux.Env(envServerKey, defServerKey),
+		clientCACerts:     mainflux.Env(envClientCACerts, defClientCACerts),
+		authCacheURL:      mainflux.Env(envAuthCacheURL, defAuthCacheURL),
+		authCachePass:     mainflux.Env(envAuthCachePass, defAuthCachePass),
+		authCacheDB:       mainflux.Env(envAuthCacheDB, defAuthCacheDB),
+		pingPeriod:        pingPeriod,
+		server: api.ServerConfig{
+			BlockSize:    blockSize,
+			BlockTimeout: blockTimeout,
+		},
+		pskPort: pskPort,
+		psk: api.PSKConfig{
+			MaxHandshakes: pskMaxHandshakes,
+			IdleTimeout:   pskIdleTimeout,
+		},
+		tcpPort: mainflux.Env(envTCPPort, defTCPPort),
+		wsPort:  mainflux.Env(envWSPort, defWSPort),
+	}
+}
+
+func messagingMiddlewares(cfg config, tracer opentracing.Tracer) []messaging.Middleware {
+	mws := []messaging.Middleware{middleware.Tracing(tracer)}
+	if cfg.msgMaxSize > 0 {
+		mws = append(mws, middleware.SizeLimit(cfg.msgMaxSize))
+	}
+	if cfg.msgRateLimit > 0 {
+		mws = append(mws, middleware.RateLimit(cfg.msgRateLimit, cfg.msgRateBurst, middleware.ByPublisher))
+	}
+	return mws
+}
+
+func loadBrokerConfig(durable string) brokers.Config {
+	maxDeliver, err := strconv.Atoi(mainflux.Env(envJSMaxDeliver, defJSMaxDeliver))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSMaxDeliver, err.Error())
+	}
+
+	ackWait, err := time.ParseDuration(mainflux.Env(envJSAckWait, defJSAckWait))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSAckWait, err.Error())
+	}
+
+	return brokers.Config{
+		Type: mainflux.Env(envBrokerType, defBrokerType),
+		URL:  mainflux.Env(envBrokerURL, mainflux.Env(envNatsURL, defNatsURL)),
+		JetStream: jetstream.Config{
+			Stream:     mainflux.Env(envJSStream, defJSStream),
+			Durable:    durable,
+			MaxDeliver: maxDeliver,
+			AckWait:    ackWait,
+		},
 	}
 }
 
@@ -195,8 +397,70 @@ func startHTTPServer(port string, logger logger.Logger, errs chan error) {
 	errs <- http.ListenAndServe(p, api.MakeHTTPHandler())
 }
 
-func startCOAPServer(cfg config, svc coap.Service, auth mainflux.ThingsServiceClient, l logger.Logger, errs chan error) {
+func startCOAPServer(cfg config, h mux.Handler, l logger.Logger, errs chan error) {
 	p := fmt.Sprintf(":%s", cfg.port)
 	l.Info(fmt.Sprintf("CoAP adapter service started, exposed port %s", cfg.port))
-	errs <- gocoap.ListenAndServe("udp", p, api.MakeCoAPHandler(svc, l))
+	errs <- api.ListenAndServe(p, cfg.server, h)
+}
+
+func startDTLSServer(cfg config, sessions *api.Sessions, h mux.Handler, l logger.Logger, errs chan error) {
+	cert, err := tls.LoadX509KeyPair(cfg.serverCert, cfg.serverKey)
+	if err != nil {
+		errs <- err
+		return
+	}
+	dtlsCfg := &piondtls.Config{
+		Certificates:         []tls.Certificate{cert},
+		ExtendedMasterSecret: piondtls.RequireExtendedMasterSecret,
+	}
+
+	if cfg.clientCACerts != "" {
+		pool, err := auth.LoadCACerts(cfg.clientCACerts)
+		if err != nil {
+			errs <- err
+			return
+		}
+		cacheClient := connectToRedis(cfg.authCacheURL, cfg.authCachePass, cfg.authCacheDB, l)
+		defer cacheClient.Close()
+
+		dtlsCfg.ClientCAs = pool
+		dtlsCfg.ClientAuth = piondtls.VerifyClientCertIfGiven
+		dtlsCfg.VerifyPeerCertificate = auth.VerifyPeerCertificate(auth.NewCertsCache(cacheClient))
+	}
+
+	p := fmt.Sprintf(":%s", cfg.dtlsPort)
+	l.Info(fmt.Sprintf("CoAP adapter service started using DTLS, exposed port %s", cfg.dtlsPort))
+	errs <- api.ListenAndServeDTLS(p, cfg.server, dtlsCfg, sessions, h)
+}
+
+func startPSKServer(cfg config, tc mainflux.ThingsServiceClient, sessions *api.Sessions, h mux.Handler, l logger.Logger, errs chan error) {
+	p := fmt.Sprintf(":%s", cfg.pskPort)
+	l.Info(fmt.Sprintf("CoAP adapter service started using DTLS with pre-shared keys, exposed port %s", cfg.pskPort))
+	errs <- api.ListenAndServePSK(p, cfg.server, cfg.psk, tc, sessions, h)
+}
+
+func startTCPServer(cfg config, h mux.Handler, l logger.Logger, errs chan error) {
+	p := fmt.Sprintf(":%s", cfg.tcpPort)
+	l.Info(fmt.Sprintf("CoAP adapter service started using TCP, exposed port %s", cfg.tcpPort))
+	errs <- api.ListenAndServeTCP(p, cfg.server, h)
+}
+
+func startWSServer(cfg config, h mux.Handler, l logger.Logger, errs chan error) {
+	p := fmt.Sprintf(":%s", cfg.wsPort)
+	l.Info(fmt.Sprintf("CoAP adapter service started using WebSockets, exposed port %s", cfg.wsPort))
+	errs <- api.ListenAndServeWS(p, cfg.server, h)
+}
+
+func connectToRedis(redisURL, redisPass, redisDB string, l logger.Logger) *redis.Client {
+	db, err := strconv.Atoi(redisDB)
+	if err != nil {
+		l.Error(fmt.Sprintf("Failed to connect to redis: %s", err))
+		os.Exit(1)
+	}
+
+	return redis.NewClient(&redis.Options{
+		Addr:     redisURL,
+		Password: redisPass,
+		DB:       db,
+	})
 }
@@ -18,11 +18,17 @@ import (
 	"google.golang.org/grpc/credentials"
 
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/go-redis/redis"
 	"github.com/mainflux/mainflux"
 	adapter "github.com/mainflux/mainflux/http"
 	"github.com/mainflux/mainflux/http/api"
 	"github.com/mainflux/mainflux/logger"
-	"github.com/mainflux/mainflux/pkg/messaging/nats"
+	"github.com/mainflux/mainflux/pkg/auth"
+	"github.com/mainflux/mainflux/pkg/limits"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/brokers"
+	"github.com/mainflux/mainflux/pkg/messaging/jetstream"
+	"github.com/mainflux/mainflux/pkg/messaging/middleware"
 	thingsapi "github.com/mainflux/mainflux/things/api/auth/grpc"
 	"github.com/opentracing/opentracing-go"
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
@@ -36,22 +42,55 @@ const (
 	defCACerts           = ""
 	defPort              = "8180"
 	defNatsURL           = "nats://localhost:4222"
+	defBrokerType        = "nats"
+	defJSStream          = "mainflux"
+	defJSMaxDeliver      = "5"
+	defJSAckWait         = "30s"
+	defMsgMaxSize        = "0"
+	defMsgRateLimit      = "0"
+	defMsgRateBurst      = "1"
+	defPublishLimits     = "false"
 	defJaegerURL         = ""
 	defThingsAuthURL     = "localhost:8181"
 	defThingsAuthTimeout = "1s"
+	defServerCert        = ""
+	defServerKey         = ""
+	defClientCACerts     = ""
+	defAuthCacheURL      = "localhost:6379"
+	defAuthCachePass     = ""
+	defAuthCacheDB       = "0"
 
 	envLogLevel          = "MF_HTTP_ADAPTER_LOG_LEVEL"
 	envClientTLS         = "MF_HTTP_ADAPTER_CLIENT_TLS"
 	envCACerts           = "MF_HTTP_ADAPTER_CA_CERTS"
 	envPort              = "MF_HTTP_ADAPTER_PORT"
 	envNatsURL           = "MF_NATS_URL"
+	envBrokerType        = "MF_BROKER_TYPE"
+	envBrokerURL         = "MF_BROKER_URL"
+	envJSStream          = "MF_JETSTREAM_STREAM"
+	envJSMaxDeliver      = "MF_JETSTREAM_MAX_DELIVER"
+	envJSAckWait         = "MF_JETSTREAM_ACK_WAIT"
+	envMsgMaxSize        = "MF_MESSAGE_MAX_SIZE"
+	envMsgRateLimit      = "MF_MESSAGE_RATE_LIMIT"
+	envMsgRateBurst      = "MF_MESSAGE_RATE_BURST"
+	envPublishLimits     = "MF_PUBLISH_LIMITS"
 	envJaegerURL         = "MF_JAEGER_URL"
 	envThingsAuthURL     = "MF_THINGS_AUTH_GRPC_URL"
 	envThingsAuthTimeout = "MF_THINGS_AUTH_GRPC_TIMEOUT"
+	envServerCert        = "MF_HTTP_ADAPTER_SERVER_CERT"
+	envServerKey         = "MF_HTTP_ADAPTER_SERVER_KEY"
+	envClientCACerts     = "MF_HTTP_ADAPTER_CLIENT_CA_CERTS"
+	envAuthCacheURL      = "MF_AUTH_CACHE_URL"
+	envAuthCachePass     = "MF_AUTH_CACHE_PASS"
+	envAuthCacheDB       = "MF_AUTH_CACHE_DB"
 )
 
 type config struct {
-	natsURL           string
+	broker            brokers.Config
+	msgMaxSize        int
+	msgRateLimit      float64
+	msgRateBurst      int
+	publishLimits     bool
 	logLevel          string
 	port              string
 	clientTLS         bool
@@ -59,6 +98,12 @@ type config struct {
 	jaegerURL         string
 	thingsAuthURL     string
 	thingsAuthTimeout time.Duration
+	serverCert        string
+	serverKey         string
+	clientCACerts     string
+	authCacheURL      string
+	authCachePass     string
+	authCacheDB       string
 }
 
 func main() {
@@ -78,15 +123,22 @@ func main() {
 	thingsTracer, thingsCloser := initJaeger("things", cfg.jaegerURL, logger)
 	defer thingsCloser.Close()
 
-	pub, err := nats.NewPublisher(cfg.natsURL)
+	pubSub, err := brokers.NewPubSub(cfg.broker, "", logger)
 	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to connect to NATS: %s", err))
+		logger.Error(fmt.Sprintf("Failed to connect to message broker: %s", err))
 		os.Exit(1)
 	}
-	defer pub.Close()
+	defer pubSub.Close()
+
+	limiter := limits.NewUnlimited()
+	if cfg.publishLimits {
+		cacheClient := connectToRedis(cfg.authCacheURL, cfg.authCachePass, cfg.authCacheDB, logger)
+		defer cacheClient.Close()
+		limiter = limits.NewLimiter(cacheClient)
+	}
 
 	tc := thingsapi.NewClient(conn, thingsTracer, cfg.thingsAuthTimeout)
-	svc := adapter.New(pub, tc)
+	svc := adapter.New(messaging.ChainPubSub(pubSub, messagingMiddlewares(cfg, tracer)...), tc, limiter)
 
 	svc = api.LoggingMiddleware(svc, logger)
 	svc = api.MetricsMiddleware(
@@ -107,11 +159,7 @@ func main() {
 
 	errs := make(chan error, 2)
 
-	go func() {
-		p := fmt.Sprintf(":%s", cfg.port)
-		logger.Info(fmt.Sprintf("HTTP adapter service started on port %s", cfg.port))
-		errs <- http.ListenAndServe(p, api.MakeHandler(svc, tracer))
-	}()
+	go startHTTPServer(cfg, api.MakeHandler(svc, tracer), logger, errs)
 
 	go func() {
 		c := make(chan os.Signal)
@@ -134,8 +182,32 @@ func loadConfig() config {
 		log.Fatalf("Invalid %s value: %s", envThingsAuthTimeout, err.Error())
 	}
 
+	msgMaxSize, err := strconv.Atoi(mainflux.Env(envMsgMaxSize, defMsgMaxSize))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envMsgMaxSize, err.Error())
+	}
+
+	msgRateLimit, err := strconv.ParseFloat(mainflux.Env(envMsgRateLimit, defMsgRateLimit), 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envMsgRateLimit, err.Error())
+	}
+
+	msgRateBurst, err := strconv.Atoi(mainflux.Env(envMsgRateBurst, defMsgRateBurst))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envMsgRateBurst, err.Error())
+	}
+
+	publishLimits, err := strconv.ParseBool(mainflux.Env(envPublishLimits, defPublishLimits))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s\n", envPublishLimits)
+	}
+
 	return config{
-		natsURL:           mainflux.Env(envNatsURL, defNatsURL),
+		broker:            loadBrokerConfig("http-adapter"),
+		msgMaxSize:        msgMaxSize,
+		msgRateLimit:      msgRateLimit,
+		msgRateBurst:      msgRateBurst,
+		publishLimits:     publishLimits,
 		logLevel:          mainflux.Env(envLogLevel, defLogLevel),
 		port:              mainflux.Env(envPort, defPort),
 		clientTLS:         tls,
@@ -143,6 +215,46 @@ func loadConfig() config {
 		jaegerURL:         mainflux.Env(envJaegerURL, defJaegerURL),
 		thingsAuthURL:     mainflux.Env(envThingsAuthURL, defThingsAuthURL),
 		thingsAuthTimeout: authTimeout,
+		serverCert:        mainflux.Env(envServerCert, defServerCert),
+		serverKey:         mainflux.Env(envServerKey, defServerKey),
+		clientCACerts:     mainflux.Env(envClientCACerts, defClientCACerts),
+		authCacheURL:      mainflux.Env(envAuthCacheURL, defAuthCacheURL),
+		authCachePass:     mainflux.Env(envAuthCachePass, defAuthCachePass),
+		authCacheDB:       mainflux.Env(envAuthCacheDB, defAuthCacheDB),
+	}
+}
+
+func messagingMiddlewares(cfg config, tracer opentracing.Tracer) []messaging.Middleware {
+	mws := []messaging.Middleware{middleware.Tracing(tracer)}
+	if cfg.msgMaxSize > 0 {
+		mws = append(mws, middleware.SizeLimit(cfg.msgMaxSize))
+	}
+	if cfg.msgRateLimit > 0 {
+		mws = append(mws, middleware.RateLimit(cfg.msgRateLimit, cfg.msgRateBurst, middleware.ByPublisher))
+	}
+	return mws
+}
+
+func loadBrokerConfig(durable string) brokers.Config {
+	maxDeliver, err := strconv.Atoi(mainflux.Env(envJSMaxDeliver, defJSMaxDeliver))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSMaxDeliver, err.Error())
+	}
+
+	ackWait, err := time.ParseDuration(mainflux.Env(envJSAckWait, defJSAckWait))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSAckWait, err.Error())
+	}
+
+	return brokers.Config{
+		Type: mainflux.Env(envBrokerType, defBrokerType),
+		URL:  mainflux.Env(envBrokerURL, mainflux.Env(envNatsURL, defNatsURL)),
+		JetStream: jetstream.Config{
+			Stream:     mainflux.Env(envJSStream, defJSStream),
+			Durable:    durable,
+			MaxDeliver: maxDeliver,
+			AckWait:    ackWait,
+		},
 	}
 }
 
@@ -193,3 +305,47 @@ func connectToThings(cfg config, logger logger.Logger) *grpc.ClientConn {
 	}
 	return conn
 }
+
+func connectToRedis(redisURL, redisPass, redisDB string, logger logger.Logger) *redis.Client {
+	db, err := strconv.Atoi(redisDB)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to connect to redis: %s", err))
+		os.Exit(1)
+	}
+
+	return redis.NewClient(&redis.Options{
+		Addr:     redisURL,
+		Password: redisPass,
+		DB:       db,
+	})
+}
+
+func startHTTPServer(cfg config, handler http.Handler, logger logger.Logger, errs chan error) {
+	p := fmt.Sprintf(":%s", cfg.port)
+	if cfg.serverCert == "" || cfg.serverKey == "" {
+		logger.Info(fmt.Sprintf("HTTP adapter service started on port %s", cfg.port))
+		errs <- http.ListenAndServe(p, handler)
+		return
+	}
+
+	var certs auth.CertsCache
+	if cfg.clientCACerts != "" {
+		cacheClient := connectToRedis(cfg.authCacheURL, cfg.authCachePass, cfg.authCacheDB, logger)
+		defer cacheClient.Close()
+		certs = auth.NewCertsCache(cacheClient)
+	}
+	tlsCfg, err := auth.ServerTLSConfig(cfg.clientCACerts, cfg.serverCert, cfg.serverKey, certs)
+	if err != nil {
+		errs <- err
+		return
+	}
+
+	server := &http.Server{
+		Addr:      p,
+		Handler:   handler,
+		TLSConfig: tlsCfg,
+	}
+	logger.Info(fmt.Sprintf("HTTP adapter service started using https on port %s with cert %s key %s",
+		cfg.port, cfg.serverCert, cfg.serverKey))
+	errs <- server.ListenAndServeTLS("", "")
+}
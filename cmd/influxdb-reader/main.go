@@ -16,6 +16,7 @@ import (
 	influxdata "github.com/influxdata/influxdb/client/v2"
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/envelope"
 	"github.com/mainflux/mainflux/readers"
 	"github.com/mainflux/mainflux/readers/api"
 	"github.com/mainflux/mainflux/readers/influxdb"
@@ -42,6 +43,7 @@ const (
 	defJaegerURL         = ""
 	defThingsAuthURL     = "localhost:8181"
 	defThingsAuthTimeout = "1s"
+	defKeysFile          = ""
 
 	envLogLevel          = "MF_INFLUX_READER_LOG_LEVEL"
 	envPort              = "MF_INFLUX_READER_PORT"
@@ -57,6 +59,7 @@ const (
 	envJaegerURL         = "MF_JAEGER_URL"
 	envThingsAuthURL     = "MF_THINGS_AUTH_GRPC_URL"
 	envThingsAuthTimeout = "MF_THINGS_AUTH_GRPC_TIMEOUT"
+	envKeysFile          = "MF_INFLUX_READER_KEYS_FILE"
 )
 
 type config struct {
@@ -74,6 +77,7 @@ type config struct {
 	jaegerURL         string
 	thingsAuthURL     string
 	thingsAuthTimeout time.Duration
+	keysFile          string
 }
 
 func main() {
@@ -98,6 +102,9 @@ func main() {
 	defer client.Close()
 
 	repo := newService(client, cfg.dbName, logger)
+	if cfg.keysFile != "" {
+		repo = api.DecryptionMiddleware(repo, newSealer(cfg.keysFile, logger))
+	}
 
 	errs := make(chan error, 2)
 	go func() {
@@ -138,6 +145,7 @@ func loadConfigs() (config, influxdata.HTTPConfig) {
 		jaegerURL:         mainflux.Env(envJaegerURL, defJaegerURL),
 		thingsAuthURL:     mainflux.Env(envThingsAuthURL, defThingsAuthURL),
 		thingsAuthTimeout: authTimeout,
+		keysFile:          mainflux.Env(envKeysFile, defKeysFile),
 	}
 
 	clientCfg := influxdata.HTTPConfig{
@@ -230,3 +238,12 @@ func startHTTPServer(repo readers.MessageRepository, tc mainflux.ThingsServiceCl
 	logger.Info(fmt.Sprintf("InfluxDB reader service started, exposed port %s", cfg.port))
 	errs <- http.ListenAndServe(p, api.MakeHandler(repo, tc, "influxdb-reader"))
 }
+
+func newSealer(keysFile string, logger logger.Logger) envelope.Sealer {
+	keys, err := envelope.NewFileProvider(keysFile)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load encryption keys: %s", err))
+		os.Exit(1)
+	}
+	return envelope.New(keys)
+}
@@ -9,13 +9,18 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
 	influxdata "github.com/influxdata/influxdb/client/v2"
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/logger"
-	"github.com/mainflux/mainflux/pkg/messaging/nats"
+	"github.com/mainflux/mainflux/pkg/envelope"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/brokers"
+	"github.com/mainflux/mainflux/pkg/messaging/jetstream"
 	"github.com/mainflux/mainflux/pkg/transformers/senml"
 	"github.com/mainflux/mainflux/writers"
 	"github.com/mainflux/mainflux/writers/api"
@@ -27,6 +32,10 @@ const (
 	svcName = "influxdb-writer"
 
 	defNatsURL         = "nats://localhost:4222"
+	defBrokerType      = "nats"
+	defJSStream        = "mainflux"
+	defJSMaxDeliver    = "5"
+	defJSAckWait       = "30s"
 	defLogLevel        = "error"
 	defPort            = "8180"
 	defDB              = "mainflux"
@@ -36,8 +45,19 @@ const (
 	defDBPass          = "mainflux"
 	defSubjectsCfgPath = "/config/subjects.toml"
 	defContentType     = "application/senml+json"
+	defPartition       = "0"
+	defPartitions      = "1"
+	defHeaders         = ""
+	defTimePolicy      = "device"
+	defMaxTimeSkew     = "5m"
+	defKeysFile        = ""
 
 	envNatsURL         = "MF_NATS_URL"
+	envBrokerType      = "MF_BROKER_TYPE"
+	envBrokerURL       = "MF_BROKER_URL"
+	envJSStream        = "MF_JETSTREAM_STREAM"
+	envJSMaxDeliver    = "MF_JETSTREAM_MAX_DELIVER"
+	envJSAckWait       = "MF_JETSTREAM_ACK_WAIT"
 	envLogLevel        = "MF_INFLUX_WRITER_LOG_LEVEL"
 	envPort            = "MF_INFLUX_WRITER_PORT"
 	envDB              = "MF_INFLUX_WRITER_DB"
@@ -47,10 +67,16 @@ const (
 	envDBPass          = "MF_INFLUX_WRITER_DB_PASS"
 	envSubjectsCfgPath = "MF_INFLUX_WRITER_SUBJECTS_CONFIG"
 	envContentType     = "MF_INFLUX_WRITER_CONTENT_TYPE"
+	envPartition       = "MF_INFLUX_WRITER_PARTITION"
+	envPartitions      = "MF_INFLUX_WRITER_PARTITIONS"
+	envHeaders         = "MF_INFLUX_WRITER_HEADERS"
+	envTimePolicy      = "MF_INFLUX_WRITER_TIME_POLICY"
+	envMaxTimeSkew     = "MF_INFLUX_WRITER_MAX_TIME_SKEW"
+	envKeysFile        = "MF_INFLUX_WRITER_KEYS_FILE"
 )
 
 type config struct {
-	natsURL         string
+	broker          brokers.Config
 	logLevel        string
 	port            string
 	dbName          string
@@ -60,6 +86,10 @@ type config struct {
 	dbPass          string
 	subjectsCfgPath string
 	contentType     string
+	partition       writers.Partition
+	headers         []string
+	timestamping    senml.Timestamping
+	keysFile        string
 }
 
 func main() {
@@ -70,13 +100,31 @@ func main() {
 		log.Fatalf(err.Error())
 	}
 
-	pubSub, err := nats.NewPubSub(cfg.natsURL, "", logger)
+	queue := cfg.partition.Queue(svcName)
+	pubSub, err := brokers.NewPubSub(cfg.broker, queue, logger)
 	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to connect to NATS: %s", err))
+		logger.Error(fmt.Sprintf("Failed to connect to message broker: %s", err))
 		os.Exit(1)
 	}
 	defer pubSub.Close()
 
+	if cfg.partition.Count > 1 {
+		lease, err := brokers.NewLease(cfg.broker, queue)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to create partition lease: %s", err))
+			os.Exit(1)
+		}
+		defer lease.Close()
+		cfg.partition.Lease = lease
+	}
+
+	api.PartitionMetrics(kitprometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Namespace: "influxdb",
+		Subsystem: "message_writer",
+		Name:      "partition",
+		Help:      "Queue group and partition assignment of the writer replica.",
+	}, []string{"queue", "partition", "partitions"}), queue, cfg.partition)
+
 	client, err := influxdata.NewHTTPClient(clientCfg)
 	if err != nil {
 		logger.Error(fmt.Sprintf("Failed to create InfluxDB client: %s", err))
@@ -86,12 +134,15 @@ func main() {
 
 	repo := influxdb.New(client, cfg.dbName)
 
-	counter, latency := makeMetrics()
+	counter, latency, corrected := makeMetrics()
 	repo = api.LoggingMiddleware(repo, logger)
-	repo = api.MetricsMiddleware(repo, counter, latency)
-	st := senml.New(cfg.contentType)
+	repo = api.MetricsMiddleware(repo, counter, latency, corrected)
+	if cfg.keysFile != "" {
+		repo = api.EncryptionMiddleware(repo, newSealer(cfg.keysFile, logger))
+	}
+	st := senml.NewWithTimestamping(cfg.contentType, cfg.timestamping)
 
-	if err := writers.Start(pubSub, repo, st, svcName, cfg.subjectsCfgPath, logger); err != nil {
+	if err := writers.Start(pubSub, repo, st, cfg.partition, cfg.headers, cfg.subjectsCfgPath, logger); err != nil {
 		logger.Error(fmt.Sprintf("Failed to start InfluxDB writer: %s", err))
 		os.Exit(1)
 	}
@@ -110,8 +161,13 @@ func main() {
 }
 
 func loadConfigs() (config, influxdata.HTTPConfig) {
+	partition := loadPartition()
+
 	cfg := config{
-		natsURL:         mainflux.Env(envNatsURL, defNatsURL),
+		broker:          loadBrokerConfig(partition.Queue(svcName)),
+		partition:       partition,
+		headers:         messaging.ParseHeaderNames(mainflux.Env(envHeaders, defHeaders)),
+		timestamping:    loadTimestamping(),
 		logLevel:        mainflux.Env(envLogLevel, defLogLevel),
 		port:            mainflux.Env(envPort, defPort),
 		dbName:          mainflux.Env(envDB, defDB),
@@ -121,6 +177,7 @@ func loadConfigs() (config, influxdata.HTTPConfig) {
 		dbPass:          mainflux.Env(envDBPass, defDBPass),
 		subjectsCfgPath: mainflux.Env(envSubjectsCfgPath, defSubjectsCfgPath),
 		contentType:     mainflux.Env(envContentType, defContentType),
+		keysFile:        mainflux.Env(envKeysFile, defKeysFile),
 	}
 
 	clientCfg := influxdata.HTTPConfig{
@@ -132,7 +189,64 @@ func loadConfigs() (config, influxdata.HTTPConfig) {
 	return cfg, clientCfg
 }
 
-func makeMetrics() (*kitprometheus.Counter, *kitprometheus.Summary) {
+func loadPartition() writers.Partition {
+	id, err := strconv.Atoi(mainflux.Env(envPartition, defPartition))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envPartition, err.Error())
+	}
+
+	count, err := strconv.Atoi(mainflux.Env(envPartitions, defPartitions))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envPartitions, err.Error())
+	}
+
+	p := writers.Partition{ID: id, Count: count}
+	if err := p.Validate(); err != nil {
+		log.Fatalf("Invalid partition %d of %d: %s", id, count, err.Error())
+	}
+	return p
+}
+
+func loadTimestamping() senml.Timestamping {
+	maxSkew, err := time.ParseDuration(mainflux.Env(envMaxTimeSkew, defMaxTimeSkew))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envMaxTimeSkew, err.Error())
+	}
+
+	ts := senml.Timestamping{
+		Policy:  senml.TimePolicy(mainflux.Env(envTimePolicy, defTimePolicy)),
+		MaxSkew: maxSkew,
+	}
+	if err := ts.Validate(); err != nil {
+		log.Fatalf("Invalid %s value %s: %s", envTimePolicy, ts.Policy, err.Error())
+	}
+	return ts
+}
+
+func loadBrokerConfig(durable string) brokers.Config {
+	maxDeliver, err := strconv.Atoi(mainflux.Env(envJSMaxDeliver, defJSMaxDeliver))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSMaxDeliver, err.Error())
+	}
+
+	ackWait, err := time.ParseDuration(mainflux.Env(envJSAckWait, defJSAckWait))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSAckWait, err.Error())
+	}
+
+	return brokers.Config{
+		Type: mainflux.Env(envBrokerType, defBrokerType),
+		URL:  mainflux.Env(envBrokerURL, mainflux.Env(envNatsURL, defNatsURL)),
+		JetStream: jetstream.Config{
+			Stream:     mainflux.Env(envJSStream, defJSStream),
+			Durable:    durable,
+			MaxDeliver: maxDeliver,
+			AckWait:    ackWait,
+		},
+	}
+}
+
+func makeMetrics() (*kitprometheus.Counter, *kitprometheus.Summary, *kitprometheus.Counter) {
 	counter := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
 		Namespace: "influxdb",
 		Subsystem: "message_writer",
@@ -147,7 +261,14 @@ func makeMetrics() (*kitprometheus.Counter, *kitprometheus.Summary) {
 		Help:      "Total duration of inserts in microseconds.",
 	}, []string{"method"})
 
-	return counter, latency
+	corrected := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: "influxdb",
+		Subsystem: "message_writer",
+		Name:      "corrected_timestamps",
+		Help:      "Number of saved messages with the device time replaced by the time policy.",
+	}, []string{})
+
+	return counter, latency, corrected
 }
 
 func startHTTPService(port string, logger logger.Logger, errs chan error) {
@@ -155,3 +276,12 @@ func startHTTPService(port string, logger logger.Logger, errs chan error) {
 	logger.Info(fmt.Sprintf("InfluxDB writer service started, exposed port %s", p))
 	errs <- http.ListenAndServe(p, api.MakeHandler(svcName))
 }
+
+func newSealer(keysFile string, logger logger.Logger) envelope.Sealer {
+	keys, err := envelope.NewFileProvider(keysFile)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load encryption keys: %s", err))
+		os.Exit(1)
+	}
+	return envelope.New(keys)
+}
@@ -4,13 +4,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	mqttPaho "github.com/eclipse/paho.mqtt.golang"
 	r "github.com/go-redis/redis"
@@ -19,7 +22,9 @@ import (
 	"github.com/mainflux/mainflux/lora"
 	"github.com/mainflux/mainflux/lora/api"
 	"github.com/mainflux/mainflux/lora/mqtt"
-	"github.com/mainflux/mainflux/pkg/messaging/nats"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/brokers"
+	"github.com/mainflux/mainflux/pkg/messaging/jetstream"
 
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
 	"github.com/mainflux/mainflux/lora/redis"
@@ -31,6 +36,10 @@ const (
 	defHTTPPort       = "8180"
 	defLoraMsgURL     = "tcp://localhost:1883"
 	defNatsURL        = "nats://localhost:4222"
+	defBrokerType     = "nats"
+	defJSStream       = "mainflux"
+	defJSMaxDeliver   = "5"
+	defJSAckWait      = "30s"
 	defESURL          = "localhost:6379"
 	defESPass         = ""
 	defESDB           = "0"
@@ -38,10 +47,16 @@ const (
 	defRouteMapURL    = "localhost:6379"
 	defRouteMapPass   = ""
 	defRouteMapDB     = "0"
+	defLoraMsgTimeout = "30s"
 
 	envHTTPPort       = "MF_LORA_ADAPTER_HTTP_PORT"
 	envLoraMsgURL     = "MF_LORA_ADAPTER_MESSAGES_URL"
 	envNatsURL        = "MF_NATS_URL"
+	envBrokerType     = "MF_BROKER_TYPE"
+	envBrokerURL      = "MF_BROKER_URL"
+	envJSStream       = "MF_JETSTREAM_STREAM"
+	envJSMaxDeliver   = "MF_JETSTREAM_MAX_DELIVER"
+	envJSAckWait      = "MF_JETSTREAM_ACK_WAIT"
 	envLogLevel       = "MF_LORA_ADAPTER_LOG_LEVEL"
 	envESURL          = "MF_THINGS_ES_URL"
 	envESPass         = "MF_THINGS_ES_PASS"
@@ -50,17 +65,22 @@ const (
 	envRouteMapURL    = "MF_LORA_ADAPTER_ROUTE_MAP_URL"
 	envRouteMapPass   = "MF_LORA_ADAPTER_ROUTE_MAP_PASS"
 	envRouteMapDB     = "MF_LORA_ADAPTER_ROUTE_MAP_DB"
+	envLoraMsgTimeout = "MF_LORA_ADAPTER_MESSAGES_TIMEOUT"
 
 	loraServerTopic = "application/+/device/+/rx"
 
+	downlinkSubject  = "channels.*.downlink.*"
+	downlinkSubtopic = "downlink"
+
 	thingsRMPrefix   = "thing"
 	channelsRMPrefix = "channel"
+	metaRMPrefix     = "meta"
 )
 
 type config struct {
 	httpPort       string
 	loraMsgURL     string
-	natsURL        string
+	broker         brokers.Config
 	logLevel       string
 	esURL          string
 	esPass         string
@@ -69,6 +89,7 @@ type config struct {
 	routeMapURL    string
 	routeMapPass   string
 	routeMapDB     string
+	loraMsgTimeout time.Duration
 }
 
 func main() {
@@ -85,19 +106,22 @@ func main() {
 	esConn := connectToRedis(cfg.esURL, cfg.esPass, cfg.esDB, logger)
 	defer esConn.Close()
 
-	pub, err := nats.NewPublisher(cfg.natsURL)
+	pubSub, err := brokers.NewPubSub(cfg.broker, "", logger)
 	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to connect to NATS: %s", err))
+		logger.Error(fmt.Sprintf("Failed to connect to message broker: %s", err))
 		os.Exit(1)
 	}
-	defer pub.Close()
+	defer pubSub.Close()
 
 	thingRM := newRouteMapRepositoy(rmConn, thingsRMPrefix, logger)
 	chanRM := newRouteMapRepositoy(rmConn, channelsRMPrefix, logger)
+	metaRM := newRouteMapRepositoy(rmConn, metaRMPrefix, logger)
 
 	mqttConn := connectToMQTTBroker(cfg.loraMsgURL, logger)
 
-	svc := lora.New(pub, thingRM, chanRM)
+	downlinks := mqtt.NewPublisher(mqttConn, cfg.loraMsgTimeout)
+
+	svc := lora.New(pubSub, downlinks, thingRM, chanRM, metaRM)
 	svc = api.LoggingMiddleware(svc, logger)
 	svc = api.MetricsMiddleware(
 		svc,
@@ -117,6 +141,7 @@ func main() {
 
 	go subscribeToLoRaBroker(svc, mqttConn, logger)
 	go subscribeToThingsES(svc, esConn, cfg.esConsumerName, logger)
+	go subscribeToDownlinks(svc, pubSub, logger)
 
 	errs := make(chan error, 2)
 
@@ -133,10 +158,15 @@ func main() {
 }
 
 func loadConfig() config {
+	loraMsgTimeout, err := time.ParseDuration(mainflux.Env(envLoraMsgTimeout, defLoraMsgTimeout))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envLoraMsgTimeout, err.Error())
+	}
+
 	return config{
 		httpPort:       mainflux.Env(envHTTPPort, defHTTPPort),
 		loraMsgURL:     mainflux.Env(envLoraMsgURL, defLoraMsgURL),
-		natsURL:        mainflux.Env(envNatsURL, defNatsURL),
+		broker:         loadBrokerConfig("lora-adapter"),
 		logLevel:       mainflux.Env(envLogLevel, defLogLevel),
 		esURL:          mainflux.Env(envESURL, defESURL),
 		esPass:         mainflux.Env(envESPass, defESPass),
@@ -145,6 +175,30 @@ func loadConfig() config {
 		routeMapURL:    mainflux.Env(envRouteMapURL, defRouteMapURL),
 		routeMapPass:   mainflux.Env(envRouteMapPass, defRouteMapPass),
 		routeMapDB:     mainflux.Env(envRouteMapDB, defRouteMapDB),
+		loraMsgTimeout: loraMsgTimeout,
+	}
+}
+
+func loadBrokerConfig(durable string) brokers.Config {
+	maxDeliver, err := strconv.Atoi(mainflux.Env(envJSMaxDeliver, defJSMaxDeliver))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSMaxDeliver, err.Error())
+	}
+
+	ackWait, err := time.ParseDuration(mainflux.Env(envJSAckWait, defJSAckWait))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSAckWait, err.Error())
+	}
+
+	return brokers.Config{
+		Type: mainflux.Env(envBrokerType, defBrokerType),
+		URL:  mainflux.Env(envBrokerURL, mainflux.Env(envNatsURL, defNatsURL)),
+		JetStream: jetstream.Config{
+			Stream:     mainflux.Env(envJSStream, defJSStream),
+			Durable:    durable,
+			MaxDeliver: maxDeliver,
+			AckWait:    ackWait,
+		},
 	}
 }
 
@@ -201,6 +255,21 @@ func subscribeToThingsES(svc lora.Service, client *r.Client, consumer string, lo
 	}
 }
 
+func subscribeToDownlinks(svc lora.Service, ps messaging.PubSub, logger logger.Logger) {
+	err := ps.Subscribe(downlinkSubject, func(msg messaging.Message) error {
+		thingID := strings.TrimPrefix(msg.Subtopic, downlinkSubtopic+".")
+		if err := svc.Downlink(context.Background(), msg.Channel, thingID, msg.Payload); err != nil {
+			logger.Warn(fmt.Sprintf("Failed to send downlink to thing %s: %s", thingID, err))
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to subscribe to Mainflux downlinks: %s", err))
+		os.Exit(1)
+	}
+	logger.Info("Subscribed to Mainflux downlinks")
+}
+
 func newRouteMapRepositoy(client *r.Client, prefix string, logger logger.Logger) lora.RouteMapRepository {
 	logger.Info(fmt.Sprintf("Connected to %s Redis Route-map", prefix))
 	return redis.NewRouteMapRepository(client, prefix)
@@ -19,6 +19,7 @@ import (
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/envelope"
 	"github.com/mainflux/mainflux/readers"
 	"github.com/mainflux/mainflux/readers/api"
 	"github.com/mainflux/mainflux/readers/mongodb"
@@ -45,6 +46,7 @@ const (
 	defJaegerURL         = ""
 	defThingsAuthURL     = "localhost:8181"
 	defThingsAuthTimeout = "1s"
+	defKeysFile          = ""
 
 	envLogLevel          = "MF_MONGO_READER_LOG_LEVEL"
 	envPort              = "MF_MONGO_READER_PORT"
@@ -58,6 +60,7 @@ const (
 	envJaegerURL         = "MF_JAEGER_URL"
 	envThingsAuthURL     = "MF_THINGS_AUTH_GRPC_URL"
 	envThingsAuthTimeout = "MF_THINGS_AUTH_GRPC_TIMEOUT"
+	envKeysFile          = "MF_MONGO_READER_KEYS_FILE"
 )
 
 type config struct {
@@ -73,6 +76,7 @@ type config struct {
 	jaegerURL         string
 	thingsAuthURL     string
 	thingsAuthTimeout time.Duration
+	keysFile          string
 }
 
 func main() {
@@ -93,6 +97,9 @@ func main() {
 	db := connectToMongoDB(cfg.dbHost, cfg.dbPort, cfg.dbName, logger)
 
 	repo := newService(db, logger)
+	if cfg.keysFile != "" {
+		repo = api.DecryptionMiddleware(repo, newSealer(cfg.keysFile, logger))
+	}
 
 	errs := make(chan error, 2)
 	go func() {
@@ -131,6 +138,7 @@ func loadConfigs() config {
 		jaegerURL:         mainflux.Env(envJaegerURL, defJaegerURL),
 		thingsAuthURL:     mainflux.Env(envThingsAuthURL, defThingsAuthURL),
 		thingsAuthTimeout: authTimeout,
+		keysFile:          mainflux.Env(envKeysFile, defKeysFile),
 	}
 }
 
@@ -226,3 +234,12 @@ func startHTTPServer(repo readers.MessageRepository, tc mainflux.ThingsServiceCl
 	logger.Info(fmt.Sprintf("Mongo reader service started, exposed port %s", cfg.port))
 	errs <- http.ListenAndServe(p, api.MakeHandler(repo, tc, "mongodb-reader"))
 }
+
+func newSealer(keysFile string, logger logger.Logger) envelope.Sealer {
+	keys, err := envelope.NewFileProvider(keysFile)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load encryption keys: %s", err))
+		os.Exit(1)
+	}
+	return envelope.New(keys)
+}
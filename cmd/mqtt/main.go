@@ -18,7 +18,11 @@ import (
 	"github.com/mainflux/mainflux/mqtt"
 	mqttredis "github.com/mainflux/mainflux/mqtt/redis"
 	"github.com/mainflux/mainflux/pkg/auth"
+	"github.com/mainflux/mainflux/pkg/limits"
 	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/brokers"
+	"github.com/mainflux/mainflux/pkg/messaging/jetstream"
+	"github.com/mainflux/mainflux/pkg/messaging/middleware"
 	mqttpub "github.com/mainflux/mainflux/pkg/messaging/mqtt"
 	"github.com/mainflux/mainflux/pkg/messaging/nats"
 	thingsapi "github.com/mainflux/mainflux/things/api/auth/grpc"
@@ -44,6 +48,15 @@ const (
 	envMQTTTargetHost       = "MF_MQTT_ADAPTER_MQTT_TARGET_HOST"
 	envMQTTTargetPort       = "MF_MQTT_ADAPTER_MQTT_TARGET_PORT"
 	envMQTTForwarderTimeout = "MF_MQTT_ADAPTER_FORWARDER_TIMEOUT"
+	defMQTTForwardHeaders   = ""
+	envMQTTForwardHeaders   = "MF_MQTT_ADAPTER_FORWARD_HEADERS"
+	// MQTT over TLS
+	defMQTTServerCert = ""
+	defMQTTServerKey  = ""
+	defMQTTClientCA   = ""
+	envMQTTServerCert = "MF_MQTT_ADAPTER_SERVER_CERT"
+	envMQTTServerKey  = "MF_MQTT_ADAPTER_SERVER_KEY"
+	envMQTTClientCA   = "MF_MQTT_ADAPTER_CLIENT_CA_CERTS"
 	// HTTP
 	defHTTPPort       = "8080"
 	defHTTPTargetHost = "localhost"
@@ -58,9 +71,26 @@ const (
 	defThingsAuthTimeout = "1s"
 	envThingsAuthURL     = "MF_THINGS_AUTH_GRPC_URL"
 	envThingsAuthTimeout = "MF_THINGS_AUTH_GRPC_TIMEOUT"
-	// Nats
-	defNatsURL = "nats://localhost:4222"
-	envNatsURL = "MF_NATS_URL"
+	// Message broker
+	defNatsURL      = "nats://localhost:4222"
+	defBrokerType   = "nats"
+	defJSStream     = "mainflux"
+	defJSMaxDeliver = "5"
+	defJSAckWait    = "30s"
+	defMsgMaxSize   = "0"
+	defMsgRateLimit = "0"
+	defMsgRateBurst = "1"
+	defPubLimits    = "false"
+	envNatsURL      = "MF_NATS_URL"
+	envBrokerType   = "MF_BROKER_TYPE"
+	envBrokerURL    = "MF_BROKER_URL"
+	envJSStream     = "MF_JETSTREAM_STREAM"
+	envJSMaxDeliver = "MF_JETSTREAM_MAX_DELIVER"
+	envJSAckWait    = "MF_JETSTREAM_ACK_WAIT"
+	envMsgMaxSize   = "MF_MESSAGE_MAX_SIZE"
+	envMsgRateLimit = "MF_MESSAGE_RATE_LIMIT"
+	envMsgRateBurst = "MF_MESSAGE_RATE_BURST"
+	envPubLimits    = "MF_PUBLISH_LIMITS"
 	// Jaeger
 	defJaegerURL = ""
 	envJaegerURL = "MF_JAEGER_URL"
@@ -93,6 +123,10 @@ type config struct {
 	mqttTargetHost       string
 	mqttTargetPort       string
 	mqttForwarderTimeout time.Duration
+	mqttForwardHeaders   []string
+	mqttServerCert       string
+	mqttServerKey        string
+	mqttClientCA         string
 	httpPort             string
 	httpTargetHost       string
 	httpTargetPort       string
@@ -102,7 +136,11 @@ type config struct {
 	thingsURL            string
 	thingsAuthURL        string
 	thingsAuthTimeout    time.Duration
-	natsURL              string
+	broker               brokers.Config
+	msgMaxSize           int
+	msgRateLimit         float64
+	msgRateBurst         int
+	publishLimits        bool
 	clientTLS            bool
 	caCerts              string
 	instance             string
@@ -128,31 +166,28 @@ func main() {
 	ec := connectToRedis(cfg.esURL, cfg.esPass, cfg.esDB, logger)
 	defer ec.Close()
 
-	nps, err := nats.NewPubSub(cfg.natsURL, "mqtt", logger)
+	nps, err := brokers.NewPubSub(cfg.broker, "mqtt", logger)
 	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to connect to NATS: %s", err))
+		logger.Error(fmt.Sprintf("Failed to connect to message broker: %s", err))
 		os.Exit(1)
 	}
 	defer nps.Close()
 
+	tracer, closer := initJaeger("mqtt_adapter", cfg.jaegerURL, logger)
+	defer closer.Close()
+	ps := messaging.ChainPubSub(nps, messagingMiddlewares(cfg, tracer)...)
+
 	mp, err := mqttpub.NewPublisher(fmt.Sprintf("%s:%s", cfg.mqttTargetHost, cfg.mqttTargetPort), cfg.mqttForwarderTimeout)
 	if err != nil {
 		logger.Error(fmt.Sprintf("Failed to create MQTT publisher: %s", err))
 		os.Exit(1)
 	}
 
-	fwd := mqtt.NewForwarder(nats.SubjectAllChannels, logger)
-	if err := fwd.Forward(nps, mp); err != nil {
-		logger.Error(fmt.Sprintf("Failed to forward NATS messages: %s", err))
-		os.Exit(1)
-	}
-
-	np, err := nats.NewPublisher(cfg.natsURL)
-	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to connect to NATS: %s", err))
+	fwd := mqtt.NewForwarder(nats.SubjectAllChannels, cfg.mqttForwardHeaders, logger)
+	if err := fwd.Forward(ps, mp); err != nil {
+		logger.Error(fmt.Sprintf("Failed to forward broker messages: %s", err))
 		os.Exit(1)
 	}
-	defer np.Close()
 
 	es := mqttredis.NewEventStore(ec, cfg.instance)
 
@@ -165,13 +200,18 @@ func main() {
 
 	authClient := auth.New(ac, tc)
 
+	limiter := limits.NewUnlimited()
+	if cfg.publishLimits {
+		limiter = limits.NewLimiter(ac)
+	}
+
 	// Event handler for MQTT hooks
-	h := mqtt.NewHandler([]messaging.Publisher{np}, es, logger, authClient)
+	h := mqtt.NewHandler([]messaging.Publisher{ps}, es, logger, authClient, limiter)
 
 	errs := make(chan error, 2)
 
 	logger.Info(fmt.Sprintf("Starting MQTT proxy on port %s", cfg.mqttPort))
-	go proxyMQTT(cfg, logger, h, errs)
+	go proxyMQTT(cfg, logger, h, auth.NewCertsCache(ac), errs)
 
 	logger.Info(fmt.Sprintf("Starting MQTT over WS  proxy on port %s", cfg.httpPort))
 	go proxyWS(cfg, logger, h, errs)
@@ -202,11 +242,35 @@ func loadConfig() config {
 		log.Fatalf("Invalid %s value: %s", envThingsAuthTimeout, err.Error())
 	}
 
+	msgMaxSize, err := strconv.Atoi(mainflux.Env(envMsgMaxSize, defMsgMaxSize))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envMsgMaxSize, err.Error())
+	}
+
+	msgRateLimit, err := strconv.ParseFloat(mainflux.Env(envMsgRateLimit, defMsgRateLimit), 64)
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envMsgRateLimit, err.Error())
+	}
+
+	msgRateBurst, err := strconv.Atoi(mainflux.Env(envMsgRateBurst, defMsgRateBurst))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envMsgRateBurst, err.Error())
+	}
+
+	publishLimits, err := strconv.ParseBool(mainflux.Env(envPubLimits, defPubLimits))
+	if err != nil {
+		log.Fatalf("Invalid value passed for %s\n", envPubLimits)
+	}
+
 	return config{
 		mqttPort:             mainflux.Env(envMQTTPort, defMQTTPort),
 		mqttTargetHost:       mainflux.Env(envMQTTTargetHost, defMQTTTargetHost),
 		mqttTargetPort:       mainflux.Env(envMQTTTargetPort, defMQTTTargetPort),
 		mqttForwarderTimeout: mqttTimeout,
+		mqttForwardHeaders:   messaging.ParseHeaderNames(mainflux.Env(envMQTTForwardHeaders, defMQTTForwardHeaders)),
+		mqttServerCert:       mainflux.Env(envMQTTServerCert, defMQTTServerCert),
+		mqttServerKey:        mainflux.Env(envMQTTServerKey, defMQTTServerKey),
+		mqttClientCA:         mainflux.Env(envMQTTClientCA, defMQTTClientCA),
 		httpPort:             mainflux.Env(envHTTPPort, defHTTPPort),
 		httpTargetHost:       mainflux.Env(envHTTPTargetHost, defHTTPTargetHost),
 		httpTargetPort:       mainflux.Env(envHTTPTargetPort, defHTTPTargetPort),
@@ -215,7 +279,11 @@ func loadConfig() config {
 		thingsAuthURL:        mainflux.Env(envThingsAuthURL, defThingsAuthURL),
 		thingsAuthTimeout:    authTimeout,
 		thingsURL:            mainflux.Env(envThingsAuthURL, defThingsAuthURL),
-		natsURL:              mainflux.Env(envNatsURL, defNatsURL),
+		broker:               loadBrokerConfig("mqtt-adapter"),
+		msgMaxSize:           msgMaxSize,
+		msgRateLimit:         msgRateLimit,
+		msgRateBurst:         msgRateBurst,
+		publishLimits:        publishLimits,
 		logLevel:             mainflux.Env(envLogLevel, defLogLevel),
 		clientTLS:            tls,
 		caCerts:              mainflux.Env(envCACerts, defCACerts),
@@ -229,6 +297,40 @@ func loadConfig() config {
 	}
 }
 
+func messagingMiddlewares(cfg config, tracer opentracing.Tracer) []messaging.Middleware {
+	mws := []messaging.Middleware{middleware.Tracing(tracer)}
+	if cfg.msgMaxSize > 0 {
+		mws = append(mws, middleware.SizeLimit(cfg.msgMaxSize))
+	}
+	if cfg.msgRateLimit > 0 {
+		mws = append(mws, middleware.RateLimit(cfg.msgRateLimit, cfg.msgRateBurst, middleware.ByPublisher))
+	}
+	return mws
+}
+
+func loadBrokerConfig(durable string) brokers.Config {
+	maxDeliver, err := strconv.Atoi(mainflux.Env(envJSMaxDeliver, defJSMaxDeliver))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSMaxDeliver, err.Error())
+	}
+
+	ackWait, err := time.ParseDuration(mainflux.Env(envJSAckWait, defJSAckWait))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSAckWait, err.Error())
+	}
+
+	return brokers.Config{
+		Type: mainflux.Env(envBrokerType, defBrokerType),
+		URL:  mainflux.Env(envBrokerURL, mainflux.Env(envNatsURL, defNatsURL)),
+		JetStream: jetstream.Config{
+			Stream:     mainflux.Env(envJSStream, defJSStream),
+			Durable:    durable,
+			MaxDeliver: maxDeliver,
+			AckWait:    ackWait,
+		},
+	}
+}
+
 func initJaeger(svcName, url string, logger mflog.Logger) (opentracing.Tracer, io.Closer) {
 	if url == "" {
 		return opentracing.NoopTracer{}, ioutil.NopCloser(nil)
@@ -291,12 +393,23 @@ func connectToRedis(redisURL, redisPass, redisDB string, logger mflog.Logger) *r
 	})
 }
 
-func proxyMQTT(cfg config, logger mflog.Logger, handler session.Handler, errs chan error) {
+func proxyMQTT(cfg config, logger mflog.Logger, handler session.Handler, certs auth.CertsCache, errs chan error) {
 	address := fmt.Sprintf(":%s", cfg.mqttPort)
 	target := fmt.Sprintf("%s:%s", cfg.mqttTargetHost, cfg.mqttTargetPort)
 	mp := mp.New(address, target, handler, logger)
 
-	errs <- mp.Listen()
+	if cfg.mqttServerCert == "" || cfg.mqttServerKey == "" {
+		errs <- mp.Listen()
+		return
+	}
+
+	tlsCfg, err := auth.ServerTLSConfig(cfg.mqttClientCA, cfg.mqttServerCert, cfg.mqttServerKey, certs)
+	if err != nil {
+		errs <- err
+		return
+	}
+	logger.Info(fmt.Sprintf("MQTT proxy uses TLS with cert %s and key %s", cfg.mqttServerCert, cfg.mqttServerKey))
+	errs <- mp.ListenTLS(tlsCfg)
 }
 func proxyWS(cfg config, logger mflog.Logger, handler session.Handler, errs chan error) {
 	target := fmt.Sprintf("%s:%s", cfg.httpTargetHost, cfg.httpTargetPort)
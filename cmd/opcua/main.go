@@ -5,13 +5,16 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	r "github.com/go-redis/redis"
 	"github.com/mainflux/mainflux"
@@ -21,7 +24,9 @@ import (
 	"github.com/mainflux/mainflux/opcua/db"
 	"github.com/mainflux/mainflux/opcua/gopcua"
 	"github.com/mainflux/mainflux/opcua/redis"
-	"github.com/mainflux/mainflux/pkg/messaging/nats"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/brokers"
+	"github.com/mainflux/mainflux/pkg/messaging/jetstream"
 
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
@@ -35,7 +40,13 @@ const (
 	defOPCMode        = ""
 	defOPCCertFile    = ""
 	defOPCKeyFile     = ""
+	defSecretsDir     = ""
+	defSecretsPrefix  = "MF_OPCUA_SECRET_"
 	defNatsURL        = "nats://localhost:4222"
+	defBrokerType     = "nats"
+	defJSStream       = "mainflux"
+	defJSMaxDeliver   = "5"
+	defJSAckWait      = "30s"
 	defESURL          = "localhost:6379"
 	defESPass         = ""
 	defESDB           = "0"
@@ -51,7 +62,14 @@ const (
 	envOPCMode        = "MF_OPCUA_ADAPTER_MODE"
 	envOPCCertFile    = "MF_OPCUA_ADAPTER_CERT_FILE"
 	envOPCKeyFile     = "MF_OPCUA_ADAPTER_KEY_FILE"
+	envSecretsDir     = "MF_OPCUA_ADAPTER_SECRETS_DIR"
+	envSecretsPrefix  = "MF_OPCUA_ADAPTER_SECRETS_ENV_PREFIX"
 	envNatsURL        = "MF_NATS_URL"
+	envBrokerType     = "MF_BROKER_TYPE"
+	envBrokerURL      = "MF_BROKER_URL"
+	envJSStream       = "MF_JETSTREAM_STREAM"
+	envJSMaxDeliver   = "MF_JETSTREAM_MAX_DELIVER"
+	envJSAckWait      = "MF_JETSTREAM_ACK_WAIT"
 	envESURL          = "MF_THINGS_ES_URL"
 	envESPass         = "MF_THINGS_ES_PASS"
 	envESDB           = "MF_THINGS_ES_DB"
@@ -63,12 +81,21 @@ const (
 	thingsRMPrefix     = "thing"
 	channelsRMPrefix   = "channel"
 	connectionRMPrefix = "connection"
+	eventsRMPrefix     = "event"
+	tokensRMPrefix     = "token"
+
+	// Values are written to OPC-UA nodes by publishing SenML to the
+	// channels/<channel_id>/messages/write/<thing_id> topic. Write
+	// failures are reported on the write/<thing_id>/response subtopic.
+	writeSubject     = "channels.*.write.*"
+	writeSubtopic    = "write"
+	responseSubtopic = "response"
 )
 
 type config struct {
 	httpPort       string
 	opcuaConfig    opcua.Config
-	natsURL        string
+	broker         brokers.Config
 	logLevel       string
 	esURL          string
 	esPass         string
@@ -93,22 +120,24 @@ func main() {
 	thingRM := newRouteMapRepositoy(rmConn, thingsRMPrefix, logger)
 	chanRM := newRouteMapRepositoy(rmConn, channelsRMPrefix, logger)
 	connRM := newRouteMapRepositoy(rmConn, connectionRMPrefix, logger)
+	eventsRM := newRouteMapRepositoy(rmConn, eventsRMPrefix, logger)
+	tokensRM := newRouteMapRepositoy(rmConn, tokensRMPrefix, logger)
 
 	esConn := connectToRedis(cfg.esURL, cfg.esPass, cfg.esDB, logger)
 	defer esConn.Close()
 
-	pubSub, err := nats.NewPubSub(cfg.natsURL, "", logger)
+	pubSub, err := brokers.NewPubSub(cfg.broker, "", logger)
 	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to connect to NATS: %s", err))
+		logger.Error(fmt.Sprintf("Failed to connect to message broker: %s", err))
 		os.Exit(1)
 	}
 	defer pubSub.Close()
 
 	ctx := context.Background()
-	sub := gopcua.NewSubscriber(ctx, pubSub, thingRM, chanRM, connRM, logger)
+	client := gopcua.NewClient(ctx, pubSub, thingRM, chanRM, connRM, logger)
 	browser := gopcua.NewBrowser(ctx, logger)
 
-	svc := opcua.New(sub, browser, thingRM, chanRM, connRM, cfg.opcuaConfig, logger)
+	svc := opcua.New(client, browser, client, thingRM, chanRM, connRM, eventsRM, tokensRM, cfg.opcuaConfig, logger)
 	svc = api.LoggingMiddleware(svc, logger)
 	svc = api.MetricsMiddleware(
 		svc,
@@ -126,8 +155,9 @@ func main() {
 		}, []string{"method"}),
 	)
 
-	go subscribeToStoredSubs(sub, cfg.opcuaConfig, logger)
+	go subscribeToStoredSubs(client, chanRM, tokensRM, cfg.opcuaConfig, logger)
 	go subscribeToThingsES(svc, esConn, cfg.esConsumerName, logger)
+	go subscribeToWrites(svc, pubSub, logger)
 
 	errs := make(chan error, 2)
 
@@ -150,11 +180,15 @@ func loadConfig() config {
 		Mode:     mainflux.Env(envOPCMode, defOPCMode),
 		CertFile: mainflux.Env(envOPCCertFile, defOPCCertFile),
 		KeyFile:  mainflux.Env(envOPCKeyFile, defOPCKeyFile),
+		Secrets: opcua.Secrets{
+			Dir:       mainflux.Env(envSecretsDir, defSecretsDir),
+			EnvPrefix: mainflux.Env(envSecretsPrefix, defSecretsPrefix),
+		},
 	}
 	return config{
 		httpPort:       mainflux.Env(envHTTPPort, defHTTPPort),
 		opcuaConfig:    oc,
-		natsURL:        mainflux.Env(envNatsURL, defNatsURL),
+		broker:         loadBrokerConfig("opcua"),
 		logLevel:       mainflux.Env(envLogLevel, defLogLevel),
 		esURL:          mainflux.Env(envESURL, defESURL),
 		esPass:         mainflux.Env(envESPass, defESPass),
@@ -166,6 +200,29 @@ func loadConfig() config {
 	}
 }
 
+func loadBrokerConfig(durable string) brokers.Config {
+	maxDeliver, err := strconv.Atoi(mainflux.Env(envJSMaxDeliver, defJSMaxDeliver))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSMaxDeliver, err.Error())
+	}
+
+	ackWait, err := time.ParseDuration(mainflux.Env(envJSAckWait, defJSAckWait))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSAckWait, err.Error())
+	}
+
+	return brokers.Config{
+		Type: mainflux.Env(envBrokerType, defBrokerType),
+		URL:  mainflux.Env(envBrokerURL, mainflux.Env(envNatsURL, defNatsURL)),
+		JetStream: jetstream.Config{
+			Stream:     mainflux.Env(envJSStream, defJSStream),
+			Durable:    durable,
+			MaxDeliver: maxDeliver,
+			AckWait:    ackWait,
+		},
+	}
+}
+
 func connectToRedis(redisURL, redisPass, redisDB string, logger logger.Logger) *r.Client {
 	db, err := strconv.Atoi(redisDB)
 	if err != nil {
@@ -180,7 +237,7 @@ func connectToRedis(redisURL, redisPass, redisDB string, logger logger.Logger) *
 	})
 }
 
-func subscribeToStoredSubs(sub opcua.Subscriber, cfg opcua.Config, logger logger.Logger) {
+func subscribeToStoredSubs(sub opcua.Subscriber, chanRM, tokensRM opcua.RouteMapRepository, cfg opcua.Config, logger logger.Logger) {
 	// Get all stored subscriptions
 	nodes, err := db.ReadAll()
 	if err != nil {
@@ -190,14 +247,34 @@ func subscribeToStoredSubs(sub opcua.Subscriber, cfg opcua.Config, logger logger
 	for _, n := range nodes {
 		cfg.ServerURI = n.ServerURI
 		cfg.NodeID = n.NodeID
-		go func() {
-			if err := sub.Subscribe(cfg); err != nil {
-				logger.Warn(fmt.Sprintf("Subscription failed: %s", err))
-			}
-		}()
+		cfg.Events = opcua.EventFilter{
+			Type:   n.EventType,
+			Fields: n.EventFields,
+		}
+		cfg.UserToken = userToken(chanRM, tokensRM, n.ServerURI)
+		if err := sub.Subscribe(cfg); err != nil {
+			logger.Warn(fmt.Sprintf("Subscription failed: %s", err))
+		}
 	}
 }
 
+func userToken(chanRM, tokensRM opcua.RouteMapRepository, serverURI string) opcua.UserToken {
+	var token opcua.UserToken
+
+	chanID, err := chanRM.Get(serverURI)
+	if err != nil {
+		return token
+	}
+
+	data, err := tokensRM.Get(chanID)
+	if err != nil {
+		return token
+	}
+
+	json.Unmarshal([]byte(data), &token)
+	return token
+}
+
 func subscribeToOpcuaServer(gc opcua.Subscriber, cfg opcua.Config, logger logger.Logger) {
 	if err := gc.Subscribe(cfg); err != nil {
 		logger.Warn(fmt.Sprintf("OPC-UA Subscription failed: %s", err))
@@ -211,6 +288,35 @@ func subscribeToThingsES(svc opcua.Service, client *r.Client, prefix string, log
 	}
 }
 
+func subscribeToWrites(svc opcua.Service, ps messaging.PubSub, logger logger.Logger) {
+	err := ps.Subscribe(writeSubject, func(msg messaging.Message) error {
+		thingID := strings.TrimPrefix(msg.Subtopic, writeSubtopic+".")
+
+		err := svc.Write(msg.Channel, thingID, msg.Payload)
+		if err == nil || err == opcua.ErrNotFoundServerURI {
+			return nil
+		}
+
+		payload, _ := json.Marshal(map[string]string{"error": err.Error()})
+		res := messaging.Message{
+			Channel:   msg.Channel,
+			Subtopic:  fmt.Sprintf("%s.%s.%s", writeSubtopic, thingID, responseSubtopic),
+			Publisher: thingID,
+			Protocol:  "opcua",
+			Payload:   payload,
+			Created:   time.Now().UnixNano(),
+		}
+		if err := ps.Publish(res.Channel, res); err != nil {
+			logger.Warn(fmt.Sprintf("Failed to publish write response: %s", err))
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Failed to subscribe to OPC-UA writes: %s", err))
+	}
+}
+
 func newRouteMapRepositoy(client *r.Client, prefix string, logger logger.Logger) opcua.RouteMapRepository {
 	logger.Info(fmt.Sprintf("Connected to %s Redis Route-map", prefix))
 	return redis.NewRouteMapRepository(client, prefix)
@@ -19,6 +19,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/envelope"
 	"github.com/mainflux/mainflux/readers"
 	"github.com/mainflux/mainflux/readers/api"
 	"github.com/mainflux/mainflux/readers/postgres"
@@ -50,6 +51,7 @@ const (
 	defJaegerURL         = ""
 	defThingsAuthURL     = "localhost:8181"
 	defThingsAuthTimeout = "1s"
+	defKeysFile          = ""
 
 	envLogLevel          = "MF_POSTGRES_READER_LOG_LEVEL"
 	envPort              = "MF_POSTGRES_READER_PORT"
@@ -67,6 +69,7 @@ const (
 	envJaegerURL         = "MF_JAEGER_URL"
 	envThingsAuthURL     = "MF_THINGS_AUTH_GRPC_URL"
 	envThingsAuthTimeout = "MF_THINGS_AUTH_GRPC_TIMEOUT"
+	envKeysFile          = "MF_POSTGRES_READER_KEYS_FILE"
 )
 
 type config struct {
@@ -78,6 +81,7 @@ type config struct {
 	jaegerURL         string
 	thingsAuthURL     string
 	thingsAuthTimeout time.Duration
+	keysFile          string
 }
 
 func main() {
@@ -100,6 +104,9 @@ func main() {
 	defer db.Close()
 
 	repo := newService(db, logger)
+	if cfg.keysFile != "" {
+		repo = api.DecryptionMiddleware(repo, newSealer(cfg.keysFile, logger))
+	}
 
 	errs := make(chan error, 2)
 
@@ -147,6 +154,7 @@ func loadConfig() config {
 		jaegerURL:         mainflux.Env(envJaegerURL, defJaegerURL),
 		thingsAuthURL:     mainflux.Env(envThingsAuthURL, defThingsAuthURL),
 		thingsAuthTimeout: authTimeout,
+		keysFile:          mainflux.Env(envKeysFile, defKeysFile),
 	}
 }
 
@@ -234,3 +242,12 @@ func startHTTPServer(repo readers.MessageRepository, tc mainflux.ThingsServiceCl
 	logger.Info(fmt.Sprintf("Postgres reader service started, exposed port %s", port))
 	errs <- http.ListenAndServe(p, api.MakeHandler(repo, tc, svcName))
 }
+
+func newSealer(keysFile string, logger logger.Logger) envelope.Sealer {
+	keys, err := envelope.NewFileProvider(keysFile)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load encryption keys: %s", err))
+		os.Exit(1)
+	}
+	return envelope.New(keys)
+}
@@ -9,13 +9,18 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
 	"github.com/jmoiron/sqlx"
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/logger"
-	"github.com/mainflux/mainflux/pkg/messaging/nats"
+	"github.com/mainflux/mainflux/pkg/envelope"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/brokers"
+	"github.com/mainflux/mainflux/pkg/messaging/jetstream"
 	"github.com/mainflux/mainflux/pkg/transformers/senml"
 	"github.com/mainflux/mainflux/writers"
 	"github.com/mainflux/mainflux/writers/api"
@@ -29,6 +34,10 @@ const (
 
 	defLogLevel        = "error"
 	defNatsURL         = "nats://localhost:4222"
+	defBrokerType      = "nats"
+	defJSStream        = "mainflux"
+	defJSMaxDeliver    = "5"
+	defJSAckWait       = "30s"
 	defPort            = "8180"
 	defDBHost          = "localhost"
 	defDBPort          = "5432"
@@ -41,8 +50,19 @@ const (
 	defDBSSLRootCert   = ""
 	defSubjectsCfgPath = "/config/subjects.toml"
 	defContentType     = "application/senml+json"
+	defPartition       = "0"
+	defPartitions      = "1"
+	defHeaders         = ""
+	defTimePolicy      = "device"
+	defMaxTimeSkew     = "5m"
+	defKeysFile        = ""
 
 	envNatsURL         = "MF_NATS_URL"
+	envBrokerType      = "MF_BROKER_TYPE"
+	envBrokerURL       = "MF_BROKER_URL"
+	envJSStream        = "MF_JETSTREAM_STREAM"
+	envJSMaxDeliver    = "MF_JETSTREAM_MAX_DELIVER"
+	envJSAckWait       = "MF_JETSTREAM_ACK_WAIT"
 	envLogLevel        = "MF_POSTGRES_WRITER_LOG_LEVEL"
 	envPort            = "MF_POSTGRES_WRITER_PORT"
 	envDBHost          = "MF_POSTGRES_WRITER_DB_HOST"
@@ -56,15 +76,25 @@ const (
 	envDBSSLRootCert   = "MF_POSTGRES_WRITER_DB_SSL_ROOT_CERT"
 	envSubjectsCfgPath = "MF_POSTGRES_WRITER_SUBJECTS_CONFIG"
 	envContentType     = "MF_POSTGRES_WRITER_CONTENT_TYPE"
+	envPartition       = "MF_POSTGRES_WRITER_PARTITION"
+	envPartitions      = "MF_POSTGRES_WRITER_PARTITIONS"
+	envHeaders         = "MF_POSTGRES_WRITER_HEADERS"
+	envTimePolicy      = "MF_POSTGRES_WRITER_TIME_POLICY"
+	envMaxTimeSkew     = "MF_POSTGRES_WRITER_MAX_TIME_SKEW"
+	envKeysFile        = "MF_POSTGRES_WRITER_KEYS_FILE"
 )
 
 type config struct {
-	natsURL         string
+	broker          brokers.Config
 	logLevel        string
 	port            string
 	subjectsCfgPath string
 	contentType     string
+	partition       writers.Partition
+	headers         []string
+	timestamping    senml.Timestamping
 	dbConfig        postgres.Config
+	keysFile        string
 }
 
 func main() {
@@ -75,19 +105,40 @@ func main() {
 		log.Fatalf(err.Error())
 	}
 
-	pubSub, err := nats.NewPubSub(cfg.natsURL, "", logger)
+	queue := cfg.partition.Queue(svcName)
+	pubSub, err := brokers.NewPubSub(cfg.broker, queue, logger)
 	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to connect to NATS: %s", err))
+		logger.Error(fmt.Sprintf("Failed to connect to message broker: %s", err))
 		os.Exit(1)
 	}
 	defer pubSub.Close()
 
+	if cfg.partition.Count > 1 {
+		lease, err := brokers.NewLease(cfg.broker, queue)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to create partition lease: %s", err))
+			os.Exit(1)
+		}
+		defer lease.Close()
+		cfg.partition.Lease = lease
+	}
+
+	api.PartitionMetrics(kitprometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Namespace: "postgres",
+		Subsystem: "message_writer",
+		Name:      "partition",
+		Help:      "Queue group and partition assignment of the writer replica.",
+	}, []string{"queue", "partition", "partitions"}), queue, cfg.partition)
+
 	db := connectToDB(cfg.dbConfig, logger)
 	defer db.Close()
 
 	repo := newService(db, logger)
-	st := senml.New(cfg.contentType)
-	if err = writers.Start(pubSub, repo, st, svcName, cfg.subjectsCfgPath, logger); err != nil {
+	if cfg.keysFile != "" {
+		repo = api.EncryptionMiddleware(repo, newSealer(cfg.keysFile, logger))
+	}
+	st := senml.NewWithTimestamping(cfg.contentType, cfg.timestamping)
+	if err = writers.Start(pubSub, repo, st, cfg.partition, cfg.headers, cfg.subjectsCfgPath, logger); err != nil {
 		logger.Error(fmt.Sprintf("Failed to create Postgres writer: %s", err))
 	}
 
@@ -118,13 +169,76 @@ func loadConfig() config {
 		SSLRootCert: mainflux.Env(envDBSSLRootCert, defDBSSLRootCert),
 	}
 
+	partition := loadPartition()
+
 	return config{
-		natsURL:         mainflux.Env(envNatsURL, defNatsURL),
+		broker:          loadBrokerConfig(partition.Queue(svcName)),
+		partition:       partition,
+		headers:         messaging.ParseHeaderNames(mainflux.Env(envHeaders, defHeaders)),
+		timestamping:    loadTimestamping(),
 		logLevel:        mainflux.Env(envLogLevel, defLogLevel),
 		port:            mainflux.Env(envPort, defPort),
 		subjectsCfgPath: mainflux.Env(envSubjectsCfgPath, defSubjectsCfgPath),
 		contentType:     mainflux.Env(envContentType, defContentType),
 		dbConfig:        dbConfig,
+		keysFile:        mainflux.Env(envKeysFile, defKeysFile),
+	}
+}
+
+func loadPartition() writers.Partition {
+	id, err := strconv.Atoi(mainflux.Env(envPartition, defPartition))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envPartition, err.Error())
+	}
+
+	count, err := strconv.Atoi(mainflux.Env(envPartitions, defPartitions))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envPartitions, err.Error())
+	}
+
+	p := writers.Partition{ID: id, Count: count}
+	if err := p.Validate(); err != nil {
+		log.Fatalf("Invalid partition %d of %d: %s", id, count, err.Error())
+	}
+	return p
+}
+
+func loadTimestamping() senml.Timestamping {
+	maxSkew, err := time.ParseDuration(mainflux.Env(envMaxTimeSkew, defMaxTimeSkew))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envMaxTimeSkew, err.Error())
+	}
+
+	ts := senml.Timestamping{
+		Policy:  senml.TimePolicy(mainflux.Env(envTimePolicy, defTimePolicy)),
+		MaxSkew: maxSkew,
+	}
+	if err := ts.Validate(); err != nil {
+		log.Fatalf("Invalid %s value %s: %s", envTimePolicy, ts.Policy, err.Error())
+	}
+	return ts
+}
+
+func loadBrokerConfig(durable string) brokers.Config {
+	maxDeliver, err := strconv.Atoi(mainflux.Env(envJSMaxDeliver, defJSMaxDeliver))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSMaxDeliver, err.Error())
+	}
+
+	ackWait, err := time.ParseDuration(mainflux.Env(envJSAckWait, defJSAckWait))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSAckWait, err.Error())
+	}
+
+	return brokers.Config{
+		Type: mainflux.Env(envBrokerType, defBrokerType),
+		URL:  mainflux.Env(envBrokerURL, mainflux.Env(envNatsURL, defNatsURL)),
+		JetStream: jetstream.Config{
+			Stream:     mainflux.Env(envJSStream, defJSStream),
+			Durable:    durable,
+			MaxDeliver: maxDeliver,
+			AckWait:    ackWait,
+		},
 	}
 }
 
@@ -154,6 +268,12 @@ func newService(db *sqlx.DB, logger logger.Logger) writers.MessageRepository {
 			Name:      "request_latency_microseconds",
 			Help:      "Total duration of requests in microseconds.",
 		}, []string{"method"}),
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "postgres",
+			Subsystem: "message_writer",
+			Name:      "corrected_timestamps",
+			Help:      "Number of saved messages with the device time replaced by the time policy.",
+		}, []string{}),
 	)
 
 	return svc
@@ -164,3 +284,12 @@ func startHTTPServer(port string, errs chan error, logger logger.Logger) {
 	logger.Info(fmt.Sprintf("Postgres writer service started, exposed port %s", port))
 	errs <- http.ListenAndServe(p, api.MakeHandler(svcName))
 }
+
+func newSealer(keysFile string, logger logger.Logger) envelope.Sealer {
+	keys, err := envelope.NewFileProvider(keysFile)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to load encryption keys: %s", err))
+		os.Exit(1)
+	}
+	return envelope.New(keys)
+}
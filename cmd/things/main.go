@@ -4,6 +4,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -27,6 +28,7 @@ import (
 	"github.com/mainflux/mainflux"
 	authapi "github.com/mainflux/mainflux/authn/api/grpc"
 	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/limits"
 	uuidProvider "github.com/mainflux/mainflux/pkg/uuid"
 	"github.com/mainflux/mainflux/things"
 	"github.com/mainflux/mainflux/things/api"
@@ -320,7 +322,14 @@ func newService(auth mainflux.AuthNServiceClient, dbTracer opentracing.Tracer, c
 	thingCache = tracing.ThingCacheMiddleware(cacheTracer, thingCache)
 	up := uuidProvider.New()
 
+	limitsCache := limits.NewCache(cacheClient)
+	if err := rediscache.WarmLimits(context.Background(), postgres.NewLimitsRepository(database), limitsCache); err != nil {
+		logger.Error(fmt.Sprintf("Failed to warm limits cache: %s", err))
+		os.Exit(1)
+	}
+
 	svc := things.New(auth, thingsRepo, channelsRepo, groupsRepo, chanCache, thingCache, up)
+	svc = rediscache.NewLimitsMiddleware(svc, limitsCache)
 	svc = rediscache.NewEventStoreMiddleware(svc, esClient)
 	svc = api.LoggingMiddleware(svc, logger)
 	svc = api.MetricsMiddleware(
@@ -21,6 +21,8 @@ import (
 	authapi "github.com/mainflux/mainflux/authn/api/grpc"
 	"github.com/mainflux/mainflux/logger"
 	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/brokers"
+	"github.com/mainflux/mainflux/pkg/messaging/jetstream"
 	"github.com/mainflux/mainflux/pkg/messaging/nats"
 	uuidProvider "github.com/mainflux/mainflux/pkg/uuid"
 	localusers "github.com/mainflux/mainflux/things/users"
@@ -59,6 +61,10 @@ const (
 	defCACerts         = ""
 	defChannelID       = ""
 	defNatsURL         = "nats://localhost:4222"
+	defBrokerType      = "nats"
+	defJSStream        = "mainflux"
+	defJSMaxDeliver    = "5"
+	defJSAckWait       = "30s"
 	defAuthnURL        = "localhost:8181"
 	defAuthnTimeout    = "1s"
 
@@ -79,6 +85,11 @@ const (
 	envCACerts         = "MF_TWINS_CA_CERTS"
 	envChannelID       = "MF_TWINS_CHANNEL_ID"
 	envNatsURL         = "MF_NATS_URL"
+	envBrokerType      = "MF_BROKER_TYPE"
+	envBrokerURL       = "MF_BROKER_URL"
+	envJSStream        = "MF_JETSTREAM_STREAM"
+	envJSMaxDeliver    = "MF_JETSTREAM_MAX_DELIVER"
+	envJSAckWait       = "MF_JETSTREAM_ACK_WAIT"
 	envAuthnURL        = "MF_AUTHN_GRPC_URL"
 	envAuthnTimeout    = "MF_AUTHN_GRPC_TIMEOUT"
 )
@@ -98,7 +109,7 @@ type config struct {
 	clientTLS       bool
 	caCerts         string
 	channelID       string
-	natsURL         string
+	broker          brokers.Config
 
 	authnURL     string
 	authnTimeout time.Duration
@@ -128,9 +139,9 @@ func main() {
 	defer authCloser.Close()
 	auth, _ := createAuthClient(cfg, authTracer, logger)
 
-	pubSub, err := nats.NewPubSub(cfg.natsURL, queue, logger)
+	pubSub, err := brokers.NewPubSub(cfg.broker, queue, logger)
 	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to connect to NATS: %s", err))
+		logger.Error(fmt.Sprintf("Failed to connect to message broker: %s", err))
 		os.Exit(1)
 	}
 	defer pubSub.Close()
@@ -184,12 +195,35 @@ func loadConfig() config {
 		clientTLS:       tls,
 		caCerts:         mainflux.Env(envCACerts, defCACerts),
 		channelID:       mainflux.Env(envChannelID, defChannelID),
-		natsURL:         mainflux.Env(envNatsURL, defNatsURL),
+		broker:          loadBrokerConfig(queue),
 		authnURL:        mainflux.Env(envAuthnURL, defAuthnURL),
 		authnTimeout:    authnTimeout,
 	}
 }
 
+func loadBrokerConfig(durable string) brokers.Config {
+	maxDeliver, err := strconv.Atoi(mainflux.Env(envJSMaxDeliver, defJSMaxDeliver))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSMaxDeliver, err.Error())
+	}
+
+	ackWait, err := time.ParseDuration(mainflux.Env(envJSAckWait, defJSAckWait))
+	if err != nil {
+		log.Fatalf("Invalid %s value: %s", envJSAckWait, err.Error())
+	}
+
+	return brokers.Config{
+		Type: mainflux.Env(envBrokerType, defBrokerType),
+		URL:  mainflux.Env(envBrokerURL, mainflux.Env(envNatsURL, defNatsURL)),
+		JetStream: jetstream.Config{
+			Stream:     mainflux.Env(envJSStream, defJSStream),
+			Durable:    durable,
+			MaxDeliver: maxDeliver,
+			AckWait:    ackWait,
+		},
+	}
+}
+
 func initJaeger(svcName, url string, logger logger.Logger) (opentracing.Tracer, io.Closer) {
 	if url == "" {
 		return opentracing.NoopTracer{}, ioutil.NopCloser(nil)
@@ -10,12 +10,12 @@ import (
 	"context"
 	"fmt"
 	"sync"
-
-	"github.com/gogo/protobuf/proto"
-	"github.com/mainflux/mainflux/pkg/errors"
-	broker "github.com/nats-io/nats.go"
+	"time"
 
 	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/pkg/auth"
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/mainflux/mainflux/pkg/limits"
 	"github.com/mainflux/mainflux/pkg/messaging"
 )
 
@@ -27,9 +27,12 @@ var (
 	ErrUnsubscribe  = errors.New("unable to unsubscribe")
 )
 
-// Service specifies CoAP service API.
+// Service specifies CoAP service API. Thing is identified by the key or,
+// if the key is empty, by the ID of the thing authenticated during DTLS
+// handshake carried in the context (see auth.WithThingID).
 type Service interface {
-	// Publish Messssage
+	// Publish Messssage. Messages exceeding the limits of the thing or the
+	// channel are rejected with limits.ErrRateLimited or limits.ErrQuotaExceeded.
 	Publish(ctx context.Context, key string, msg messaging.Message) error
 
 	// Subscribes to channel with specified id, subtopic and adds subscription to
@@ -42,19 +45,26 @@ type Service interface {
 
 var _ Service = (*adapterService)(nil)
 
-// Observers is a map of maps,
+// Observers is a map of maps, observers of each subject by token.
+// Single subscription to the subject is shared by all its observers.
 type adapterService struct {
 	auth      mainflux.ThingsServiceClient
-	conn      *broker.Conn
+	pubsub    messaging.PubSub
+	limiter   limits.Limiter
+	keepAlive time.Duration
 	observers map[string]observers
 	obsLock   sync.Mutex
 }
 
-// New instantiates the CoAP adapter implementation.
-func New(auth mainflux.ThingsServiceClient, nc *broker.Conn) Service {
+// New instantiates the CoAP adapter implementation. Observers which didn't
+// receive any notification during the keepalive period are pinged to
+// detect the clients which stopped responding.
+func New(auth mainflux.ThingsServiceClient, pubsub messaging.PubSub, limiter limits.Limiter, keepAlive time.Duration) Service {
 	as := &adapterService{
 		auth:      auth,
-		conn:      nc,
+		pubsub:    pubsub,
+		limiter:   limiter,
+		keepAlive: keepAlive,
 		observers: make(map[string]observers),
 		obsLock:   sync.Mutex{},
 	}
@@ -63,35 +73,21 @@ func New(auth mainflux.ThingsServiceClient, nc *broker.Conn) Service {
 }
 
 func (svc *adapterService) Publish(ctx context.Context, key string, msg messaging.Message) error {
-	ar := &mainflux.AccessByKeyReq{
-		Token:  key,
-		ChanID: msg.Channel,
-	}
-	thid, err := svc.auth.CanAccessByKey(ctx, ar)
+	thid, err := auth.CanAccess(ctx, svc.auth, key, msg.Channel)
 	if err != nil {
 		return errors.Wrap(ErrUnauthorized, err)
 	}
-	msg.Publisher = thid.GetValue()
+	msg.Publisher = thid
 
-	data, err := proto.Marshal(&msg)
-	if err != nil {
+	if err := svc.limiter.Allow(msg.Publisher, msg.Channel); err != nil {
 		return err
 	}
 
-	subject := fmt.Sprintf("%s.%s", chansPrefix, msg.Channel)
-	if msg.Subtopic != "" {
-		subject = fmt.Sprintf("%s.%s", subject, msg.Subtopic)
-	}
-
-	return svc.conn.Publish(subject, data)
+	return svc.pubsub.Publish(msg.Channel, msg)
 }
 
 func (svc *adapterService) Subscribe(ctx context.Context, key, chanID, subtopic string, c Client) error {
-	ar := &mainflux.AccessByKeyReq{
-		Token:  key,
-		ChanID: chanID,
-	}
-	if _, err := svc.auth.CanAccessByKey(ctx, ar); err != nil {
+	if _, err := auth.CanAccess(ctx, svc.auth, key, chanID); err != nil {
 		return errors.Wrap(ErrUnauthorized, err)
 	}
 
@@ -100,25 +96,22 @@ func (svc *adapterService) Subscribe(ctx context.Context, key, chanID, subtopic
 		subject = fmt.Sprintf("%s.%s", subject, subtopic)
 	}
 
+	obs := NewObserver(c, svc.keepAlive)
+	if err := svc.put(subject, c.Token(), obs); err != nil {
+		obs.Cancel()
+		return err
+	}
+
 	go func() {
-		<-c.Done()
-		svc.remove(subject, c.Token())
+		<-obs.Done()
+		svc.release(subject, c.Token(), obs)
 	}()
 
-	obs, err := NewObserver(subject, c, svc.conn)
-	if err != nil {
-		c.Cancel()
-		return err
-	}
-	return svc.put(subject, c.Token(), obs)
+	return nil
 }
 
 func (svc *adapterService) Unsubscribe(ctx context.Context, key, chanID, subtopic, token string) error {
-	ar := &mainflux.AccessByKeyReq{
-		Token:  key,
-		ChanID: chanID,
-	}
-	if _, err := svc.auth.CanAccessByKey(ctx, ar); err != nil {
+	if _, err := auth.CanAccess(ctx, svc.auth, key, chanID); err != nil {
 		return errors.Wrap(ErrUnauthorized, err)
 	}
 	subject := fmt.Sprintf("%s.%s", chansPrefix, chanID)
@@ -129,15 +122,18 @@ func (svc *adapterService) Unsubscribe(ctx context.Context, key, chanID, subtopi
 	return svc.remove(subject, token)
 }
 
-func (svc *adapterService) put(endpoint, token string, o Observer) error {
+func (svc *adapterService) put(subject, token string, o Observer) error {
 	svc.obsLock.Lock()
 	defer svc.obsLock.Unlock()
 
-	obs, ok := svc.observers[endpoint]
-	// If there are no observers, create map and assign it to the endpoint.
+	obs, ok := svc.observers[subject]
+	// If there are no observers, subscribe to the subject and
+	// create map of its observers.
 	if !ok {
-		obs = observers{token: o}
-		svc.observers[endpoint] = obs
+		if err := svc.pubsub.Subscribe(subject, svc.handle(subject)); err != nil {
+			return err
+		}
+		svc.observers[subject] = observers{token: o}
 		return nil
 	}
 	// If observer exists, cancel subscription and replace it.
@@ -150,11 +146,11 @@ func (svc *adapterService) put(endpoint, token string, o Observer) error {
 	return nil
 }
 
-func (svc *adapterService) remove(endpoint, token string) error {
+func (svc *adapterService) remove(subject, token string) error {
 	svc.obsLock.Lock()
 	defer svc.obsLock.Unlock()
 
-	obs, ok := svc.observers[endpoint]
+	obs, ok := svc.observers[subject]
 	if !ok {
 		return nil
 	}
@@ -164,9 +160,44 @@ func (svc *adapterService) remove(endpoint, token string) error {
 		}
 	}
 	delete(obs, token)
-	// If there are no observers left for the endpint, remove the map.
-	if len(obs) == 0 {
-		delete(svc.observers, endpoint)
+	return svc.unsubscribe(subject)
+}
+
+// release removes the observer once the observation ended,
+// unless it's already replaced by the new observer.
+func (svc *adapterService) release(subject, token string, o Observer) error {
+	svc.obsLock.Lock()
+	defer svc.obsLock.Unlock()
+
+	obs, ok := svc.observers[subject]
+	if !ok || obs[token] != o {
+		return nil
+	}
+	delete(obs, token)
+	return svc.unsubscribe(subject)
+}
+
+// unsubscribe removes the subscription to the subject if
+// there are no observers left. Caller must hold the lock.
+func (svc *adapterService) unsubscribe(subject string) error {
+	if len(svc.observers[subject]) > 0 {
+		return nil
+	}
+	delete(svc.observers, subject)
+	if err := svc.pubsub.Unsubscribe(subject); err != nil {
+		return errors.Wrap(ErrUnsubscribe, err)
 	}
 	return nil
 }
+
+func (svc *adapterService) handle(subject string) messaging.MessageHandler {
+	return func(msg messaging.Message) error {
+		svc.obsLock.Lock()
+		defer svc.obsLock.Unlock()
+
+		for _, o := range svc.observers[subject] {
+			o.Handle(msg)
+		}
+		return nil
+	}
+}
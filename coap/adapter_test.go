@@ -0,0 +1,266 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package coap_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux/coap"
+	"github.com/mainflux/mainflux/coap/mocks"
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/mainflux/mainflux/pkg/limits"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	chanID       = "1"
+	thingID      = "thing"
+	key          = "key"
+	invalidKey   = "invalid"
+	subtopic     = "temperature"
+	keepAlive    = 50 * time.Millisecond
+	limitedChan  = "limited"
+	quotaChan    = "quota"
+	eventTimeout = time.Second
+)
+
+func newService(ps messaging.PubSub) coap.Service {
+	things := mocks.NewThingsClient(map[string]string{key: thingID})
+	limiter := limits.NewMock(map[string]error{
+		limitedChan: limits.ErrRateLimited,
+		quotaChan:   limits.ErrQuotaExceeded,
+	})
+	return coap.New(things, ps, limiter, keepAlive)
+}
+
+func TestPublish(t *testing.T) {
+	ps := mocks.NewPubSub()
+	svc := newService(ps)
+
+	c := mocks.NewClient("token")
+	err := svc.Subscribe(context.Background(), key, chanID, subtopic, c)
+	assert.Nil(t, err, fmt.Sprintf("subscribing to channel expected to succeed: %s", err))
+
+	cases := []struct {
+		desc string
+		key  string
+		msg  messaging.Message
+		err  error
+		recv bool
+	}{
+		{
+			desc: "publish message",
+			key:  key,
+			msg:  messaging.Message{Channel: chanID, Subtopic: subtopic, Payload: []byte("1")},
+			err:  nil,
+			recv: true,
+		},
+		{
+			desc: "publish message to other subtopic",
+			key:  key,
+			msg:  messaging.Message{Channel: chanID, Subtopic: "humidity", Payload: []byte("2")},
+			err:  nil,
+			recv: false,
+		},
+		{
+			desc: "publish message with invalid key",
+			key:  invalidKey,
+			msg:  messaging.Message{Channel: chanID, Subtopic: subtopic, Payload: []byte("3")},
+			err:  coap.ErrUnauthorized,
+			recv: false,
+		},
+		{
+			desc: "publish message exceeding rate limit",
+			key:  key,
+			msg:  messaging.Message{Channel: limitedChan, Payload: []byte("4")},
+			err:  limits.ErrRateLimited,
+			recv: false,
+		},
+		{
+			desc: "publish message exceeding daily quota",
+			key:  key,
+			msg:  messaging.Message{Channel: quotaChan, Payload: []byte("5")},
+			err:  limits.ErrQuotaExceeded,
+			recv: false,
+		},
+	}
+
+	for _, tc := range cases {
+		before := len(c.Messages())
+		err := svc.Publish(context.Background(), tc.key, tc.msg)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if !tc.recv {
+			time.Sleep(10 * time.Millisecond)
+			assert.Equal(t, before, len(c.Messages()), fmt.Sprintf("%s: expected no notification\n", tc.desc))
+			continue
+		}
+		received := eventually(func() bool { return len(c.Messages()) == before+1 })
+		assert.True(t, received, fmt.Sprintf("%s: expected notification\n", tc.desc))
+		msgs := c.Messages()
+		if len(msgs) > before {
+			assert.Equal(t, thingID, msgs[before].Publisher, fmt.Sprintf("%s: expected publisher %s got %s\n", tc.desc, thingID, msgs[before].Publisher))
+			assert.Equal(t, tc.msg.Payload, msgs[before].Payload, fmt.Sprintf("%s: expected payload %s got %s\n", tc.desc, tc.msg.Payload, msgs[before].Payload))
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	ps := mocks.NewPubSub()
+	svc := newService(ps)
+
+	cases := []struct {
+		desc   string
+		key    string
+		chanID string
+		token  string
+		subs   int
+		err    error
+	}{
+		{
+			desc:   "subscribe to channel",
+			key:    key,
+			chanID: chanID,
+			token:  "token1",
+			subs:   1,
+			err:    nil,
+		},
+		{
+			desc:   "subscribe to the same channel with other token",
+			key:    key,
+			chanID: chanID,
+			token:  "token2",
+			subs:   1,
+			err:    nil,
+		},
+		{
+			desc:   "subscribe to other channel",
+			key:    key,
+			chanID: "2",
+			token:  "token1",
+			subs:   2,
+			err:    nil,
+		},
+		{
+			desc:   "subscribe with invalid key",
+			key:    invalidKey,
+			chanID: "3",
+			token:  "token1",
+			subs:   2,
+			err:    coap.ErrUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		err := svc.Subscribe(context.Background(), tc.key, tc.chanID, "", mocks.NewClient(tc.token))
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		assert.Equal(t, tc.subs, ps.Subscriptions(), fmt.Sprintf("%s: expected %d subscriptions got %d\n", tc.desc, tc.subs, ps.Subscriptions()))
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	ps := mocks.NewPubSub()
+	svc := newService(ps)
+
+	for _, token := range []string{"token1", "token2"} {
+		err := svc.Subscribe(context.Background(), key, chanID, "", mocks.NewClient(token))
+		assert.Nil(t, err, fmt.Sprintf("subscribing to channel expected to succeed: %s", err))
+	}
+
+	cases := []struct {
+		desc  string
+		key   string
+		token string
+		subs  int
+		err   error
+	}{
+		{
+			desc:  "unsubscribe with invalid key",
+			key:   invalidKey,
+			token: "token1",
+			subs:  1,
+			err:   coap.ErrUnauthorized,
+		},
+		{
+			desc:  "unsubscribe one of the observers",
+			key:   key,
+			token: "token1",
+			subs:  1,
+			err:   nil,
+		},
+		{
+			desc:  "unsubscribe the last observer",
+			key:   key,
+			token: "token2",
+			subs:  0,
+			err:   nil,
+		},
+		{
+			desc:  "unsubscribe non-existing observer",
+			key:   key,
+			token: "token2",
+			subs:  0,
+			err:   nil,
+		},
+	}
+
+	for _, tc := range cases {
+		err := svc.Unsubscribe(context.Background(), tc.key, chanID, "", tc.token)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		assert.Equal(t, tc.subs, ps.Subscriptions(), fmt.Sprintf("%s: expected %d subscriptions got %d\n", tc.desc, tc.subs, ps.Subscriptions()))
+	}
+}
+
+func TestObserverCleanup(t *testing.T) {
+	cases := []struct {
+		desc    string
+		publish bool
+	}{
+		{
+			desc:    "remove observer which doesn't acknowledge notifications",
+			publish: true,
+		},
+		{
+			desc:    "remove observer which doesn't respond to keepalive pings",
+			publish: false,
+		},
+	}
+
+	for _, tc := range cases {
+		ps := mocks.NewPubSub()
+		svc := newService(ps)
+
+		c := mocks.NewClient("token")
+		err := svc.Subscribe(context.Background(), key, chanID, "", c)
+		assert.Nil(t, err, fmt.Sprintf("%s: subscribing to channel expected to succeed: %s", tc.desc, err))
+
+		c.Stop()
+		if tc.publish {
+			err := svc.Publish(context.Background(), key, messaging.Message{Channel: chanID, Payload: []byte("1")})
+			assert.Nil(t, err, fmt.Sprintf("%s: publishing message expected to succeed: %s", tc.desc, err))
+		}
+
+		removed := eventually(func() bool { return ps.Subscriptions() == 0 })
+		assert.True(t, removed, fmt.Sprintf("%s: expected observer to be removed\n", tc.desc))
+		select {
+		case <-c.Done():
+		default:
+			assert.Fail(t, fmt.Sprintf("%s: expected connection to be closed\n", tc.desc))
+		}
+	}
+}
+
+func eventually(cond func() bool) bool {
+	deadline := time.Now().Add(eventTimeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"io"
+	"sync"
+
+	"github.com/pion/transport/deadline"
+)
+
+// Number of received frames buffered per connection.
+const queueSize = 64
+
+// timeoutError is returned by reads which exceeded the read deadline.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// frameQueue delivers frames received by the connection to its reader.
+// CoAP servers poll the connections using short read deadlines, so
+// reads must time out without breaking the underlying connection.
+type frameQueue struct {
+	frames   chan []byte
+	deadline *deadline.Deadline
+	closed   chan struct{}
+	once     sync.Once
+}
+
+func newFrameQueue() *frameQueue {
+	return &frameQueue{
+		frames:   make(chan []byte, queueSize),
+		deadline: deadline.New(),
+		closed:   make(chan struct{}),
+	}
+}
+
+// push queues the frame, blocking until it's read if block is set.
+// Otherwise, the frame is dropped if the queue is full.
+func (q *frameQueue) push(frame []byte, block bool) {
+	if !block {
+		select {
+		case q.frames <- frame:
+		case <-q.closed:
+		default:
+		}
+		return
+	}
+
+	select {
+	case q.frames <- frame:
+	case <-q.closed:
+	}
+}
+
+func (q *frameQueue) pop() ([]byte, error) {
+	select {
+	case frame := <-q.frames:
+		return frame, nil
+	case <-q.deadline.Done():
+		return nil, timeoutError{}
+	case <-q.closed:
+		return nil, io.EOF
+	}
+}
+
+func (q *frameQueue) close() {
+	q.once.Do(func() {
+		close(q.closed)
+	})
+}
@@ -0,0 +1,472 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/pkg/auth"
+	"github.com/mainflux/mainflux/pkg/errors"
+	piondtls "github.com/pion/dtls/v2"
+	"github.com/plgd-dev/go-coap/v2/dtls"
+	"github.com/plgd-dev/go-coap/v2/mux"
+	coapnet "github.com/plgd-dev/go-coap/v2/net"
+	"github.com/plgd-dev/go-coap/v2/udp/client"
+)
+
+const (
+	// Maximum size of the received datagram.
+	maxDatagramSize = 64 * 1024
+
+	// Maximum duration of the DTLS handshake.
+	handshakeTimeout = 30 * time.Second
+
+	// Maximum duration of the thing key lookup during the handshake.
+	keyTimeout = 5 * time.Second
+
+	// DTLS record header size, handshake content type and
+	// ClientHello handshake message type (RFC 6347).
+	recordHeaderSize     = 13
+	contentHandshake     = 22
+	handshakeClientHello = 1
+)
+
+var (
+	// ErrPSKConfig indicates invalid limits of CoAP over DTLS with
+	// pre-shared keys.
+	ErrPSKConfig = errors.New("invalid DTLS PSK configuration")
+
+	errPSKIdentity = errors.New("empty PSK identity")
+)
+
+// Sessions binds the things authenticated during DTLS handshakes to the
+// clients of the sessions, so that requests sent over the session without
+// the auth query parameter are sent on behalf of the thing. Things are
+// bound to the session clients rather than the addresses, so that plain
+// UDP requests with spoofed address can't use the session.
+type Sessions struct {
+	mu sync.Mutex
+	// handshakes maps remote addresses of the accepted sessions to the
+	// authenticated things until the session clients are created.
+	handshakes map[string]string
+	clients    map[*client.ClientConn]string
+}
+
+// NewSessions returns empty DTLS sessions registry.
+func NewSessions() *Sessions {
+	return &Sessions{
+		handshakes: make(map[string]string),
+		clients:    make(map[*client.ClientConn]string),
+	}
+}
+
+// accept stores ID of the thing authenticated during the handshake of
+// the session with the given remote address.
+func (s *Sessions) accept(addr net.Addr, thingID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handshakes[addr.String()] = thingID
+}
+
+// bind binds the thing authenticated during the handshake to the session
+// client, until the client is closed.
+func (s *Sessions) bind(cc *client.ClientConn) {
+	addr := cc.RemoteAddr().String()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	thingID, ok := s.handshakes[addr]
+	if !ok {
+		return
+	}
+	delete(s.handshakes, addr)
+	s.clients[cc] = thingID
+	cc.AddOnClose(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.clients, cc)
+	})
+}
+
+// thingID returns ID of the thing authenticated during the handshake of
+// the session the client belongs to.
+func (s *Sessions) thingID(c mux.Client) (string, bool) {
+	cc, ok := c.ClientConn().(*client.ClientConn)
+	if !ok {
+		return "", false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	thingID, ok := s.clients[cc]
+	return thingID, ok
+}
+
+// dtlsListener stores things of the accepted DTLS sessions
+// authenticated with the client certificate.
+type dtlsListener struct {
+	*coapnet.DTLSListener
+	sessions *Sessions
+}
+
+func (l dtlsListener) AcceptWithContext(ctx context.Context) (net.Conn, error) {
+	conn, err := l.DTLSListener.AcceptWithContext(ctx)
+	if err != nil || conn == nil {
+		return conn, err
+	}
+
+	dc, ok := conn.(*piondtls.Conn)
+	if !ok {
+		return conn, nil
+	}
+	certs := dc.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return conn, nil
+	}
+	cert, err := x509.ParseCertificate(certs[0])
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	l.sessions.accept(conn.RemoteAddr(), auth.CertThingID(cert))
+
+	return conn, nil
+}
+
+// ListenAndServeDTLS starts CoAP server over DTLS. Client certificates are
+// verified using the given DTLS configuration and the things they are
+// issued for are stored in the sessions.
+func ListenAndServeDTLS(addr string, cfg ServerConfig, dtlsCfg *piondtls.Config, sessions *Sessions, handler mux.Handler) error {
+	l, err := coapnet.NewDTLSListener("udp", addr, dtlsCfg)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	return serveDTLS(dtlsListener{DTLSListener: l, sessions: sessions}, cfg, sessions, handler)
+}
+
+// ListenAndServePSK starts CoAP server over DTLS authenticated with the
+// pre-shared keys. PSK identity of the client is the thing ID, and the
+// pre-shared key is the thing key, which is retrieved from the things
+// service on each handshake, so that removed things and replaced keys can't
+// be used to establish new sessions. Authenticated things are stored in the
+// sessions.
+func ListenAndServePSK(addr string, cfg ServerConfig, pskCfg PSKConfig, tc mainflux.ThingsServiceClient, sessions *Sessions, handler mux.Handler) error {
+	if err := pskCfg.validate(); err != nil {
+		return err
+	}
+
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	l := newPSKListener(pc, pskCfg, tc, sessions)
+	defer l.Close()
+
+	return serveDTLS(l, cfg, sessions, handler)
+}
+
+func serveDTLS(l dtls.Listener, cfg ServerConfig, sessions *Sessions, handler mux.Handler) error {
+	szx, err := cfg.szx()
+	if err != nil {
+		return err
+	}
+
+	s := dtls.NewServer(
+		dtls.WithMux(handler),
+		dtls.WithBlockwise(true, szx, cfg.BlockTimeout),
+		dtls.WithOnNewClientConn(sessions.bind),
+	)
+	return s.Serve(l)
+}
+
+// PSKConfig contains the settings of CoAP over DTLS with pre-shared keys.
+type PSKConfig struct {
+	// MaxHandshakes is the maximum number of concurrent handshakes. Initial
+	// datagrams of new clients are dropped while the limit is reached.
+	MaxHandshakes int
+
+	// IdleTimeout is the duration after which the sessions which didn't
+	// receive any datagram are closed.
+	IdleTimeout time.Duration
+}
+
+func (cfg PSKConfig) validate() error {
+	if cfg.MaxHandshakes <= 0 || cfg.IdleTimeout <= 0 {
+		return ErrPSKConfig
+	}
+
+	return nil
+}
+
+// pskListener demultiplexes datagrams by the remote address and performs
+// DTLS handshake of each new client. Handshakes are done concurrently,
+// each with its own configuration, so that the PSK identity can be bound
+// to the remote address of the session.
+type pskListener struct {
+	pc         net.PacketConn
+	cfg        PSKConfig
+	things     mainflux.ThingsServiceClient
+	sessions   *Sessions
+	handshakes chan struct{}
+	mu         sync.Mutex
+	conns      map[string]*udpConn
+	accept     chan net.Conn
+	closed     chan struct{}
+	once       sync.Once
+}
+
+func newPSKListener(pc net.PacketConn, cfg PSKConfig, tc mainflux.ThingsServiceClient, sessions *Sessions) *pskListener {
+	l := &pskListener{
+		pc:         pc,
+		cfg:        cfg,
+		things:     tc,
+		sessions:   sessions,
+		handshakes: make(chan struct{}, cfg.MaxHandshakes),
+		conns:      make(map[string]*udpConn),
+		accept:     make(chan net.Conn),
+		closed:     make(chan struct{}),
+	}
+	go l.readLoop()
+	go l.evictLoop()
+
+	return l
+}
+
+func (l *pskListener) readLoop() {
+	defer l.Close()
+
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := l.pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		l.mu.Lock()
+		c, ok := l.conns[addr.String()]
+		if !ok {
+			c = l.newConn(addr, buf[:n])
+		}
+		l.mu.Unlock()
+		if c == nil {
+			continue
+		}
+
+		datagram := make([]byte, n)
+		copy(datagram, buf[:n])
+		c.touch()
+		// Datagrams are dropped if the session is too slow to read them.
+		c.queue.push(datagram, false)
+	}
+}
+
+// newConn starts the handshake with the new client if the datagram is the
+// initial ClientHello and the limit of concurrent handshakes isn't reached.
+// Other datagrams of unknown clients are dropped without allocating any
+// state, and the clients retransmit the ClientHello of dropped handshakes.
+// Must be called with the listener lock held.
+func (l *pskListener) newConn(addr net.Addr, datagram []byte) *udpConn {
+	if !isClientHello(datagram) {
+		return nil
+	}
+
+	select {
+	case l.handshakes <- struct{}{}:
+	default:
+		return nil
+	}
+
+	c := &udpConn{l: l, addr: addr, queue: newFrameQueue()}
+	l.conns[addr.String()] = c
+	go l.handshake(c)
+
+	return c
+}
+
+func (l *pskListener) handshake(c *udpConn) {
+	var thingID string
+	cfg := &piondtls.Config{
+		CipherSuites: []piondtls.CipherSuiteID{
+			piondtls.TLS_PSK_WITH_AES_128_CCM_8,
+			piondtls.TLS_PSK_WITH_AES_128_CCM,
+			piondtls.TLS_PSK_WITH_AES_128_GCM_SHA256,
+		},
+		PSK: func(identity []byte) ([]byte, error) {
+			if len(identity) == 0 {
+				return nil, errPSKIdentity
+			}
+			thingID = string(identity)
+			return l.key(thingID)
+		},
+		ConnectContextMaker: func() (context.Context, func()) {
+			return context.WithTimeout(context.Background(), handshakeTimeout)
+		},
+	}
+
+	dc, err := piondtls.Server(c, cfg)
+	<-l.handshakes
+	if err != nil {
+		c.Close()
+		return
+	}
+	l.sessions.accept(c.addr, thingID)
+
+	select {
+	case l.accept <- dc:
+	case <-l.closed:
+		dc.Close()
+	}
+}
+
+// key retrieves key of the thing with the given ID, which is used as the
+// pre-shared key of the session.
+func (l *pskListener) key(thingID string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), keyTimeout)
+	defer cancel()
+
+	res, err := l.things.Key(ctx, &mainflux.ThingID{Value: thingID})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(res.GetValue()), nil
+}
+
+// evictLoop closes the sessions which are idle longer than the idle timeout.
+// Closed sessions have to be established again by the clients.
+func (l *pskListener) evictLoop() {
+	t := time.NewTicker(l.cfg.IdleTimeout / 2)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			l.evict(time.Now().Add(-l.cfg.IdleTimeout))
+		case <-l.closed:
+			return
+		}
+	}
+}
+
+// evict closes the connections which didn't receive any datagram since
+// the given time.
+func (l *pskListener) evict(since time.Time) {
+	var idle []*udpConn
+	l.mu.Lock()
+	for _, c := range l.conns {
+		if c.seen().Before(since) {
+			idle = append(idle, c)
+		}
+	}
+	l.mu.Unlock()
+
+	for _, c := range idle {
+		c.Close()
+	}
+}
+
+func (l *pskListener) AcceptWithContext(ctx context.Context) (net.Conn, error) {
+	select {
+	case c := <-l.accept:
+		return c, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.closed:
+		return nil, coapnet.ErrListenerIsClosed
+	}
+}
+
+func (l *pskListener) Close() error {
+	var err error
+	l.once.Do(func() {
+		close(l.closed)
+		err = l.pc.Close()
+	})
+	return err
+}
+
+func (l *pskListener) remove(addr net.Addr) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.conns, addr.String())
+}
+
+// udpConn represents the datagrams exchanged with a single client.
+type udpConn struct {
+	// last is the time of the last received datagram in nanoseconds,
+	// accessed atomically.
+	last  int64
+	l     *pskListener
+	addr  net.Addr
+	queue *frameQueue
+}
+
+func (c *udpConn) touch() {
+	atomic.StoreInt64(&c.last, time.Now().UnixNano())
+}
+
+func (c *udpConn) seen() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&c.last))
+}
+
+func (c *udpConn) Read(b []byte) (int, error) {
+	datagram, err := c.queue.pop()
+	if err != nil {
+		return 0, err
+	}
+	return copy(b, datagram), nil
+}
+
+func (c *udpConn) Write(b []byte) (int, error) {
+	return c.l.pc.WriteTo(b, c.addr)
+}
+
+func (c *udpConn) Close() error {
+	c.l.remove(c.addr)
+	c.queue.close()
+	return nil
+}
+
+func (c *udpConn) LocalAddr() net.Addr {
+	return c.l.pc.LocalAddr()
+}
+
+func (c *udpConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+func (c *udpConn) SetDeadline(t time.Time) error {
+	return c.SetReadDeadline(t)
+}
+
+func (c *udpConn) SetReadDeadline(t time.Time) error {
+	c.queue.deadline.Set(t)
+	return nil
+}
+
+func (c *udpConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+// isClientHello returns true if the datagram starts with the DTLS record
+// of the ClientHello handshake message in the initial epoch.
+func isClientHello(datagram []byte) bool {
+	return len(datagram) > recordHeaderSize &&
+		datagram[0] == contentHandshake &&
+		datagram[3] == 0 && datagram[4] == 0 &&
+		datagram[recordHeaderSize] == handshakeClientHello
+}
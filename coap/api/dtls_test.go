@@ -0,0 +1,157 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// record returns DTLS record of the given content type and epoch
+// carrying the handshake message of the given type.
+func record(content byte, epoch uint16, msgType byte) []byte {
+	r := make([]byte, recordHeaderSize+1)
+	r[0] = content
+	r[1], r[2] = 0xfe, 0xfd
+	r[3], r[4] = byte(epoch>>8), byte(epoch)
+	r[recordHeaderSize] = msgType
+	return r
+}
+
+func newTestPSKListener(t *testing.T, maxHandshakes int) *pskListener {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	// Read and evict loops are not started, so that the test controls
+	// the datagrams and the eviction.
+	return &pskListener{
+		pc:         pc,
+		cfg:        PSKConfig{MaxHandshakes: maxHandshakes, IdleTimeout: time.Minute},
+		sessions:   NewSessions(),
+		handshakes: make(chan struct{}, maxHandshakes),
+		conns:      make(map[string]*udpConn),
+		accept:     make(chan net.Conn),
+		closed:     make(chan struct{}),
+	}
+}
+
+func TestIsClientHello(t *testing.T) {
+	cases := []struct {
+		desc     string
+		datagram []byte
+		hello    bool
+	}{
+		{
+			desc:     "initial ClientHello",
+			datagram: record(contentHandshake, 0, handshakeClientHello),
+			hello:    true,
+		},
+		{
+			desc:     "ClientHello in non-initial epoch",
+			datagram: record(contentHandshake, 1, handshakeClientHello),
+			hello:    false,
+		},
+		{
+			desc:     "other handshake message",
+			datagram: record(contentHandshake, 0, 16),
+			hello:    false,
+		},
+		{
+			desc:     "application data",
+			datagram: record(23, 1, handshakeClientHello),
+			hello:    false,
+		},
+		{
+			desc:     "datagram shorter than the record header",
+			datagram: record(contentHandshake, 0, handshakeClientHello)[:recordHeaderSize],
+			hello:    false,
+		},
+	}
+
+	for _, tc := range cases {
+		hello := isClientHello(tc.datagram)
+		assert.Equal(t, tc.hello, hello, fmt.Sprintf("%s: expected %t got %t", tc.desc, tc.hello, hello))
+	}
+}
+
+func TestNewConn(t *testing.T) {
+	l := newTestPSKListener(t, 1)
+	defer l.Close()
+
+	hello := record(contentHandshake, 0, handshakeClientHello)
+	cases := []struct {
+		desc     string
+		port     int
+		datagram []byte
+		conn     bool
+		conns    int
+	}{
+		{
+			desc:     "start session with datagram other than ClientHello",
+			port:     10001,
+			datagram: record(23, 1, 0),
+			conn:     false,
+			conns:    0,
+		},
+		{
+			desc:     "start session with ClientHello",
+			port:     10002,
+			datagram: hello,
+			conn:     true,
+			conns:    1,
+		},
+		{
+			desc:     "start session exceeding the limit of concurrent handshakes",
+			port:     10003,
+			datagram: hello,
+			conn:     false,
+			conns:    1,
+		},
+	}
+
+	for _, tc := range cases {
+		addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: tc.port}
+		l.mu.Lock()
+		c := l.newConn(addr, tc.datagram)
+		conns := len(l.conns)
+		l.mu.Unlock()
+		assert.Equal(t, tc.conn, c != nil, fmt.Sprintf("%s: expected session %t got %t", tc.desc, tc.conn, c != nil))
+		assert.Equal(t, tc.conns, conns, fmt.Sprintf("%s: expected %d sessions got %d", tc.desc, tc.conns, conns))
+	}
+
+	// Closing the session fails its handshake and frees the handshake slot.
+	l.mu.Lock()
+	for _, c := range l.conns {
+		c.queue.close()
+	}
+	l.mu.Unlock()
+	assert.Eventually(t, func() bool {
+		return len(l.handshakes) == 0
+	}, time.Second, 10*time.Millisecond, "close session: expected handshake slot to be freed")
+}
+
+func TestEvict(t *testing.T) {
+	l := newTestPSKListener(t, 1)
+	defer l.Close()
+
+	idle := &udpConn{l: l, addr: &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 10001}, queue: newFrameQueue()}
+	active := &udpConn{l: l, addr: &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 10002}, queue: newFrameQueue()}
+	active.touch()
+	l.conns[idle.addr.String()] = idle
+	l.conns[active.addr.String()] = active
+
+	l.evict(time.Now().Add(-l.cfg.IdleTimeout))
+
+	_, ok := l.conns[idle.addr.String()]
+	assert.False(t, ok, "evict sessions: expected idle session to be closed")
+	_, ok = l.conns[active.addr.String()]
+	assert.True(t, ok, "evict sessions: expected active session to be kept")
+	_, err := idle.Read(make([]byte, 1))
+	assert.NotNil(t, err, "evict sessions: expected reading from idle session to fail")
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mainflux/mainflux/coap"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	mux "github.com/plgd-dev/go-coap/v2/mux"
+)
+
+// notifications stores the last notification sent to each observation of
+// the connection. Clients fetch the rest of the notifications larger than
+// the block size using GET requests without the Observe option, as
+// described in RFC 7959 section 2.6.
+type notifications struct {
+	mu sync.Mutex
+	// last maps connections to payloads of the last notifications by path.
+	last map[interface{}]map[string][]byte
+}
+
+func newNotifications() *notifications {
+	return &notifications{
+		last: make(map[interface{}]map[string][]byte),
+	}
+}
+
+// put stores the payload of the last notification of the observation,
+// until the connection is closed.
+func (n *notifications) put(c mux.Client, path string, payload []byte) {
+	conn := c.ClientConn()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	paths, ok := n.last[conn]
+	if !ok {
+		paths = make(map[string][]byte)
+		n.last[conn] = paths
+		go func() {
+			<-c.Context().Done()
+			n.mu.Lock()
+			defer n.mu.Unlock()
+
+			delete(n.last, conn)
+		}()
+	}
+	paths[path] = payload
+}
+
+// get returns payload of the last notification of the observation.
+func (n *notifications) get(c mux.Client, path string) ([]byte, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	payload, ok := n.last[c.ClientConn()][path]
+	return payload, ok
+}
+
+// remove removes the last notification of the cancelled observation.
+func (n *notifications) remove(c mux.Client, path string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delete(n.last[c.ClientConn()], path)
+}
+
+// observer stores the notifications sent to the client.
+type observer struct {
+	coap.Client
+	conn   mux.Client
+	path   string
+	notifs *notifications
+}
+
+func (o observer) SendMessage(m messaging.Message, maxAge time.Duration) error {
+	o.notifs.put(o.conn, o.path, m.Payload)
+	return o.Client.SendMessage(m, maxAge)
+}
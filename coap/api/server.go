@@ -0,0 +1,94 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"time"
+
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/plgd-dev/go-coap/v2/mux"
+	coapnet "github.com/plgd-dev/go-coap/v2/net"
+	"github.com/plgd-dev/go-coap/v2/net/blockwise"
+	"github.com/plgd-dev/go-coap/v2/tcp"
+	"github.com/plgd-dev/go-coap/v2/udp"
+)
+
+// ErrBlockSize indicates unsupported block-wise transfer block size.
+var ErrBlockSize = errors.New("block size must be a power of two between 16 and 1024")
+
+// ServerConfig contains the CoAP server settings shared by all transports.
+type ServerConfig struct {
+	// BlockSize is the size of the blocks in block-wise transfers
+	// (RFC 7959) of large requests and notifications.
+	BlockSize int
+
+	// BlockTimeout is the maximum duration of the block-wise transfer.
+	BlockTimeout time.Duration
+}
+
+func (cfg ServerConfig) szx() (blockwise.SZX, error) {
+	switch cfg.BlockSize {
+	case 16:
+		return blockwise.SZX16, nil
+	case 32:
+		return blockwise.SZX32, nil
+	case 64:
+		return blockwise.SZX64, nil
+	case 128:
+		return blockwise.SZX128, nil
+	case 256:
+		return blockwise.SZX256, nil
+	case 512:
+		return blockwise.SZX512, nil
+	case 1024:
+		return blockwise.SZX1024, nil
+	default:
+		return 0, ErrBlockSize
+	}
+}
+
+// ListenAndServe starts CoAP server over UDP.
+func ListenAndServe(addr string, cfg ServerConfig, handler mux.Handler) error {
+	szx, err := cfg.szx()
+	if err != nil {
+		return err
+	}
+
+	l, err := coapnet.NewListenUDP("udp", addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	s := udp.NewServer(
+		udp.WithMux(handler),
+		udp.WithBlockwise(true, szx, cfg.BlockTimeout),
+	)
+	return s.Serve(l)
+}
+
+// ListenAndServeTCP starts CoAP server over TCP (RFC 8323).
+func ListenAndServeTCP(addr string, cfg ServerConfig, handler mux.Handler) error {
+	l, err := coapnet.NewTCPListener("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	return serveTCP(l, cfg, handler)
+}
+
+// serveTCP serves CoAP over reliable transport accepted by the listener.
+func serveTCP(l tcp.Listener, cfg ServerConfig, handler mux.Handler) error {
+	szx, err := cfg.szx()
+	if err != nil {
+		return err
+	}
+
+	s := tcp.NewServer(
+		tcp.WithMux(handler),
+		tcp.WithBlockwise(true, szx, cfg.BlockTimeout),
+	)
+	return s.Serve(l)
+}
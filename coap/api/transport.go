@@ -4,6 +4,7 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io/ioutil"
@@ -19,7 +20,10 @@ import (
 	"github.com/mainflux/mainflux"
 	"github.com/mainflux/mainflux/coap"
 	log "github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/auth"
+	"github.com/mainflux/mainflux/pkg/limits"
 	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/middleware"
 	"github.com/plgd-dev/go-coap/v2/message"
 	"github.com/plgd-dev/go-coap/v2/message/codes"
 	"github.com/plgd-dev/go-coap/v2/mux"
@@ -35,9 +39,13 @@ var channelPartRegExp = regexp.MustCompile(`^channels/([\w\-]+)/messages(/[^?]*)
 
 var errMalformedSubtopic = errors.New("malformed subtopic")
 
+// tooManyRequests is 4.29 Too Many Requests response code defined by RFC 8516.
+const tooManyRequests codes.Code = 157
+
 var (
 	logger  log.Logger
 	service coap.Service
+	notifs  = newNotifications()
 )
 
 //MakeHTTPHandler creates handler for version endpoint.
@@ -49,12 +57,16 @@ func MakeHTTPHandler() http.Handler {
 	return b
 }
 
-// MakeCoAPHandler creates handler for CoAP messages.
-func MakeCoAPHandler(svc coap.Service, l log.Logger) mux.HandlerFunc {
+// MakeCoAPHandler creates handler for CoAP messages. Requests without the
+// auth query parameter are sent on behalf of the thing authenticated during
+// the DTLS handshake of the session they are sent over.
+func MakeCoAPHandler(svc coap.Service, sessions *Sessions, l log.Logger) mux.HandlerFunc {
 	logger = l
 	service = svc
 
-	return handler
+	return func(w mux.ResponseWriter, m *mux.Message) {
+		handler(w, m, sessions)
+	}
 }
 
 func sendResp(w mux.ResponseWriter, resp *message.Message) {
@@ -63,7 +75,11 @@ func sendResp(w mux.ResponseWriter, resp *message.Message) {
 	}
 }
 
-func handler(w mux.ResponseWriter, m *mux.Message) {
+func handler(w mux.ResponseWriter, m *mux.Message, sessions *Sessions) {
+	if m.Code == codes.GET && !m.Options.HasOption(message.Observe) {
+		fetch(w, m)
+		return
+	}
 	resp := message.Message{
 		Code:    codes.Content,
 		Token:   m.Token,
@@ -82,7 +98,7 @@ func handler(w mux.ResponseWriter, m *mux.Message) {
 		resp.Code = codes.BadRequest
 		return
 	}
-	key, err := parseKey(m)
+	ctx, key, err := parseKey(w, m, sessions)
 	if err != nil {
 		logger.Warn(fmt.Sprintf("Error parsing auth: %s", err))
 		resp.Code = codes.Unauthorized
@@ -97,14 +113,25 @@ func handler(w mux.ResponseWriter, m *mux.Message) {
 			logger.Warn(fmt.Sprintf("Error reading observe option: %s", err))
 			return
 		}
+		path, _ := m.Options.Path()
 		if obs == 0 {
-			c := coap.NewClient(w.Client(), m.Token, logger)
-			err = service.Subscribe(context.Background(), key, msg.Channel, msg.Subtopic, c)
+			c := observer{
+				Client: coap.NewClient(w.Client(), m.Token, logger),
+				conn:   w.Client(),
+				path:   path,
+				notifs: notifs,
+			}
+			err = service.Subscribe(ctx, key, msg.Channel, msg.Subtopic, c)
+			if err == nil {
+				// Observe option in the response confirms the registration.
+				resp.Options = resp.Options.Add(message.Option{ID: message.Observe})
+			}
 			break
 		}
-		service.Unsubscribe(context.Background(), key, msg.Channel, msg.Subtopic, m.Token.String())
+		notifs.remove(w.Client(), path)
+		service.Unsubscribe(ctx, key, msg.Channel, msg.Subtopic, m.Token.String())
 	case codes.POST:
-		err = service.Publish(context.Background(), key, msg)
+		err = service.Publish(ctx, key, msg)
 	default:
 		resp.Code = codes.NotFound
 		return
@@ -116,10 +143,31 @@ func handler(w mux.ResponseWriter, m *mux.Message) {
 			return
 		case errors.Contains(err, coap.ErrUnsubscribe):
 			resp.Code = codes.InternalServerError
+		case errors.Contains(err, middleware.ErrPayloadTooLarge):
+			resp.Code = codes.RequestEntityTooLarge
+		case errors.Contains(err, limits.ErrRateLimited), errors.Contains(err, limits.ErrQuotaExceeded):
+			resp.Code = tooManyRequests
 		}
 	}
 }
 
+// fetch responds with the last notification sent to the observation of
+// the requested path, so that the client can fetch the rest of the
+// notification block by block.
+func fetch(w mux.ResponseWriter, m *mux.Message) {
+	path, err := m.Options.Path()
+	if err != nil {
+		w.SetResponse(codes.BadOption, message.TextPlain, nil)
+		return
+	}
+	payload, ok := notifs.get(w.Client(), path)
+	if !ok {
+		w.SetResponse(codes.NotFound, message.TextPlain, nil)
+		return
+	}
+	w.SetResponse(codes.Content, message.TextPlain, bytes.NewReader(payload))
+}
+
 func decodeMessage(msg *mux.Message) (messaging.Message, error) {
 	path, err := msg.Options.Path()
 	if err != nil {
@@ -140,6 +188,7 @@ func decodeMessage(msg *mux.Message) (messaging.Message, error) {
 		Subtopic: st,
 		Payload:  []byte{},
 		Created:  time.Now().UnixNano(),
+		Headers:  parseHeaders(msg),
 	}
 
 	if msg.Body != nil {
@@ -160,16 +209,51 @@ func parseID(path string) string {
 	return ""
 }
 
-func parseKey(msg *mux.Message) (string, error) {
-	auth, err := msg.Options.GetString(message.URIQuery)
+// parseKey returns the thing key sent as the auth query parameter or, if
+// the parameter is not set, the context carrying ID of the thing which
+// established the DTLS session the request is sent over.
+func parseKey(w mux.ResponseWriter, msg *mux.Message, sessions *Sessions) (context.Context, string, error) {
+	ctx := context.Background()
+	queries, err := msg.Options.Queries()
+	if err != nil && err != message.ErrOptionNotFound {
+		return ctx, "", err
+	}
+	for _, q := range queries {
+		vars := strings.SplitN(q, "=", 2)
+		if vars[0] != authQuery {
+			continue
+		}
+		if len(vars) != 2 || vars[1] == "" {
+			return ctx, "", coap.ErrUnauthorized
+		}
+		return ctx, vars[1], nil
+	}
+	if thingID, ok := sessions.thingID(w.Client()); ok {
+		return auth.WithThingID(ctx, thingID), "", nil
+	}
+	return ctx, "", coap.ErrUnauthorized
+}
+
+// parseHeaders returns the message headers sent as URI queries other
+// than the auth query, or nil if there are none.
+func parseHeaders(msg *mux.Message) map[string]string {
+	queries, err := msg.Options.Queries()
 	if err != nil {
-		return "", err
+		return nil
 	}
-	vars := strings.Split(auth, "=")
-	if len(vars) != 2 || vars[0] != authQuery {
-		return "", coap.ErrUnauthorized
+	var headers map[string]string
+	for _, q := range queries {
+		vars := strings.SplitN(q, "=", 2)
+		name := messaging.HeaderName(vars[0])
+		if len(vars) != 2 || name == "" || name == authQuery {
+			continue
+		}
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers[name] = vars[1]
 	}
-	return vars[1], nil
+	return headers
 }
 
 func parseSubtopic(subtopic string) (string, error) {
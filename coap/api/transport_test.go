@@ -0,0 +1,390 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux/coap"
+	"github.com/mainflux/mainflux/coap/api"
+	"github.com/mainflux/mainflux/coap/mocks"
+	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/auth"
+	authmocks "github.com/mainflux/mainflux/pkg/auth/mocks"
+	"github.com/mainflux/mainflux/pkg/limits"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	piondtls "github.com/pion/dtls/v2"
+	"github.com/plgd-dev/go-coap/v2/dtls"
+	"github.com/plgd-dev/go-coap/v2/message"
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+	"github.com/plgd-dev/go-coap/v2/mux"
+	"github.com/plgd-dev/go-coap/v2/net/blockwise"
+	"github.com/plgd-dev/go-coap/v2/udp"
+	"github.com/plgd-dev/go-coap/v2/udp/client"
+	"github.com/plgd-dev/go-coap/v2/udp/message/pool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	chanID       = "1"
+	thingID      = "513d02d2-16c1-4f23-98be-9e12f8fee898"
+	thingKey     = "thing-key"
+	unknownThing = "2f3d3bb1-f6b5-4bc5-9b77-2ba2a7a1bd38"
+	keepAlive    = time.Minute
+	timeout      = 5 * time.Second
+)
+
+var serverCfg = api.ServerConfig{BlockSize: 1024, BlockTimeout: timeout}
+
+type testService struct {
+	handler mux.Handler
+	msgs    chan messaging.Message
+}
+
+func newService(t *testing.T, sessions *api.Sessions) testService {
+	l, err := logger.New(ioutil.Discard, "error")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	ps := mocks.NewPubSub()
+	msgs := make(chan messaging.Message, 1)
+	err = ps.Subscribe(fmt.Sprintf("channels.%s", chanID), func(msg messaging.Message) error {
+		msgs <- msg
+		return nil
+	})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	things := mocks.NewThingsClient(map[string]string{thingKey: thingID})
+	svc := coap.New(things, ps, limits.NewUnlimited(), keepAlive)
+	return testService{
+		handler: api.MakeCoAPHandler(svc, sessions, l),
+		msgs:    msgs,
+	}
+}
+
+// freeAddr returns local address with the port free on the given network.
+func freeAddr(t *testing.T, network string) string {
+	var addr string
+	switch network {
+	case "udp":
+		pc, err := net.ListenPacket(network, "127.0.0.1:0")
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		addr = pc.LocalAddr().String()
+		pc.Close()
+	default:
+		l, err := net.Listen(network, "127.0.0.1:0")
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		addr = l.Addr().String()
+		l.Close()
+	}
+	return addr
+}
+
+// dialDTLS dials the DTLS server, retrying until the server is started.
+func dialDTLS(addr string, cfg *piondtls.Config) (*client.ClientConn, error) {
+	var cc *client.ClientConn
+	var err error
+	for i := 0; i < 10; i++ {
+		if cc, err = dtls.Dial(addr, cfg); err == nil {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return cc, err
+}
+
+// publish publishes the payload to the channel, with the auth query
+// parameter if the key is set, and returns the response code.
+func publish(cc *client.ClientConn, key string, payload []byte) (codes.Code, error) {
+	return publishTo(cc, fmt.Sprintf("/channels/%s/messages", chanID), key, payload)
+}
+
+func publishTo(cc *client.ClientConn, path, key string, payload []byte) (codes.Code, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	res, err := cc.Post(ctx, path, message.TextPlain, bytes.NewReader(payload), authOpts(key)...)
+	if err != nil {
+		return codes.Empty, err
+	}
+	return res.Code(), nil
+}
+
+func authOpts(key string) []message.Option {
+	if key == "" {
+		return nil
+	}
+	return []message.Option{{ID: message.URIQuery, Value: []byte("auth=" + key)}}
+}
+
+func TestDTLSCertSession(t *testing.T) {
+	ca, err := authmocks.NewCA()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	serverCert, err := ca.Issue(1, "localhost")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cache := authmocks.NewCertsCache()
+	issue := func(serial int64, thingID string) tls.Certificate {
+		cert, err := ca.Issue(serial, thingID)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		err = cache.Save(auth.CertSerial(cert.Leaf), thingID)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		return cert
+	}
+	thingCert := issue(2, thingID)
+	unknownThingCert := issue(3, unknownThing)
+
+	sessions := api.NewSessions()
+	svc := newService(t, sessions)
+	addr := freeAddr(t, "udp")
+	dtlsCfg := &piondtls.Config{
+		Certificates:          []tls.Certificate{serverCert},
+		ExtendedMasterSecret:  piondtls.RequireExtendedMasterSecret,
+		ClientCAs:             ca.Pool,
+		ClientAuth:            piondtls.VerifyClientCertIfGiven,
+		VerifyPeerCertificate: auth.VerifyPeerCertificate(cache),
+	}
+	go api.ListenAndServeDTLS(addr, serverCfg, dtlsCfg, sessions, svc.handler)
+
+	cases := []struct {
+		desc      string
+		certs     []tls.Certificate
+		key       string
+		code      codes.Code
+		publisher string
+	}{
+		{
+			desc:      "publish over session authenticated with client certificate",
+			certs:     []tls.Certificate{thingCert},
+			key:       "",
+			code:      codes.Content,
+			publisher: thingID,
+		},
+		{
+			desc:      "publish with key over session authenticated with client certificate",
+			certs:     []tls.Certificate{unknownThingCert},
+			key:       thingKey,
+			code:      codes.Content,
+			publisher: thingID,
+		},
+		{
+			desc:  "publish over session of thing without access",
+			certs: []tls.Certificate{unknownThingCert},
+			key:   "",
+			code:  codes.Unauthorized,
+		},
+		{
+			desc:  "publish over session without client certificate",
+			certs: nil,
+			key:   "",
+			code:  codes.Unauthorized,
+		},
+		{
+			desc:      "publish with key over session without client certificate",
+			certs:     nil,
+			key:       thingKey,
+			code:      codes.Content,
+			publisher: thingID,
+		},
+	}
+
+	for _, tc := range cases {
+		cfg := &piondtls.Config{
+			Certificates:         tc.certs,
+			RootCAs:              ca.Pool,
+			ServerName:           "localhost",
+			ExtendedMasterSecret: piondtls.RequireExtendedMasterSecret,
+		}
+		cc, err := dialDTLS(addr, cfg)
+		require.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+
+		code, err := publish(cc, tc.key, []byte(tc.desc))
+		cc.Close()
+		require.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.code, code, fmt.Sprintf("%s: expected code %s got %s", tc.desc, tc.code, code))
+		if tc.publisher == "" {
+			continue
+		}
+		msg := <-svc.msgs
+		assert.Equal(t, tc.publisher, msg.Publisher, fmt.Sprintf("%s: expected publisher %s got %s", tc.desc, tc.publisher, msg.Publisher))
+	}
+}
+
+func TestDTLSPSKSession(t *testing.T) {
+	sessions := api.NewSessions()
+	svc := newService(t, sessions)
+	addr := freeAddr(t, "udp")
+	pskCfg := api.PSKConfig{MaxHandshakes: 10, IdleTimeout: time.Minute}
+	things := mocks.NewThingsClient(map[string]string{thingKey: thingID})
+	go api.ListenAndServePSK(addr, serverCfg, pskCfg, things, sessions, svc.handler)
+
+	cases := []struct {
+		desc      string
+		identity  string
+		psk       []byte
+		key       string
+		dialErr   bool
+		code      codes.Code
+		publisher string
+	}{
+		{
+			desc:      "publish over session authenticated with thing key",
+			identity:  thingID,
+			psk:       []byte(thingKey),
+			key:       "",
+			code:      codes.Content,
+			publisher: thingID,
+		},
+		{
+			desc:      "publish with key over session authenticated with thing key",
+			identity:  thingID,
+			psk:       []byte(thingKey),
+			key:       thingKey,
+			code:      codes.Content,
+			publisher: thingID,
+		},
+		{
+			desc:     "establish session with invalid thing key",
+			identity: thingID,
+			psk:      []byte("invalid"),
+			dialErr:  true,
+		},
+		{
+			desc:     "establish session of non-existing thing",
+			identity: unknownThing,
+			psk:      []byte(thingKey),
+			dialErr:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		psk := tc.psk
+		// Failed handshakes are not aborted by the server, so the
+		// client gives up after the handshake timeout.
+		handshakeTimeout := timeout
+		if tc.dialErr {
+			handshakeTimeout = time.Second
+		}
+		cfg := &piondtls.Config{
+			PSK: func([]byte) ([]byte, error) {
+				return psk, nil
+			},
+			PSKIdentityHint: []byte(tc.identity),
+			CipherSuites:    []piondtls.CipherSuiteID{piondtls.TLS_PSK_WITH_AES_128_CCM_8},
+			ConnectContextMaker: func() (context.Context, func()) {
+				return context.WithTimeout(context.Background(), handshakeTimeout)
+			},
+		}
+		if tc.dialErr {
+			// Server is started by the preceding cases.
+			cc, err := dtls.Dial(addr, cfg)
+			assert.NotNil(t, err, fmt.Sprintf("%s: expected handshake to fail", tc.desc))
+			if err == nil {
+				cc.Close()
+			}
+			continue
+		}
+		cc, err := dialDTLS(addr, cfg)
+		require.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+
+		code, err := publish(cc, tc.key, []byte(tc.desc))
+		cc.Close()
+		require.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.code, code, fmt.Sprintf("%s: expected code %s got %s", tc.desc, tc.code, code))
+		if tc.publisher == "" {
+			continue
+		}
+		msg := <-svc.msgs
+		assert.Equal(t, tc.publisher, msg.Publisher, fmt.Sprintf("%s: expected publisher %s got %s", tc.desc, tc.publisher, msg.Publisher))
+	}
+}
+
+func TestListenAndServePSKConfig(t *testing.T) {
+	cases := []struct {
+		desc string
+		cfg  api.PSKConfig
+	}{
+		{
+			desc: "serve without handshakes",
+			cfg:  api.PSKConfig{IdleTimeout: time.Minute},
+		},
+		{
+			desc: "serve without idle timeout",
+			cfg:  api.PSKConfig{MaxHandshakes: 10},
+		},
+	}
+
+	for _, tc := range cases {
+		err := api.ListenAndServePSK(freeAddr(t, "udp"), serverCfg, tc.cfg, mocks.NewThingsClient(nil), api.NewSessions(), nil)
+		assert.Equal(t, api.ErrPSKConfig, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, api.ErrPSKConfig, err))
+	}
+}
+
+func TestBlockwise(t *testing.T) {
+	sessions := api.NewSessions()
+	svc := newService(t, sessions)
+	addr := freeAddr(t, "udp")
+	cfg := api.ServerConfig{BlockSize: 64, BlockTimeout: timeout}
+	go api.ListenAndServe(addr, cfg, svc.handler)
+
+	// Dialing UDP doesn't fail if the server is not started, so the
+	// server is pinged before the client is used.
+	dial := func() *client.ClientConn {
+		var err error
+		for i := 0; i < 10; i++ {
+			cc, err := udp.Dial(addr, udp.WithBlockwise(true, blockwise.SZX64, timeout))
+			require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			err = cc.Ping(ctx)
+			cancel()
+			if err == nil {
+				return cc
+			}
+			cc.Close()
+			time.Sleep(50 * time.Millisecond)
+		}
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		return nil
+	}
+	observer := dial()
+	defer observer.Close()
+	publisher := dial()
+	defer publisher.Close()
+
+	path := fmt.Sprintf("/channels/%s/messages/large", chanID)
+	notifications := make(chan []byte, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	obs, err := observer.Observe(ctx, path, func(n *pool.Message) {
+		if n.Body() == nil {
+			return
+		}
+		payload, err := ioutil.ReadAll(n.Body())
+		if err != nil || len(payload) == 0 {
+			return
+		}
+		notifications <- payload
+	}, authOpts(thingKey)...)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	defer obs.Cancel(context.Background())
+
+	payload := bytes.Repeat([]byte("0123456789"), 100)
+	code, err := publishTo(publisher, path, thingKey, payload)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.Equal(t, codes.Content, code, fmt.Sprintf("publish large message: expected code %s got %s", codes.Content, code))
+
+	select {
+	case n := <-notifications:
+		assert.Equal(t, payload, n, "observe large message: expected notification with the whole payload")
+	case <-time.After(timeout):
+		assert.Fail(t, "observe large message: expected notification")
+	}
+}
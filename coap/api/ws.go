@@ -0,0 +1,272 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/plgd-dev/go-coap/v2/message"
+	"github.com/plgd-dev/go-coap/v2/mux"
+	coapnet "github.com/plgd-dev/go-coap/v2/net"
+	coaptcp "github.com/plgd-dev/go-coap/v2/tcp/message"
+)
+
+const (
+	// WSPath is the well-known URI of CoAP over WebSockets endpoint.
+	WSPath = "/.well-known/coap"
+
+	wsProtocol = "coap"
+
+	// Base values of the extended length of CoAP over TCP messages.
+	len13Base = 13
+	len14Base = 269
+	len15Base = 65805
+)
+
+var errMalformedFrame = errors.New("malformed CoAP over WebSockets frame")
+
+var upgrader = websocket.Upgrader{
+	Subprotocols: []string{wsProtocol},
+	CheckOrigin:  func(r *http.Request) bool { return true },
+}
+
+// ListenAndServeWS starts CoAP server over WebSockets (RFC 8323).
+func ListenAndServeWS(addr string, cfg ServerConfig, handler mux.Handler) error {
+	l := newWSListener()
+	defer l.Close()
+
+	hm := http.NewServeMux()
+	hm.Handle(WSPath, l)
+	srv := &http.Server{Addr: addr, Handler: hm}
+	errs := make(chan error, 2)
+	go func() {
+		errs <- srv.ListenAndServe()
+	}()
+	go func() {
+		errs <- serveTCP(l, cfg, handler)
+	}()
+
+	err := <-errs
+	srv.Close()
+	return err
+}
+
+// wsListener accepts WebSocket connections as the stream of
+// CoAP over TCP messages served by the CoAP TCP server.
+type wsListener struct {
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newWSListener() *wsListener {
+	return &wsListener{
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+func (l *wsListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Failed to upgrade connection to WebSocket: %s", err))
+		return
+	}
+
+	c := newWSConn(ws)
+	select {
+	case l.conns <- c:
+	case <-l.closed:
+		c.Close()
+	}
+}
+
+func (l *wsListener) AcceptWithContext(ctx context.Context) (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.closed:
+		return nil, coapnet.ErrListenerIsClosed
+	}
+}
+
+func (l *wsListener) Close() error {
+	l.once.Do(func() {
+		close(l.closed)
+	})
+	return nil
+}
+
+// wsConn converts WebSocket frames to CoAP over TCP messages and back.
+// CoAP over WebSockets messages don't carry the message length, which
+// is given by the frame instead.
+type wsConn struct {
+	ws     *websocket.Conn
+	queue  *frameQueue
+	mu     sync.Mutex
+	buf    []byte
+	wbuf   bytes.Buffer
+	closed sync.Once
+}
+
+func newWSConn(ws *websocket.Conn) *wsConn {
+	c := &wsConn{
+		ws:    ws,
+		queue: newFrameQueue(),
+	}
+	go c.readLoop()
+
+	return c
+}
+
+func (c *wsConn) readLoop() {
+	defer c.Close()
+	for {
+		typ, frame, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		if typ != websocket.BinaryMessage {
+			continue
+		}
+		msg, err := fromWS(frame)
+		if err != nil {
+			return
+		}
+		c.queue.push(msg, true)
+	}
+}
+
+func (c *wsConn) Read(b []byte) (int, error) {
+	if len(c.buf) == 0 {
+		msg, err := c.queue.pop()
+		if err != nil {
+			return 0, err
+		}
+		c.buf = msg
+	}
+	n := copy(b, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
+
+func (c *wsConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.wbuf.Write(b)
+	for c.wbuf.Len() > 0 {
+		var hdr coaptcp.MessageHeader
+		if err := hdr.Unmarshal(c.wbuf.Bytes()); err != nil || c.wbuf.Len() < hdr.TotalLen {
+			// Wait for the rest of the message.
+			break
+		}
+		frame, err := toWS(c.wbuf.Next(hdr.TotalLen))
+		if err != nil {
+			return 0, err
+		}
+		if err := c.ws.WriteMessage(websocket.BinaryMessage, frame); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+func (c *wsConn) Close() error {
+	var err error
+	c.closed.Do(func() {
+		c.queue.close()
+		err = c.ws.Close()
+	})
+	return err
+}
+
+func (c *wsConn) LocalAddr() net.Addr {
+	return c.ws.LocalAddr()
+}
+
+func (c *wsConn) RemoteAddr() net.Addr {
+	return c.ws.RemoteAddr()
+}
+
+func (c *wsConn) SetDeadline(t time.Time) error {
+	c.queue.deadline.Set(t)
+	return c.ws.SetWriteDeadline(t)
+}
+
+func (c *wsConn) SetReadDeadline(t time.Time) error {
+	c.queue.deadline.Set(t)
+	return nil
+}
+
+func (c *wsConn) SetWriteDeadline(t time.Time) error {
+	return c.ws.SetWriteDeadline(t)
+}
+
+// fromWS converts CoAP over WebSockets frame to CoAP over TCP message
+// by encoding the length of the options and payload in the header.
+func fromWS(frame []byte) ([]byte, error) {
+	if len(frame) < 2 {
+		return nil, errMalformedFrame
+	}
+	tkl := int(frame[0] & 0x0f)
+	n := len(frame) - 2 - tkl
+	if n < 0 {
+		return nil, errMalformedFrame
+	}
+
+	var hdr []byte
+	switch {
+	case n < len13Base:
+		hdr = []byte{byte(n << 4)}
+	case n < len14Base:
+		hdr = []byte{13 << 4, byte(n - len13Base)}
+	case n < len15Base:
+		hdr = make([]byte, 3)
+		hdr[0] = 14 << 4
+		binary.BigEndian.PutUint16(hdr[1:], uint16(n-len14Base))
+	default:
+		hdr = make([]byte, 5)
+		hdr[0] = 15 << 4
+		binary.BigEndian.PutUint32(hdr[1:], uint32(n-len15Base))
+	}
+	hdr[0] |= byte(tkl)
+
+	return append(hdr, frame[1:]...), nil
+}
+
+// toWS converts CoAP over TCP message to CoAP over WebSockets frame
+// by removing the length from the header.
+func toWS(msg []byte) ([]byte, error) {
+	if len(msg) == 0 {
+		return nil, message.ErrShortRead
+	}
+	var ext int
+	switch msg[0] >> 4 {
+	case 13:
+		ext = 1
+	case 14:
+		ext = 2
+	case 15:
+		ext = 4
+	}
+	if len(msg) < 1+ext {
+		return nil, message.ErrShortRead
+	}
+
+	frame := make([]byte, 0, len(msg)-ext)
+	frame = append(frame, msg[0]&0x0f)
+	return append(frame, msg[1+ext:]...), nil
+}
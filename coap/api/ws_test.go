@@ -0,0 +1,148 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/plgd-dev/go-coap/v2/message"
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+	"github.com/plgd-dev/go-coap/v2/mux"
+	"github.com/plgd-dev/go-coap/v2/tcp"
+	coaptcp "github.com/plgd-dev/go-coap/v2/tcp/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const timeout = 5 * time.Second
+
+// wsFrame returns CoAP over WebSockets frame with the given
+// length of the options and payload.
+func wsFrame(n int) []byte {
+	token := []byte{0x01, 0x02}
+	frame := []byte{byte(len(token)), byte(codes.POST)}
+	frame = append(frame, token...)
+	return append(frame, bytes.Repeat([]byte{0xff}, n)...)
+}
+
+func TestWSFrames(t *testing.T) {
+	cases := []struct {
+		desc string
+		n    int
+	}{
+		{desc: "convert frame without options and payload", n: 0},
+		{desc: "convert frame with the largest length encoded in the header", n: len13Base - 1},
+		{desc: "convert frame with 8-bit extended length", n: len13Base},
+		{desc: "convert frame with the largest 8-bit extended length", n: len14Base - 1},
+		{desc: "convert frame with 16-bit extended length", n: len14Base},
+		{desc: "convert frame with the largest 16-bit extended length", n: len15Base - 1},
+		{desc: "convert frame with 32-bit extended length", n: len15Base},
+	}
+
+	for _, tc := range cases {
+		frame := wsFrame(tc.n)
+		msg, err := fromWS(frame)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		var hdr coaptcp.MessageHeader
+		err = hdr.Unmarshal(msg)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, len(msg), hdr.TotalLen, fmt.Sprintf("%s: expected message length %d got %d", tc.desc, len(msg), hdr.TotalLen))
+
+		ret, err := toWS(msg)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, frame, ret, fmt.Sprintf("%s: expected frame to be converted back", tc.desc))
+	}
+}
+
+func TestMalformedWSFrames(t *testing.T) {
+	cases := []struct {
+		desc  string
+		frame []byte
+	}{
+		{desc: "convert empty frame", frame: []byte{}},
+		{desc: "convert frame without code", frame: []byte{0x00}},
+		{desc: "convert frame shorter than the token", frame: []byte{0x04, byte(codes.POST), 0x01}},
+	}
+
+	for _, tc := range cases {
+		_, err := fromWS(tc.frame)
+		assert.Equal(t, errMalformedFrame, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, errMalformedFrame, err))
+	}
+
+	_, err := toWS(nil)
+	assert.Equal(t, message.ErrShortRead, err, fmt.Sprintf("convert empty message: expected %s got %s", message.ErrShortRead, err))
+}
+
+// dialWS dials CoAP over WebSockets server, retrying until the server is started.
+func dialWS(addr string) (*tcp.ClientConn, error) {
+	dialer := websocket.Dialer{Subprotocols: []string{wsProtocol}}
+	url := fmt.Sprintf("ws://%s%s", addr, WSPath)
+
+	var err error
+	for i := 0; i < 10; i++ {
+		var ws *websocket.Conn
+		ws, _, err = dialer.Dial(url, nil)
+		if err == nil {
+			return tcp.Client(newWSConn(ws)), nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return nil, err
+}
+
+func TestListenAndServeWS(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	addr := l.Addr().String()
+	l.Close()
+
+	echo := mux.HandlerFunc(func(w mux.ResponseWriter, m *mux.Message) {
+		body, err := ioutil.ReadAll(m.Body)
+		if err != nil {
+			w.SetResponse(codes.BadRequest, message.TextPlain, nil)
+			return
+		}
+		w.SetResponse(codes.Content, message.TextPlain, bytes.NewReader(body))
+	})
+	cfg := ServerConfig{BlockSize: 1024, BlockTimeout: timeout}
+	go ListenAndServeWS(addr, cfg, echo)
+
+	cc, err := dialWS(addr)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	defer cc.Close()
+
+	cases := []struct {
+		desc    string
+		payload []byte
+	}{
+		{desc: "send message with short payload", payload: []byte("hello")},
+		{desc: "send message with 8-bit extended length", payload: bytes.Repeat([]byte("a"), 100)},
+		{desc: "send message with 16-bit extended length", payload: bytes.Repeat([]byte("a"), 1000)},
+		{desc: "send message larger than the block size", payload: bytes.Repeat([]byte("a"), 5000)},
+	}
+
+	for _, tc := range cases {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		resp, err := cc.Post(ctx, "/echo", message.TextPlain, bytes.NewReader(tc.payload))
+		cancel()
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, codes.Content, resp.Code(), fmt.Sprintf("%s: expected code %s got %s", tc.desc, codes.Content, resp.Code()))
+
+		var body []byte
+		if resp.Body() != nil {
+			body, err = ioutil.ReadAll(resp.Body())
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		}
+		assert.Equal(t, len(tc.payload), len(body), fmt.Sprintf("%s: expected payload of %d bytes got %d", tc.desc, len(tc.payload), len(body)))
+		assert.True(t, bytes.Equal(tc.payload, body), fmt.Sprintf("%s: expected payload to be echoed", tc.desc))
+	}
+}
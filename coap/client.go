@@ -5,7 +5,10 @@ package coap
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/mainflux/mainflux/logger"
 	"github.com/mainflux/mainflux/pkg/errors"
@@ -15,24 +18,38 @@ import (
 	mux "github.com/plgd-dev/go-coap/v2/mux"
 )
 
+// Observe option value is a 24-bit sequence number.
+const maxObserve = 1<<24 - 1
+
 // Client wraps CoAP client.
 type Client interface {
 	// In CoAP terminology, Token similar to the Session ID.
 	Token() string
-	SendMessage(m messaging.Message) error
+
+	// SendMessage sends the message to the client as a confirmable
+	// notification which is fresh for the given max-age. An error is
+	// returned if the client doesn't acknowledge the notification.
+	SendMessage(m messaging.Message, maxAge time.Duration) error
+
+	// Ping checks if the client is still reachable.
+	Ping(ctx context.Context) error
+
+	// Cancel closes the connection with the client.
 	Cancel() error
+
+	// Done returns a channel which is closed once the
+	// connection with the client is closed.
 	Done() <-chan struct{}
 }
 
-type observers map[string]Observer
-
 // ErrOption indicates an error when adding an option.
 var ErrOption = errors.New("unable to set option")
 
 type client struct {
-	client mux.Client
-	token  message.Token
-	logger logger.Logger
+	client  mux.Client
+	token   message.Token
+	observe uint32
+	logger  logger.Logger
 }
 
 // NewClient instantiates a new Observer.
@@ -56,29 +73,53 @@ func (c *client) Token() string {
 	return c.token.String()
 }
 
-func (c *client) SendMessage(msg messaging.Message) error {
+func (c *client) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx)
+}
+
+func (c *client) SendMessage(msg messaging.Message, maxAge time.Duration) error {
+	body := bytes.NewReader(msg.Payload)
 	m := message.Message{
 		Code:    codes.Content,
 		Token:   c.token,
 		Context: c.client.Context(),
-		Body:    bytes.NewReader(msg.Payload),
-	}
-	var opts message.Options
-	var buff []byte
-
-	opts, n, err := opts.SetContentFormat(buff, message.TextPlain)
-	if err == message.ErrTooSmall {
-		buff = append(buff, make([]byte, n)...)
-		opts, n, err = opts.SetContentFormat(buff, message.TextPlain)
+		Body:    body,
 	}
+	// ETag lets the client check that the blocks of the notification
+	// larger than the block size, fetched using GET requests, belong to it.
+	etag, err := message.GetETag(body)
 	if err != nil {
-		c.logger.Error(fmt.Sprintf("Can't set content format: %s.", err))
 		return errors.Wrap(ErrOption, err)
 	}
-	m.Options = opts
+
+	// Notifications are ordered by the Observe option. Observe values
+	// 0 and 1 are reserved for the (de)registration requests.
+	obs := (atomic.AddUint32(&c.observe, 1) + 1) & maxObserve
+	opts := []message.Option{
+		{ID: message.ContentFormat, Value: encodeUint(uint32(message.TextPlain))},
+		{ID: message.Observe, Value: encodeUint(obs)},
+		{ID: message.ETag, Value: etag},
+	}
+	if maxAge > 0 {
+		opts = append(opts, message.Option{ID: message.MaxAge, Value: encodeUint(uint32(maxAge / time.Second))})
+	}
+	for _, opt := range opts {
+		m.Options = m.Options.Add(opt)
+	}
+
 	if err := c.client.WriteMessage(&m); err != nil {
 		c.logger.Error(fmt.Sprintf("Error sending message: %s.", err))
 		return err
 	}
 	return nil
 }
+
+// encodeUint encodes the value of the uint option using the minimal number of bytes.
+func encodeUint(v uint32) []byte {
+	buf := make([]byte, 4)
+	n, err := message.EncodeUint32(buf, v)
+	if err != nil {
+		return nil
+	}
+	return buf[:n]
+}
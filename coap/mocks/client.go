@@ -0,0 +1,96 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package mocks
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mainflux/mainflux/coap"
+	"github.com/mainflux/mainflux/pkg/messaging"
+)
+
+var errNoAck = errors.New("timeout: retransmission exhausted")
+
+var _ coap.Client = (*client)(nil)
+
+// Client is CoAP client mock which records the received notifications.
+type Client interface {
+	coap.Client
+
+	// Messages returns the notifications acknowledged by the client.
+	Messages() []messaging.Message
+
+	// Stop makes the client stop acknowledging notifications and pings.
+	Stop()
+}
+
+type client struct {
+	mu      sync.Mutex
+	token   string
+	msgs    []messaging.Message
+	stopped bool
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewClient returns mock CoAP client with the given token.
+func NewClient(token string) Client {
+	return &client{
+		token: token,
+		done:  make(chan struct{}),
+	}
+}
+
+func (c *client) Token() string {
+	return c.token
+}
+
+func (c *client) SendMessage(msg messaging.Message, maxAge time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopped {
+		return errNoAck
+	}
+	c.msgs = append(c.msgs, msg)
+	return nil
+}
+
+func (c *client) Ping(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopped {
+		return errNoAck
+	}
+	return nil
+}
+
+func (c *client) Cancel() error {
+	c.once.Do(func() {
+		close(c.done)
+	})
+	return nil
+}
+
+func (c *client) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *client) Messages() []messaging.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]messaging.Message{}, c.msgs...)
+}
+
+func (c *client) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stopped = true
+}
@@ -4,44 +4,121 @@
 package coap
 
 import (
-	"github.com/gogo/protobuf/proto"
+	"context"
+	"sync"
+	"time"
+
 	"github.com/mainflux/mainflux/pkg/messaging"
-	broker "github.com/nats-io/nats.go"
 )
 
+// Number of notifications queued for the client waiting for the
+// acknowledgement of the previous notification.
+const queueSize = 64
+
 // Observer represents an internal observer used to handle CoAP observe messages.
 type Observer interface {
+	// Handle queues the message to be sent to the client.
+	Handle(msg messaging.Message)
+
+	// Cancel stops the observation.
 	Cancel() error
+
+	// Done returns a channel which is closed once the observation ends.
+	Done() <-chan struct{}
 }
 
-// NewObserver returns a new Observer instance.
-func NewObserver(subject string, c Client, conn *broker.Conn) (Observer, error) {
-	sub, err := conn.Subscribe(subject, func(m *broker.Msg) {
-		var msg messaging.Message
-		if err := proto.Unmarshal(m.Data, &msg); err != nil {
-			return
-		}
-		// There is no error handling, but the client takes care to log the error.
-		c.SendMessage(msg)
-	})
-	if err != nil {
-		return nil, err
-	}
-	ret := &observer{
-		client: c,
-		sub:    sub,
+type observers map[string]Observer
+
+type observer struct {
+	client    Client
+	keepAlive time.Duration
+	msgs      chan messaging.Message
+	done      chan struct{}
+	once      sync.Once
+}
+
+// NewObserver returns a new Observer instance. Messages are sent to the
+// client one by one as confirmable notifications. If there were no
+// notifications during the keepalive period, the client is pinged instead.
+// The observation ends and the connection with the client is closed if the
+// client doesn't acknowledge the notification or the ping.
+func NewObserver(c Client, keepAlive time.Duration) Observer {
+	o := &observer{
+		client:    c,
+		keepAlive: keepAlive,
+		msgs:      make(chan messaging.Message, queueSize),
+		done:      make(chan struct{}),
 	}
-	return ret, nil
+	go o.run()
+
+	return o
 }
 
-type observer struct {
-	client Client
-	sub    *broker.Subscription
+func (o *observer) Handle(msg messaging.Message) {
+	select {
+	case o.msgs <- msg:
+	case <-o.done:
+	default:
+		// Observe guarantees eventual consistency only, so
+		// notifications are skipped if the client is too slow.
+	}
 }
 
 func (o *observer) Cancel() error {
-	if err := o.sub.Unsubscribe(); err != nil && err != broker.ErrConnectionClosed {
-		return err
+	o.once.Do(func() {
+		close(o.done)
+	})
+	return nil
+}
+
+func (o *observer) Done() <-chan struct{} {
+	return o.done
+}
+
+func (o *observer) run() {
+	var tick <-chan time.Time
+	if o.keepAlive > 0 {
+		ticker := time.NewTicker(o.keepAlive)
+		defer ticker.Stop()
+		tick = ticker.C
 	}
-	return o.client.Cancel()
+
+	last := time.Now()
+	for {
+		select {
+		case <-o.done:
+			return
+		case <-o.client.Done():
+			o.Cancel()
+			return
+		case msg := <-o.msgs:
+			if err := o.client.SendMessage(msg, o.keepAlive); err != nil {
+				o.close()
+				return
+			}
+			last = time.Now()
+		case <-tick:
+			if time.Since(last) < o.keepAlive {
+				continue
+			}
+			if err := o.ping(); err != nil {
+				o.close()
+				return
+			}
+			last = time.Now()
+		}
+	}
+}
+
+func (o *observer) ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), o.keepAlive)
+	defer cancel()
+
+	return o.client.Ping(ctx)
+}
+
+// close ends the observation of the unresponsive client.
+func (o *observer) close() {
+	o.Cancel()
+	o.client.Cancel()
 }
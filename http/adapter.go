@@ -7,42 +7,165 @@ package http
 
 import (
 	"context"
+	"fmt"
+	"sync"
 
 	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/pkg/auth"
+	"github.com/mainflux/mainflux/pkg/limits"
 	"github.com/mainflux/mainflux/pkg/messaging"
 )
 
-// Service specifies coap service API.
+const (
+	chansPrefix = "channels"
+
+	// Number of messages buffered for each subscriber. Messages received
+	// while the buffer is full are dropped, so that slow subscribers can't
+	// block delivery to other subscribers of the same subject.
+	bufferSize = 64
+)
+
+// Service specifies coap service API. Thing is identified by the token or,
+// if the token is empty, by the ID of the thing authenticated with the
+// client certificate carried in the context (see auth.WithThingID).
 type Service interface {
-	// Publish Messssage
+	// Publish Messssage. Messages exceeding the limits of the thing or the
+	// channel are rejected with limits.ErrRateLimited or limits.ErrQuotaExceeded.
 	Publish(ctx context.Context, token string, msg messaging.Message) error
+
+	// PublishBatch publishes messages to the channel. Thing is authorized
+	// once for all the messages, and the returned errors are the results
+	// of publishing each message.
+	PublishBatch(ctx context.Context, token, chanID string, msgs []messaging.Message) ([]error, error)
+
+	// Subscribe subscribes thing identified by the given token to the
+	// messages of the channel subtopic. Messages are delivered to the
+	// returned channel which is closed once the context is done.
+	Subscribe(ctx context.Context, token, chanID, subtopic string) (<-chan messaging.Message, error)
 }
 
 var _ Service = (*adapterService)(nil)
 
+// subscribers is a set of message channels of the subject subscribers.
+type subscribers map[chan messaging.Message]struct{}
+
 type adapterService struct {
-	publisher messaging.Publisher
-	things    mainflux.ThingsServiceClient
+	pubsub      messaging.PubSub
+	things      mainflux.ThingsServiceClient
+	limiter     limits.Limiter
+	subscribers map[string]subscribers
+	subsLock    sync.Mutex
 }
 
 // New instantiates the HTTP adapter implementation.
-func New(publisher messaging.Publisher, things mainflux.ThingsServiceClient) Service {
+func New(pubsub messaging.PubSub, things mainflux.ThingsServiceClient, limiter limits.Limiter) Service {
 	return &adapterService{
-		publisher: publisher,
-		things:    things,
+		pubsub:      pubsub,
+		things:      things,
+		limiter:     limiter,
+		subscribers: make(map[string]subscribers),
 	}
 }
 
 func (as *adapterService) Publish(ctx context.Context, token string, msg messaging.Message) error {
-	ar := &mainflux.AccessByKeyReq{
-		Token:  token,
-		ChanID: msg.Channel,
-	}
-	thid, err := as.things.CanAccessByKey(ctx, ar)
+	thid, err := auth.CanAccess(ctx, as.things, token, msg.Channel)
 	if err != nil {
 		return err
 	}
-	msg.Publisher = thid.GetValue()
+	msg.Publisher = thid
+
+	if err := as.limiter.Allow(msg.Publisher, msg.Channel); err != nil {
+		return err
+	}
+
+	return as.pubsub.Publish(msg.Channel, msg)
+}
+
+func (as *adapterService) PublishBatch(ctx context.Context, token, chanID string, msgs []messaging.Message) ([]error, error) {
+	thid, err := auth.CanAccess(ctx, as.things, token, chanID)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, len(msgs))
+	for i, msg := range msgs {
+		msg.Channel = chanID
+		msg.Publisher = thid
+		if err := as.limiter.Allow(msg.Publisher, chanID); err != nil {
+			errs[i] = err
+			continue
+		}
+		errs[i] = as.pubsub.Publish(chanID, msg)
+	}
+
+	return errs, nil
+}
+
+func (as *adapterService) Subscribe(ctx context.Context, token, chanID, subtopic string) (<-chan messaging.Message, error) {
+	if _, err := auth.CanAccess(ctx, as.things, token, chanID); err != nil {
+		return nil, err
+	}
+
+	subject := fmt.Sprintf("%s.%s", chansPrefix, chanID)
+	if subtopic != "" {
+		subject = fmt.Sprintf("%s.%s", subject, subtopic)
+	}
+
+	ch := make(chan messaging.Message, bufferSize)
+	if err := as.put(subject, ch); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		as.remove(subject, ch)
+	}()
+
+	return ch, nil
+}
+
+func (as *adapterService) put(subject string, ch chan messaging.Message) error {
+	as.subsLock.Lock()
+	defer as.subsLock.Unlock()
 
-	return as.publisher.Publish(msg.Channel, msg)
+	// Single subscription to the subject is shared by all its subscribers.
+	subs, ok := as.subscribers[subject]
+	if !ok {
+		if err := as.pubsub.Subscribe(subject, as.handle(subject)); err != nil {
+			return err
+		}
+		subs = subscribers{}
+		as.subscribers[subject] = subs
+	}
+	subs[ch] = struct{}{}
+	return nil
+}
+
+func (as *adapterService) remove(subject string, ch chan messaging.Message) error {
+	as.subsLock.Lock()
+	defer as.subsLock.Unlock()
+
+	subs := as.subscribers[subject]
+	delete(subs, ch)
+	close(ch)
+	if len(subs) > 0 {
+		return nil
+	}
+	delete(as.subscribers, subject)
+	return as.pubsub.Unsubscribe(subject)
+}
+
+func (as *adapterService) handle(subject string) messaging.MessageHandler {
+	return func(msg messaging.Message) error {
+		as.subsLock.Lock()
+		defer as.subsLock.Unlock()
+
+		for ch := range as.subscribers[subject] {
+			select {
+			case ch <- msg:
+			default:
+			}
+		}
+		return nil
+	}
 }
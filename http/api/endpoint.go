@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/mainflux/mainflux/http"
+	"github.com/mainflux/mainflux/pkg/messaging"
 )
 
 func sendMessageEndpoint(svc http.Service) endpoint.Endpoint {
@@ -17,3 +18,64 @@ func sendMessageEndpoint(svc http.Service) endpoint.Endpoint {
 		return nil, err
 	}
 }
+
+func sendBatchEndpoint(svc http.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(publishBatchReq)
+
+		// Messages which failed to decode are not published.
+		msgs := []messaging.Message{}
+		for _, m := range req.msgs {
+			if m.err == nil {
+				msgs = append(msgs, m.msg)
+			}
+		}
+
+		errs, err := svc.PublishBatch(ctx, req.token, req.chanID, msgs)
+		if err != nil {
+			return nil, err
+		}
+
+		res := publishBatchRes{Results: make([]publishResult, len(req.msgs))}
+		for i, m := range req.msgs {
+			err := m.err
+			if err == nil {
+				err, errs = errs[0], errs[1:]
+			}
+			res.Results[i] = publishResult{Accepted: err == nil}
+			if err != nil {
+				res.Results[i].Error = err.Error()
+			}
+		}
+
+		return res, nil
+	}
+}
+
+func subscribeEndpoint(svc http.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(subscribeReq)
+
+		// Event stream lasts until the client closes the connection.
+		if req.stream {
+			msgs, err := svc.Subscribe(ctx, req.token, req.chanID, req.subtopic)
+			if err != nil {
+				return nil, err
+			}
+			return streamRes{messages: msgs}, nil
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, req.timeout)
+		defer cancel()
+
+		msgs, err := svc.Subscribe(ctx, req.token, req.chanID, req.subtopic)
+		if err != nil {
+			return nil, err
+		}
+		msg, ok := <-msgs
+		if !ok {
+			return pollRes{}, nil
+		}
+		return pollRes{msg: &msg}, nil
+	}
+}
@@ -4,12 +4,16 @@
 package api_test
 
 import (
+	"bufio"
+	"crypto/tls"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/opentracing/opentracing-go/mocktracer"
 
@@ -17,12 +21,27 @@ import (
 	adapter "github.com/mainflux/mainflux/http"
 	"github.com/mainflux/mainflux/http/api"
 	"github.com/mainflux/mainflux/http/mocks"
+	"github.com/mainflux/mainflux/pkg/auth"
+	authmocks "github.com/mainflux/mainflux/pkg/auth/mocks"
+	"github.com/mainflux/mainflux/pkg/limits"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	rateLimitedToken   = "rate_limited_token"
+	quotaExceededToken = "quota_exceeded_token"
+	rateLimitedThing   = "rate_limited_thing"
+	quotaExceededThing = "quota_exceeded_thing"
 )
 
 func newService(cc mainflux.ThingsServiceClient) adapter.Service {
-	pub := mocks.NewPublisher()
-	return adapter.New(pub, cc)
+	pubSub := mocks.NewPubSub()
+	limiter := limits.NewMock(map[string]error{
+		rateLimitedThing:   limits.ErrRateLimited,
+		quotaExceededThing: limits.ErrQuotaExceeded,
+	})
+	return adapter.New(pubSub, cc, limiter)
 }
 
 func newHTTPServer(svc adapter.Service) *httptest.Server {
@@ -36,6 +55,7 @@ type testRequest struct {
 	url         string
 	contentType string
 	token       string
+	headers     map[string]string
 	body        io.Reader
 }
 
@@ -50,6 +70,9 @@ func (tr testRequest) make() (*http.Response, error) {
 	if tr.contentType != "" {
 		req.Header.Set("Content-Type", tr.contentType)
 	}
+	for k, v := range tr.headers {
+		req.Header.Set(k, v)
+	}
 	return tr.client.Do(req)
 }
 
@@ -59,7 +82,11 @@ func TestPublish(t *testing.T) {
 	token := "auth_token"
 	invalidToken := "invalid_token"
 	msg := `[{"n":"current","t":-1,"v":1.6}]`
-	thingsClient := mocks.NewThingsClient(map[string]string{token: chanID})
+	thingsClient := mocks.NewThingsClient(map[string]string{
+		token:              chanID,
+		rateLimitedToken:   rateLimitedThing,
+		quotaExceededToken: quotaExceededThing,
+	})
 	svc := newService(thingsClient)
 	ts := newHTTPServer(svc)
 	defer ts.Close()
@@ -113,6 +140,20 @@ func TestPublish(t *testing.T) {
 			auth:        mocks.ServiceErrToken,
 			status:      http.StatusServiceUnavailable,
 		},
+		"publish message exceeding rate limit": {
+			chanID:      chanID,
+			msg:         msg,
+			contentType: contentType,
+			auth:        rateLimitedToken,
+			status:      http.StatusTooManyRequests,
+		},
+		"publish message exceeding daily quota": {
+			chanID:      chanID,
+			msg:         msg,
+			contentType: contentType,
+			auth:        quotaExceededToken,
+			status:      http.StatusTooManyRequests,
+		},
 	}
 
 	for desc, tc := range cases {
@@ -129,3 +170,352 @@ func TestPublish(t *testing.T) {
 		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
 	}
 }
+
+func TestPublishWithCert(t *testing.T) {
+	chanID := "1"
+	token := "auth_token"
+	thingID := "513d02d2-16c1-4f23-98be-9e12f8fee898"
+	unknownThing := "2f3d3bb1-f6b5-4bc5-9b77-2ba2a7a1bd38"
+	msg := `[{"n":"current","t":-1,"v":1.6}]`
+	thingsClient := mocks.NewThingsClient(map[string]string{token: thingID})
+	svc := newService(thingsClient)
+
+	ca, err := authmocks.NewCA()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	cache := authmocks.NewCertsCache()
+	issue := func(serial int64, thingID string, save bool) []tls.Certificate {
+		cert, err := ca.Issue(serial, thingID)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		if save {
+			err = cache.Save(auth.CertSerial(cert.Leaf), thingID)
+			require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		}
+		return []tls.Certificate{cert}
+	}
+	thingCert := issue(1, thingID, true)
+	unknownThingCert := issue(2, unknownThing, true)
+	unknownCert := issue(3, thingID, false)
+
+	ts := httptest.NewUnstartedServer(api.MakeHandler(svc, mocktracer.New()))
+	ts.TLS = &tls.Config{
+		ClientCAs:             ca.Pool,
+		ClientAuth:            tls.VerifyClientCertIfGiven,
+		VerifyPeerCertificate: auth.VerifyPeerCertificate(cache),
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	cases := []struct {
+		desc   string
+		certs  []tls.Certificate
+		auth   string
+		status int
+		err    bool
+	}{
+		{
+			desc:   "publish message with client certificate",
+			certs:  thingCert,
+			auth:   "",
+			status: http.StatusAccepted,
+		},
+		{
+			desc:   "publish message with client certificate and authorization token",
+			certs:  unknownThingCert,
+			auth:   token,
+			status: http.StatusAccepted,
+		},
+		{
+			desc:   "publish message with client certificate of thing without access",
+			certs:  unknownThingCert,
+			auth:   "",
+			status: http.StatusForbidden,
+		},
+		{
+			desc:  "publish message with client certificate unknown to certs service",
+			certs: unknownCert,
+			auth:  "",
+			err:   true,
+		},
+		{
+			desc:   "publish message without client certificate",
+			certs:  nil,
+			auth:   "",
+			status: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		transport := ts.Client().Transport.(*http.Transport).Clone()
+		transport.TLSClientConfig.Certificates = tc.certs
+		req := testRequest{
+			client:      &http.Client{Transport: transport},
+			method:      http.MethodPost,
+			url:         fmt.Sprintf("%s/channels/%s/messages", ts.URL, chanID),
+			contentType: "application/senml+json",
+			token:       tc.auth,
+			body:        strings.NewReader(msg),
+		}
+		res, err := req.make()
+		if tc.err {
+			assert.NotNil(t, err, fmt.Sprintf("%s: expected error got nil", tc.desc))
+			continue
+		}
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+	}
+}
+
+func TestPublishBatch(t *testing.T) {
+	chanID := "1"
+	token := "auth_token"
+	invalidToken := "invalid_token"
+	thingsClient := mocks.NewThingsClient(map[string]string{
+		token:            chanID,
+		rateLimitedToken: rateLimitedThing,
+	})
+	svc := newService(thingsClient)
+	ts := newHTTPServer(svc)
+	defer ts.Close()
+
+	batch := `{"messages": [
+		{"payload": [{"n":"current","t":-1,"v":1.6}]},
+		{"subtopic": "temperature/room", "payload": "21.5", "created": 1600000000000000000},
+		{"subtopic": "temperature/room", "payload": "21.5", "headers": {"correlation-id": "42"}},
+		{"subtopic": "temp*", "payload": "21.5"}
+	]}`
+	results := `{"results":[{"accepted":true},{"accepted":true},{"accepted":true},{"accepted":false,"error":"malformed subtopic"}]}` + "\n"
+	rateLimitedResults := `{"results":[{"accepted":false,"error":"publish rate limit exceeded"},{"accepted":false,"error":"publish rate limit exceeded"},{"accepted":false,"error":"publish rate limit exceeded"},{"accepted":false,"error":"malformed subtopic"}]}` + "\n"
+
+	cases := map[string]struct {
+		chanID string
+		body   string
+		auth   string
+		status int
+		res    string
+	}{
+		"publish batch": {
+			chanID: chanID,
+			body:   batch,
+			auth:   token,
+			status: http.StatusAccepted,
+			res:    results,
+		},
+		"publish empty batch": {
+			chanID: chanID,
+			body:   `{"messages": []}`,
+			auth:   token,
+			status: http.StatusBadRequest,
+		},
+		"publish batch with malformed body": {
+			chanID: chanID,
+			body:   `{"messages": {}}`,
+			auth:   token,
+			status: http.StatusBadRequest,
+		},
+		"publish batch with too many messages": {
+			chanID: chanID,
+			body:   fmt.Sprintf(`{"messages": [%s{"payload": 1}]}`, strings.Repeat(`{"payload": 1},`, 1000)),
+			auth:   token,
+			status: http.StatusBadRequest,
+		},
+		"publish batch with too large body": {
+			chanID: chanID,
+			body:   fmt.Sprintf(`{"messages": [{"payload": "%s"}]}`, strings.Repeat("a", 10<<20)),
+			auth:   token,
+			status: http.StatusRequestEntityTooLarge,
+		},
+		"publish batch without authorization token": {
+			chanID: chanID,
+			body:   batch,
+			auth:   "",
+			status: http.StatusForbidden,
+		},
+		"publish batch with invalid authorization token": {
+			chanID: chanID,
+			body:   batch,
+			auth:   invalidToken,
+			status: http.StatusForbidden,
+		},
+		"publish batch unable to authorize": {
+			chanID: chanID,
+			body:   batch,
+			auth:   mocks.ServiceErrToken,
+			status: http.StatusServiceUnavailable,
+		},
+		"publish batch exceeding rate limit": {
+			chanID: chanID,
+			body:   batch,
+			auth:   rateLimitedToken,
+			status: http.StatusAccepted,
+			res:    rateLimitedResults,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client:      ts.Client(),
+			method:      http.MethodPost,
+			url:         fmt.Sprintf("%s/channels/%s/batch", ts.URL, tc.chanID),
+			contentType: "application/json",
+			token:       tc.auth,
+			body:        strings.NewReader(tc.body),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.res, string(body), fmt.Sprintf("%s: expected body %s got %s", desc, tc.res, body))
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	chanID := "1"
+	token := "auth_token"
+	invalidToken := "invalid_token"
+	msg := `[{"n":"current","t":-1,"v":1.6}]`
+	thingsClient := mocks.NewThingsClient(map[string]string{token: chanID})
+	svc := newService(thingsClient)
+	ts := newHTTPServer(svc)
+	defer ts.Close()
+
+	cases := map[string]struct {
+		chanID  string
+		query   string
+		auth    string
+		publish bool
+		status  int
+		body    string
+		headers map[string]string
+	}{
+		"long-poll message": {
+			chanID:  chanID,
+			auth:    token,
+			publish: true,
+			status:  http.StatusOK,
+			body:    msg,
+		},
+		"long-poll message with headers": {
+			chanID:  chanID,
+			auth:    token,
+			publish: true,
+			status:  http.StatusOK,
+			body:    msg,
+			headers: map[string]string{"X-Message-Correlation-Id": "42", "X-Message-Reply-To": "responses"},
+		},
+		"long-poll message with timeout": {
+			chanID: chanID,
+			query:  "?timeout=1",
+			auth:   token,
+			status: http.StatusNoContent,
+		},
+		"long-poll message with invalid timeout": {
+			chanID: chanID,
+			query:  "?timeout=invalid",
+			auth:   token,
+			status: http.StatusBadRequest,
+		},
+		"long-poll message with too long timeout": {
+			chanID: chanID,
+			query:  "?timeout=3600",
+			auth:   token,
+			status: http.StatusBadRequest,
+		},
+		"long-poll message without authorization token": {
+			chanID: chanID,
+			auth:   "",
+			status: http.StatusForbidden,
+		},
+		"long-poll message with invalid authorization token": {
+			chanID: chanID,
+			auth:   invalidToken,
+			status: http.StatusForbidden,
+		},
+		"long-poll message unable to authorize": {
+			chanID: chanID,
+			auth:   mocks.ServiceErrToken,
+			status: http.StatusServiceUnavailable,
+		},
+	}
+
+	for desc, tc := range cases {
+		done := make(chan struct{})
+		if tc.publish {
+			go publish(ts, fmt.Sprintf("%s/channels/%s/messages", ts.URL, tc.chanID), tc.auth, msg, tc.headers, done)
+		}
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/channels/%s/messages%s", ts.URL, tc.chanID, tc.query),
+			token:  tc.auth,
+		}
+		res, err := req.make()
+		close(done)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", desc, tc.status, res.StatusCode))
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.body, string(body), fmt.Sprintf("%s: expected body %s got %s", desc, tc.body, body))
+		for k, v := range tc.headers {
+			assert.Equal(t, v, res.Header.Get(k), fmt.Sprintf("%s: expected header %s value %s got %s", desc, k, v, res.Header.Get(k)))
+		}
+	}
+}
+
+func TestSubscribeStream(t *testing.T) {
+	chanID := "1"
+	token := "auth_token"
+	msg := `[{"n":"current","t":-1,"v":1.6}]`
+	thingsClient := mocks.NewThingsClient(map[string]string{token: chanID})
+	svc := newService(thingsClient)
+	ts := newHTTPServer(svc)
+	defer ts.Close()
+
+	url := fmt.Sprintf("%s/channels/%s/messages/temperature", ts.URL, chanID)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	req.Header.Set("Authorization", token)
+	req.Header.Set("Accept", "text/event-stream")
+	res, err := ts.Client().Do(req)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode, fmt.Sprintf("expected status code %d got %d", http.StatusOK, res.StatusCode))
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"), "expected event stream content type")
+
+	done := make(chan struct{})
+	defer close(done)
+	go publish(ts, url, token, msg, nil, done)
+
+	r := bufio.NewReader(res.Body)
+	event, err := r.ReadString('\n')
+	assert.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	assert.Equal(t, "event: message\n", event, fmt.Sprintf("expected message event got %s", event))
+	data, err := r.ReadString('\n')
+	assert.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	assert.Equal(t, fmt.Sprintf("data: %s\n", msg), data, fmt.Sprintf("expected message data got %s", data))
+}
+
+// publish repeatedly publishes the message until done is closed, since
+// messages published before the subscription is made are not delivered.
+func publish(ts *httptest.Server, url, token, msg string, headers map[string]string, done chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+		req := testRequest{
+			client:  ts.Client(),
+			method:  http.MethodPost,
+			url:     url,
+			token:   token,
+			headers: headers,
+			body:    strings.NewReader(msg),
+		}
+		if res, err := req.make(); err == nil {
+			res.Body.Close()
+		}
+	}
+}
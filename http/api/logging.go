@@ -43,3 +43,33 @@ func (lm *loggingMiddleware) Publish(ctx context.Context, token string, msg mess
 
 	return lm.svc.Publish(ctx, token, msg)
 }
+
+func (lm *loggingMiddleware) PublishBatch(ctx context.Context, token, chanID string, msgs []messaging.Message) (_ []error, err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("Method publish_batch of %d messages to channel %s took %s to complete", len(msgs), chanID, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.PublishBatch(ctx, token, chanID, msgs)
+}
+
+func (lm *loggingMiddleware) Subscribe(ctx context.Context, token, chanID, subtopic string) (_ <-chan messaging.Message, err error) {
+	defer func(begin time.Time) {
+		destChannel := chanID
+		if subtopic != "" {
+			destChannel = fmt.Sprintf("%s.%s", destChannel, subtopic)
+		}
+		message := fmt.Sprintf("Method subscribe to channel %s took %s to complete", destChannel, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.Subscribe(ctx, token, chanID, subtopic)
+}
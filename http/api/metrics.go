@@ -39,3 +39,21 @@ func (mm *metricsMiddleware) Publish(ctx context.Context, token string, msg mess
 
 	return mm.svc.Publish(ctx, token, msg)
 }
+
+func (mm *metricsMiddleware) PublishBatch(ctx context.Context, token, chanID string, msgs []messaging.Message) ([]error, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "publish_batch").Add(1)
+		mm.latency.With("method", "publish_batch").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.PublishBatch(ctx, token, chanID, msgs)
+}
+
+func (mm *metricsMiddleware) Subscribe(ctx context.Context, token, chanID, subtopic string) (<-chan messaging.Message, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "subscribe").Add(1)
+		mm.latency.With("method", "subscribe").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.Subscribe(ctx, token, chanID, subtopic)
+}
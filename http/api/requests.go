@@ -4,6 +4,8 @@
 package api
 
 import (
+	"time"
+
 	"github.com/mainflux/mainflux/pkg/messaging"
 )
 
@@ -11,3 +13,24 @@ type publishReq struct {
 	msg   messaging.Message
 	token string
 }
+
+type publishBatchReq struct {
+	token  string
+	chanID string
+	msgs   []batchMessage
+}
+
+// batchMessage is the decoded message of the batch, or
+// the error which occurred while decoding the message.
+type batchMessage struct {
+	msg messaging.Message
+	err error
+}
+
+type subscribeReq struct {
+	token    string
+	chanID   string
+	subtopic string
+	stream   bool
+	timeout  time.Duration
+}
@@ -0,0 +1,30 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"github.com/mainflux/mainflux/pkg/messaging"
+)
+
+// pollRes contains the first message received during the long-poll,
+// or nil message if the long-poll timed out.
+type pollRes struct {
+	msg *messaging.Message
+}
+
+// streamRes contains messages sent as Server-Sent Events.
+type streamRes struct {
+	messages <-chan messaging.Message
+}
+
+type publishResult struct {
+	Accepted bool   `json:"accepted"`
+	Error    string `json:"error,omitempty"`
+}
+
+// publishBatchRes contains results of publishing
+// the batch messages, in the order of the request.
+type publishBatchRes struct {
+	Results []publishResult `json:"results"`
+}
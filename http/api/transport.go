@@ -4,13 +4,16 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,7 +22,10 @@ import (
 	"github.com/go-zoo/bone"
 	"github.com/mainflux/mainflux"
 	adapter "github.com/mainflux/mainflux/http"
+	"github.com/mainflux/mainflux/pkg/auth"
+	"github.com/mainflux/mainflux/pkg/limits"
 	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/middleware"
 	"github.com/mainflux/mainflux/things"
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -27,11 +33,34 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-const protocol = "http"
+const (
+	protocol = "http"
+
+	eventStream    = "text/event-stream"
+	timeoutQuery   = "timeout"
+	defPollTimeout = 30 * time.Second
+	maxPollTimeout = 120 * time.Second
+
+	// Period of sending comments to keep the idle event stream open
+	// through the proxies which close inactive connections.
+	keepAlivePeriod = 30 * time.Second
+
+	maxBatchSize = 1000
+
+	// Maximum size of the batch request body in bytes.
+	maxBatchBodySize = 10 << 20
+
+	// Message headers are sent as HTTP headers with the prefix,
+	// e.g. X-Message-Correlation-Id.
+	headerPrefix = "X-Message-"
+)
 
 var (
 	errMalformedData     = errors.New("malformed request data")
 	errMalformedSubtopic = errors.New("malformed subtopic")
+	errInvalidTimeout    = errors.New("invalid long-poll timeout")
+	errBatchSize         = errors.New("invalid number of batch messages")
+	errMalformedPayload  = errors.New("malformed payload")
 )
 
 var channelPartRegExp = regexp.MustCompile(`^/channels/([\w\-]+)/messages(/[^?]*)?(\?.*)?$`)
@@ -39,6 +68,7 @@ var channelPartRegExp = regexp.MustCompile(`^/channels/([\w\-]+)/messages(/[^?]*
 // MakeHandler returns a HTTP handler for API endpoints.
 func MakeHandler(svc adapter.Service, tracer opentracing.Tracer) http.Handler {
 	opts := []kithttp.ServerOption{
+		kithttp.ServerBefore(certThing),
 		kithttp.ServerErrorEncoder(encodeError),
 	}
 
@@ -57,6 +87,27 @@ func MakeHandler(svc adapter.Service, tracer opentracing.Tracer) http.Handler {
 		opts...,
 	))
 
+	r.Post("/channels/:id/batch", kithttp.NewServer(
+		kitot.TraceServer(tracer, "publish_batch")(sendBatchEndpoint(svc)),
+		decodeBatchRequest,
+		encodeBatchResponse,
+		opts...,
+	))
+
+	r.Get("/channels/:id/messages", kithttp.NewServer(
+		kitot.TraceServer(tracer, "subscribe")(subscribeEndpoint(svc)),
+		decodeSubscribe,
+		encodeSubscribeResponse,
+		opts...,
+	))
+
+	r.Get("/channels/:id/messages/*", kithttp.NewServer(
+		kitot.TraceServer(tracer, "subscribe")(subscribeEndpoint(svc)),
+		decodeSubscribe,
+		encodeSubscribeResponse,
+		opts...,
+	))
+
 	r.GetFunc("/version", mainflux.Version("http"))
 	r.Handle("/metrics", promhttp.Handler())
 
@@ -115,6 +166,7 @@ func decodeRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 		Subtopic: subtopic,
 		Payload:  payload,
 		Created:  time.Now().UnixNano(),
+		Headers:  messageHeaders(r.Header),
 	}
 
 	req := publishReq{
@@ -125,6 +177,152 @@ func decodeRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	return req, nil
 }
 
+// batchEntry is the message of the batch request. Payload is published
+// as is, unless it is a JSON string whose content is published instead.
+// Created is the message creation time in nanoseconds since the epoch.
+// Headers are added to the headers sent with the request.
+type batchEntry struct {
+	Subtopic string            `json:"subtopic"`
+	Payload  json.RawMessage   `json:"payload"`
+	Created  int64             `json:"created"`
+	Headers  map[string]string `json:"headers"`
+}
+
+func decodeBatchRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var body struct {
+		Messages []batchEntry `json:"messages"`
+	}
+	// The body is read up to a byte over the limit, so that the requests
+	// exceeding the limit are rejected before they're decoded.
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBatchBodySize+1))
+	if err != nil {
+		return nil, errMalformedData
+	}
+	if len(data) > maxBatchBodySize {
+		return nil, middleware.ErrPayloadTooLarge
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, errMalformedData
+	}
+	if len(body.Messages) == 0 || len(body.Messages) > maxBatchSize {
+		return nil, errBatchSize
+	}
+
+	req := publishBatchReq{
+		token:  r.Header.Get("Authorization"),
+		chanID: bone.GetValue(r, "id"),
+		msgs:   make([]batchMessage, len(body.Messages)),
+	}
+	now := time.Now().UnixNano()
+	headers := messageHeaders(r.Header)
+	for i, e := range body.Messages {
+		req.msgs[i] = decodeBatchEntry(e, now, headers)
+	}
+
+	return req, nil
+}
+
+func decodeBatchEntry(e batchEntry, now int64, headers map[string]string) batchMessage {
+	subtopic, err := parseSubtopic(e.Subtopic)
+	if err != nil {
+		return batchMessage{err: err}
+	}
+
+	payload := []byte(e.Payload)
+	if len(payload) > 0 && payload[0] == '"' {
+		var str string
+		if err := json.Unmarshal(payload, &str); err != nil {
+			return batchMessage{err: errMalformedPayload}
+		}
+		payload = []byte(str)
+	}
+
+	created := e.Created
+	if created == 0 {
+		created = now
+	}
+
+	if len(e.Headers) > 0 {
+		h := make(map[string]string, len(headers)+len(e.Headers))
+		for k, v := range headers {
+			h[k] = v
+		}
+		for k, v := range e.Headers {
+			h[messaging.HeaderName(k)] = v
+		}
+		headers = h
+	}
+
+	msg := messaging.Message{
+		Protocol: protocol,
+		Subtopic: subtopic,
+		Payload:  payload,
+		Created:  created,
+		Headers:  headers,
+	}
+	return batchMessage{msg: msg}
+}
+
+// decodeSubscribe decodes the request for the event stream if the client
+// accepts Server-Sent Events, and for the long-poll otherwise. Long-poll
+// timeout is given in seconds using the timeout query parameter.
+func decodeSubscribe(_ context.Context, r *http.Request) (interface{}, error) {
+	channelParts := channelPartRegExp.FindStringSubmatch(r.RequestURI)
+	if len(channelParts) < 2 {
+		return nil, errMalformedData
+	}
+
+	subtopic, err := parseSubtopic(channelParts[2])
+	if err != nil {
+		return nil, err
+	}
+
+	req := subscribeReq{
+		token:    r.Header.Get("Authorization"),
+		chanID:   bone.GetValue(r, "id"),
+		subtopic: subtopic,
+		stream:   strings.Contains(r.Header.Get("Accept"), eventStream),
+		timeout:  defPollTimeout,
+	}
+
+	if t := r.URL.Query().Get(timeoutQuery); t != "" {
+		secs, err := strconv.ParseUint(t, 10, 32)
+		if err != nil || secs == 0 || time.Duration(secs)*time.Second > maxPollTimeout {
+			return nil, errInvalidTimeout
+		}
+		req.timeout = time.Duration(secs) * time.Second
+	}
+
+	return req, nil
+}
+
+// certThing adds ID of the thing client certificate is issued for to the
+// request context, so that the thing is identified by the certificate if
+// the Authorization header is not set. Client certificate is checked
+// against the certificates issued by the certs service during TLS handshake.
+func certThing(ctx context.Context, r *http.Request) context.Context {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return ctx
+	}
+	return auth.WithThingID(ctx, auth.CertThingID(r.TLS.PeerCertificates[0]))
+}
+
+// messageHeaders returns the message headers sent as the HTTP headers
+// with the X-Message- prefix, or nil if there are none.
+func messageHeaders(h http.Header) map[string]string {
+	var headers map[string]string
+	for k, v := range h {
+		if len(k) <= len(headerPrefix) || !strings.HasPrefix(k, headerPrefix) || len(v) == 0 {
+			continue
+		}
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers[messaging.HeaderName(k[len(headerPrefix):])] = v[0]
+	}
+	return headers
+}
+
 func decodePayload(body io.ReadCloser) ([]byte, error) {
 	payload, err := ioutil.ReadAll(body)
 	if err != nil {
@@ -140,12 +338,92 @@ func encodeResponse(_ context.Context, w http.ResponseWriter, response interface
 	return nil
 }
 
+func encodeBatchResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeSubscribeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	switch res := response.(type) {
+	case pollRes:
+		if res.msg == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		}
+		for k, v := range res.msg.Headers {
+			w.Header().Set(headerPrefix+k, v)
+		}
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write(res.msg.Payload)
+		return err
+	case streamRes:
+		return writeEvents(ctx, w, res.messages)
+	default:
+		return errMalformedData
+	}
+}
+
+// writeEvents writes messages as Server-Sent Events until the messages
+// channel is closed. Each message is sent as the "message" event whose
+// data is the message payload.
+func writeEvents(ctx context.Context, w http.ResponseWriter, msgs <-chan messaging.Message) error {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		return errMalformedData
+	}
+
+	w.Header().Set("Content-Type", eventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+
+	ticker := time.NewTicker(keepAlivePeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg, ok := <-msgs:
+			if !ok {
+				return nil
+			}
+			if _, err := w.Write(encodeEvent(msg)); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ":\n\n"); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+		f.Flush()
+	}
+}
+
+func encodeEvent(msg messaging.Message) []byte {
+	var b bytes.Buffer
+	b.WriteString("event: message\n")
+	for _, line := range strings.Split(string(msg.Payload), "\n") {
+		b.WriteString("data: ")
+		b.WriteString(strings.TrimSuffix(line, "\r"))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	return b.Bytes()
+}
+
 func encodeError(_ context.Context, err error, w http.ResponseWriter) {
 	switch err {
-	case errMalformedData, errMalformedSubtopic:
+	case errMalformedData, errMalformedSubtopic, errInvalidTimeout, errBatchSize:
 		w.WriteHeader(http.StatusBadRequest)
 	case things.ErrUnauthorizedAccess:
 		w.WriteHeader(http.StatusForbidden)
+	case middleware.ErrPayloadTooLarge:
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	case limits.ErrRateLimited, limits.ErrQuotaExceeded:
+		w.WriteHeader(http.StatusTooManyRequests)
 	default:
 		if e, ok := status.FromError(err); ok {
 			switch e.Code() {
@@ -0,0 +1,65 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package mocks
+
+import (
+	"sync"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/memory"
+)
+
+var _ messaging.PubSub = (*pubsub)(nil)
+
+// PubSub is in-memory message publisher/subscriber.
+type PubSub interface {
+	messaging.PubSub
+
+	// Subscriptions returns the number of active subscriptions.
+	Subscriptions() int
+}
+
+type pubsub struct {
+	memory.PubSub
+	mu     sync.Mutex
+	topics map[string]bool
+}
+
+// NewPubSub returns in-memory message publisher/subscriber which delivers
+// published messages synchronously to the subscribers of the subject.
+func NewPubSub() PubSub {
+	return &pubsub{
+		PubSub: memory.NewPubSub(),
+		topics: make(map[string]bool),
+	}
+}
+
+func (ps *pubsub) Subscribe(topic string, handler messaging.MessageHandler) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if err := ps.PubSub.Subscribe(topic, handler); err != nil {
+		return err
+	}
+	ps.topics[topic] = true
+	return nil
+}
+
+func (ps *pubsub) Unsubscribe(topic string) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if err := ps.PubSub.Unsubscribe(topic); err != nil {
+		return err
+	}
+	delete(ps.topics, topic)
+	return nil
+}
+
+func (ps *pubsub) Subscriptions() int {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	return len(ps.topics)
+}
@@ -50,10 +50,20 @@ func (tc thingsClient) CanAccessByKey(ctx context.Context, req *mainflux.AccessB
 	return &mainflux.ThingID{Value: id}, nil
 }
 
-func (tc thingsClient) CanAccessByID(context.Context, *mainflux.AccessByIDReq, ...grpc.CallOption) (*empty.Empty, error) {
-	panic("not implemented")
+func (tc thingsClient) CanAccessByID(ctx context.Context, req *mainflux.AccessByIDReq, opts ...grpc.CallOption) (*empty.Empty, error) {
+	for _, id := range tc.things {
+		if id == req.GetThingID() {
+			return &empty.Empty{}, nil
+		}
+	}
+
+	return nil, status.Error(codes.PermissionDenied, "invalid credentials provided")
 }
 
 func (tc thingsClient) Identify(ctx context.Context, req *mainflux.Token, opts ...grpc.CallOption) (*mainflux.ThingID, error) {
 	panic("not implemented")
 }
+
+func (tc thingsClient) Key(ctx context.Context, req *mainflux.ThingID, opts ...grpc.CallOption) (*mainflux.Token, error) {
+	panic("not implemented")
+}
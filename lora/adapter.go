@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/senml"
 )
 
 const (
@@ -39,33 +40,43 @@ type Service interface {
 	// RemoveThing removes thingID:devEUI route-map
 	RemoveThing(thingID string) error
 
-	// CreateChannel creates channelID:appID route-map
-	CreateChannel(chanID string, appID string) error
+	// CreateChannel creates channelID:appID route-map and stores the radio
+	// metadata publishing mode of the channel
+	CreateChannel(chanID string, appID string, meta string) error
 
-	// UpdateChannel updates channelID:appID route-map
-	UpdateChannel(chanID string, appID string) error
+	// UpdateChannel updates channelID:appID route-map and the radio metadata
+	// publishing mode of the channel
+	UpdateChannel(chanID string, appID string, meta string) error
 
 	// RemoveChannel removes channelID:appID route-map
 	RemoveChannel(chanID string) error
 
 	// Publish forwards messages from the LoRa MQTT broker to Mainflux NATS broker
 	Publish(ctx context.Context, token string, msg Message) error
+
+	// Downlink forwards messages sent to the thing over the channel to the
+	// mapped LoRa device
+	Downlink(ctx context.Context, chanID, thingID string, payload []byte) error
 }
 
 var _ Service = (*adapterService)(nil)
 
 type adapterService struct {
 	publisher  messaging.Publisher
+	downlinks  DownlinkPublisher
 	thingsRM   RouteMapRepository
 	channelsRM RouteMapRepository
+	metaRM     RouteMapRepository
 }
 
 // New instantiates the LoRa adapter implementation.
-func New(publisher messaging.Publisher, thingsRM, channelsRM RouteMapRepository) Service {
+func New(publisher messaging.Publisher, downlinks DownlinkPublisher, thingsRM, channelsRM, metaRM RouteMapRepository) Service {
 	return &adapterService{
 		publisher:  publisher,
+		downlinks:  downlinks,
 		thingsRM:   thingsRM,
 		channelsRM: channelsRM,
+		metaRM:     metaRM,
 	}
 }
 
@@ -109,9 +120,74 @@ func (as *adapterService) Publish(ctx context.Context, token string, m Message)
 		Created:   time.Now().UnixNano(),
 	}
 
+	switch as.meta(channel) {
+	case MetaSubtopic:
+		if err := as.publisher.Publish(msg.Channel, msg); err != nil {
+			return err
+		}
+		return as.publishMeta(msg, m)
+	case MetaMerge:
+		// Payloads which are not SenML are published unchanged,
+		// with the metadata on the separate subtopic.
+		merged, err := mergeMeta(payload, m.metaRecords())
+		if err != nil {
+			if err := as.publisher.Publish(msg.Channel, msg); err != nil {
+				return err
+			}
+			return as.publishMeta(msg, m)
+		}
+		msg.Payload = merged
+	}
+
 	return as.publisher.Publish(msg.Channel, msg)
 }
 
+func (as *adapterService) publishMeta(msg messaging.Message, m Message) error {
+	payload, err := senml.Encode(senml.Pack{Records: m.metaRecords()}, senml.JSON)
+	if err != nil {
+		return err
+	}
+
+	msg.Subtopic = metaSubtopic
+	msg.Payload = payload
+	return as.publisher.Publish(msg.Channel, msg)
+}
+
+func (as *adapterService) meta(chanID string) string {
+	mode, err := as.metaRM.Get(chanID)
+	if err != nil {
+		return MetaNone
+	}
+
+	return mode
+}
+
+// Downlink forwards messages from Mainflux NATS broker to Lora MQTT broker
+func (as *adapterService) Downlink(ctx context.Context, chanID, thingID string, payload []byte) error {
+	// Get route map of mainflux channel
+	appID, err := as.channelsRM.Get(chanID)
+	if err != nil {
+		return ErrNotFoundApp
+	}
+
+	// Get route map of mainflux thing
+	devEUI, err := as.thingsRM.Get(thingID)
+	if err != nil {
+		return ErrNotFoundDev
+	}
+
+	var d Downlink
+	if err := json.Unmarshal(payload, &d); err != nil {
+		return ErrMalformedMessage
+	}
+
+	if err := d.validate(); err != nil {
+		return err
+	}
+
+	return as.downlinks.Publish(appID, devEUI, d)
+}
+
 func (as *adapterService) CreateThing(thingID string, devEUI string) error {
 	return as.thingsRM.Save(thingID, devEUI)
 }
@@ -124,14 +200,34 @@ func (as *adapterService) RemoveThing(thingID string) error {
 	return as.thingsRM.Remove(thingID)
 }
 
-func (as *adapterService) CreateChannel(chanID string, appID string) error {
-	return as.channelsRM.Save(chanID, appID)
+func (as *adapterService) CreateChannel(chanID string, appID string, meta string) error {
+	if err := as.channelsRM.Save(chanID, appID); err != nil {
+		return err
+	}
+
+	return as.saveMeta(chanID, meta)
 }
 
-func (as *adapterService) UpdateChannel(chanID string, appID string) error {
-	return as.channelsRM.Save(chanID, appID)
+func (as *adapterService) UpdateChannel(chanID string, appID string, meta string) error {
+	if err := as.channelsRM.Save(chanID, appID); err != nil {
+		return err
+	}
+
+	return as.saveMeta(chanID, meta)
 }
 
 func (as *adapterService) RemoveChannel(chanID string) error {
+	// Metadata publishing mode is stored only for the channels publishing metadata.
+	as.metaRM.Remove(chanID)
+
 	return as.channelsRM.Remove(chanID)
 }
+
+func (as *adapterService) saveMeta(chanID string, meta string) error {
+	if meta == "" || meta == MetaNone {
+		as.metaRM.Remove(chanID)
+		return nil
+	}
+
+	return as.metaRM.Save(chanID, meta)
+}
@@ -0,0 +1,282 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package lora_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/lora"
+	"github.com/mainflux/mainflux/lora/mocks"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/memory"
+	"github.com/mainflux/senml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	chanID  = "1"
+	thingID = "2"
+	appID   = "10"
+	devEUI  = "0101010101010101"
+	unknown = "unknown"
+	gwMac   = "0303030303030303"
+)
+
+type testAdapter struct {
+	svc       lora.Service
+	pubsub    memory.PubSub
+	downlinks mocks.DownlinkPublisher
+}
+
+func newAdapter(t *testing.T) testAdapter {
+	ta := testAdapter{
+		pubsub:    memory.NewPubSub(),
+		downlinks: mocks.NewDownlinkPublisher(),
+	}
+	ta.svc = lora.New(ta.pubsub, ta.downlinks, mocks.NewRouteMapRepository(), mocks.NewRouteMapRepository(), mocks.NewRouteMapRepository())
+
+	err := ta.svc.CreateThing(thingID, devEUI)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = ta.svc.CreateChannel(chanID, appID, lora.MetaNone)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	return ta
+}
+
+func TestDownlink(t *testing.T) {
+	ta := newAdapter(t)
+
+	cases := []struct {
+		desc     string
+		chanID   string
+		thingID  string
+		payload  string
+		downlink lora.Downlink
+		err      error
+	}{
+		{
+			desc:     "send downlink with data",
+			chanID:   chanID,
+			thingID:  thingID,
+			payload:  `{"confirmed":true,"fPort":10,"data":"AQID"}`,
+			downlink: lora.Downlink{Confirmed: true, FPort: 10, Data: "AQID"},
+			err:      nil,
+		},
+		{
+			desc:     "send downlink with object",
+			chanID:   chanID,
+			thingID:  thingID,
+			payload:  `{"fPort":223,"object":{"led":true}}`,
+			downlink: lora.Downlink{FPort: 223, Object: map[string]interface{}{"led": true}},
+			err:      nil,
+		},
+		{
+			desc:    "send downlink without data and object",
+			chanID:  chanID,
+			thingID: thingID,
+			payload: `{"fPort":10}`,
+			err:     lora.ErrMalformedMessage,
+		},
+		{
+			desc:    "send downlink with data not base64 encoded",
+			chanID:  chanID,
+			thingID: thingID,
+			payload: `{"fPort":10,"data":"not base64"}`,
+			err:     lora.ErrMalformedMessage,
+		},
+		{
+			desc:    "send downlink to reserved port",
+			chanID:  chanID,
+			thingID: thingID,
+			payload: `{"fPort":0,"data":"AQID"}`,
+			err:     lora.ErrMalformedMessage,
+		},
+		{
+			desc:    "send downlink to port out of range",
+			chanID:  chanID,
+			thingID: thingID,
+			payload: `{"fPort":224,"data":"AQID"}`,
+			err:     lora.ErrMalformedMessage,
+		},
+		{
+			desc:    "send malformed downlink",
+			chanID:  chanID,
+			thingID: thingID,
+			payload: `[{"v":1}]`,
+			err:     lora.ErrMalformedMessage,
+		},
+		{
+			desc:    "send downlink over channel without route-map",
+			chanID:  unknown,
+			thingID: thingID,
+			payload: `{"fPort":10,"data":"AQID"}`,
+			err:     lora.ErrNotFoundApp,
+		},
+		{
+			desc:    "send downlink to thing without route-map",
+			chanID:  chanID,
+			thingID: unknown,
+			payload: `{"fPort":10,"data":"AQID"}`,
+			err:     lora.ErrNotFoundDev,
+		},
+	}
+
+	for _, tc := range cases {
+		sent := len(ta.downlinks.Downlinks())
+		err := ta.svc.Downlink(context.Background(), tc.chanID, tc.thingID, []byte(tc.payload))
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+
+		downlinks := ta.downlinks.Downlinks()
+		if tc.err != nil {
+			assert.Equal(t, sent, len(downlinks), fmt.Sprintf("%s: expected no downlink to be sent", tc.desc))
+			continue
+		}
+		require.Equal(t, sent+1, len(downlinks), fmt.Sprintf("%s: expected downlink to be sent", tc.desc))
+		d := downlinks[len(downlinks)-1]
+		assert.Equal(t, appID, d.AppID, fmt.Sprintf("%s: expected application %s got %s", tc.desc, appID, d.AppID))
+		assert.Equal(t, devEUI, d.DevEUI, fmt.Sprintf("%s: expected device %s got %s", tc.desc, devEUI, d.DevEUI))
+		assert.Equal(t, tc.downlink, d.Downlink, fmt.Sprintf("%s: expected downlink %v got %v", tc.desc, tc.downlink, d.Downlink))
+	}
+}
+
+func TestPublish(t *testing.T) {
+	ta := newAdapter(t)
+	msgs := make(chan messaging.Message, 2)
+	err := ta.pubsub.Subscribe("channels.>", func(msg messaging.Message) error {
+		msgs <- msg
+		return nil
+	})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	senmlPayload := `[{"n":"temperature","v":21.5}]`
+	rawPayload := "raw"
+	uplink := func(payload string) lora.Message {
+		return lora.Message{
+			ApplicationID:       appID,
+			DevEUI:              devEUI,
+			DeviceStatusBattery: "254",
+			RxInfo: lora.RxInfo{
+				{Mac: gwMac, Time: "2020-05-01T10:00:00Z", Rssi: -57, LoRaSNR: 10},
+			},
+			TxInfo: lora.TxInfo{Frequency: 868100000, DataRate: lora.DataRate{Bandwith: 125, SpreadFactor: 7}},
+			FCnt:   10,
+			FPort:  5,
+			Data:   base64.StdEncoding.EncodeToString([]byte(payload)),
+		}
+	}
+	metaNames := []string{
+		devEUI + ":fcnt", devEUI + ":fport", devEUI + ":frequency", devEUI + ":sf",
+		devEUI + ":bandwidth", devEUI + ":adr", devEUI + ":battery",
+		devEUI + ":gw/" + gwMac + "/rssi", devEUI + ":gw/" + gwMac + "/snr",
+		devEUI + ":gw/" + gwMac + "/lat", devEUI + ":gw/" + gwMac + "/lon",
+		devEUI + ":gw/" + gwMac + "/alt",
+	}
+
+	cases := []struct {
+		desc     string
+		meta     string
+		msg      lora.Message
+		payload  string
+		merged   []string
+		subtopic bool
+		err      error
+	}{
+		{
+			desc:    "publish without metadata",
+			meta:    lora.MetaNone,
+			msg:     uplink(senmlPayload),
+			payload: senmlPayload,
+			err:     nil,
+		},
+		{
+			desc:     "publish with metadata on subtopic",
+			meta:     lora.MetaSubtopic,
+			msg:      uplink(senmlPayload),
+			payload:  senmlPayload,
+			subtopic: true,
+			err:      nil,
+		},
+		{
+			desc:   "publish SenML message with merged metadata",
+			meta:   lora.MetaMerge,
+			msg:    uplink(senmlPayload),
+			merged: append([]string{"temperature"}, metaNames...),
+			err:    nil,
+		},
+		{
+			desc:     "publish message which is not SenML with merged metadata",
+			meta:     lora.MetaMerge,
+			msg:      uplink(rawPayload),
+			payload:  rawPayload,
+			subtopic: true,
+			err:      nil,
+		},
+		{
+			desc: "publish message with data not base64 encoded",
+			meta: lora.MetaNone,
+			msg:  lora.Message{ApplicationID: appID, DevEUI: devEUI, Data: "not base64"},
+			err:  lora.ErrMalformedMessage,
+		},
+		{
+			desc: "publish message of unknown device",
+			meta: lora.MetaNone,
+			msg:  lora.Message{ApplicationID: appID, DevEUI: unknown},
+			err:  lora.ErrNotFoundDev,
+		},
+		{
+			desc: "publish message of unknown application",
+			meta: lora.MetaNone,
+			msg:  lora.Message{ApplicationID: unknown, DevEUI: devEUI},
+			err:  lora.ErrNotFoundApp,
+		},
+	}
+
+	for _, tc := range cases {
+		err := ta.svc.UpdateChannel(chanID, appID, tc.meta)
+		require.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+
+		err = ta.svc.Publish(context.Background(), "", tc.msg)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		if tc.err != nil {
+			assert.Len(t, msgs, 0, fmt.Sprintf("%s: expected no messages", tc.desc))
+			continue
+		}
+
+		msg := <-msgs
+		assert.Equal(t, chanID, msg.Channel, fmt.Sprintf("%s: expected channel %s got %s", tc.desc, chanID, msg.Channel))
+		assert.Equal(t, thingID, msg.Publisher, fmt.Sprintf("%s: expected publisher %s got %s", tc.desc, thingID, msg.Publisher))
+		assert.Equal(t, "", msg.Subtopic, fmt.Sprintf("%s: expected message without subtopic got %s", tc.desc, msg.Subtopic))
+		if tc.merged != nil {
+			names := recordNames(t, msg.Payload)
+			assert.Equal(t, tc.merged, names, fmt.Sprintf("%s: expected records %v got %v", tc.desc, tc.merged, names))
+		} else {
+			assert.Equal(t, tc.payload, string(msg.Payload), fmt.Sprintf("%s: expected payload %s got %s", tc.desc, tc.payload, msg.Payload))
+		}
+
+		if !tc.subtopic {
+			assert.Len(t, msgs, 0, fmt.Sprintf("%s: expected no metadata message", tc.desc))
+			continue
+		}
+		meta := <-msgs
+		assert.Equal(t, "lora.meta", meta.Subtopic, fmt.Sprintf("%s: expected subtopic lora.meta got %s", tc.desc, meta.Subtopic))
+		names := recordNames(t, meta.Payload)
+		assert.Equal(t, metaNames, names, fmt.Sprintf("%s: expected records %v got %v", tc.desc, metaNames, names))
+	}
+}
+
+func recordNames(t *testing.T, payload []byte) []string {
+	pack, err := senml.Decode(payload, senml.JSON)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	pack, err = senml.Normalize(pack)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	var names []string
+	for _, r := range pack.Records {
+		names = append(names, r.Name)
+	}
+	return names
+}
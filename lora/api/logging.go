@@ -66,7 +66,7 @@ func (lm loggingMiddleware) RemoveThing(mfxThing string) (err error) {
 	return lm.svc.RemoveThing(mfxThing)
 }
 
-func (lm loggingMiddleware) CreateChannel(mfxChan string, loraApp string) (err error) {
+func (lm loggingMiddleware) CreateChannel(mfxChan string, loraApp string, meta string) (err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("create_channel mfx:lora:%s:%s took %s to complete", mfxChan, loraApp, time.Since(begin))
 		if err != nil {
@@ -76,10 +76,10 @@ func (lm loggingMiddleware) CreateChannel(mfxChan string, loraApp string) (err e
 		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
 	}(time.Now())
 
-	return lm.svc.CreateChannel(mfxChan, loraApp)
+	return lm.svc.CreateChannel(mfxChan, loraApp, meta)
 }
 
-func (lm loggingMiddleware) UpdateChannel(mfxChanID string, loraApp string) (err error) {
+func (lm loggingMiddleware) UpdateChannel(mfxChanID string, loraApp string, meta string) (err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("update_channel mfx:lora:%s:%s took %s to complete", mfxChanID, loraApp, time.Since(begin))
 		if err != nil {
@@ -89,7 +89,7 @@ func (lm loggingMiddleware) UpdateChannel(mfxChanID string, loraApp string) (err
 		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
 	}(time.Now())
 
-	return lm.svc.UpdateChannel(mfxChanID, loraApp)
+	return lm.svc.UpdateChannel(mfxChanID, loraApp, meta)
 }
 
 func (lm loggingMiddleware) RemoveChannel(mfxChanID string) (err error) {
@@ -117,3 +117,16 @@ func (lm loggingMiddleware) Publish(ctx context.Context, token string, m lora.Me
 
 	return lm.svc.Publish(ctx, token, m)
 }
+
+func (lm loggingMiddleware) Downlink(ctx context.Context, chanID, thingID string, payload []byte) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("downlink channels/%s/messages/downlink/%s took %s to complete", chanID, thingID, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.Downlink(ctx, chanID, thingID, payload)
+}
@@ -55,22 +55,22 @@ func (mm *metricsMiddleware) RemoveThing(mfxDevID string) error {
 	return mm.svc.RemoveThing(mfxDevID)
 }
 
-func (mm *metricsMiddleware) CreateChannel(mfxChanID string, loraApp string) error {
+func (mm *metricsMiddleware) CreateChannel(mfxChanID string, loraApp string, meta string) error {
 	defer func(begin time.Time) {
 		mm.counter.With("method", "create_channel").Add(1)
 		mm.latency.With("method", "create_channel").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return mm.svc.CreateChannel(mfxChanID, loraApp)
+	return mm.svc.CreateChannel(mfxChanID, loraApp, meta)
 }
 
-func (mm *metricsMiddleware) UpdateChannel(mfxChanID string, loraApp string) error {
+func (mm *metricsMiddleware) UpdateChannel(mfxChanID string, loraApp string, meta string) error {
 	defer func(begin time.Time) {
 		mm.counter.With("method", "update_channel").Add(1)
 		mm.latency.With("method", "update_channel").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return mm.svc.UpdateChannel(mfxChanID, loraApp)
+	return mm.svc.UpdateChannel(mfxChanID, loraApp, meta)
 }
 
 func (mm *metricsMiddleware) RemoveChannel(mfxChanID string) error {
@@ -90,3 +90,12 @@ func (mm *metricsMiddleware) Publish(ctx context.Context, token string, m lora.M
 
 	return mm.svc.Publish(ctx, token, m)
 }
+
+func (mm *metricsMiddleware) Downlink(ctx context.Context, chanID, thingID string, payload []byte) error {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "downlink").Add(1)
+		mm.latency.With("method", "downlink").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.Downlink(ctx, chanID, thingID, payload)
+}
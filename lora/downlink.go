@@ -0,0 +1,44 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package lora
+
+import "encoding/base64"
+
+const (
+	minFPort = 1
+	maxFPort = 223
+)
+
+// Downlink represents the message enqueued for the LoRa device
+// (www.loraserver.io/lora-app-server/integrate/sending-receiving/mqtt/).
+// Data is base64 encoded payload. If Object is set instead, the payload
+// is encoded by the LoRa App Server device codec.
+type Downlink struct {
+	Confirmed bool        `json:"confirmed"`
+	FPort     int         `json:"fPort"`
+	Data      string      `json:"data,omitempty"`
+	Object    interface{} `json:"object,omitempty"`
+}
+
+func (d Downlink) validate() error {
+	if d.FPort < minFPort || d.FPort > maxFPort {
+		return ErrMalformedMessage
+	}
+
+	if d.Object != nil {
+		return nil
+	}
+
+	if _, err := base64.StdEncoding.DecodeString(d.Data); err != nil || d.Data == "" {
+		return ErrMalformedMessage
+	}
+
+	return nil
+}
+
+// DownlinkPublisher represents the LoRa Server MQTT broker downlink publisher.
+type DownlinkPublisher interface {
+	// Publish enqueues the downlink for the device of the LoRa application.
+	Publish(appID, devEUI string, d Downlink) error
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package lora
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mainflux/senml"
+)
+
+// Radio metadata publishing modes configured per channel.
+const (
+	// MetaNone drops the radio metadata.
+	MetaNone = "none"
+
+	// MetaSubtopic publishes the radio metadata as SenML message
+	// on the separate subtopic of the channel.
+	MetaSubtopic = "subtopic"
+
+	// MetaMerge appends the radio metadata records to the SenML payload.
+	MetaMerge = "merge"
+)
+
+const metaSubtopic = "lora.meta"
+
+// ValidMeta returns true if the radio metadata publishing mode is supported.
+func ValidMeta(mode string) bool {
+	switch mode {
+	case "", MetaNone, MetaSubtopic, MetaMerge:
+		return true
+	default:
+		return false
+	}
+}
+
+// metaRecords converts the radio metadata of the uplink to SenML records.
+// Records of each gateway which received the uplink are named by the
+// gateway MAC (e.g. "gw/0303030303030303/rssi").
+func (m Message) metaRecords() []senml.Record {
+	t := float64(time.Now().UnixNano()) / float64(time.Second)
+	for _, rx := range m.RxInfo {
+		if rt, err := time.Parse(time.RFC3339Nano, rx.Time); err == nil {
+			t = float64(rt.UnixNano()) / float64(time.Second)
+			break
+		}
+	}
+
+	name := m.DevEUI + ":"
+	recs := []senml.Record{
+		{BaseName: name, BaseTime: t, Name: "fcnt", Value: value(float64(m.FCnt))},
+		{Name: "fport", Value: value(float64(m.FPort))},
+		{Name: "frequency", Unit: "Hz", Value: value(m.TxInfo.Frequency)},
+		{Name: "sf", Value: value(float64(m.TxInfo.DataRate.SpreadFactor))},
+		{Name: "bandwidth", Unit: "kHz", Value: value(m.TxInfo.DataRate.Bandwith)},
+		{Name: "adr", BoolValue: &m.TxInfo.Adr},
+	}
+	if battery, err := strconv.ParseFloat(m.DeviceStatusBattery, 64); err == nil {
+		recs = append(recs, senml.Record{Name: "battery", Value: value(battery)})
+	}
+	if margin, err := strconv.ParseFloat(m.DeviceStatusMrgin, 64); err == nil {
+		recs = append(recs, senml.Record{Name: "margin", Unit: "dB", Value: value(margin)})
+	}
+
+	for i, rx := range m.RxInfo {
+		gw := rx.Mac
+		if gw == "" {
+			gw = strconv.Itoa(i)
+		}
+		gw = fmt.Sprintf("gw/%s/", gw)
+
+		recs = append(recs,
+			senml.Record{Name: gw + "rssi", Unit: "dBm", Value: value(rx.Rssi)},
+			senml.Record{Name: gw + "snr", Unit: "dB", Value: value(rx.LoRaSNR)},
+			senml.Record{Name: gw + "lat", Unit: "lat", Value: value(rx.Latitude)},
+			senml.Record{Name: gw + "lon", Unit: "lon", Value: value(rx.Longitude)},
+			senml.Record{Name: gw + "alt", Unit: "m", Value: value(rx.Altitude)},
+		)
+	}
+
+	return recs
+}
+
+// mergeMeta appends the radio metadata records to the SenML payload.
+func mergeMeta(payload []byte, meta []senml.Record) ([]byte, error) {
+	pack, err := senml.Decode(payload, senml.JSON)
+	if err != nil {
+		return nil, err
+	}
+
+	// Base values of the payload must not apply to the metadata records.
+	pack, err = senml.Normalize(pack)
+	if err != nil {
+		return nil, err
+	}
+
+	pack.Records = append(pack.Records, meta...)
+	return senml.Encode(pack, senml.JSON)
+}
+
+func value(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,54 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package mocks
+
+import (
+	"sync"
+
+	"github.com/mainflux/mainflux/lora"
+)
+
+// Downlink represents the downlink enqueued for the device of the
+// LoRa application.
+type Downlink struct {
+	AppID    string
+	DevEUI   string
+	Downlink lora.Downlink
+}
+
+// DownlinkPublisher represents LoRa downlink publisher mock which keeps
+// published downlinks.
+type DownlinkPublisher interface {
+	lora.DownlinkPublisher
+
+	// Downlinks returns downlinks published so far.
+	Downlinks() []Downlink
+}
+
+var _ DownlinkPublisher = (*downlinksMock)(nil)
+
+type downlinksMock struct {
+	mu        sync.Mutex
+	downlinks []Downlink
+}
+
+// NewDownlinkPublisher returns mock of the LoRa downlink publisher.
+func NewDownlinkPublisher() DownlinkPublisher {
+	return &downlinksMock{}
+}
+
+func (dm *downlinksMock) Publish(appID, devEUI string, d lora.Downlink) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.downlinks = append(dm.downlinks, Downlink{AppID: appID, DevEUI: devEUI, Downlink: d})
+	return nil
+}
+
+func (dm *downlinksMock) Downlinks() []Downlink {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	return append([]Downlink{}, dm.downlinks...)
+}
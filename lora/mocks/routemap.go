@@ -0,0 +1,61 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package mocks
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/mainflux/mainflux/lora"
+)
+
+var errNotFound = errors.New("route-map not found")
+
+var _ lora.RouteMapRepository = (*routeMapMock)(nil)
+
+type routeMapMock struct {
+	mu     sync.Mutex
+	routes map[string]string
+}
+
+// NewRouteMapRepository returns mock of the route-map repository.
+func NewRouteMapRepository() lora.RouteMapRepository {
+	return &routeMapMock{
+		routes: make(map[string]string),
+	}
+}
+
+func (rm *routeMapMock) Save(mfxID, loraID string) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.routes[mfxID] = loraID
+	rm.routes[loraID] = mfxID
+	return nil
+}
+
+func (rm *routeMapMock) Get(loraID string) (string, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	id, ok := rm.routes[loraID]
+	if !ok {
+		return "", errNotFound
+	}
+	return id, nil
+}
+
+func (rm *routeMapMock) Remove(mfxID string) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	id, ok := rm.routes[mfxID]
+	if !ok {
+		return errNotFound
+	}
+
+	delete(rm.routes, mfxID)
+	delete(rm.routes, id)
+	return nil
+}
@@ -0,0 +1,45 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mainflux/mainflux/lora"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+var errPublishTimeout = errors.New("failed to publish downlink due to timeout")
+
+var _ lora.DownlinkPublisher = (*publisher)(nil)
+
+type publisher struct {
+	client  mqtt.Client
+	timeout time.Duration
+}
+
+// NewPublisher returns new MQTT downlink publisher instance.
+func NewPublisher(client mqtt.Client, timeout time.Duration) lora.DownlinkPublisher {
+	return publisher{
+		client:  client,
+		timeout: timeout,
+	}
+}
+
+// Publish publishes the downlink to the device queue topic of the Lora MQTT broker
+func (p publisher) Publish(appID, devEUI string, d lora.Downlink) error {
+	payload, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	topic := fmt.Sprintf("application/%s/device/%s/tx", appID, devEUI)
+	token := p.client.Publish(topic, 0, false, payload)
+	if !token.WaitTimeout(p.timeout) {
+		return errPublishTimeout
+	}
+
+	return token.Error()
+}
@@ -0,0 +1,89 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/mainflux/mainflux/lora"
+	loramqtt "github.com/mainflux/mainflux/lora/mqtt"
+	"github.com/stretchr/testify/assert"
+)
+
+const timeout = time.Second
+
+type token struct {
+	done bool
+}
+
+func (t token) Wait() bool {
+	return t.done
+}
+
+func (t token) WaitTimeout(time.Duration) bool {
+	return t.done
+}
+
+func (t token) Error() error {
+	return nil
+}
+
+// client keeps the last published message. Methods other than Publish
+// are not used by the downlink publisher.
+type client struct {
+	mqtt.Client
+	done    bool
+	topic   string
+	payload []byte
+}
+
+func (c *client) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	c.topic = topic
+	c.payload = payload.([]byte)
+	return token{done: c.done}
+}
+
+func TestPublish(t *testing.T) {
+	cases := []struct {
+		desc     string
+		done     bool
+		downlink lora.Downlink
+		payload  string
+		err      bool
+	}{
+		{
+			desc:     "publish downlink with data",
+			done:     true,
+			downlink: lora.Downlink{Confirmed: true, FPort: 10, Data: "AQID"},
+			payload:  `{"confirmed":true,"fPort":10,"data":"AQID"}`,
+			err:      false,
+		},
+		{
+			desc:     "publish downlink with object",
+			done:     true,
+			downlink: lora.Downlink{FPort: 2, Object: map[string]interface{}{"led": true}},
+			payload:  `{"confirmed":false,"fPort":2,"object":{"led":true}}`,
+			err:      false,
+		},
+		{
+			desc:     "publish downlink with timeout",
+			done:     false,
+			downlink: lora.Downlink{FPort: 10, Data: "AQID"},
+			payload:  `{"confirmed":false,"fPort":10,"data":"AQID"}`,
+			err:      true,
+		},
+	}
+
+	for _, tc := range cases {
+		c := &client{done: tc.done}
+		pub := loramqtt.NewPublisher(c, timeout)
+		err := pub.Publish("10", "0101010101010101", tc.downlink)
+		assert.Equal(t, tc.err, err != nil, fmt.Sprintf("%s: expected error %t got %s", tc.desc, tc.err, err))
+		assert.Equal(t, "application/10/device/0101010101010101/tx", c.topic, fmt.Sprintf("%s: expected device queue topic got %s", tc.desc, c.topic))
+		assert.JSONEq(t, tc.payload, string(c.payload), fmt.Sprintf("%s: expected payload %s got %s", tc.desc, tc.payload, c.payload))
+	}
+}
@@ -12,6 +12,7 @@ type removeThingEvent struct {
 type createChannelEvent struct {
 	id        string
 	loraAppID string
+	loraMeta  string
 }
 
 type removeChannelEvent struct {
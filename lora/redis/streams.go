@@ -14,6 +14,7 @@ const (
 	keyType   = "lora"
 	keyDevEUI = "dev_eui"
 	keyAppID  = "app_id"
+	keyMeta   = "meta"
 
 	group  = "mainflux.lora"
 	stream = "mainflux.things"
@@ -39,6 +40,8 @@ var (
 	errMetadataAppID = errors.New("application ID not found in channel metadatada")
 
 	errMetadataDevEUI = errors.New("device EUI not found in thing metadatada")
+
+	errMetadataMeta = errors.New("invalid radio metadata mode in channel metadata")
 )
 
 // Subscriber represents event source for things and channels provisioning.
@@ -193,6 +196,13 @@ func decodeCreateChannel(event map[string]interface{}) (createChannelEvent, erro
 	}
 
 	cce.loraAppID = val
+
+	meta, _ := lm[keyMeta].(string)
+	if !lora.ValidMeta(meta) {
+		return createChannelEvent{}, errMetadataMeta
+	}
+	cce.loraMeta = meta
+
 	return cce, nil
 }
 
@@ -211,7 +221,7 @@ func (es eventStore) handleRemoveThing(rte removeThingEvent) error {
 }
 
 func (es eventStore) handleCreateChannel(cce createChannelEvent) error {
-	return es.svc.CreateChannel(cce.id, cce.loraAppID)
+	return es.svc.CreateChannel(cce.id, cce.loraAppID, cce.loraMeta)
 }
 
 func (es eventStore) handleRemoveChannel(rce removeChannelEvent) error {
@@ -0,0 +1,66 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package redis
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeCreateChannel(t *testing.T) {
+	id := "1"
+	appID := "10"
+
+	cases := []struct {
+		desc     string
+		metadata string
+		event    createChannelEvent
+		err      error
+	}{
+		{
+			desc:     "decode channel without metadata mode",
+			metadata: `{"lora":{"app_id":"10"}}`,
+			event:    createChannelEvent{id: id, loraAppID: appID},
+			err:      nil,
+		},
+		{
+			desc:     "decode channel with metadata on subtopic",
+			metadata: `{"lora":{"app_id":"10","meta":"subtopic"}}`,
+			event:    createChannelEvent{id: id, loraAppID: appID, loraMeta: "subtopic"},
+			err:      nil,
+		},
+		{
+			desc:     "decode channel with merged metadata",
+			metadata: `{"lora":{"app_id":"10","meta":"merge"}}`,
+			event:    createChannelEvent{id: id, loraAppID: appID, loraMeta: "merge"},
+			err:      nil,
+		},
+		{
+			desc:     "decode channel with invalid metadata mode",
+			metadata: `{"lora":{"app_id":"10","meta":"all"}}`,
+			event:    createChannelEvent{},
+			err:      errMetadataMeta,
+		},
+		{
+			desc:     "decode channel without application ID",
+			metadata: `{"lora":{"meta":"merge"}}`,
+			event:    createChannelEvent{},
+			err:      errMetadataAppID,
+		},
+		{
+			desc:     "decode channel without lora metadata",
+			metadata: `{}`,
+			event:    createChannelEvent{},
+			err:      errMetadataType,
+		},
+	}
+
+	for _, tc := range cases {
+		event, err := decodeCreateChannel(map[string]interface{}{"id": id, "metadata": tc.metadata})
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", tc.desc, tc.err, err))
+		assert.Equal(t, tc.event, event, fmt.Sprintf("%s: expected event %v got %v", tc.desc, tc.event, event))
+	}
+}
@@ -5,6 +5,7 @@ package mqtt
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 
 	log "github.com/mainflux/mainflux/logger"
@@ -24,15 +25,20 @@ type Forwarder interface {
 }
 
 type forwarder struct {
-	topic  string
-	logger log.Logger
+	topic   string
+	headers []string
+	logger  log.Logger
 }
 
-// NewForwarder returns new Forwarder implementation.
-func NewForwarder(topic string, logger log.Logger) Forwarder {
+// NewForwarder returns new Forwarder implementation. Message headers
+// with the given names are forwarded as the query of the MQTT topic. Since
+// the query changes the topic name, no names should be given unless the
+// subscribers expect it.
+func NewForwarder(topic string, headers []string, logger log.Logger) Forwarder {
 	return forwarder{
-		topic:  topic,
-		logger: logger,
+		topic:   topic,
+		headers: headers,
+		logger:  logger,
 	}
 }
 
@@ -51,6 +57,13 @@ func (f forwarder) handle(pub messaging.Publisher) messaging.MessageHandler {
 		if msg.Subtopic != "" {
 			topic += "/" + strings.ReplaceAll(msg.Subtopic, ".", "/")
 		}
+		if headers := messaging.SelectHeaders(msg.Headers, f.headers); headers != nil {
+			query := url.Values{}
+			for k, v := range headers {
+				query.Set(k, v)
+			}
+			topic += "?" + query.Encode()
+		}
 		go func() {
 			if err := pub.Publish(topic, msg); err != nil {
 				f.logger.Warn(fmt.Sprintf("Failed to forward message: %s", err))
@@ -0,0 +1,74 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/mqtt"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const chanID = "9b7b1b3f-b1b0-46a8-a717-b8213f9eda3b"
+
+// topicPublisher passes the topics of the published messages to the channel.
+type topicPublisher chan string
+
+func (tp topicPublisher) Publish(topic string, msg messaging.Message) error {
+	tp <- topic
+	return nil
+}
+
+func TestForward(t *testing.T) {
+	l, err := logger.New(ioutil.Discard, "error")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	msg := messaging.Message{
+		Channel:  chanID,
+		Subtopic: "res",
+		Protocol: "http",
+		Headers:  map[string]string{"correlation-id": "42", "reply-to": "req"},
+	}
+
+	cases := []struct {
+		desc    string
+		headers []string
+		topic   string
+	}{
+		{
+			desc:    "forward message without headers",
+			headers: nil,
+			topic:   fmt.Sprintf("channels/%s/messages/res", chanID),
+		},
+		{
+			desc:    "forward message with headers",
+			headers: []string{"correlation-id"},
+			topic:   fmt.Sprintf("channels/%s/messages/res?correlation-id=42", chanID),
+		},
+	}
+
+	for _, tc := range cases {
+		ps := memory.NewPubSub()
+		pub := make(topicPublisher, 1)
+		err := mqtt.NewForwarder("channels.>", tc.headers, l).Forward(ps, pub)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		err = ps.Publish(chanID, msg)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		select {
+		case topic := <-pub:
+			assert.Equal(t, tc.topic, topic, fmt.Sprintf("%s: expected topic %s got %s", tc.desc, tc.topic, topic))
+		case <-time.After(time.Second):
+			assert.Fail(t, fmt.Sprintf("%s: expected message to be forwarded", tc.desc))
+		}
+	}
+}
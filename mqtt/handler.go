@@ -13,6 +13,7 @@ import (
 	"github.com/mainflux/mainflux/logger"
 	"github.com/mainflux/mainflux/mqtt/redis"
 	"github.com/mainflux/mainflux/pkg/auth"
+	"github.com/mainflux/mainflux/pkg/limits"
 	"github.com/mainflux/mainflux/pkg/messaging"
 	"github.com/mainflux/mproxy/pkg/session"
 )
@@ -37,18 +38,20 @@ var (
 type handler struct {
 	publishers []messaging.Publisher
 	auth       auth.Client
+	limiter    limits.Limiter
 	logger     logger.Logger
 	es         redis.EventStore
 }
 
 // NewHandler creates new Handler entity
 func NewHandler(publishers []messaging.Publisher, es redis.EventStore,
-	logger logger.Logger, auth auth.Client) session.Handler {
+	logger logger.Logger, auth auth.Client, limiter limits.Limiter) session.Handler {
 	return &handler{
 		es:         es,
 		logger:     logger,
 		publishers: publishers,
 		auth:       auth,
+		limiter:    limiter,
 	}
 }
 
@@ -59,7 +62,7 @@ func (h *handler) AuthConnect(c *session.Client) error {
 		return errInvalidConnect
 	}
 
-	thid, err := h.auth.Identify(string(c.Password))
+	thid, err := h.identify(c)
 	if err != nil {
 		return err
 	}
@@ -75,8 +78,21 @@ func (h *handler) AuthConnect(c *session.Client) error {
 	return nil
 }
 
+// identify returns ID of the thing client certificate is issued for or,
+// if the client is connected without the certificate, the ID of the thing
+// identified by the password. Client certificate is checked against the
+// certificates issued by the certs service during TLS handshake.
+func (h *handler) identify(c *session.Client) (string, error) {
+	if len(c.Cert.Raw) > 0 {
+		return auth.CertThingID(&c.Cert), nil
+	}
+	return h.auth.Identify(string(c.Password))
+}
+
 // AuthPublish is called on device publish,
-// prior forwarding to the MQTT broker
+// prior forwarding to the MQTT broker. MQTT 3.1.1 has no way to reject
+// a single message, so the connection of the client exceeding the publish
+// limits is closed.
 func (h *handler) AuthPublish(c *session.Client, topic *string, payload *[]byte) error {
 	if c == nil {
 		return errNilClient
@@ -85,7 +101,17 @@ func (h *handler) AuthPublish(c *session.Client, topic *string, payload *[]byte)
 		return errNilTopicPub
 	}
 
-	return h.authAccess(c.Username, *topic)
+	if err := h.authAccess(c.Username, *topic); err != nil {
+		return err
+	}
+
+	chanID := channelRegExp.FindStringSubmatch(*topic)[1]
+	if err := h.limiter.Allow(c.Username, chanID); err != nil {
+		h.logger.Warn("Rejected publish - client with ID " + c.ID + " to the topic " + *topic + ": " + err.Error())
+		return err
+	}
+
+	return nil
 }
 
 // AuthSubscribe is called on device publish,
@@ -125,7 +151,7 @@ func (h *handler) Publish(c *session.Client, topic *string, payload *[]byte) {
 	}
 	h.logger.Info("Publish - client ID " + c.ID + " to the topic: " + *topic)
 	// Topics are in the format:
-	// channels/<channel_id>/messages/<subtopic>/.../ct/<content_type>
+	// channels/<channel_id>/messages/<subtopic>/.../ct/<content_type>?<headers>
 
 	channelParts := channelRegExp.FindStringSubmatch(*topic)
 	if len(channelParts) < 1 {
@@ -149,6 +175,7 @@ func (h *handler) Publish(c *session.Client, topic *string, payload *[]byte) {
 		Publisher: c.Username,
 		Payload:   *payload,
 		Created:   time.Now().UnixNano(),
+		Headers:   parseHeaders(channelParts[3]),
 	}
 
 	for _, pub := range h.publishers {
@@ -233,3 +260,29 @@ func parseSubtopic(subtopic string) (string, error) {
 	subtopic = strings.Join(filteredElems, ".")
 	return subtopic, nil
 }
+
+// parseHeaders returns the message headers sent as the topic query,
+// or nil if there are none or the query is malformed.
+func parseHeaders(query string) map[string]string {
+	if len(query) <= 1 {
+		return nil
+	}
+
+	vals, err := url.ParseQuery(query[1:])
+	if err != nil {
+		return nil
+	}
+
+	var headers map[string]string
+	for k, v := range vals {
+		name := messaging.HeaderName(k)
+		if name == "" || len(v) == 0 {
+			continue
+		}
+		if headers == nil {
+			headers = make(map[string]string, len(vals))
+		}
+		headers[name] = v[0]
+	}
+	return headers
+}
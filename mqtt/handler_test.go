@@ -0,0 +1,100 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	goredis "github.com/go-redis/redis"
+	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/mqtt"
+	"github.com/mainflux/mainflux/mqtt/mocks"
+	"github.com/mainflux/mainflux/mqtt/redis"
+	authmocks "github.com/mainflux/mainflux/pkg/auth/mocks"
+	"github.com/mainflux/mainflux/pkg/limits"
+	"github.com/mainflux/mproxy/pkg/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	thingID    = "513d02d2-16c1-4f23-98be-9e12f8fee898"
+	otherThing = "2f3d3bb1-f6b5-4bc5-9b77-2ba2a7a1bd38"
+	thingKey   = "thing-key"
+	otherKey   = "other-key"
+	invalidKey = "invalid"
+)
+
+func newHandler(t *testing.T) session.Handler {
+	l, err := logger.New(ioutil.Discard, "error")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	// Connection events are not stored since there is no Redis running.
+	es := redis.NewEventStore(goredis.NewClient(&goredis.Options{Addr: "localhost:1"}), "")
+	things := mocks.NewAuth(map[string]string{thingKey: thingID, otherKey: otherThing})
+	return mqtt.NewHandler(nil, es, l, things, limits.NewUnlimited())
+}
+
+func TestAuthConnect(t *testing.T) {
+	h := newHandler(t)
+
+	ca, err := authmocks.NewCA()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	cert, err := ca.Issue(1, thingID)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc   string
+		client *session.Client
+		err    bool
+	}{
+		{
+			desc:   "connect with thing key",
+			client: &session.Client{ID: "1", Username: thingID, Password: []byte(thingKey)},
+			err:    false,
+		},
+		{
+			desc:   "connect with key of another thing",
+			client: &session.Client{ID: "1", Username: thingID, Password: []byte(otherKey)},
+			err:    true,
+		},
+		{
+			desc:   "connect with invalid key",
+			client: &session.Client{ID: "1", Username: thingID, Password: []byte(invalidKey)},
+			err:    true,
+		},
+		{
+			desc:   "connect with client certificate",
+			client: &session.Client{ID: "1", Username: thingID, Cert: *cert.Leaf},
+			err:    false,
+		},
+		{
+			desc:   "connect with client certificate and invalid key",
+			client: &session.Client{ID: "1", Username: thingID, Password: []byte(invalidKey), Cert: *cert.Leaf},
+			err:    false,
+		},
+		{
+			desc:   "connect as another thing with client certificate",
+			client: &session.Client{ID: "1", Username: otherThing, Cert: *cert.Leaf},
+			err:    true,
+		},
+		{
+			desc:   "connect as another thing with client certificate and its key",
+			client: &session.Client{ID: "1", Username: otherThing, Password: []byte(otherKey), Cert: *cert.Leaf},
+			err:    true,
+		},
+		{
+			desc:   "connect without client",
+			client: nil,
+			err:    true,
+		},
+	}
+
+	for _, tc := range cases {
+		err := h.AuthConnect(tc.client)
+		assert.Equal(t, tc.err, err != nil, fmt.Sprintf("%s: expected error %t got %s", tc.desc, tc.err, err))
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package mocks
+
+import (
+	"errors"
+
+	"github.com/mainflux/mainflux/pkg/auth"
+)
+
+var (
+	_ auth.Client = (*authMock)(nil)
+
+	errUnauthorized = errors.New("unauthorized access")
+)
+
+type authMock struct {
+	things map[string]string
+}
+
+// NewAuth returns mock implementation of the auth client, which grants
+// access to all the channels to the things with the given keys mapped
+// to their IDs.
+func NewAuth(things map[string]string) auth.Client {
+	return authMock{things: things}
+}
+
+func (am authMock) Authorize(chanID, thingID string) error {
+	for _, id := range am.things {
+		if id == thingID {
+			return nil
+		}
+	}
+	return errUnauthorized
+}
+
+func (am authMock) Identify(thingKey string) (string, error) {
+	id, ok := am.things[thingKey]
+	if !ok {
+		return "", errUnauthorized
+	}
+	return id, nil
+}
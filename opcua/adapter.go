@@ -4,11 +4,13 @@
 package opcua
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 
 	"github.com/mainflux/mainflux/logger"
 	"github.com/mainflux/mainflux/opcua/db"
+	"github.com/mainflux/senml"
 )
 
 const protocol = "opcua"
@@ -16,25 +18,41 @@ const protocol = "opcua"
 var (
 	// ErrMalformedEntity indicates malformed entity specification.
 	ErrMalformedEntity = errors.New("malformed entity specification")
+
+	// ErrMalformedMessage indicates malformed SenML message.
+	ErrMalformedMessage = errors.New("malformed message")
+
+	// ErrNotFoundServerURI indicates a non-existent route map for a channel.
+	ErrNotFoundServerURI = errors.New("route map not found for this channel")
+
+	// ErrNotFoundNodeID indicates a non-existent route map for a thing.
+	ErrNotFoundNodeID = errors.New("route map not found for this thing")
+
+	// ErrNotFoundConn indicates a non-existent connection between the channel and the thing.
+	ErrNotFoundConn = errors.New("connection not found")
 )
 
 // Service specifies an API that must be fullfiled by the domain service
 // implementation, and all of its decorators (e.g. logging & metrics).
 type Service interface {
-	// CreateThing creates thingID:OPC-UA-nodeID route-map
-	CreateThing(thingID, nodeID string) error
+	// CreateThing creates thingID:OPC-UA-nodeID route-map and stores
+	// the thing event filter
+	CreateThing(thingID, nodeID string, events EventFilter) error
 
-	// UpdateThing updates thingID:OPC-UA-nodeID route-map
-	UpdateThing(thingID, nodeID string) error
+	// UpdateThing updates thingID:OPC-UA-nodeID route-map and the thing
+	// event filter
+	UpdateThing(thingID, nodeID string, events EventFilter) error
 
 	// RemoveThing removes thingID:OPC-UA-nodeID route-map
 	RemoveThing(thingID string) error
 
-	// CreateChannel creates channelID:OPC-UA-serverURI route-map
-	CreateChannel(chanID, serverURI string) error
+	// CreateChannel creates channelID:OPC-UA-serverURI route-map and stores
+	// the user token used to open sessions with the server
+	CreateChannel(chanID, serverURI string, token UserToken) error
 
-	// UpdateChannel updates channelID:OPC-UA-serverURI route-map
-	UpdateChannel(chanID, serverURI string) error
+	// UpdateChannel updates channelID:OPC-UA-serverURI route-map and the
+	// server user token
+	UpdateChannel(chanID, serverURI string, token UserToken) error
 
 	// RemoveChannel removes channelID:OPC-UA-serverURI route-map
 	RemoveChannel(chanID string) error
@@ -47,6 +65,9 @@ type Service interface {
 
 	// Browse browses available nodes for a given OPC-UA Server URI and NodeID
 	Browse(serverURI, namespace, identifier string) ([]BrowsedNode, error)
+
+	// Write writes SenML value sent to the channel to the thing OPC-UA NodeID
+	Write(chanID, thingID string, payload []byte) error
 }
 
 // Config OPC-UA Server
@@ -58,6 +79,18 @@ type Config struct {
 	Mode      string
 	CertFile  string
 	KeyFile   string
+	Events    EventFilter
+	UserToken UserToken
+	Secrets   Secrets
+}
+
+// EventFilter represents OPC-UA event subscription of the thing. If Fields
+// are empty, the value of the NodeID is monitored instead of its events.
+// Type is the NodeID of the event type (BaseEventType if empty) and Fields
+// are the browse paths of the selected event fields (e.g. "ActiveState/Id").
+type EventFilter struct {
+	Type   string   `json:"type,omitempty"`
+	Fields []string `json:"fields,omitempty"`
 }
 
 var _ Service = (*adapterService)(nil)
@@ -65,47 +98,75 @@ var _ Service = (*adapterService)(nil)
 type adapterService struct {
 	subscriber Subscriber
 	browser    Browser
+	writer     Writer
 	thingsRM   RouteMapRepository
 	channelsRM RouteMapRepository
 	connectRM  RouteMapRepository
+	eventsRM   RouteMapRepository
+	tokensRM   RouteMapRepository
 	cfg        Config
 	logger     logger.Logger
 }
 
 // New instantiates the OPC-UA adapter implementation.
-func New(sub Subscriber, brow Browser, thingsRM, channelsRM, connectRM RouteMapRepository, cfg Config, log logger.Logger) Service {
+func New(sub Subscriber, brow Browser, wr Writer, thingsRM, channelsRM, connectRM, eventsRM, tokensRM RouteMapRepository, cfg Config, log logger.Logger) Service {
 	return &adapterService{
 		subscriber: sub,
 		browser:    brow,
+		writer:     wr,
 		thingsRM:   thingsRM,
 		channelsRM: channelsRM,
 		connectRM:  connectRM,
+		eventsRM:   eventsRM,
+		tokensRM:   tokensRM,
 		cfg:        cfg,
 		logger:     log,
 	}
 }
 
-func (as *adapterService) CreateThing(thingID, nodeID string) error {
-	return as.thingsRM.Save(thingID, nodeID)
+func (as *adapterService) CreateThing(thingID, nodeID string, events EventFilter) error {
+	if err := as.thingsRM.Save(thingID, nodeID); err != nil {
+		return err
+	}
+
+	return as.saveEvents(thingID, events)
 }
 
-func (as *adapterService) UpdateThing(thingID, nodeID string) error {
-	return as.thingsRM.Save(thingID, nodeID)
+func (as *adapterService) UpdateThing(thingID, nodeID string, events EventFilter) error {
+	if err := as.thingsRM.Save(thingID, nodeID); err != nil {
+		return err
+	}
+
+	return as.saveEvents(thingID, events)
 }
 
 func (as *adapterService) RemoveThing(thingID string) error {
+	// Event filter is stored only for the things subscribed to events.
+	as.eventsRM.Remove(thingID)
+
 	return as.thingsRM.Remove(thingID)
 }
 
-func (as *adapterService) CreateChannel(chanID, serverURI string) error {
-	return as.channelsRM.Save(chanID, serverURI)
+func (as *adapterService) CreateChannel(chanID, serverURI string, token UserToken) error {
+	if err := as.channelsRM.Save(chanID, serverURI); err != nil {
+		return err
+	}
+
+	return as.saveUserToken(chanID, token)
 }
 
-func (as *adapterService) UpdateChannel(chanID, serverURI string) error {
-	return as.channelsRM.Save(chanID, serverURI)
+func (as *adapterService) UpdateChannel(chanID, serverURI string, token UserToken) error {
+	if err := as.channelsRM.Save(chanID, serverURI); err != nil {
+		return err
+	}
+
+	return as.saveUserToken(chanID, token)
 }
 
 func (as *adapterService) RemoveChannel(chanID string) error {
+	// User token is stored only for the channels with configured user identity.
+	as.tokensRM.Remove(chanID)
+
 	return as.channelsRM.Remove(chanID)
 }
 
@@ -120,28 +181,39 @@ func (as *adapterService) ConnectThing(chanID, thingID string) error {
 		return err
 	}
 
-	as.cfg.NodeID = nodeID
-	as.cfg.ServerURI = serverURI
+	cfg := as.cfg
+	cfg.NodeID = nodeID
+	cfg.ServerURI = serverURI
+	cfg.Events = as.events(thingID)
+	cfg.UserToken = as.userToken(chanID)
 
 	c := fmt.Sprintf("%s:%s", chanID, thingID)
 	if err := as.connectRM.Save(c, c); err != nil {
 		return err
 	}
 
-	go func() {
-		if err := as.subscriber.Subscribe(as.cfg); err != nil {
-			as.logger.Warn(fmt.Sprintf("subscription failed: %s", err))
-		}
-	}()
+	if err := as.subscriber.Subscribe(cfg); err != nil {
+		as.logger.Warn(fmt.Sprintf("subscription failed: %s", err))
+	}
 
 	// Store subscription details
-	return db.Save(serverURI, nodeID)
+	return db.Save(db.Node{
+		ServerURI:   serverURI,
+		NodeID:      nodeID,
+		EventType:   cfg.Events.Type,
+		EventFields: cfg.Events.Fields,
+	})
 }
 
 func (as *adapterService) Browse(serverURI, namespace, identifier string) ([]BrowsedNode, error) {
-	nodeID := fmt.Sprintf("%s;%s", namespace, identifier)
+	cfg := as.cfg
+	cfg.ServerURI = serverURI
+	cfg.NodeID = fmt.Sprintf("%s;%s", namespace, identifier)
+	if chanID, err := as.channelsRM.Get(serverURI); err == nil {
+		cfg.UserToken = as.userToken(chanID)
+	}
 
-	nodes, err := as.browser.Browse(serverURI, nodeID)
+	nodes, err := as.browser.Browse(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -150,5 +222,139 @@ func (as *adapterService) Browse(serverURI, namespace, identifier string) ([]Bro
 
 func (as *adapterService) DisconnectThing(chanID, thingID string) error {
 	c := fmt.Sprintf("%s:%s", chanID, thingID)
-	return as.connectRM.Remove(c)
+	if err := as.connectRM.Remove(c); err != nil {
+		return err
+	}
+
+	serverURI, err := as.channelsRM.Get(chanID)
+	if err != nil {
+		return nil
+	}
+
+	nodeID, err := as.thingsRM.Get(thingID)
+	if err != nil {
+		return nil
+	}
+
+	cfg := as.cfg
+	cfg.NodeID = nodeID
+	cfg.ServerURI = serverURI
+
+	if err := as.subscriber.Unsubscribe(cfg); err != nil {
+		as.logger.Warn(fmt.Sprintf("unsubscription failed: %s", err))
+	}
+
+	// Remove subscription details
+	return db.Remove(serverURI, nodeID)
+}
+
+func (as *adapterService) Write(chanID, thingID string, payload []byte) error {
+	serverURI, err := as.channelsRM.Get(chanID)
+	if err != nil {
+		return ErrNotFoundServerURI
+	}
+
+	nodeID, err := as.thingsRM.Get(thingID)
+	if err != nil {
+		return ErrNotFoundNodeID
+	}
+
+	c := fmt.Sprintf("%s:%s", chanID, thingID)
+	if _, err := as.connectRM.Get(c); err != nil {
+		return ErrNotFoundConn
+	}
+
+	value, err := senmlValue(payload)
+	if err != nil {
+		return err
+	}
+
+	cfg := as.cfg
+	cfg.ServerURI = serverURI
+	cfg.NodeID = nodeID
+	cfg.UserToken = as.userToken(chanID)
+
+	return as.writer.Write(cfg, value)
+}
+
+// senmlValue returns the value of the first record of the SenML message.
+func senmlValue(payload []byte) (interface{}, error) {
+	pack, err := senml.Decode(payload, senml.JSON)
+	if err != nil || len(pack.Records) == 0 {
+		return nil, ErrMalformedMessage
+	}
+
+	r := pack.Records[0]
+	switch {
+	case r.Value != nil:
+		return *r.Value, nil
+	case r.BoolValue != nil:
+		return *r.BoolValue, nil
+	case r.StringValue != nil:
+		return *r.StringValue, nil
+	case r.DataValue != nil:
+		return *r.DataValue, nil
+	default:
+		return nil, ErrMalformedMessage
+	}
+}
+
+func (as *adapterService) saveEvents(thingID string, events EventFilter) error {
+	if len(events.Fields) == 0 {
+		// Thing may be updated to monitor the value of the NodeID.
+		as.eventsRM.Remove(thingID)
+		return nil
+	}
+
+	data, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	return as.eventsRM.Save(thingID, string(data))
+}
+
+func (as *adapterService) events(thingID string) EventFilter {
+	var events EventFilter
+
+	data, err := as.eventsRM.Get(thingID)
+	if err != nil {
+		return events
+	}
+
+	if err := json.Unmarshal([]byte(data), &events); err != nil {
+		as.logger.Warn(fmt.Sprintf("Failed to decode event filter of thing %s: %s", thingID, err))
+	}
+
+	return events
+}
+
+func (as *adapterService) saveUserToken(chanID string, token UserToken) error {
+	if token.Type == "" || token.Type == UserTokenAnonymous {
+		// Channel may be updated to use anonymous sessions.
+		as.tokensRM.Remove(chanID)
+		return nil
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	return as.tokensRM.Save(chanID, string(data))
+}
+
+func (as *adapterService) userToken(chanID string) UserToken {
+	var token UserToken
+
+	data, err := as.tokensRM.Get(chanID)
+	if err != nil {
+		return token
+	}
+
+	if err := json.Unmarshal([]byte(data), &token); err != nil {
+		as.logger.Warn(fmt.Sprintf("Failed to decode user token of channel %s: %s", chanID, err))
+	}
+
+	return token
 }
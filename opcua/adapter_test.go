@@ -0,0 +1,200 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package opcua_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/opcua"
+	"github.com/mainflux/mainflux/opcua/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	chanID    = "1"
+	thingID   = "2"
+	serverURI = "opc.tcp://localhost:4840"
+	nodeID    = "ns=2;s=Temperature"
+	unknown   = "unknown"
+)
+
+type testAdapter struct {
+	svc      opcua.Service
+	writer   mocks.Writer
+	connects opcua.RouteMapRepository
+	tokens   opcua.RouteMapRepository
+}
+
+func newAdapter(t *testing.T) testAdapter {
+	l, err := logger.New(ioutil.Discard, "error")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	ta := testAdapter{
+		writer:   mocks.NewWriter(),
+		connects: mocks.NewRouteMapRepository(),
+		tokens:   mocks.NewRouteMapRepository(),
+	}
+	ta.svc = opcua.New(nil, nil, ta.writer, mocks.NewRouteMapRepository(), mocks.NewRouteMapRepository(),
+		ta.connects, mocks.NewRouteMapRepository(), ta.tokens, opcua.Config{Policy: "None", Mode: "None"}, l)
+	return ta
+}
+
+func TestWrite(t *testing.T) {
+	ta := newAdapter(t)
+
+	err := ta.svc.CreateThing(thingID, nodeID, opcua.EventFilter{})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = ta.svc.CreateThing(unknown, nodeID, opcua.EventFilter{})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	token := opcua.UserToken{Type: opcua.UserTokenUsername, Username: "user", Password: "env:OPCUA_PASS"}
+	err = ta.svc.CreateChannel(chanID, serverURI, token)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	// Connection is saved directly since connecting subscribes to the Node.
+	c := fmt.Sprintf("%s:%s", chanID, thingID)
+	err = ta.connects.Save(c, c)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc    string
+		chanID  string
+		thingID string
+		payload string
+		value   interface{}
+		err     error
+	}{
+		{
+			desc:    "write float value",
+			chanID:  chanID,
+			thingID: thingID,
+			payload: `[{"n":"temperature","v":21.5}]`,
+			value:   21.5,
+			err:     nil,
+		},
+		{
+			desc:    "write boolean value",
+			chanID:  chanID,
+			thingID: thingID,
+			payload: `[{"n":"switch","vb":true}]`,
+			value:   true,
+			err:     nil,
+		},
+		{
+			desc:    "write string value",
+			chanID:  chanID,
+			thingID: thingID,
+			payload: `[{"n":"mode","vs":"auto"}]`,
+			value:   "auto",
+			err:     nil,
+		},
+		{
+			desc:    "write value of the first record",
+			chanID:  chanID,
+			thingID: thingID,
+			payload: `[{"n":"temperature","v":1},{"n":"temperature","v":2}]`,
+			value:   1.0,
+			err:     nil,
+		},
+		{
+			desc:    "write record without value",
+			chanID:  chanID,
+			thingID: thingID,
+			payload: `[{"n":"temperature"}]`,
+			err:     opcua.ErrMalformedMessage,
+		},
+		{
+			desc:    "write malformed message",
+			chanID:  chanID,
+			thingID: thingID,
+			payload: `{"v":1}`,
+			err:     opcua.ErrMalformedMessage,
+		},
+		{
+			desc:    "write to channel without route-map",
+			chanID:  unknown,
+			thingID: thingID,
+			payload: `[{"v":1}]`,
+			err:     opcua.ErrNotFoundServerURI,
+		},
+		{
+			desc:    "write to thing without route-map",
+			chanID:  chanID,
+			thingID: "3",
+			payload: `[{"v":1}]`,
+			err:     opcua.ErrNotFoundNodeID,
+		},
+		{
+			desc:    "write to thing not connected to channel",
+			chanID:  chanID,
+			thingID: unknown,
+			payload: `[{"v":1}]`,
+			err:     opcua.ErrNotFoundConn,
+		},
+	}
+
+	for _, tc := range cases {
+		sent := len(ta.writer.Writes())
+		err := ta.svc.Write(tc.chanID, tc.thingID, []byte(tc.payload))
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+
+		writes := ta.writer.Writes()
+		if tc.err != nil {
+			assert.Equal(t, sent, len(writes), fmt.Sprintf("%s: expected no value to be written", tc.desc))
+			continue
+		}
+		require.Equal(t, sent+1, len(writes), fmt.Sprintf("%s: expected value to be written", tc.desc))
+		w := writes[len(writes)-1]
+		assert.Equal(t, tc.value, w.Value, fmt.Sprintf("%s: expected value %v got %v", tc.desc, tc.value, w.Value))
+		assert.Equal(t, serverURI, w.Config.ServerURI, fmt.Sprintf("%s: expected server %s got %s", tc.desc, serverURI, w.Config.ServerURI))
+		assert.Equal(t, nodeID, w.Config.NodeID, fmt.Sprintf("%s: expected node %s got %s", tc.desc, nodeID, w.Config.NodeID))
+		assert.Equal(t, token, w.Config.UserToken, fmt.Sprintf("%s: expected user token %v got %v", tc.desc, token, w.Config.UserToken))
+	}
+}
+
+func TestUpdateChannelUserToken(t *testing.T) {
+	ta := newAdapter(t)
+
+	token := opcua.UserToken{Type: opcua.UserTokenCertificate, Certificate: "/certs/user.crt"}
+	err := ta.svc.CreateChannel(chanID, serverURI, token)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc  string
+		token opcua.UserToken
+		saved bool
+	}{
+		{
+			desc:  "update channel with username user token",
+			token: opcua.UserToken{Type: opcua.UserTokenUsername, Username: "user", Password: "env:OPCUA_PASS"},
+			saved: true,
+		},
+		{
+			desc:  "update channel with anonymous user token",
+			token: opcua.UserToken{Type: opcua.UserTokenAnonymous},
+			saved: false,
+		},
+		{
+			desc:  "update channel without user token",
+			token: opcua.UserToken{},
+			saved: false,
+		},
+	}
+
+	for _, tc := range cases {
+		err := ta.svc.UpdateChannel(chanID, serverURI, tc.token)
+		assert.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+		_, err = ta.tokens.Get(chanID)
+		assert.Equal(t, tc.saved, err == nil, fmt.Sprintf("%s: expected user token saved %t got error %s", tc.desc, tc.saved, err))
+	}
+
+	err = ta.svc.CreateChannel(chanID, serverURI, token)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = ta.svc.RemoveChannel(chanID)
+	assert.Nil(t, err, fmt.Sprintf("remove channel: got unexpected error: %s", err))
+	_, err = ta.tokens.Get(chanID)
+	assert.NotNil(t, err, "remove channel: expected user token to be removed")
+}
@@ -26,7 +26,7 @@ func LoggingMiddleware(svc opcua.Service, logger logger.Logger) opcua.Service {
 	}
 }
 
-func (lm loggingMiddleware) CreateThing(mfxThing, opcuaNodeID string) (err error) {
+func (lm loggingMiddleware) CreateThing(mfxThing, opcuaNodeID string, events opcua.EventFilter) (err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("create_thing %s with NodeID %s, took %s to complete", mfxThing, opcuaNodeID, time.Since(begin))
 		if err != nil {
@@ -36,10 +36,10 @@ func (lm loggingMiddleware) CreateThing(mfxThing, opcuaNodeID string) (err error
 		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
 	}(time.Now())
 
-	return lm.svc.CreateThing(mfxThing, opcuaNodeID)
+	return lm.svc.CreateThing(mfxThing, opcuaNodeID, events)
 }
 
-func (lm loggingMiddleware) UpdateThing(mfxThing, opcuaNodeID string) (err error) {
+func (lm loggingMiddleware) UpdateThing(mfxThing, opcuaNodeID string, events opcua.EventFilter) (err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("update_thing %s with NodeID %s, took %s to complete", mfxThing, opcuaNodeID, time.Since(begin))
 		if err != nil {
@@ -49,7 +49,7 @@ func (lm loggingMiddleware) UpdateThing(mfxThing, opcuaNodeID string) (err error
 		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
 	}(time.Now())
 
-	return lm.svc.UpdateThing(mfxThing, opcuaNodeID)
+	return lm.svc.UpdateThing(mfxThing, opcuaNodeID, events)
 }
 
 func (lm loggingMiddleware) RemoveThing(mfxThing string) (err error) {
@@ -65,7 +65,7 @@ func (lm loggingMiddleware) RemoveThing(mfxThing string) (err error) {
 	return lm.svc.RemoveThing(mfxThing)
 }
 
-func (lm loggingMiddleware) CreateChannel(mfxChan, opcuaServerURI string) (err error) {
+func (lm loggingMiddleware) CreateChannel(mfxChan, opcuaServerURI string, token opcua.UserToken) (err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("create_channel %s with ServerURI %s, took %s to complete", mfxChan, opcuaServerURI, time.Since(begin))
 		if err != nil {
@@ -75,10 +75,10 @@ func (lm loggingMiddleware) CreateChannel(mfxChan, opcuaServerURI string) (err e
 		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
 	}(time.Now())
 
-	return lm.svc.CreateChannel(mfxChan, opcuaServerURI)
+	return lm.svc.CreateChannel(mfxChan, opcuaServerURI, token)
 }
 
-func (lm loggingMiddleware) UpdateChannel(mfxChanID, opcuaServerURI string) (err error) {
+func (lm loggingMiddleware) UpdateChannel(mfxChanID, opcuaServerURI string, token opcua.UserToken) (err error) {
 	defer func(begin time.Time) {
 		message := fmt.Sprintf("update_channel %s with ServerURI %s, took %s to complete", mfxChanID, opcuaServerURI, time.Since(begin))
 		if err != nil {
@@ -88,7 +88,7 @@ func (lm loggingMiddleware) UpdateChannel(mfxChanID, opcuaServerURI string) (err
 		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
 	}(time.Now())
 
-	return lm.svc.UpdateChannel(mfxChanID, opcuaServerURI)
+	return lm.svc.UpdateChannel(mfxChanID, opcuaServerURI, token)
 }
 
 func (lm loggingMiddleware) RemoveChannel(mfxChanID string) (err error) {
@@ -142,3 +142,16 @@ func (lm loggingMiddleware) Browse(serverURI, namespace, identifier string) (nod
 
 	return lm.svc.Browse(serverURI, namespace, identifier)
 }
+
+func (lm loggingMiddleware) Write(chanID, thingID string, payload []byte) (err error) {
+	defer func(begin time.Time) {
+		message := fmt.Sprintf("write to thing %s of channel %s, took %s to complete", thingID, chanID, time.Since(begin))
+		if err != nil {
+			lm.logger.Warn(fmt.Sprintf("%s with error: %s.", message, err))
+			return
+		}
+		lm.logger.Info(fmt.Sprintf("%s without errors.", message))
+	}(time.Now())
+
+	return lm.svc.Write(chanID, thingID, payload)
+}
@@ -27,22 +27,22 @@ func MetricsMiddleware(svc opcua.Service, counter metrics.Counter, latency metri
 	}
 }
 
-func (mm *metricsMiddleware) CreateThing(mfxDevID, opcuaNodeID string) error {
+func (mm *metricsMiddleware) CreateThing(mfxDevID, opcuaNodeID string, events opcua.EventFilter) error {
 	defer func(begin time.Time) {
 		mm.counter.With("method", "create_thing").Add(1)
 		mm.latency.With("method", "create_thing").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return mm.svc.CreateThing(mfxDevID, opcuaNodeID)
+	return mm.svc.CreateThing(mfxDevID, opcuaNodeID, events)
 }
 
-func (mm *metricsMiddleware) UpdateThing(mfxDevID, opcuaNodeID string) error {
+func (mm *metricsMiddleware) UpdateThing(mfxDevID, opcuaNodeID string, events opcua.EventFilter) error {
 	defer func(begin time.Time) {
 		mm.counter.With("method", "update_thing").Add(1)
 		mm.latency.With("method", "update_thing").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return mm.svc.UpdateThing(mfxDevID, opcuaNodeID)
+	return mm.svc.UpdateThing(mfxDevID, opcuaNodeID, events)
 }
 
 func (mm *metricsMiddleware) RemoveThing(mfxDevID string) error {
@@ -54,22 +54,22 @@ func (mm *metricsMiddleware) RemoveThing(mfxDevID string) error {
 	return mm.svc.RemoveThing(mfxDevID)
 }
 
-func (mm *metricsMiddleware) CreateChannel(mfxChanID, opcuaServerURI string) error {
+func (mm *metricsMiddleware) CreateChannel(mfxChanID, opcuaServerURI string, token opcua.UserToken) error {
 	defer func(begin time.Time) {
 		mm.counter.With("method", "create_channel").Add(1)
 		mm.latency.With("method", "create_channel").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return mm.svc.CreateChannel(mfxChanID, opcuaServerURI)
+	return mm.svc.CreateChannel(mfxChanID, opcuaServerURI, token)
 }
 
-func (mm *metricsMiddleware) UpdateChannel(mfxChanID, opcuaServerURI string) error {
+func (mm *metricsMiddleware) UpdateChannel(mfxChanID, opcuaServerURI string, token opcua.UserToken) error {
 	defer func(begin time.Time) {
 		mm.counter.With("method", "update_channel").Add(1)
 		mm.latency.With("method", "update_channel").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return mm.svc.UpdateChannel(mfxChanID, opcuaServerURI)
+	return mm.svc.UpdateChannel(mfxChanID, opcuaServerURI, token)
 }
 
 func (mm *metricsMiddleware) RemoveChannel(mfxChanID string) error {
@@ -107,3 +107,12 @@ func (mm *metricsMiddleware) Browse(serverURI, namespace, identifier string) ([]
 
 	return mm.svc.Browse(serverURI, namespace, identifier)
 }
+
+func (mm *metricsMiddleware) Write(chanID, thingID string, payload []byte) error {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "write").Add(1)
+		mm.latency.With("method", "write").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.Write(chanID, thingID, payload)
+}
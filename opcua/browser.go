@@ -15,6 +15,6 @@ type BrowsedNode struct {
 
 // Browser represents the OPC-UA Server Nodes browser.
 type Browser interface {
-	// Browse availlable Nodes for a given URI.
-	Browse(string, string) ([]BrowsedNode, error)
+	// Browse availlable Nodes for a given URI and NodeID.
+	Browse(Config) ([]BrowsedNode, error)
 }
@@ -24,18 +24,50 @@ var (
 
 // Node represents an OPC-UA node
 type Node struct {
-	ServerURI string
-	NodeID    string
+	ServerURI   string
+	NodeID      string
+	EventType   string
+	EventFields []string
 }
 
 // Save stores a successfull subscription
-func Save(serverURI, nodeID string) error {
+func Save(node Node) error {
 	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, os.ModePerm)
 	if err != nil {
 		errors.Wrap(errWriteFile, err)
 	}
 	csvWriter := csv.NewWriter(file)
-	csvWriter.Write([]string{serverURI, nodeID})
+	csvWriter.Write(record(node))
+	csvWriter.Flush()
+
+	return nil
+}
+
+// Remove deletes the stored subscription
+func Remove(serverURI, nodeID string) error {
+	nodes, err := ReadAll()
+	if err != nil {
+		if errors.Contains(err, errNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.ModePerm)
+	if err != nil {
+		return errors.Wrap(errWriteFile, err)
+	}
+	defer file.Close()
+
+	csvWriter := csv.NewWriter(file)
+	for _, n := range nodes {
+		if n.ServerURI == serverURI && n.NodeID == nodeID {
+			continue
+		}
+		if err := csvWriter.Write(record(n)); err != nil {
+			return errors.Wrap(errWriteFile, err)
+		}
+	}
 	csvWriter.Flush()
 
 	return nil
@@ -54,6 +86,8 @@ func ReadAll() ([]Node, error) {
 	defer file.Close()
 
 	reader := csv.NewReader(file)
+	// Event subscriptions store event type and fields in additional columns.
+	reader.FieldsPerRecord = -1
 	nodes := []Node{}
 	for {
 		l, err := reader.Read()
@@ -68,8 +102,26 @@ func ReadAll() ([]Node, error) {
 			return nil, errEmptyLine
 		}
 
-		nodes = append(nodes, Node{l[0], l[1]})
+		node := Node{
+			ServerURI: l[0],
+			NodeID:    l[1],
+		}
+		if len(l) > columns {
+			node.EventType = l[columns]
+			node.EventFields = l[columns+1:]
+		}
+
+		nodes = append(nodes, node)
 	}
 
 	return nodes, nil
 }
+
+func record(node Node) []string {
+	if len(node.EventFields) == 0 {
+		return []string{node.ServerURI, node.NodeID}
+	}
+
+	rec := []string{node.ServerURI, node.NodeID, node.EventType}
+	return append(rec, node.EventFields...)
+}
@@ -47,18 +47,19 @@ func NewBrowser(ctx context.Context, log logger.Logger) opcua.Browser {
 	}
 }
 
-func (c browser) Browse(serverURI, nodeID string) ([]opcua.BrowsedNode, error) {
-	opts := []opcuaGopcua.Option{
-		opcuaGopcua.SecurityMode(uaGopcua.MessageSecurityModeNone),
+func (c browser) Browse(cfg opcua.Config) ([]opcua.BrowsedNode, error) {
+	opts, err := clientOptions(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	oc := opcuaGopcua.NewClient(serverURI, opts...)
+	oc := opcuaGopcua.NewClient(cfg.ServerURI, opts...)
 	if err := oc.Connect(c.ctx); err != nil {
 		return nil, errors.Wrap(errFailedConn, err)
 	}
 	defer oc.Close()
 
-	nodeList, err := browse(oc, nodeID, "", 0)
+	nodeList, err := browse(oc, cfg.NodeID, "", 0)
 	if err != nil {
 		return nil, err
 	}
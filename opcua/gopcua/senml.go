@@ -0,0 +1,115 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package gopcua
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	uaGopcua "github.com/gopcua/opcua/ua"
+	"github.com/mainflux/senml"
+)
+
+// toRecords flattens the OPC-UA value into SenML records. Array elements are
+// named by their index and structure fields by their field name, relative to
+// the given name (e.g. "Pressure/0" or "Status/Code").
+func toRecords(name string, val interface{}, t float64) []senml.Record {
+	rv := reflect.ValueOf(val)
+	if !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		return nil
+	}
+
+	switch v := val.(type) {
+	case *uaGopcua.Variant:
+		return toRecords(name, v.Value(), t)
+	case *uaGopcua.DataValue:
+		return toRecords(name, v.Value, t)
+	case *uaGopcua.ExtensionObject:
+		return toRecords(name, v.Value, t)
+	case *uaGopcua.LocalizedText:
+		return []senml.Record{stringRecord(name, v.Text, t)}
+	case *uaGopcua.QualifiedName:
+		return []senml.Record{stringRecord(name, v.Name, t)}
+	case *uaGopcua.XMLElement:
+		return []senml.Record{stringRecord(name, string(*v), t)}
+	case bool:
+		return []senml.Record{{Name: name, Time: t, BoolValue: &v}}
+	case []byte:
+		data := base64.RawURLEncoding.EncodeToString(v)
+		return []senml.Record{{Name: name, Time: t, DataValue: &data}}
+	case time.Time:
+		return []senml.Record{valueRecord(name, float64(v.Unix()), t)}
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []senml.Record{valueRecord(name, float64(rv.Int()), t)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []senml.Record{valueRecord(name, float64(rv.Uint()), t)}
+	case reflect.Float32, reflect.Float64:
+		return []senml.Record{valueRecord(name, rv.Float(), t)}
+	case reflect.String:
+		return []senml.Record{stringRecord(name, rv.String(), t)}
+	}
+
+	// NodeIDs, GUIDs and similar identifiers are published as strings.
+	if s, ok := val.(fmt.Stringer); ok {
+		return []senml.Record{stringRecord(name, s.String(), t)}
+	}
+
+	var recs []senml.Record
+	switch rv.Kind() {
+	case reflect.Ptr:
+		return toRecords(name, rv.Elem().Interface(), t)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			recs = append(recs, toRecords(fmt.Sprintf("%s/%d", name, i), rv.Index(i).Interface(), t)...)
+		}
+	case reflect.Struct:
+		for i := 0; i < rv.NumField(); i++ {
+			f := rv.Type().Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			recs = append(recs, toRecords(fmt.Sprintf("%s/%s", name, f.Name), rv.Field(i).Interface(), t)...)
+		}
+	}
+
+	return recs
+}
+
+func valueRecord(name string, v float64, t float64) senml.Record {
+	return senml.Record{Name: name, Time: t, Value: &v}
+}
+
+func stringRecord(name, v string, t float64) senml.Record {
+	return senml.Record{Name: name, Time: t, StringValue: &v}
+}
+
+// senmlName replaces characters not allowed in SenML names with underscores.
+func senmlName(s string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '-', r == ':', r == '.', r == '/', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+
+	return strings.TrimLeft(name, "-:./_")
+}
+
+func senmlTime(t time.Time) float64 {
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	return float64(t.UnixNano()) / float64(time.Second)
+}
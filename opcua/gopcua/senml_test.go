@@ -0,0 +1,309 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package gopcua
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	uaGopcua "github.com/gopcua/opcua/ua"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/memory"
+	"github.com/mainflux/senml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	nodeID   = "ns=2;i=1"
+	nodeName = "Boiler"
+	chanID   = "1"
+	thingID  = "513d02d2-16c1-4f23-98be-9e12f8fee898"
+	recTime  = float64(1600000000)
+)
+
+type status struct {
+	Code    uint32
+	Message string
+	private int
+}
+
+func floatRecord(name string, v float64) senml.Record {
+	return valueRecord(name, v, recTime)
+}
+
+func strRecord(name, v string) senml.Record {
+	return stringRecord(name, v, recTime)
+}
+
+func TestToRecords(t *testing.T) {
+	vTrue := true
+	data := "AQID"
+
+	cases := []struct {
+		desc string
+		val  interface{}
+		recs []senml.Record
+	}{
+		{
+			desc: "convert integer",
+			val:  int32(-5),
+			recs: []senml.Record{floatRecord(nodeName, -5)},
+		},
+		{
+			desc: "convert unsigned integer",
+			val:  uint16(5),
+			recs: []senml.Record{floatRecord(nodeName, 5)},
+		},
+		{
+			desc: "convert float",
+			val:  float32(1.5),
+			recs: []senml.Record{floatRecord(nodeName, 1.5)},
+		},
+		{
+			desc: "convert string",
+			val:  "on",
+			recs: []senml.Record{strRecord(nodeName, "on")},
+		},
+		{
+			desc: "convert bool",
+			val:  true,
+			recs: []senml.Record{{Name: nodeName, Time: recTime, BoolValue: &vTrue}},
+		},
+		{
+			desc: "convert byte string",
+			val:  []byte{1, 2, 3},
+			recs: []senml.Record{{Name: nodeName, Time: recTime, DataValue: &data}},
+		},
+		{
+			desc: "convert date time",
+			val:  time.Unix(1500000000, 0),
+			recs: []senml.Record{floatRecord(nodeName, 1500000000)},
+		},
+		{
+			desc: "convert localized text",
+			val:  &uaGopcua.LocalizedText{Locale: "en", Text: "Overheated"},
+			recs: []senml.Record{strRecord(nodeName, "Overheated")},
+		},
+		{
+			desc: "convert node ID",
+			val:  uaGopcua.NewNumericNodeID(2, 1),
+			recs: []senml.Record{strRecord(nodeName, nodeID)},
+		},
+		{
+			desc: "convert variant",
+			val:  uaGopcua.MustVariant(float64(21.5)),
+			recs: []senml.Record{floatRecord(nodeName, 21.5)},
+		},
+		{
+			desc: "convert data value",
+			val:  &uaGopcua.DataValue{Value: uaGopcua.MustVariant(int64(7))},
+			recs: []senml.Record{floatRecord(nodeName, 7)},
+		},
+		{
+			desc: "convert array",
+			val:  uaGopcua.MustVariant([]float64{1, 2}),
+			recs: []senml.Record{
+				floatRecord(nodeName+"/0", 1),
+				floatRecord(nodeName+"/1", 2),
+			},
+		},
+		{
+			desc: "convert structure",
+			val:  &status{Code: 3, Message: "high", private: 1},
+			recs: []senml.Record{
+				floatRecord(nodeName+"/Code", 3),
+				strRecord(nodeName+"/Message", "high"),
+			},
+		},
+		{
+			desc: "convert array of structures",
+			val:  []status{{Code: 1}},
+			recs: []senml.Record{
+				floatRecord(nodeName+"/0/Code", 1),
+				strRecord(nodeName+"/0/Message", ""),
+			},
+		},
+		{
+			desc: "convert extension object",
+			val:  &uaGopcua.ExtensionObject{Value: &status{Code: 2, Message: "low"}},
+			recs: []senml.Record{
+				floatRecord(nodeName+"/Code", 2),
+				strRecord(nodeName+"/Message", "low"),
+			},
+		},
+		{
+			desc: "convert nil",
+			val:  nil,
+			recs: nil,
+		},
+		{
+			desc: "convert nil pointer",
+			val:  (*status)(nil),
+			recs: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		recs := toRecords(nodeName, tc.val, recTime)
+		assert.Equal(t, tc.recs, recs, fmt.Sprintf("%s: expected records %v got %v", tc.desc, tc.recs, recs))
+	}
+}
+
+func TestSenmlName(t *testing.T) {
+	cases := []struct {
+		desc string
+		name string
+		res  string
+	}{
+		{
+			desc: "name with allowed characters",
+			name: "2:Boiler/Temperature-1.0_a",
+			res:  "2:Boiler/Temperature-1.0_a",
+		},
+		{
+			desc: "name with disallowed characters",
+			name: "Boiler Temperature (C)",
+			res:  "Boiler_Temperature__C_",
+		},
+		{
+			desc: "name with leading separators",
+			name: "/_Boiler",
+			res:  "Boiler",
+		},
+	}
+
+	for _, tc := range cases {
+		res := senmlName(tc.name)
+		assert.Equal(t, tc.res, res, fmt.Sprintf("%s: expected name %s got %s", tc.desc, tc.res, res))
+	}
+}
+
+func TestBrowsePath(t *testing.T) {
+	cases := []struct {
+		desc  string
+		field string
+		path  []*uaGopcua.QualifiedName
+	}{
+		{
+			desc:  "parse field",
+			field: "Severity",
+			path:  []*uaGopcua.QualifiedName{{Name: "Severity"}},
+		},
+		{
+			desc:  "parse field with namespace index",
+			field: "2:Temperature",
+			path:  []*uaGopcua.QualifiedName{{NamespaceIndex: 2, Name: "Temperature"}},
+		},
+		{
+			desc:  "parse nested field",
+			field: "EnabledState/Id",
+			path:  []*uaGopcua.QualifiedName{{Name: "EnabledState"}, {Name: "Id"}},
+		},
+		{
+			desc:  "parse field with invalid namespace index",
+			field: "a:Temperature",
+			path:  []*uaGopcua.QualifiedName{{Name: "a:Temperature"}},
+		},
+	}
+
+	for _, tc := range cases {
+		path := browsePath(tc.field)
+		assert.Equal(t, tc.path, path, fmt.Sprintf("%s: expected path %v got %v", tc.desc, tc.path, path))
+	}
+}
+
+func TestEventRecords(t *testing.T) {
+	evTime := time.Unix(1500000000, 0)
+	n := node{name: nodeName, fields: []string{"Message", "Severity", timeField}}
+
+	recs := eventRecords(n, []*uaGopcua.Variant{
+		uaGopcua.MustVariant(&uaGopcua.LocalizedText{Text: "Overheated"}),
+		uaGopcua.MustVariant(uint16(500)),
+		uaGopcua.MustVariant(evTime),
+		uaGopcua.MustVariant("unselected"),
+	})
+	require.Len(t, recs, 3, fmt.Sprintf("expected 3 records got %d", len(recs)))
+
+	names := []string{nodeName + "/Message", nodeName + "/Severity", nodeName + "/Time"}
+	for i, rec := range recs {
+		assert.Equal(t, names[i], rec.Name, fmt.Sprintf("expected record name %s got %s", names[i], rec.Name))
+		assert.Equal(t, senmlTime(evTime), rec.Time, fmt.Sprintf("%s: expected time %f got %f", rec.Name, senmlTime(evTime), rec.Time))
+	}
+	require.NotNil(t, recs[0].StringValue, "expected string value of message")
+	assert.Equal(t, "Overheated", *recs[0].StringValue, fmt.Sprintf("expected message Overheated got %s", *recs[0].StringValue))
+	require.NotNil(t, recs[1].Value, "expected value of severity")
+	assert.Equal(t, float64(500), *recs[1].Value, fmt.Sprintf("expected severity 500 got %f", *recs[1].Value))
+}
+
+func TestPublish(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := newClient(t, ctx)
+	ps := memory.NewPubSub()
+	c.publisher = ps
+	msgs := make(chan messaging.Message, 1)
+	err := ps.Subscribe("channels.>", func(msg messaging.Message) error {
+		msgs <- msg
+		return nil
+	})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	err = c.channelsRM.Save(serverURI, chanID)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = c.thingsRM.Save(nodeID, thingID)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = c.connectRM.Save(fmt.Sprintf("%s:%s", chanID, thingID), "connected")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	recs := []senml.Record{floatRecord(nodeName+"/0", 1), floatRecord(nodeName+"/1", 2)}
+	payload, err := senml.Encode(senml.Pack{Records: recs}, senml.JSON)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc    string
+		msg     message
+		err     error
+		payload []byte
+	}{
+		{
+			desc:    "publish records",
+			msg:     message{ServerURI: serverURI, NodeID: nodeID, Records: recs},
+			err:     nil,
+			payload: payload,
+		},
+		{
+			desc: "publish without records",
+			msg:  message{ServerURI: serverURI, NodeID: nodeID},
+			err:  nil,
+		},
+		{
+			desc: "publish from unknown server",
+			msg:  message{ServerURI: otherServerURI, NodeID: nodeID, Records: recs},
+			err:  errNotFoundServerURI,
+		},
+		{
+			desc: "publish from unknown node",
+			msg:  message{ServerURI: serverURI, NodeID: "ns=2;i=2", Records: recs},
+			err:  errNotFoundNodeID,
+		},
+	}
+
+	for _, tc := range cases {
+		err := c.publish("", tc.msg)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", tc.desc, tc.err, err))
+		if tc.payload == nil {
+			assert.Len(t, msgs, 0, fmt.Sprintf("%s: expected no messages", tc.desc))
+			continue
+		}
+		msg := <-msgs
+		assert.Equal(t, chanID, msg.Channel, fmt.Sprintf("%s: expected channel %s got %s", tc.desc, chanID, msg.Channel))
+		assert.Equal(t, thingID, msg.Publisher, fmt.Sprintf("%s: expected publisher %s got %s", tc.desc, thingID, msg.Publisher))
+		assert.Equal(t, nodeID, msg.Subtopic, fmt.Sprintf("%s: expected subtopic %s got %s", tc.desc, nodeID, msg.Subtopic))
+		assert.Equal(t, tc.payload, msg.Payload, fmt.Sprintf("%s: expected payload %s got %s", tc.desc, tc.payload, msg.Payload))
+	}
+}
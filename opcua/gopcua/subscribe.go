@@ -5,20 +5,37 @@ package gopcua
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	opcuaGopcua "github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/id"
 	uaGopcua "github.com/gopcua/opcua/ua"
 	"github.com/mainflux/mainflux/logger"
 	"github.com/mainflux/mainflux/opcua"
 	"github.com/mainflux/mainflux/pkg/errors"
 	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/senml"
 )
 
-const protocol = "opcua"
-const token = ""
+const (
+	protocol = "opcua"
+	token    = ""
+
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+
+	// Maximum duration of waiting for the session to write the value.
+	writeTimeout = 10 * time.Second
+
+	timeField  = "Time"
+	noSecurity = "None"
+)
 
 var (
 	errNotFoundServerURI = errors.New("route map not found for Server URI")
@@ -32,11 +49,25 @@ var (
 	errFailedFindEndpoint  = errors.New("failed to find suitable endpoint")
 	errFailedFetchEndpoint = errors.New("failed to fetch OPC-UA server endpoints")
 	errFailedParseNodeID   = errors.New("failed to parse NodeID")
+	errFailedParseEvent    = errors.New("failed to parse event type")
+	errFailedUserToken     = errors.New("failed to load user token")
+	errInvalidCert         = errors.New("invalid user certificate")
+	errFailedEncode        = errors.New("failed to encode SenML message")
 	errFailedCreateReq     = errors.New("failed to create request")
+	errFailedDeleteReq     = errors.New("failed to delete monitored item")
 	errResponseStatus      = errors.New("response status not OK")
+	errSessionClosed       = errors.New("session closed")
+	errNoSession           = errors.New("session not established")
 )
 
-var _ opcua.Subscriber = (*client)(nil)
+// Client represents the OPC-UA Server client which monitors and writes the
+// Node values using the same session per Server URI.
+type Client interface {
+	opcua.Subscriber
+	opcua.Writer
+}
+
+var _ Client = (*client)(nil)
 
 type client struct {
 	ctx        context.Context
@@ -45,107 +76,208 @@ type client struct {
 	channelsRM opcua.RouteMapRepository
 	connectRM  opcua.RouteMapRepository
 	logger     logger.Logger
+	// writeTimeout is the maximum duration of waiting for the session
+	// to write the value.
+	writeTimeout time.Duration
+
+	mu      sync.Mutex
+	servers map[string]*server
+}
+
+// server represents the OPC-UA Server session and subscription
+// shared by all the monitored nodes of the server.
+type server struct {
+	cfg    opcua.Config
+	cancel context.CancelFunc
+	// done is closed once the session is closed and no longer reestablished.
+	done chan struct{}
+
+	mu sync.Mutex
+	// ready is closed once the session is open, and replaced when the
+	// session is closed.
+	ready   chan struct{}
+	oc      *opcuaGopcua.Client
+	sub     *opcuaGopcua.Subscription
+	nodes   map[string]*node
+	handles map[uint32]string
+	next    uint32
+}
+
+// node represents the monitored item of the OPC-UA Server subscription.
+// Nodes with event fields are monitored for events instead of value changes.
+type node struct {
+	id     *uaGopcua.NodeID
+	name   string
+	fields []string
+	filter *uaGopcua.ExtensionObject
+	handle uint32
+	itemID uint32
 }
 
 type message struct {
 	ServerURI string
 	NodeID    string
-	Type      string
-	Time      int64
-	DataKey   string
-	Data      interface{}
+	Records   []senml.Record
 }
 
-// NewSubscriber returns new OPC-UA client instance.
-func NewSubscriber(ctx context.Context, publisher messaging.Publisher, thingsRM, channelsRM, connectRM opcua.RouteMapRepository, log logger.Logger) opcua.Subscriber {
-	return client{
-		ctx:        ctx,
-		publisher:  publisher,
-		thingsRM:   thingsRM,
-		channelsRM: channelsRM,
-		connectRM:  connectRM,
-		logger:     log,
+// NewClient returns new OPC-UA client instance.
+func NewClient(ctx context.Context, publisher messaging.Publisher, thingsRM, channelsRM, connectRM opcua.RouteMapRepository, log logger.Logger) Client {
+	return &client{
+		ctx:          ctx,
+		publisher:    publisher,
+		thingsRM:     thingsRM,
+		channelsRM:   channelsRM,
+		connectRM:    connectRM,
+		logger:       log,
+		writeTimeout: writeTimeout,
+		servers:      make(map[string]*server),
 	}
 }
 
-// Subscribe subscribes to the OPC-UA Server.
-func (c client) Subscribe(cfg opcua.Config) error {
-	opts := []opcuaGopcua.Option{
-		opcuaGopcua.SecurityMode(uaGopcua.MessageSecurityModeNone),
+// Subscribe adds the NodeID to the OPC-UA Server subscription. Session
+// with the server is opened on the first subscribed NodeID and
+// reestablished if the connection with the server is lost.
+func (c *client) Subscribe(cfg opcua.Config) error {
+	nodeID, err := uaGopcua.ParseNodeID(cfg.NodeID)
+	if err != nil {
+		return errors.Wrap(errFailedParseNodeID, err)
 	}
 
-	if cfg.Mode != "" {
-		endpoints, err := opcuaGopcua.GetEndpoints(cfg.ServerURI)
+	n := &node{id: nodeID}
+	if len(cfg.Events.Fields) > 0 {
+		filter, err := eventFilter(cfg.Events)
 		if err != nil {
-			return errors.Wrap(errFailedFetchEndpoint, err)
+			return err
 		}
+		n.fields = cfg.Events.Fields
+		n.filter = filter
+	}
 
-		ep := opcuaGopcua.SelectEndpoint(endpoints, cfg.Policy, uaGopcua.MessageSecurityModeFromString(cfg.Mode))
-		if ep == nil {
-			return errFailedFindEndpoint
-		}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-		opts = []opcuaGopcua.Option{
-			opcuaGopcua.SecurityPolicy(cfg.Policy),
-			opcuaGopcua.SecurityModeString(cfg.Mode),
-			opcuaGopcua.CertificateFile(cfg.CertFile),
-			opcuaGopcua.PrivateKeyFile(cfg.KeyFile),
-			opcuaGopcua.AuthAnonymous(),
-			opcuaGopcua.SecurityFromEndpoint(ep, uaGopcua.UserTokenTypeAnonymous),
-		}
-	}
+	return c.server(cfg).add(cfg.NodeID, n)
+}
+
+// Unsubscribe removes the NodeID from the OPC-UA Server subscription.
+// Session with the server is closed when the last NodeID is removed.
+func (c *client) Unsubscribe(cfg opcua.Config) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	oc := opcuaGopcua.NewClient(cfg.ServerURI, opts...)
-	if err := oc.Connect(c.ctx); err != nil {
-		return errors.Wrap(errFailedConn, err)
+	srv, ok := c.servers[cfg.ServerURI]
+	if !ok {
+		return nil
 	}
-	defer oc.Close()
 
-	i, err := strconv.Atoi(cfg.Interval)
-	if err != nil {
-		return errors.Wrap(errFailedParseInterval, err)
+	err := srv.remove(cfg.NodeID)
+	if srv.empty() {
+		srv.cancel()
+		delete(c.servers, cfg.ServerURI)
 	}
 
-	sub, err := oc.Subscribe(&opcuaGopcua.SubscriptionParameters{
-		Interval: time.Duration(i) * time.Millisecond,
-	})
-	if err != nil {
-		return errors.Wrap(errFailedSub, err)
+	return err
+}
+
+// server returns the session with the OPC-UA Server, opening it if the
+// server has no session. Must be called with the client lock held.
+func (c *client) server(cfg opcua.Config) *server {
+	srv, ok := c.servers[cfg.ServerURI]
+	if ok {
+		return srv
 	}
-	defer sub.Cancel()
 
-	if err := c.runHandler(sub, cfg.ServerURI, cfg.NodeID); err != nil {
-		c.logger.Warn(fmt.Sprintf("Unsubscribed from OPC-UA node %s.%s: %s", cfg.ServerURI, cfg.NodeID, err))
+	ctx, cancel := context.WithCancel(c.ctx)
+	srv = &server{
+		cfg:     cfg,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		ready:   make(chan struct{}),
+		nodes:   make(map[string]*node),
+		handles: make(map[uint32]string),
 	}
+	c.servers[cfg.ServerURI] = srv
+	go c.run(ctx, srv)
 
-	return nil
+	return srv
+}
+
+// run keeps the session with the OPC-UA Server open, reconnecting
+// with exponential backoff until the context is canceled.
+func (c *client) run(ctx context.Context, srv *server) {
+	defer close(srv.done)
+
+	backoff := minBackoff
+	for {
+		established, err := c.session(ctx, srv)
+		if ctx.Err() != nil {
+			return
+		}
+		if established {
+			backoff = minBackoff
+		}
+
+		c.logger.Warn(fmt.Sprintf("Session with OPC-UA server %s failed: %s, reconnecting in %s", srv.cfg.ServerURI, err, backoff))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
 }
 
-func (c client) runHandler(sub *opcuaGopcua.Subscription, uri, node string) error {
-	nodeID, err := uaGopcua.ParseNodeID(node)
+// session connects to the OPC-UA Server, monitors all the subscribed nodes and
+// handles notifications until the connection is lost or the context is canceled.
+func (c *client) session(ctx context.Context, srv *server) (bool, error) {
+	opts, err := clientOptions(srv.cfg)
 	if err != nil {
-		return errors.Wrap(errFailedParseNodeID, err)
+		return false, err
 	}
 
-	// arbitrary client handle for the monitoring item
-	handle := uint32(42)
-	miCreateRequest := opcuaGopcua.NewMonitoredItemCreateRequestWithDefaults(nodeID, uaGopcua.AttributeIDValue, handle)
-	res, err := sub.Monitor(uaGopcua.TimestampsToReturnBoth, miCreateRequest)
+	i, err := strconv.Atoi(srv.cfg.Interval)
 	if err != nil {
-		return errors.Wrap(errFailedCreateReq, err)
+		return false, errors.Wrap(errFailedParseInterval, err)
 	}
-	if res.Results[0].StatusCode != uaGopcua.StatusOK {
-		return errResponseStatus
+
+	oc := opcuaGopcua.NewClient(srv.cfg.ServerURI, opts...)
+	if err := oc.Connect(ctx); err != nil {
+		return false, errors.Wrap(errFailedConn, err)
+	}
+	defer oc.Close()
+
+	sub, err := oc.Subscribe(&opcuaGopcua.SubscriptionParameters{
+		Interval: time.Duration(i) * time.Millisecond,
+	})
+	if err != nil {
+		return false, errors.Wrap(errFailedSub, err)
 	}
+	defer sub.Cancel()
 
-	go sub.Run(c.ctx)
+	srv.start(oc, sub, c.logger)
+	defer srv.stop()
 
-	c.logger.Info(fmt.Sprintf("subscribed to server %s and node_id %s", uri, node))
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		sub.Run(runCtx)
+		close(done)
+	}()
+
+	c.logger.Info(fmt.Sprintf("subscribed to server %s", srv.cfg.ServerURI))
 
 	for {
 		select {
-		case <-c.ctx.Done():
-			return nil
+		case <-ctx.Done():
+			return true, nil
+		case <-done:
+			return true, errSessionClosed
 		case res := <-sub.Notifs:
 			if res.Error != nil {
 				c.logger.Error(res.Error.Error())
@@ -155,45 +287,35 @@ func (c client) runHandler(sub *opcuaGopcua.Subscription, uri, node string) erro
 			switch x := res.Value.(type) {
 			case *uaGopcua.DataChangeNotification:
 				for _, item := range x.MonitoredItems {
+					nodeID, n, ok := srv.node(item.ClientHandle)
+					if !ok || item.Value == nil {
+						continue
+					}
+
 					msg := message{
-						ServerURI: uri,
-						NodeID:    node,
-						Type:      item.Value.Value.Type().String(),
-						Time:      item.Value.SourceTimestamp.Unix(),
-						DataKey:   "v",
+						ServerURI: srv.cfg.ServerURI,
+						NodeID:    nodeID,
+						Records:   toRecords(n.name, item.Value.Value, senmlTime(item.Value.SourceTimestamp)),
+					}
+					if err := c.publish(token, msg); err != nil {
+						c.logger.Warn(fmt.Sprintf("Failed to publish from node %s: %s", nodeID, err))
 					}
+				}
 
-					switch item.Value.Value.Type() {
-					case uaGopcua.TypeIDBoolean:
-						msg.DataKey = "vb"
-						msg.Data = item.Value.Value.Bool()
-					case uaGopcua.TypeIDString, uaGopcua.TypeIDByteString:
-						msg.DataKey = "vs"
-						msg.Data = item.Value.Value.String()
-					case uaGopcua.TypeIDDataValue:
-						msg.DataKey = "vd"
-						msg.Data = item.Value.Value.String()
-					case uaGopcua.TypeIDInt64, uaGopcua.TypeIDInt32, uaGopcua.TypeIDInt16:
-						msg.Data = float64(item.Value.Value.Int())
-					case uaGopcua.TypeIDUint64, uaGopcua.TypeIDUint32, uaGopcua.TypeIDUint16:
-						msg.Data = float64(item.Value.Value.Uint())
-					case uaGopcua.TypeIDFloat, uaGopcua.TypeIDDouble:
-						msg.Data = item.Value.Value.Float()
-					case uaGopcua.TypeIDByte:
-						msg.Data = float64(item.Value.Value.Uint())
-					case uaGopcua.TypeIDDateTime:
-						msg.Data = item.Value.Value.Time().Unix()
-					default:
-						msg.Data = 0
+			case *uaGopcua.EventNotificationList:
+				for _, event := range x.Events {
+					nodeID, n, ok := srv.node(event.ClientHandle)
+					if !ok {
+						continue
 					}
 
+					msg := message{
+						ServerURI: srv.cfg.ServerURI,
+						NodeID:    nodeID,
+						Records:   eventRecords(n, event.EventFields),
+					}
 					if err := c.publish(token, msg); err != nil {
-						switch err {
-						case errNotFoundServerURI, errNotFoundNodeID, errNotFoundConn:
-							return err
-						default:
-							c.logger.Error(fmt.Sprintf("Failed to publish: %s", err))
-						}
+						c.logger.Warn(fmt.Sprintf("Failed to publish event from node %s: %s", nodeID, err))
 					}
 				}
 
@@ -205,7 +327,7 @@ func (c client) runHandler(sub *opcuaGopcua.Subscription, uri, node string) erro
 }
 
 // Publish forwards messages from the OPC-UA Server to Mainflux NATS broker
-func (c client) publish(token string, m message) error {
+func (c *client) publish(token string, m message) error {
 	// Get route-map of the OPC-UA ServerURI
 	chanID, err := c.channelsRM.Get(m.ServerURI)
 	if err != nil {
@@ -224,9 +346,15 @@ func (c client) publish(token string, m message) error {
 		return fmt.Errorf("%s between channel %s and thing %s", errNotFoundConn, chanID, thingID)
 	}
 
+	if len(m.Records) == 0 {
+		return nil
+	}
+
 	// Publish on Mainflux NATS broker
-	SenML := fmt.Sprintf(`[{"n":"%s", "t": %d, "%s":%v}]`, m.Type, m.Time, m.DataKey, m.Data)
-	payload := []byte(SenML)
+	payload, err := senml.Encode(senml.Pack{Records: m.Records}, senml.JSON)
+	if err != nil {
+		return errors.Wrap(errFailedEncode, err)
+	}
 
 	msg := messaging.Message{
 		Publisher: thingID,
@@ -241,6 +369,370 @@ func (c client) publish(token string, m message) error {
 		return err
 	}
 
-	c.logger.Info(fmt.Sprintf("publish from server %s and node_id %s with value %v", m.ServerURI, m.NodeID, m.Data))
+	c.logger.Info(fmt.Sprintf("publish from server %s and node_id %s with %d records", m.ServerURI, m.NodeID, len(m.Records)))
 	return nil
 }
+
+// add registers the node and monitors it if the session is open.
+func (s *server) add(id string, n *node) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.nodes[id]; ok {
+		return nil
+	}
+
+	s.next++
+	n.handle = s.next
+	s.nodes[id] = n
+	s.handles[n.handle] = id
+
+	if s.sub == nil {
+		return nil
+	}
+
+	return s.monitor(n)
+}
+
+// remove unregisters the node and stops monitoring it if the session is open.
+func (s *server) remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.nodes[id]
+	if !ok {
+		return nil
+	}
+	delete(s.nodes, id)
+	delete(s.handles, n.handle)
+
+	if s.sub == nil || n.itemID == 0 {
+		return nil
+	}
+
+	res, err := s.sub.Unmonitor(n.itemID)
+	if err != nil {
+		return errors.Wrap(errFailedDeleteReq, err)
+	}
+	if len(res.Results) == 0 || res.Results[0] != uaGopcua.StatusOK {
+		return errResponseStatus
+	}
+
+	return nil
+}
+
+func (s *server) empty() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.nodes) == 0
+}
+
+func (s *server) node(handle uint32) (string, node, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, ok := s.handles[handle]
+	if !ok {
+		return "", node{}, false
+	}
+
+	return id, *s.nodes[id], true
+}
+
+// start monitors all the registered nodes using the new subscription.
+func (s *server) start(oc *opcuaGopcua.Client, sub *opcuaGopcua.Subscription, log logger.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.oc = oc
+	s.sub = sub
+	close(s.ready)
+
+	events := false
+	for id, n := range s.nodes {
+		if err := s.monitor(n); err != nil {
+			log.Warn(fmt.Sprintf("Failed to monitor node %s of server %s: %s", id, s.cfg.ServerURI, err))
+		}
+		events = events || n.filter != nil
+	}
+
+	if events {
+		// Request the current state of the conditions (alarms) so that the
+		// active ones are reported after (re)connecting.
+		if err := s.refresh(); err != nil {
+			log.Debug(fmt.Sprintf("Failed to refresh conditions of server %s: %s", s.cfg.ServerURI, err))
+		}
+	}
+}
+
+// stop resets monitored items of the closed subscription.
+func (s *server) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.oc = nil
+	s.sub = nil
+	s.ready = make(chan struct{})
+	for _, n := range s.nodes {
+		n.itemID = 0
+	}
+}
+
+// session waits until the session is open and returns its client.
+func (s *server) session(ctx context.Context) (*opcuaGopcua.Client, error) {
+	s.mu.Lock()
+	ready := s.ready
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+	case <-s.done:
+		return nil, errSessionClosed
+	case <-ctx.Done():
+		return nil, errNoSession
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.oc == nil {
+		return nil, errSessionClosed
+	}
+	return s.oc, nil
+}
+
+func (s *server) monitor(n *node) error {
+	if n.name == "" {
+		n.name = s.browseName(n.id)
+	}
+
+	req := opcuaGopcua.NewMonitoredItemCreateRequestWithDefaults(n.id, uaGopcua.AttributeIDValue, n.handle)
+	if n.filter != nil {
+		req.ItemToMonitor.AttributeID = uaGopcua.AttributeIDEventNotifier
+		req.RequestedParameters.Filter = n.filter
+	}
+
+	res, err := s.sub.Monitor(uaGopcua.TimestampsToReturnBoth, req)
+	if err != nil {
+		return errors.Wrap(errFailedCreateReq, err)
+	}
+	if len(res.Results) == 0 || res.Results[0].StatusCode != uaGopcua.StatusOK {
+		return errResponseStatus
+	}
+
+	n.itemID = res.Results[0].MonitoredItemID
+	return nil
+}
+
+// browseName returns the node browse name used as SenML name of its records.
+// If the browse name can't be read, the NodeID is used instead.
+func (s *server) browseName(nodeID *uaGopcua.NodeID) string {
+	req := &uaGopcua.ReadRequest{
+		NodesToRead: []*uaGopcua.ReadValueID{
+			{NodeID: nodeID, AttributeID: uaGopcua.AttributeIDBrowseName},
+		},
+		TimestampsToReturn: uaGopcua.TimestampsToReturnNeither,
+	}
+
+	res, err := s.oc.Read(req)
+	if err == nil && len(res.Results) > 0 && res.Results[0].Status == uaGopcua.StatusOK && res.Results[0].Value != nil {
+		if qn, ok := res.Results[0].Value.Value().(*uaGopcua.QualifiedName); ok {
+			if name := senmlName(qn.Name); name != "" {
+				return name
+			}
+		}
+	}
+
+	return senmlName(nodeID.String())
+}
+
+// refresh calls ConditionRefresh method on the server.
+func (s *server) refresh() error {
+	req := &uaGopcua.CallMethodRequest{
+		ObjectID:       uaGopcua.NewNumericNodeID(0, id.ConditionType),
+		MethodID:       uaGopcua.NewNumericNodeID(0, id.ConditionType_ConditionRefresh),
+		InputArguments: []*uaGopcua.Variant{uaGopcua.MustVariant(s.sub.SubscriptionID)},
+	}
+
+	res, err := s.oc.Call(req)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != uaGopcua.StatusOK {
+		return res.StatusCode
+	}
+
+	return nil
+}
+
+// eventFilter creates the event filter which selects the configured event
+// fields of the events of the configured type.
+func eventFilter(events opcua.EventFilter) (*uaGopcua.ExtensionObject, error) {
+	typeID := uaGopcua.NewNumericNodeID(0, id.BaseEventType)
+	if events.Type != "" {
+		t, err := uaGopcua.ParseNodeID(events.Type)
+		if err != nil {
+			return nil, errors.Wrap(errFailedParseEvent, err)
+		}
+		typeID = t
+	}
+
+	filter := &uaGopcua.EventFilter{
+		WhereClause: &uaGopcua.ContentFilter{},
+	}
+	for _, f := range events.Fields {
+		filter.SelectClauses = append(filter.SelectClauses, &uaGopcua.SimpleAttributeOperand{
+			TypeDefinitionID: typeID,
+			BrowsePath:       browsePath(f),
+			AttributeID:      uaGopcua.AttributeIDValue,
+		})
+	}
+
+	if events.Type != "" {
+		filter.WhereClause.Elements = []*uaGopcua.ContentFilterElement{
+			{
+				FilterOperator: uaGopcua.FilterOperatorOfType,
+				FilterOperands: []*uaGopcua.ExtensionObject{
+					{
+						EncodingMask: uaGopcua.ExtensionObjectBinary,
+						TypeID:       uaGopcua.NewFourByteExpandedNodeID(0, id.LiteralOperand_Encoding_DefaultBinary),
+						Value:        &uaGopcua.LiteralOperand{Value: uaGopcua.MustVariant(typeID)},
+					},
+				},
+			},
+		}
+	}
+
+	return &uaGopcua.ExtensionObject{
+		EncodingMask: uaGopcua.ExtensionObjectBinary,
+		TypeID:       uaGopcua.NewFourByteExpandedNodeID(0, id.EventFilter_Encoding_DefaultBinary),
+		Value:        filter,
+	}, nil
+}
+
+// browsePath parses the event field browse path. Path elements are separated
+// by slash and may be prefixed with the namespace index (e.g. "2:Temperature").
+func browsePath(field string) []*uaGopcua.QualifiedName {
+	var path []*uaGopcua.QualifiedName
+	for _, p := range strings.Split(field, "/") {
+		qn := &uaGopcua.QualifiedName{Name: p}
+		if i := strings.Index(p, ":"); i > 0 {
+			if ns, err := strconv.ParseUint(p[:i], 10, 16); err == nil {
+				qn.NamespaceIndex = uint16(ns)
+				qn.Name = p[i+1:]
+			}
+		}
+		path = append(path, qn)
+	}
+
+	return path
+}
+
+// eventRecords converts the selected event fields to SenML records named
+// by the field browse path. Event time is taken from the Time field if
+// it is selected.
+func eventRecords(n node, fields []*uaGopcua.Variant) []senml.Record {
+	t := senmlTime(time.Time{})
+	for i, f := range fields {
+		if i < len(n.fields) && n.fields[i] == timeField && f != nil && f.Type() == uaGopcua.TypeIDDateTime {
+			t = senmlTime(f.Time())
+		}
+	}
+
+	var recs []senml.Record
+	for i, f := range fields {
+		if i >= len(n.fields) {
+			break
+		}
+		name := fmt.Sprintf("%s/%s", n.name, senmlName(n.fields[i]))
+		recs = append(recs, toRecords(name, f, t)...)
+	}
+
+	return recs
+}
+
+// clientOptions returns OPC-UA client options for the configured security mode,
+// policy and user token.
+func clientOptions(cfg opcua.Config) ([]opcuaGopcua.Option, error) {
+	anonymous := cfg.UserToken.Type == "" || cfg.UserToken.Type == opcua.UserTokenAnonymous
+	if cfg.Mode == "" && anonymous {
+		return []opcuaGopcua.Option{
+			opcuaGopcua.SecurityMode(uaGopcua.MessageSecurityModeNone),
+		}, nil
+	}
+
+	mode, policy := cfg.Mode, cfg.Policy
+	if mode == "" {
+		mode, policy = noSecurity, noSecurity
+	}
+
+	endpoints, err := opcuaGopcua.GetEndpoints(cfg.ServerURI)
+	if err != nil {
+		return nil, errors.Wrap(errFailedFetchEndpoint, err)
+	}
+
+	ep := opcuaGopcua.SelectEndpoint(endpoints, policy, uaGopcua.MessageSecurityModeFromString(mode))
+	if ep == nil {
+		return nil, errFailedFindEndpoint
+	}
+
+	auth, tokenType, err := authOption(cfg.UserToken, cfg.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []opcuaGopcua.Option{
+		opcuaGopcua.SecurityPolicy(policy),
+		opcuaGopcua.SecurityModeString(mode),
+	}
+	if cfg.Mode != "" {
+		opts = append(opts,
+			opcuaGopcua.CertificateFile(cfg.CertFile),
+			opcuaGopcua.PrivateKeyFile(cfg.KeyFile),
+		)
+	}
+
+	return append(opts, auth, opcuaGopcua.SecurityFromEndpoint(ep, tokenType)), nil
+}
+
+// authOption returns the OPC-UA client option which sets the user identity
+// with the secrets resolved from the given ones.
+func authOption(token opcua.UserToken, secrets opcua.Secrets) (opcuaGopcua.Option, uaGopcua.UserTokenType, error) {
+	switch token.Type {
+	case opcua.UserTokenUsername:
+		pass, err := secrets.Secret(token.Password)
+		if err != nil {
+			return nil, 0, errors.Wrap(errFailedUserToken, err)
+		}
+		return opcuaGopcua.AuthUsername(token.Username, pass), uaGopcua.UserTokenTypeUserName, nil
+	case opcua.UserTokenCertificate:
+		cert, err := loadCert(secrets, token.Certificate)
+		if err != nil {
+			return nil, 0, errors.Wrap(errFailedUserToken, err)
+		}
+		return opcuaGopcua.AuthCertificate(cert), uaGopcua.UserTokenTypeCertificate, nil
+	default:
+		return opcuaGopcua.AuthAnonymous(), uaGopcua.UserTokenTypeAnonymous, nil
+	}
+}
+
+// loadCert returns DER encoded certificate from the PEM file in the secrets
+// directory. Files other than PEM certificates are rejected, so that their
+// content is never sent to the server.
+func loadCert(secrets opcua.Secrets, path string) ([]byte, error) {
+	b, err := secrets.File(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errInvalidCert
+	}
+	if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+		return nil, errors.Wrap(errInvalidCert, err)
+	}
+
+	return block.Bytes, nil
+}
@@ -0,0 +1,314 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package gopcua
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	uaGopcua "github.com/gopcua/opcua/ua"
+	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/opcua"
+	"github.com/mainflux/mainflux/opcua/mocks"
+	authmocks "github.com/mainflux/mainflux/pkg/auth/mocks"
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// Nothing listens on the servers, so sessions are reestablished
+	// until the subscription is canceled.
+	serverURI      = "opc.tcp://127.0.0.1:1"
+	otherServerURI = "opc.tcp://127.0.0.1:2"
+	interval       = "1000"
+	timeout        = 5 * time.Second
+	envPrefix      = "MF_OPCUA_SECRET_"
+	passEnv        = envPrefix + "TEST_PASSWORD"
+)
+
+func newClient(t *testing.T, ctx context.Context) *client {
+	l, err := logger.New(ioutil.Discard, "error")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	rm := mocks.NewRouteMapRepository()
+	return NewClient(ctx, nil, rm, rm, rm, l).(*client)
+}
+
+func closed(done chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func TestSubscribeSharesSession(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := newClient(t, ctx)
+
+	cases := []struct {
+		desc    string
+		cfg     opcua.Config
+		servers int
+		nodes   int
+		err     error
+	}{
+		{
+			desc:    "subscribe to the first node of the server",
+			cfg:     opcua.Config{ServerURI: serverURI, NodeID: "ns=2;s=A", Interval: interval},
+			servers: 1,
+			nodes:   1,
+			err:     nil,
+		},
+		{
+			desc:    "subscribe to another node of the server",
+			cfg:     opcua.Config{ServerURI: serverURI, NodeID: "ns=2;s=B", Interval: interval},
+			servers: 1,
+			nodes:   2,
+			err:     nil,
+		},
+		{
+			desc:    "subscribe to the subscribed node",
+			cfg:     opcua.Config{ServerURI: serverURI, NodeID: "ns=2;s=A", Interval: interval},
+			servers: 1,
+			nodes:   2,
+			err:     nil,
+		},
+		{
+			desc: "subscribe to node events",
+			cfg: opcua.Config{ServerURI: serverURI, NodeID: "ns=2;s=C", Interval: interval,
+				Events: opcua.EventFilter{Fields: []string{"Message", "Severity"}}},
+			servers: 1,
+			nodes:   3,
+			err:     nil,
+		},
+		{
+			desc:    "subscribe to invalid node",
+			cfg:     opcua.Config{ServerURI: serverURI, NodeID: "i=abc", Interval: interval},
+			servers: 1,
+			nodes:   3,
+			err:     errFailedParseNodeID,
+		},
+		{
+			desc: "subscribe to events of invalid type",
+			cfg: opcua.Config{ServerURI: serverURI, NodeID: "ns=2;s=D", Interval: interval,
+				Events: opcua.EventFilter{Type: "i=abc", Fields: []string{"Message"}}},
+			servers: 1,
+			nodes:   3,
+			err:     errFailedParseEvent,
+		},
+		{
+			desc:    "subscribe to node of another server",
+			cfg:     opcua.Config{ServerURI: otherServerURI, NodeID: "ns=2;s=A", Interval: interval},
+			servers: 2,
+			nodes:   3,
+			err:     nil,
+		},
+	}
+
+	for _, tc := range cases {
+		err := c.Subscribe(tc.cfg)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+
+		c.mu.Lock()
+		servers := len(c.servers)
+		srv := c.servers[serverURI]
+		c.mu.Unlock()
+		assert.Equal(t, tc.servers, servers, fmt.Sprintf("%s: expected %d sessions got %d", tc.desc, tc.servers, servers))
+		srv.mu.Lock()
+		nodes := len(srv.nodes)
+		srv.mu.Unlock()
+		assert.Equal(t, tc.nodes, nodes, fmt.Sprintf("%s: expected %d nodes got %d", tc.desc, tc.nodes, nodes))
+	}
+
+	srv := c.servers[serverURI]
+	handles := map[uint32]bool{}
+	for id, n := range srv.nodes {
+		assert.False(t, handles[n.handle], fmt.Sprintf("expected unique handle of node %s", id))
+		handles[n.handle] = true
+		hid, _, ok := srv.node(n.handle)
+		assert.True(t, ok, fmt.Sprintf("expected node %s to be found by handle", id))
+		assert.Equal(t, id, hid, fmt.Sprintf("expected node %s got %s", id, hid))
+	}
+	_, n, _ := srv.node(srv.nodes["ns=2;s=C"].handle)
+	assert.NotNil(t, n.filter, "expected event node to have event filter")
+	assert.Equal(t, []string{"Message", "Severity"}, n.fields, fmt.Sprintf("expected event fields %v got %v", []string{"Message", "Severity"}, n.fields))
+}
+
+func TestUnsubscribeClosesSession(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := newClient(t, ctx)
+
+	nodes := []string{"ns=2;s=A", "ns=2;s=B"}
+	for _, id := range nodes {
+		err := c.Subscribe(opcua.Config{ServerURI: serverURI, NodeID: id, Interval: interval})
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	}
+	err := c.Subscribe(opcua.Config{ServerURI: otherServerURI, NodeID: nodes[0], Interval: interval})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	srv := c.servers[serverURI]
+	other := c.servers[otherServerURI]
+
+	err = c.Unsubscribe(opcua.Config{ServerURI: serverURI, NodeID: nodes[0]})
+	assert.Nil(t, err, fmt.Sprintf("unsubscribe node: got unexpected error: %s", err))
+	assert.Equal(t, srv, c.servers[serverURI], "unsubscribe node: expected session to be kept for remaining node")
+	select {
+	case <-srv.done:
+		assert.Fail(t, "unsubscribe node: expected session to be kept for remaining node")
+	default:
+	}
+
+	err = c.Unsubscribe(opcua.Config{ServerURI: serverURI, NodeID: nodes[0]})
+	assert.Nil(t, err, fmt.Sprintf("unsubscribe unsubscribed node: got unexpected error: %s", err))
+
+	err = c.Unsubscribe(opcua.Config{ServerURI: serverURI, NodeID: nodes[1]})
+	assert.Nil(t, err, fmt.Sprintf("unsubscribe last node: got unexpected error: %s", err))
+	_, ok := c.servers[serverURI]
+	assert.False(t, ok, "unsubscribe last node: expected session to be removed")
+	assert.True(t, closed(srv.done), "unsubscribe last node: expected session to be closed")
+
+	err = c.Unsubscribe(opcua.Config{ServerURI: serverURI, NodeID: nodes[1]})
+	assert.Nil(t, err, fmt.Sprintf("unsubscribe from closed session: got unexpected error: %s", err))
+
+	// Subscribing again opens the new session.
+	err = c.Subscribe(opcua.Config{ServerURI: serverURI, NodeID: nodes[0], Interval: interval})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.NotEqual(t, srv, c.servers[serverURI], "subscribe after session closed: expected new session")
+
+	// Canceling the client context closes all the sessions.
+	cancel()
+	assert.True(t, closed(other.done), "cancel context: expected session to be closed")
+	assert.True(t, closed(c.servers[serverURI].done), "cancel context: expected session to be closed")
+}
+
+func TestAuthOption(t *testing.T) {
+	os.Setenv(passEnv, "secret")
+	defer os.Unsetenv(passEnv)
+
+	ca, err := authmocks.NewCA()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	cert, err := ca.Issue(1, "opcua-user")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	der := cert.Certificate[0]
+
+	dir, err := ioutil.TempDir("", "opcua-certs")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	defer os.RemoveAll(dir)
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		err := ioutil.WriteFile(path, data, 0600)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		return path
+	}
+	pemFile := write("user.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	derFile := write("user.der", der)
+	keyFile := write("user.key", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("key")}))
+	garbageFile := write("garbage.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("garbage")}))
+	textFile := write("passwd", []byte("root:x:0:0:root:/root:/bin/bash\n"))
+
+	outside, err := ioutil.TempFile("", "opcua-user.pem")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	defer os.Remove(outside.Name())
+	_, err = outside.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	outside.Close()
+
+	secrets := opcua.Secrets{Dir: dir, EnvPrefix: envPrefix}
+
+	cases := []struct {
+		desc      string
+		token     opcua.UserToken
+		tokenType uaGopcua.UserTokenType
+		err       error
+	}{
+		{
+			desc:      "anonymous user token",
+			token:     opcua.UserToken{},
+			tokenType: uaGopcua.UserTokenTypeAnonymous,
+			err:       nil,
+		},
+		{
+			desc:      "username user token",
+			token:     opcua.UserToken{Type: opcua.UserTokenUsername, Username: "user", Password: "env:" + passEnv},
+			tokenType: uaGopcua.UserTokenTypeUserName,
+			err:       nil,
+		},
+		{
+			desc:  "username user token with unresolved password",
+			token: opcua.UserToken{Type: opcua.UserTokenUsername, Username: "user", Password: "env:" + envPrefix + "UNSET"},
+			err:   errFailedUserToken,
+		},
+		{
+			desc:  "username user token with password in environment variable without prefix",
+			token: opcua.UserToken{Type: opcua.UserTokenUsername, Username: "user", Password: "env:HOME"},
+			err:   opcua.ErrSecretRef,
+		},
+		{
+			desc:      "certificate user token in PEM file",
+			token:     opcua.UserToken{Type: opcua.UserTokenCertificate, Certificate: "user.pem"},
+			tokenType: uaGopcua.UserTokenTypeCertificate,
+			err:       nil,
+		},
+		{
+			desc:      "certificate user token with absolute path in secrets directory",
+			token:     opcua.UserToken{Type: opcua.UserTokenCertificate, Certificate: pemFile},
+			tokenType: uaGopcua.UserTokenTypeCertificate,
+			err:       nil,
+		},
+		{
+			desc:  "certificate user token in DER file",
+			token: opcua.UserToken{Type: opcua.UserTokenCertificate, Certificate: derFile},
+			err:   errInvalidCert,
+		},
+		{
+			desc:  "certificate user token in PEM file without certificate",
+			token: opcua.UserToken{Type: opcua.UserTokenCertificate, Certificate: keyFile},
+			err:   errInvalidCert,
+		},
+		{
+			desc:  "certificate user token in PEM file with malformed certificate",
+			token: opcua.UserToken{Type: opcua.UserTokenCertificate, Certificate: garbageFile},
+			err:   errInvalidCert,
+		},
+		{
+			desc:  "certificate user token in text file",
+			token: opcua.UserToken{Type: opcua.UserTokenCertificate, Certificate: textFile},
+			err:   errInvalidCert,
+		},
+		{
+			desc:  "certificate user token outside of secrets directory",
+			token: opcua.UserToken{Type: opcua.UserTokenCertificate, Certificate: outside.Name()},
+			err:   opcua.ErrSecretRef,
+		},
+		{
+			desc:  "certificate user token in missing file",
+			token: opcua.UserToken{Type: opcua.UserTokenCertificate, Certificate: filepath.Join(dir, "missing")},
+			err:   errFailedUserToken,
+		},
+	}
+
+	for _, tc := range cases {
+		opt, tokenType, err := authOption(tc.token, secrets)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		if tc.err != nil {
+			continue
+		}
+		assert.NotNil(t, opt, fmt.Sprintf("%s: expected client option", tc.desc))
+		assert.Equal(t, tc.tokenType, tokenType, fmt.Sprintf("%s: expected token type %s got %s", tc.desc, tc.tokenType, tokenType))
+	}
+
+	loaded, err := loadCert(secrets, pemFile)
+	assert.Nil(t, err, fmt.Sprintf("load %s: got unexpected error: %s", pemFile, err))
+	assert.Equal(t, der, loaded, fmt.Sprintf("load %s: expected DER encoded certificate", pemFile))
+}
@@ -0,0 +1,172 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package gopcua
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	uaGopcua "github.com/gopcua/opcua/ua"
+	"github.com/mainflux/mainflux/opcua"
+	"github.com/mainflux/mainflux/pkg/errors"
+)
+
+var (
+	errFailedWrite      = errors.New("failed to write")
+	errUnsupportedType  = errors.New("unsupported node type")
+	errInvalidValueType = errors.New("invalid value type")
+)
+
+// Write writes the value to the NodeID using the session of the OPC-UA
+// Server. Servers without subscribed NodeIDs get the session on the first
+// write, which is kept open for the subsequent writes.
+func (c *client) Write(cfg opcua.Config, value interface{}) error {
+	nodeID, err := uaGopcua.ParseNodeID(cfg.NodeID)
+	if err != nil {
+		return errors.Wrap(errFailedParseNodeID, err)
+	}
+
+	c.mu.Lock()
+	srv := c.server(cfg)
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(c.ctx, c.writeTimeout)
+	defer cancel()
+
+	oc, err := srv.session(ctx)
+	if err != nil {
+		return errors.Wrap(errFailedWrite, err)
+	}
+
+	// Read the current value to find out the Node type.
+	rres, err := oc.Read(&uaGopcua.ReadRequest{
+		NodesToRead: []*uaGopcua.ReadValueID{
+			{NodeID: nodeID, AttributeID: uaGopcua.AttributeIDValue},
+		},
+		TimestampsToReturn: uaGopcua.TimestampsToReturnBoth,
+	})
+	if err != nil {
+		return errors.Wrap(errFailedRead, err)
+	}
+	if len(rres.Results) == 0 || rres.Results[0].Status != uaGopcua.StatusOK || rres.Results[0].Value == nil {
+		return errResponseStatus
+	}
+
+	v, err := toVariant(rres.Results[0].Value.Type(), value)
+	if err != nil {
+		return err
+	}
+
+	wres, err := oc.Write(&uaGopcua.WriteRequest{
+		NodesToWrite: []*uaGopcua.WriteValue{
+			{
+				NodeID:      nodeID,
+				AttributeID: uaGopcua.AttributeIDValue,
+				Value: &uaGopcua.DataValue{
+					EncodingMask: uaGopcua.DataValueValue,
+					Value:        v,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(errFailedWrite, err)
+	}
+	if len(wres.Results) == 0 || wres.Results[0] != uaGopcua.StatusOK {
+		return errResponseStatus
+	}
+
+	c.logger.Info(fmt.Sprintf("write to server %s and node_id %s with value %v", cfg.ServerURI, cfg.NodeID, value))
+	return nil
+}
+
+// toVariant converts SenML value to the variant of the given type.
+func toVariant(t uaGopcua.TypeID, value interface{}) (*uaGopcua.Variant, error) {
+	switch t {
+	case uaGopcua.TypeIDBoolean:
+		switch v := value.(type) {
+		case bool:
+			return uaGopcua.NewVariant(v)
+		case float64:
+			return uaGopcua.NewVariant(v != 0)
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, errors.Wrap(errInvalidValueType, err)
+			}
+			return uaGopcua.NewVariant(b)
+		}
+	case uaGopcua.TypeIDString:
+		return uaGopcua.NewVariant(fmt.Sprintf("%v", value))
+	case uaGopcua.TypeIDByteString:
+		return uaGopcua.NewVariant([]byte(fmt.Sprintf("%v", value)))
+	case uaGopcua.TypeIDDateTime:
+		f, err := toFloat(value)
+		if err != nil {
+			return nil, err
+		}
+		return uaGopcua.NewVariant(time.Unix(0, int64(f*float64(time.Second))))
+	case uaGopcua.TypeIDSByte, uaGopcua.TypeIDByte,
+		uaGopcua.TypeIDInt16, uaGopcua.TypeIDUint16,
+		uaGopcua.TypeIDInt32, uaGopcua.TypeIDUint32,
+		uaGopcua.TypeIDInt64, uaGopcua.TypeIDUint64,
+		uaGopcua.TypeIDFloat, uaGopcua.TypeIDDouble:
+		f, err := toFloat(value)
+		if err != nil {
+			return nil, err
+		}
+		return uaGopcua.NewVariant(toNumber(t, f))
+	default:
+		return nil, errUnsupportedType
+	}
+
+	return nil, errInvalidValueType
+}
+
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, errors.Wrap(errInvalidValueType, err)
+		}
+		return f, nil
+	default:
+		return 0, errInvalidValueType
+	}
+}
+
+func toNumber(t uaGopcua.TypeID, f float64) interface{} {
+	switch t {
+	case uaGopcua.TypeIDSByte:
+		return int8(f)
+	case uaGopcua.TypeIDByte:
+		return uint8(f)
+	case uaGopcua.TypeIDInt16:
+		return int16(f)
+	case uaGopcua.TypeIDUint16:
+		return uint16(f)
+	case uaGopcua.TypeIDInt32:
+		return int32(f)
+	case uaGopcua.TypeIDUint32:
+		return uint32(f)
+	case uaGopcua.TypeIDInt64:
+		return int64(f)
+	case uaGopcua.TypeIDUint64:
+		return uint64(f)
+	case uaGopcua.TypeIDFloat:
+		return float32(f)
+	default:
+		return f
+	}
+}
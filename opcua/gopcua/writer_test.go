@@ -0,0 +1,231 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package gopcua
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	uaGopcua "github.com/gopcua/opcua/ua"
+	"github.com/mainflux/mainflux/opcua"
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSharesSession(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := newClient(t, ctx)
+	c.writeTimeout = 100 * time.Millisecond
+
+	err := c.Subscribe(opcua.Config{ServerURI: serverURI, NodeID: "ns=2;s=A", Interval: interval})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	srv := c.servers[serverURI]
+
+	cases := []struct {
+		desc    string
+		cfg     opcua.Config
+		servers int
+		err     error
+	}{
+		{
+			desc:    "write to node of subscribed server",
+			cfg:     opcua.Config{ServerURI: serverURI, NodeID: "ns=2;s=B", Interval: interval},
+			servers: 1,
+			err:     errNoSession,
+		},
+		{
+			desc:    "write to invalid node",
+			cfg:     opcua.Config{ServerURI: otherServerURI, NodeID: "i=abc", Interval: interval},
+			servers: 1,
+			err:     errFailedParseNodeID,
+		},
+		{
+			desc:    "write to node of server without subscriptions",
+			cfg:     opcua.Config{ServerURI: otherServerURI, NodeID: "ns=2;s=A", Interval: interval},
+			servers: 2,
+			err:     errNoSession,
+		},
+		{
+			desc:    "write again to node of server without subscriptions",
+			cfg:     opcua.Config{ServerURI: otherServerURI, NodeID: "ns=2;s=A", Interval: interval},
+			servers: 2,
+			err:     errNoSession,
+		},
+	}
+
+	for _, tc := range cases {
+		err := c.Write(tc.cfg, 1.0)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+
+		c.mu.Lock()
+		servers := len(c.servers)
+		c.mu.Unlock()
+		assert.Equal(t, tc.servers, servers, fmt.Sprintf("%s: expected %d sessions got %d", tc.desc, tc.servers, servers))
+	}
+	assert.Equal(t, srv, c.servers[serverURI], "write: expected session of subscribed server to be used")
+
+	// Writes fail immediately once the session is closed.
+	other := c.servers[otherServerURI]
+	other.cancel()
+	require.True(t, closed(other.done), "cancel session: expected session to be closed")
+	_, err = other.session(ctx)
+	assert.Equal(t, errSessionClosed, err, fmt.Sprintf("write over closed session: expected %s got %s", errSessionClosed, err))
+}
+
+func TestToVariant(t *testing.T) {
+	cases := []struct {
+		desc     string
+		typeID   uaGopcua.TypeID
+		value    interface{}
+		expected interface{}
+		err      error
+	}{
+		{
+			desc:     "convert boolean to boolean",
+			typeID:   uaGopcua.TypeIDBoolean,
+			value:    true,
+			expected: true,
+		},
+		{
+			desc:     "convert number to boolean",
+			typeID:   uaGopcua.TypeIDBoolean,
+			value:    0.0,
+			expected: false,
+		},
+		{
+			desc:     "convert string to boolean",
+			typeID:   uaGopcua.TypeIDBoolean,
+			value:    "true",
+			expected: true,
+		},
+		{
+			desc:   "convert invalid string to boolean",
+			typeID: uaGopcua.TypeIDBoolean,
+			value:  "on",
+			err:    errInvalidValueType,
+		},
+		{
+			desc:     "convert number to string",
+			typeID:   uaGopcua.TypeIDString,
+			value:    1.5,
+			expected: "1.5",
+		},
+		{
+			desc:     "convert string to byte string",
+			typeID:   uaGopcua.TypeIDByteString,
+			value:    "data",
+			expected: []byte("data"),
+		},
+		{
+			desc:     "convert number to date time",
+			typeID:   uaGopcua.TypeIDDateTime,
+			value:    1.5,
+			expected: time.Unix(1, int64(500*time.Millisecond)),
+		},
+		{
+			desc:     "convert number to signed byte",
+			typeID:   uaGopcua.TypeIDSByte,
+			value:    -5.0,
+			expected: int8(-5),
+		},
+		{
+			desc:     "convert number to byte",
+			typeID:   uaGopcua.TypeIDByte,
+			value:    5.0,
+			expected: uint8(5),
+		},
+		{
+			desc:     "convert number to int16",
+			typeID:   uaGopcua.TypeIDInt16,
+			value:    -300.0,
+			expected: int16(-300),
+		},
+		{
+			desc:     "convert number to uint16",
+			typeID:   uaGopcua.TypeIDUint16,
+			value:    300.0,
+			expected: uint16(300),
+		},
+		{
+			desc:     "convert number to int32",
+			typeID:   uaGopcua.TypeIDInt32,
+			value:    -70000.0,
+			expected: int32(-70000),
+		},
+		{
+			desc:     "convert number to uint32",
+			typeID:   uaGopcua.TypeIDUint32,
+			value:    70000.0,
+			expected: uint32(70000),
+		},
+		{
+			desc:     "convert number to int64",
+			typeID:   uaGopcua.TypeIDInt64,
+			value:    -5000000000.0,
+			expected: int64(-5000000000),
+		},
+		{
+			desc:     "convert number to uint64",
+			typeID:   uaGopcua.TypeIDUint64,
+			value:    5000000000.0,
+			expected: uint64(5000000000),
+		},
+		{
+			desc:     "convert number to float",
+			typeID:   uaGopcua.TypeIDFloat,
+			value:    1.5,
+			expected: float32(1.5),
+		},
+		{
+			desc:     "convert number to double",
+			typeID:   uaGopcua.TypeIDDouble,
+			value:    1.5,
+			expected: 1.5,
+		},
+		{
+			desc:     "convert boolean to number",
+			typeID:   uaGopcua.TypeIDInt32,
+			value:    true,
+			expected: int32(1),
+		},
+		{
+			desc:     "convert string to number",
+			typeID:   uaGopcua.TypeIDInt32,
+			value:    "42",
+			expected: int32(42),
+		},
+		{
+			desc:   "convert invalid string to number",
+			typeID: uaGopcua.TypeIDInt32,
+			value:  "forty-two",
+			err:    errInvalidValueType,
+		},
+		{
+			desc:   "convert data to number",
+			typeID: uaGopcua.TypeIDDouble,
+			value:  []byte("42"),
+			err:    errInvalidValueType,
+		},
+		{
+			desc:   "convert to unsupported type",
+			typeID: uaGopcua.TypeIDGUID,
+			value:  "42",
+			err:    errUnsupportedType,
+		},
+	}
+
+	for _, tc := range cases {
+		v, err := toVariant(tc.typeID, tc.value)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		if tc.err != nil {
+			continue
+		}
+		assert.Equal(t, tc.typeID, v.Type(), fmt.Sprintf("%s: expected type %s got %s", tc.desc, tc.typeID, v.Type()))
+		assert.Equal(t, tc.expected, v.Value(), fmt.Sprintf("%s: expected value %v got %v", tc.desc, tc.expected, v.Value()))
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package mocks
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/mainflux/mainflux/opcua"
+)
+
+var errNotFound = errors.New("route-map not found")
+
+var _ opcua.RouteMapRepository = (*routeMapMock)(nil)
+
+type routeMapMock struct {
+	mu     sync.Mutex
+	routes map[string]string
+}
+
+// NewRouteMapRepository returns mock of the route-map repository.
+func NewRouteMapRepository() opcua.RouteMapRepository {
+	return &routeMapMock{
+		routes: make(map[string]string),
+	}
+}
+
+func (rm *routeMapMock) Save(mfxID, opcuaID string) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.routes[mfxID] = opcuaID
+	rm.routes[opcuaID] = mfxID
+	return nil
+}
+
+func (rm *routeMapMock) Get(opcuaID string) (string, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	id, ok := rm.routes[opcuaID]
+	if !ok {
+		return "", errNotFound
+	}
+	return id, nil
+}
+
+func (rm *routeMapMock) Remove(mfxID string) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	id, ok := rm.routes[mfxID]
+	if !ok {
+		return errNotFound
+	}
+
+	delete(rm.routes, mfxID)
+	delete(rm.routes, id)
+	return nil
+}
@@ -0,0 +1,51 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package mocks
+
+import (
+	"sync"
+
+	"github.com/mainflux/mainflux/opcua"
+)
+
+// Write represents the value written to the OPC-UA Node.
+type Write struct {
+	Config opcua.Config
+	Value  interface{}
+}
+
+// Writer represents OPC-UA writer mock which keeps written values.
+type Writer interface {
+	opcua.Writer
+
+	// Writes returns values written so far.
+	Writes() []Write
+}
+
+var _ Writer = (*writerMock)(nil)
+
+type writerMock struct {
+	mu     sync.Mutex
+	writes []Write
+}
+
+// NewWriter returns mock of the OPC-UA writer.
+func NewWriter() Writer {
+	return &writerMock{}
+}
+
+func (wm *writerMock) Write(cfg opcua.Config, value interface{}) error {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	wm.writes = append(wm.writes, Write{Config: cfg, Value: value})
+	return nil
+}
+
+func (wm *writerMock) Writes() []Write {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	return wm.writes
+}
@@ -3,9 +3,13 @@
 
 package redis
 
+import "github.com/mainflux/mainflux/opcua"
+
 type createThingEvent struct {
 	id          string
 	opcuaNodeID string
+	eventType   string
+	eventFields []string
 }
 
 type removeThingEvent struct {
@@ -20,6 +24,7 @@ type connectThingEvent struct {
 type createChannelEvent struct {
 	id             string
 	opcuaServerURI string
+	userToken      opcua.UserToken
 }
 
 type removeChannelEvent struct {
@@ -14,9 +14,12 @@ import (
 )
 
 const (
-	keyType      = "opcua"
-	keyNodeID    = "node_id"
-	keyServerURI = "server_uri"
+	keyType        = "opcua"
+	keyNodeID      = "node_id"
+	keyServerURI   = "server_uri"
+	keyEventType   = "event_type"
+	keyEventFields = "event_fields"
+	keyUserToken   = "user_token"
 
 	group  = "mainflux.opcua"
 	stream = "mainflux.things"
@@ -44,6 +47,10 @@ var (
 	errMetadataServerURI = errors.New("ServerURI not found in channel metadatada")
 
 	errMetadataNodeID = errors.New("NodeID not found in thing metadatada")
+
+	errMetadataEvents = errors.New("malformed event fields in thing metadata")
+
+	errMetadataUserToken = errors.New("malformed user token in channel metadata")
 )
 
 var _ opcua.EventStore = (*eventStore)(nil)
@@ -164,6 +171,25 @@ func decodeCreateThing(event map[string]interface{}) (createThingEvent, error) {
 	}
 
 	cte.opcuaNodeID = val
+	cte.eventType, _ = metadataVal[keyEventType].(string)
+
+	fields, ok := metadataVal[keyEventFields]
+	if !ok {
+		return cte, nil
+	}
+
+	vals, ok := fields.([]interface{})
+	if !ok {
+		return createThingEvent{}, errMetadataEvents
+	}
+	for _, v := range vals {
+		field, ok := v.(string)
+		if !ok || field == "" {
+			return createThingEvent{}, errMetadataEvents
+		}
+		cte.eventFields = append(cte.eventFields, field)
+	}
+
 	return cte, nil
 }
 
@@ -200,6 +226,27 @@ func decodeCreateChannel(event map[string]interface{}) (createChannelEvent, erro
 	}
 
 	cce.opcuaServerURI = val
+
+	token, ok := metadataVal[keyUserToken]
+	if !ok {
+		return cce, nil
+	}
+
+	tokenVal, ok := token.(map[string]interface{})
+	if !ok {
+		return createChannelEvent{}, errMetadataUserToken
+	}
+
+	cce.userToken = opcua.UserToken{
+		Type:        readMeta(tokenVal, "type"),
+		Username:    readMeta(tokenVal, "username"),
+		Password:    readMeta(tokenVal, "password"),
+		Certificate: readMeta(tokenVal, "certificate"),
+	}
+	if err := cce.userToken.Validate(); err != nil {
+		return createChannelEvent{}, err
+	}
+
 	return cce, nil
 }
 
@@ -224,7 +271,12 @@ func decodeDisconnectThing(event map[string]interface{}) connectThingEvent {
 }
 
 func (es eventStore) handleCreateThing(cte createThingEvent) error {
-	return es.svc.CreateThing(cte.id, cte.opcuaNodeID)
+	events := opcua.EventFilter{
+		Type:   cte.eventType,
+		Fields: cte.eventFields,
+	}
+
+	return es.svc.CreateThing(cte.id, cte.opcuaNodeID, events)
 }
 
 func (es eventStore) handleRemoveThing(rte removeThingEvent) error {
@@ -232,7 +284,7 @@ func (es eventStore) handleRemoveThing(rte removeThingEvent) error {
 }
 
 func (es eventStore) handleCreateChannel(cce createChannelEvent) error {
-	return es.svc.CreateChannel(cce.id, cce.opcuaServerURI)
+	return es.svc.CreateChannel(cce.id, cce.opcuaServerURI, cce.userToken)
 }
 
 func (es eventStore) handleRemoveChannel(rce removeChannelEvent) error {
@@ -255,3 +307,8 @@ func read(event map[string]interface{}, key, def string) string {
 
 	return val
 }
+
+func readMeta(meta map[string]interface{}, key string) string {
+	val, _ := meta[key].(string)
+	return val
+}
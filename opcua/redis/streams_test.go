@@ -0,0 +1,134 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package redis
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/opcua"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeCreateThing(t *testing.T) {
+	id := "513d02d2-16c1-4f23-98be-9e12f8fee898"
+
+	cases := []struct {
+		desc     string
+		metadata string
+		event    createThingEvent
+		err      error
+	}{
+		{
+			desc:     "decode thing with node ID",
+			metadata: `{"opcua":{"node_id":"ns=2;i=1"}}`,
+			event:    createThingEvent{id: id, opcuaNodeID: "ns=2;i=1"},
+			err:      nil,
+		},
+		{
+			desc:     "decode thing with event type and fields",
+			metadata: `{"opcua":{"node_id":"i=2253","event_type":"i=2915","event_fields":["Message","Severity","Time"]}}`,
+			event: createThingEvent{
+				id:          id,
+				opcuaNodeID: "i=2253",
+				eventType:   "i=2915",
+				eventFields: []string{"Message", "Severity", "Time"},
+			},
+			err: nil,
+		},
+		{
+			desc:     "decode thing with malformed event fields",
+			metadata: `{"opcua":{"node_id":"i=2253","event_fields":"Message"}}`,
+			event:    createThingEvent{},
+			err:      errMetadataEvents,
+		},
+		{
+			desc:     "decode thing with empty event field",
+			metadata: `{"opcua":{"node_id":"i=2253","event_fields":["Message",""]}}`,
+			event:    createThingEvent{},
+			err:      errMetadataEvents,
+		},
+		{
+			desc:     "decode thing without node ID",
+			metadata: `{"opcua":{"event_fields":["Message"]}}`,
+			event:    createThingEvent{},
+			err:      errMetadataNodeID,
+		},
+		{
+			desc:     "decode thing without opcua metadata",
+			metadata: `{}`,
+			event:    createThingEvent{},
+			err:      errMetadataType,
+		},
+	}
+
+	for _, tc := range cases {
+		event, err := decodeCreateThing(map[string]interface{}{"id": id, "metadata": tc.metadata})
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", tc.desc, tc.err, err))
+		assert.Equal(t, tc.event, event, fmt.Sprintf("%s: expected event %v got %v", tc.desc, tc.event, event))
+	}
+}
+
+func TestDecodeCreateChannel(t *testing.T) {
+	id := "1"
+	serverURI := "opc.tcp://localhost:4840"
+
+	cases := []struct {
+		desc     string
+		metadata string
+		event    createChannelEvent
+		err      error
+	}{
+		{
+			desc:     "decode channel without user token",
+			metadata: `{"opcua":{"server_uri":"opc.tcp://localhost:4840"}}`,
+			event:    createChannelEvent{id: id, opcuaServerURI: serverURI},
+			err:      nil,
+		},
+		{
+			desc:     "decode channel with username user token",
+			metadata: `{"opcua":{"server_uri":"opc.tcp://localhost:4840","user_token":{"type":"username","username":"user","password":"env:OPCUA_PASS"}}}`,
+			event: createChannelEvent{
+				id:             id,
+				opcuaServerURI: serverURI,
+				userToken:      opcua.UserToken{Type: opcua.UserTokenUsername, Username: "user", Password: "env:OPCUA_PASS"},
+			},
+			err: nil,
+		},
+		{
+			desc:     "decode channel with certificate user token",
+			metadata: `{"opcua":{"server_uri":"opc.tcp://localhost:4840","user_token":{"type":"certificate","certificate":"/certs/user.crt"}}}`,
+			event: createChannelEvent{
+				id:             id,
+				opcuaServerURI: serverURI,
+				userToken:      opcua.UserToken{Type: opcua.UserTokenCertificate, Certificate: "/certs/user.crt"},
+			},
+			err: nil,
+		},
+		{
+			desc:     "decode channel with plain text password",
+			metadata: `{"opcua":{"server_uri":"opc.tcp://localhost:4840","user_token":{"type":"username","username":"user","password":"secret"}}}`,
+			event:    createChannelEvent{},
+			err:      opcua.ErrInvalidUserToken,
+		},
+		{
+			desc:     "decode channel with malformed user token",
+			metadata: `{"opcua":{"server_uri":"opc.tcp://localhost:4840","user_token":"user"}}`,
+			event:    createChannelEvent{},
+			err:      errMetadataUserToken,
+		},
+		{
+			desc:     "decode channel without server URI",
+			metadata: `{"opcua":{}}`,
+			event:    createChannelEvent{},
+			err:      errMetadataServerURI,
+		},
+	}
+
+	for _, tc := range cases {
+		event, err := decodeCreateChannel(map[string]interface{}{"id": id, "metadata": tc.metadata})
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", tc.desc, tc.err, err))
+		assert.Equal(t, tc.event, event, fmt.Sprintf("%s: expected event %v got %v", tc.desc, tc.event, event))
+	}
+}
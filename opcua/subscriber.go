@@ -7,4 +7,7 @@ package opcua
 type Subscriber interface {
 	// Subscribes to given NodeID and receives events.
 	Subscribe(Config) error
+
+	// Unsubscribe stops receiving events of given NodeID.
+	Unsubscribe(Config) error
 }
@@ -0,0 +1,139 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package opcua
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// UserTokenAnonymous represents anonymous OPC-UA session.
+	UserTokenAnonymous = "anonymous"
+
+	// UserTokenUsername represents username and password OPC-UA user token.
+	UserTokenUsername = "username"
+
+	// UserTokenCertificate represents X.509 certificate OPC-UA user token.
+	UserTokenCertificate = "certificate"
+
+	envRef  = "env:"
+	fileRef = "file:"
+)
+
+var (
+	// ErrInvalidUserToken indicates malformed user token specification.
+	ErrInvalidUserToken = errors.New("invalid user token")
+
+	// ErrSecretRef indicates that the secret is not given as a reference
+	// or that the referenced secret can't be resolved.
+	ErrSecretRef = errors.New("invalid secret reference")
+)
+
+// UserToken represents the user identity used to activate OPC-UA sessions
+// with the server. Secrets are not stored in plain text: Password is a
+// reference to the environment variable ("env:<name>") or to the file
+// ("file:<path>") holding the password, while Certificate is the path of
+// the user certificate file. References are resolved using Secrets.
+type UserToken struct {
+	Type        string `json:"type,omitempty"`
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"password,omitempty"`
+	Certificate string `json:"certificate,omitempty"`
+}
+
+// Validate returns an error if user token is not valid.
+func (ut UserToken) Validate() error {
+	switch ut.Type {
+	case "", UserTokenAnonymous:
+		return nil
+	case UserTokenUsername:
+		if ut.Username == "" || !isRef(ut.Password) {
+			return ErrInvalidUserToken
+		}
+		return nil
+	case UserTokenCertificate:
+		if ut.Certificate == "" {
+			return ErrInvalidUserToken
+		}
+		return nil
+	default:
+		return ErrInvalidUserToken
+	}
+}
+
+// Secrets specifies the environment variables and the files the secret
+// references of the user tokens are resolved from. Since the user tokens are
+// set by the channel owners, references to anything else are rejected.
+type Secrets struct {
+	// Dir is the directory holding the referenced files, e.g. the mounted
+	// Docker secrets. Relative paths are resolved against it. Files are
+	// not resolved if it's empty.
+	Dir string
+
+	// EnvPrefix is the prefix of the names of the referenced environment
+	// variables. Environment variables are not resolved if it's empty.
+	EnvPrefix string
+}
+
+// Secret resolves the secret reference.
+func (s Secrets) Secret(ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, envRef):
+		name := strings.TrimPrefix(ref, envRef)
+		if s.EnvPrefix == "" || !strings.HasPrefix(name, s.EnvPrefix) {
+			return "", ErrSecretRef
+		}
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			return "", ErrSecretRef
+		}
+		return val, nil
+	case strings.HasPrefix(ref, fileRef):
+		b, err := s.File(strings.TrimPrefix(ref, fileRef))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(b)), nil
+	default:
+		return "", ErrSecretRef
+	}
+}
+
+// File returns the content of the file in the secrets directory.
+func (s Secrets) File(path string) ([]byte, error) {
+	if s.Dir == "" || path == "" {
+		return nil, ErrSecretRef
+	}
+	dir, err := filepath.EvalSymlinks(s.Dir)
+	if err != nil {
+		return nil, ErrSecretRef
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.Dir, path)
+	}
+	// Symbolic links are resolved, so that they can't point outside
+	// of the secrets directory.
+	path, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, ErrSecretRef
+	}
+	if rel, err := filepath.Rel(dir, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, ErrSecretRef
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, ErrSecretRef
+	}
+	return b, nil
+}
+
+func isRef(ref string) bool {
+	return len(ref) > len(envRef) && strings.HasPrefix(ref, envRef) ||
+		len(ref) > len(fileRef) && strings.HasPrefix(ref, fileRef)
+}
@@ -0,0 +1,209 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package opcua_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mainflux/mainflux/opcua"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	envPrefix = "MF_OPCUA_SECRET_"
+	passEnv   = envPrefix + "TEST_PASSWORD"
+	otherEnv  = "MF_OPCUA_TEST_PASSWORD"
+	pass      = "secret"
+)
+
+func TestValidateUserToken(t *testing.T) {
+	cases := []struct {
+		desc  string
+		token opcua.UserToken
+		err   error
+	}{
+		{
+			desc:  "validate empty user token",
+			token: opcua.UserToken{},
+			err:   nil,
+		},
+		{
+			desc:  "validate anonymous user token",
+			token: opcua.UserToken{Type: opcua.UserTokenAnonymous},
+			err:   nil,
+		},
+		{
+			desc:  "validate username user token with environment reference",
+			token: opcua.UserToken{Type: opcua.UserTokenUsername, Username: "user", Password: "env:" + passEnv},
+			err:   nil,
+		},
+		{
+			desc:  "validate username user token with file reference",
+			token: opcua.UserToken{Type: opcua.UserTokenUsername, Username: "user", Password: "file:/run/secrets/opcua"},
+			err:   nil,
+		},
+		{
+			desc:  "validate username user token with plain text password",
+			token: opcua.UserToken{Type: opcua.UserTokenUsername, Username: "user", Password: pass},
+			err:   opcua.ErrInvalidUserToken,
+		},
+		{
+			desc:  "validate username user token with empty reference",
+			token: opcua.UserToken{Type: opcua.UserTokenUsername, Username: "user", Password: "env:"},
+			err:   opcua.ErrInvalidUserToken,
+		},
+		{
+			desc:  "validate username user token without username",
+			token: opcua.UserToken{Type: opcua.UserTokenUsername, Password: "env:" + passEnv},
+			err:   opcua.ErrInvalidUserToken,
+		},
+		{
+			desc:  "validate certificate user token",
+			token: opcua.UserToken{Type: opcua.UserTokenCertificate, Certificate: "/run/secrets/user.crt"},
+			err:   nil,
+		},
+		{
+			desc:  "validate certificate user token without certificate",
+			token: opcua.UserToken{Type: opcua.UserTokenCertificate},
+			err:   opcua.ErrInvalidUserToken,
+		},
+		{
+			desc:  "validate user token of unknown type",
+			token: opcua.UserToken{Type: "kerberos"},
+			err:   opcua.ErrInvalidUserToken,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.token.Validate()
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", tc.desc, tc.err, err))
+	}
+}
+
+func TestSecret(t *testing.T) {
+	os.Setenv(passEnv, pass)
+	defer os.Unsetenv(passEnv)
+	os.Setenv(otherEnv, pass)
+	defer os.Unsetenv(otherEnv)
+
+	dir, err := ioutil.TempDir("", "opcua-secrets")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "pass"), []byte(pass+"\n"), 0600)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	outside, err := ioutil.TempFile("", "opcua-secret")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	defer os.Remove(outside.Name())
+	outside.Close()
+	link := filepath.Join(dir, "link")
+	err = os.Symlink(outside.Name(), link)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	secrets := opcua.Secrets{Dir: dir, EnvPrefix: envPrefix}
+
+	cases := []struct {
+		desc    string
+		secrets opcua.Secrets
+		ref     string
+		secret  string
+		err     error
+	}{
+		{
+			desc:    "resolve environment reference",
+			secrets: secrets,
+			ref:     "env:" + passEnv,
+			secret:  pass,
+			err:     nil,
+		},
+		{
+			desc:    "resolve reference to unset environment variable",
+			secrets: secrets,
+			ref:     "env:" + envPrefix + "UNSET",
+			secret:  "",
+			err:     opcua.ErrSecretRef,
+		},
+		{
+			desc:    "resolve reference to environment variable without prefix",
+			secrets: secrets,
+			ref:     "env:" + otherEnv,
+			secret:  "",
+			err:     opcua.ErrSecretRef,
+		},
+		{
+			desc:    "resolve environment reference without configured prefix",
+			secrets: opcua.Secrets{Dir: dir},
+			ref:     "env:" + passEnv,
+			secret:  "",
+			err:     opcua.ErrSecretRef,
+		},
+		{
+			desc:    "resolve file reference",
+			secrets: secrets,
+			ref:     "file:pass",
+			secret:  pass,
+			err:     nil,
+		},
+		{
+			desc:    "resolve absolute file reference in secrets directory",
+			secrets: secrets,
+			ref:     "file:" + filepath.Join(dir, "pass"),
+			secret:  pass,
+			err:     nil,
+		},
+		{
+			desc:    "resolve reference to missing file",
+			secrets: secrets,
+			ref:     "file:missing",
+			secret:  "",
+			err:     opcua.ErrSecretRef,
+		},
+		{
+			desc:    "resolve reference to file outside of secrets directory",
+			secrets: secrets,
+			ref:     "file:" + outside.Name(),
+			secret:  "",
+			err:     opcua.ErrSecretRef,
+		},
+		{
+			desc:    "resolve relative reference outside of secrets directory",
+			secrets: secrets,
+			ref:     "file:../" + filepath.Base(outside.Name()),
+			secret:  "",
+			err:     opcua.ErrSecretRef,
+		},
+		{
+			desc:    "resolve reference to link outside of secrets directory",
+			secrets: secrets,
+			ref:     "file:link",
+			secret:  "",
+			err:     opcua.ErrSecretRef,
+		},
+		{
+			desc:    "resolve file reference without configured directory",
+			secrets: opcua.Secrets{EnvPrefix: envPrefix},
+			ref:     "file:" + filepath.Join(dir, "pass"),
+			secret:  "",
+			err:     opcua.ErrSecretRef,
+		},
+		{
+			desc:    "resolve plain text secret",
+			secrets: secrets,
+			ref:     pass,
+			secret:  "",
+			err:     opcua.ErrSecretRef,
+		},
+	}
+
+	for _, tc := range cases {
+		secret, err := tc.secrets.Secret(tc.ref)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", tc.desc, tc.err, err))
+		assert.Equal(t, tc.secret, secret, fmt.Sprintf("%s: expected secret %s got %s", tc.desc, tc.secret, secret))
+	}
+}
@@ -0,0 +1,10 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package opcua
+
+// Writer represents the OPC-UA Server client used to write Node values.
+type Writer interface {
+	// Write writes the value to the given NodeID converting it to the Node type.
+	Write(cfg Config, value interface{}) error
+}
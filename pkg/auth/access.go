@@ -0,0 +1,44 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+
+	"github.com/mainflux/mainflux"
+)
+
+type thingIDKey struct{}
+
+// WithThingID returns copy of the context carrying ID of the thing
+// authenticated by the transport, e.g. using the client certificate.
+func WithThingID(ctx context.Context, thingID string) context.Context {
+	return context.WithValue(ctx, thingIDKey{}, thingID)
+}
+
+// ThingID returns ID of the thing authenticated by the transport, if any.
+func ThingID(ctx context.Context) (string, bool) {
+	thingID, ok := ctx.Value(thingIDKey{}).(string)
+	return thingID, ok && thingID != ""
+}
+
+// CanAccess checks if the thing can access the channel and returns the
+// thing ID. Thing is identified by the key if it's sent, or by the ID
+// carried in the context otherwise.
+func CanAccess(ctx context.Context, things mainflux.ThingsServiceClient, key, chanID string) (string, error) {
+	if thingID, ok := ThingID(ctx); ok && key == "" {
+		req := &mainflux.AccessByIDReq{ThingID: thingID, ChanID: chanID}
+		if _, err := things.CanAccessByID(ctx, req); err != nil {
+			return "", err
+		}
+		return thingID, nil
+	}
+
+	req := &mainflux.AccessByKeyReq{Token: key, ChanID: chanID}
+	thid, err := things.CanAccessByKey(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return thid.GetValue(), nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package auth_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/pkg/auth"
+	"github.com/mainflux/mainflux/pkg/auth/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	key     = "key"
+	chanID  = "1"
+	invalid = "invalid"
+)
+
+func TestCanAccess(t *testing.T) {
+	things := mocks.NewThingsClient(map[string]string{key: thingID})
+
+	cases := []struct {
+		desc string
+		ctx  context.Context
+		key  string
+		id   string
+		err  bool
+	}{
+		{
+			desc: "access with key",
+			ctx:  context.Background(),
+			key:  key,
+			id:   thingID,
+			err:  false,
+		},
+		{
+			desc: "access with invalid key",
+			ctx:  context.Background(),
+			key:  invalid,
+			id:   "",
+			err:  true,
+		},
+		{
+			desc: "access with thing authenticated by transport",
+			ctx:  auth.WithThingID(context.Background(), thingID),
+			key:  "",
+			id:   thingID,
+			err:  false,
+		},
+		{
+			desc: "access with unknown thing authenticated by transport",
+			ctx:  auth.WithThingID(context.Background(), otherThing),
+			key:  "",
+			id:   "",
+			err:  true,
+		},
+		{
+			desc: "access with key and thing authenticated by transport",
+			ctx:  auth.WithThingID(context.Background(), otherThing),
+			key:  key,
+			id:   thingID,
+			err:  false,
+		},
+		{
+			desc: "access without credentials",
+			ctx:  context.Background(),
+			key:  "",
+			id:   "",
+			err:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		id, err := auth.CanAccess(tc.ctx, things, tc.key, chanID)
+		assert.Equal(t, tc.id, id, fmt.Sprintf("%s: expected thing ID %s got %s", tc.desc, tc.id, id))
+		assert.Equal(t, tc.err, err != nil, fmt.Sprintf("%s: expected error %t got %s", tc.desc, tc.err, err))
+	}
+}
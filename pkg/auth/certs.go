@@ -0,0 +1,149 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/go-redis/redis"
+	"github.com/mainflux/mainflux/pkg/errors"
+)
+
+const certsKey = "certs"
+
+var (
+	// ErrUnknownCert indicates that client certificate is not issued by
+	// the certs service, or that it's revoked.
+	ErrUnknownCert = errors.New("unknown or revoked client certificate")
+
+	errParseCert   = errors.New("failed to parse client certificate")
+	errParseCACert = errors.New("failed to parse CA certificate")
+)
+
+// CertsCache represents cache of the client certificates issued by the
+// certs service. Certs service adds the certificates it issues, removes
+// the revoked ones and seeds the cache with the certificates from its
+// repository at startup. Protocol adapters accept only the client
+// certificates found in the cache.
+type CertsCache interface {
+	// Save stores serial of the certificate issued for the thing.
+	Save(serial, thingID string) error
+
+	// Remove removes certificate with the given serial.
+	Remove(serial string) error
+
+	// ThingID returns ID of the thing the certificate with the given
+	// serial is issued for, or ErrUnknownCert if there is no such
+	// certificate.
+	ThingID(serial string) (string, error)
+}
+
+type certsCache struct {
+	redisClient *redis.Client
+}
+
+// NewCertsCache returns redis certificates cache implementation.
+func NewCertsCache(redisClient *redis.Client) CertsCache {
+	return certsCache{redisClient: redisClient}
+}
+
+func (cc certsCache) Save(serial, thingID string) error {
+	return cc.redisClient.HSet(certsKey, serial, thingID).Err()
+}
+
+func (cc certsCache) Remove(serial string) error {
+	return cc.redisClient.HDel(certsKey, serial).Err()
+}
+
+func (cc certsCache) ThingID(serial string) (string, error) {
+	thingID, err := cc.redisClient.HGet(certsKey, serial).Result()
+	if err == redis.Nil {
+		return "", ErrUnknownCert
+	}
+	return thingID, err
+}
+
+// CertSerial returns certificate serial number formatted the same way
+// certs service stores it, as colon separated hex encoded bytes.
+func CertSerial(cert *x509.Certificate) string {
+	b := cert.SerialNumber.Bytes()
+	parts := make([]string, len(b))
+	for i, v := range b {
+		parts[i] = fmt.Sprintf("%02x", v)
+	}
+	return strings.Join(parts, ":")
+}
+
+// CertThingID returns ID of the thing certificate is issued for. Only the
+// certificates accepted by VerifyPeerCertificate can be trusted.
+func CertThingID(cert *x509.Certificate) string {
+	return cert.Subject.CommonName
+}
+
+// VerifyPeerCertificate returns callback which is meant to be used as
+// TLS and DTLS VerifyPeerCertificate configuration option. It accepts
+// only the client certificates issued by the certs service for the thing
+// they are presented for, and not revoked since. Chain of trust is
+// verified by the TLS implementation before the callback is invoked.
+func VerifyPeerCertificate(cache CertsCache) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return nil
+		}
+		cert, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return errors.Wrap(errParseCert, err)
+		}
+		thingID, err := cache.ThingID(CertSerial(cert))
+		if err != nil {
+			return err
+		}
+		if thingID != CertThingID(cert) {
+			return ErrUnknownCert
+		}
+		return nil
+	}
+}
+
+// LoadCACerts loads pool of CA certificates used to verify client certificates.
+func LoadCACerts(caPath string) (*x509.CertPool, error) {
+	b, err := ioutil.ReadFile(caPath)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, errParseCACert
+	}
+	return pool, nil
+}
+
+// ServerTLSConfig returns server TLS configuration. Client certificates are
+// optional so that things can still authenticate using their keys, but if
+// provided, they must be issued by the given CA and found in the cache.
+func ServerTLSConfig(caPath, certPath, keyPath string, cache CertsCache) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+	if caPath == "" {
+		return cfg, nil
+	}
+
+	pool, err := LoadCACerts(caPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ClientCAs = pool
+	cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	cfg.VerifyPeerCertificate = VerifyPeerCertificate(cache)
+	return cfg, nil
+}
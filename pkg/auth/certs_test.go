@@ -0,0 +1,126 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package auth_test
+
+import (
+	"crypto/x509"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/mainflux/mainflux/pkg/auth"
+	"github.com/mainflux/mainflux/pkg/auth/mocks"
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	thingID    = "513d02d2-16c1-4f23-98be-9e12f8fee898"
+	otherThing = "2f3d3bb1-f6b5-4bc5-9b77-2ba2a7a1bd38"
+)
+
+func TestCertSerial(t *testing.T) {
+	cases := []struct {
+		desc   string
+		serial *big.Int
+		str    string
+	}{
+		{
+			desc:   "format single byte serial",
+			serial: big.NewInt(10),
+			str:    "0a",
+		},
+		{
+			desc:   "format multiple bytes serial",
+			serial: big.NewInt(0x1a2b3c),
+			str:    "1a:2b:3c",
+		},
+	}
+
+	for _, tc := range cases {
+		str := auth.CertSerial(&x509.Certificate{SerialNumber: tc.serial})
+		assert.Equal(t, tc.str, str, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.str, str))
+	}
+}
+
+func TestVerifyPeerCertificate(t *testing.T) {
+	ca, err := mocks.NewCA()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cache := mocks.NewCertsCache()
+	verify := auth.VerifyPeerCertificate(cache)
+
+	issued, err := ca.Issue(1, thingID)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = cache.Save(auth.CertSerial(issued.Leaf), thingID)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	// Certificate issued for one thing and saved for another
+	// simulates certificate with the forged common name.
+	forged, err := ca.Issue(2, otherThing)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = cache.Save(auth.CertSerial(forged.Leaf), thingID)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	revoked, err := ca.Issue(3, thingID)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = cache.Save(auth.CertSerial(revoked.Leaf), thingID)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = cache.Remove(auth.CertSerial(revoked.Leaf))
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	unknown, err := ca.Issue(4, thingID)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc  string
+		certs [][]byte
+		err   error
+	}{
+		{
+			desc:  "verify issued certificate",
+			certs: issued.Certificate,
+			err:   nil,
+		},
+		{
+			desc:  "verify certificate with forged common name",
+			certs: forged.Certificate,
+			err:   auth.ErrUnknownCert,
+		},
+		{
+			desc:  "verify revoked certificate",
+			certs: revoked.Certificate,
+			err:   auth.ErrUnknownCert,
+		},
+		{
+			desc:  "verify certificate unknown to the certs service",
+			certs: unknown.Certificate,
+			err:   auth.ErrUnknownCert,
+		},
+		{
+			desc:  "verify without certificate",
+			certs: nil,
+			err:   nil,
+		},
+	}
+
+	for _, tc := range cases {
+		err := verify(tc.certs, nil)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+
+	err = verify([][]byte{[]byte("certificate")}, nil)
+	assert.NotNil(t, err, "verify malformed certificate: expected error got nil")
+}
+
+func TestCertThingID(t *testing.T) {
+	ca, err := mocks.NewCA()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	cert, err := ca.Issue(1, thingID)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	id := auth.CertThingID(cert.Leaf)
+	assert.Equal(t, thingID, id, fmt.Sprintf("expected thing ID %s got %s", thingID, id))
+}
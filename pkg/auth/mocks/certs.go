@@ -0,0 +1,49 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package mocks
+
+import (
+	"sync"
+
+	"github.com/mainflux/mainflux/pkg/auth"
+)
+
+var _ auth.CertsCache = (*certsCacheMock)(nil)
+
+type certsCacheMock struct {
+	mu    sync.Mutex
+	certs map[string]string
+}
+
+// NewCertsCache returns mock implementation of the certificates cache.
+func NewCertsCache() auth.CertsCache {
+	return &certsCacheMock{certs: make(map[string]string)}
+}
+
+func (ccm *certsCacheMock) Save(serial, thingID string) error {
+	ccm.mu.Lock()
+	defer ccm.mu.Unlock()
+
+	ccm.certs[serial] = thingID
+	return nil
+}
+
+func (ccm *certsCacheMock) Remove(serial string) error {
+	ccm.mu.Lock()
+	defer ccm.mu.Unlock()
+
+	delete(ccm.certs, serial)
+	return nil
+}
+
+func (ccm *certsCacheMock) ThingID(serial string) (string, error) {
+	ccm.mu.Lock()
+	defer ccm.mu.Unlock()
+
+	thingID, ok := ccm.certs[serial]
+	if !ok {
+		return "", auth.ErrUnknownCert
+	}
+	return thingID, nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package mocks
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/mainflux/mainflux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ mainflux.ThingsServiceClient = (*thingsClient)(nil)
+
+type thingsClient struct {
+	things map[string]string
+}
+
+// NewThingsClient returns mock implementation of things service client,
+// which grants access to all the channels to the things with the given
+// keys mapped to their IDs.
+func NewThingsClient(data map[string]string) mainflux.ThingsServiceClient {
+	return thingsClient{things: data}
+}
+
+func (tc thingsClient) CanAccessByKey(ctx context.Context, req *mainflux.AccessByKeyReq, opts ...grpc.CallOption) (*mainflux.ThingID, error) {
+	id, ok := tc.things[req.GetToken()]
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "invalid credentials provided")
+	}
+	return &mainflux.ThingID{Value: id}, nil
+}
+
+func (tc thingsClient) CanAccessByID(ctx context.Context, req *mainflux.AccessByIDReq, opts ...grpc.CallOption) (*empty.Empty, error) {
+	for _, id := range tc.things {
+		if id == req.GetThingID() {
+			return &empty.Empty{}, nil
+		}
+	}
+	return nil, status.Error(codes.PermissionDenied, "invalid credentials provided")
+}
+
+func (tc thingsClient) Identify(ctx context.Context, req *mainflux.Token, opts ...grpc.CallOption) (*mainflux.ThingID, error) {
+	id, ok := tc.things[req.GetValue()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "entity does not exist")
+	}
+	return &mainflux.ThingID{Value: id}, nil
+}
+
+func (tc thingsClient) Key(ctx context.Context, req *mainflux.ThingID, opts ...grpc.CallOption) (*mainflux.Token, error) {
+	panic("not implemented")
+}
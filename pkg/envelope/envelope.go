@@ -0,0 +1,209 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package envelope provides per-channel envelope encryption of the
+// message values stored by the writers. Each value is encrypted using
+// a random data key, which is in turn encrypted using the channel key
+// obtained from the key provider and stored along with the value.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"strings"
+
+	"github.com/mainflux/mainflux/pkg/errors"
+)
+
+// prefix marks the sealed values and the envelope format version.
+const prefix = "mfenc:v1:"
+
+// KeySize is the size of the channel and data keys in bytes.
+const KeySize = 32
+
+var (
+	// ErrKeyNotFound indicates that the channel has no encryption key.
+	ErrKeyNotFound = errors.New("encryption key not found")
+
+	// ErrMalformedKey indicates malformed encryption key.
+	ErrMalformedKey = errors.New("malformed encryption key")
+
+	// ErrMalformedEnvelope indicates malformed sealed value.
+	ErrMalformedEnvelope = errors.New("malformed envelope")
+
+	// ErrEncrypt indicates failure to encrypt the value.
+	ErrEncrypt = errors.New("failed to encrypt value")
+
+	// ErrDecrypt indicates failure to decrypt the value.
+	ErrDecrypt = errors.New("failed to decrypt value")
+)
+
+// Key is the channel key encrypting the data keys.
+type Key struct {
+	// ID identifies the key among the keys of the channel, so that
+	// values sealed before the key rotation can still be opened.
+	ID     string
+	Secret []byte
+}
+
+// KeyProvider provides the channel keys.
+type KeyProvider interface {
+	// Key returns the current key of the channel, or ErrKeyNotFound
+	// if the values of the channel are not encrypted.
+	Key(chanID string) (Key, error)
+
+	// KeyByID returns the key of the channel with the given ID.
+	KeyByID(chanID, keyID string) (Key, error)
+}
+
+// Sealer encrypts and decrypts the values of the channel.
+type Sealer interface {
+	// Seal encrypts the value of the channel. Values of the channels
+	// without a key are returned as is.
+	Seal(chanID, value string) (string, error)
+
+	// Open decrypts the value of the channel sealed using Seal. Values
+	// which are not sealed, or values of the channels without a key,
+	// are returned as is.
+	Open(chanID, value string) (string, error)
+
+	// Encrypted returns true if the values of the channel are encrypted.
+	Encrypted(chanID string) (bool, error)
+}
+
+var _ Sealer = (*sealer)(nil)
+
+type sealer struct {
+	keys KeyProvider
+}
+
+// New returns sealer using AES-256-GCM to encrypt the values and the
+// data keys with the keys of the given provider.
+func New(keys KeyProvider) Sealer {
+	return sealer{keys: keys}
+}
+
+func (s sealer) Seal(chanID, value string) (string, error) {
+	key, err := s.keys.Key(chanID)
+	if err != nil {
+		if errors.Contains(err, ErrKeyNotFound) {
+			return value, nil
+		}
+		return "", errors.Wrap(ErrEncrypt, err)
+	}
+
+	dataKey := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", errors.Wrap(ErrEncrypt, err)
+	}
+
+	// Channel ID is authenticated with both the value and the data key,
+	// so that sealed values can't be moved between the channels.
+	aad := []byte(chanID)
+	sealedKey, err := encrypt(key.Secret, dataKey, aad)
+	if err != nil {
+		return "", errors.Wrap(ErrEncrypt, err)
+	}
+	sealedValue, err := encrypt(dataKey, []byte(value), aad)
+	if err != nil {
+		return "", errors.Wrap(ErrEncrypt, err)
+	}
+
+	enc := base64.RawStdEncoding
+	return prefix + key.ID + ":" + enc.EncodeToString(sealedKey) + ":" + enc.EncodeToString(sealedValue), nil
+}
+
+func (s sealer) Open(chanID, value string) (string, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return value, nil
+	}
+	// Values of the channels without a key are never sealed, even if
+	// they look like that.
+	if _, err := s.keys.Key(chanID); errors.Contains(err, ErrKeyNotFound) {
+		return value, nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", ErrMalformedEnvelope
+	}
+
+	enc := base64.RawStdEncoding
+	sealedKey, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return "", errors.Wrap(ErrMalformedEnvelope, err)
+	}
+	sealedValue, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.Wrap(ErrMalformedEnvelope, err)
+	}
+
+	key, err := s.keys.KeyByID(chanID, parts[0])
+	if err != nil {
+		return "", errors.Wrap(ErrDecrypt, err)
+	}
+
+	aad := []byte(chanID)
+	dataKey, err := decrypt(key.Secret, sealedKey, aad)
+	if err != nil {
+		return "", errors.Wrap(ErrDecrypt, err)
+	}
+	plain, err := decrypt(dataKey, sealedValue, aad)
+	if err != nil {
+		return "", errors.Wrap(ErrDecrypt, err)
+	}
+
+	return string(plain), nil
+}
+
+func (s sealer) Encrypted(chanID string) (bool, error) {
+	if _, err := s.keys.Key(chanID); err != nil {
+		if errors.Contains(err, ErrKeyNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// encrypt returns the nonce followed by the ciphertext.
+func encrypt(key, plain, aad []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plain)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain, aad), nil
+}
+
+func decrypt(key, sealed, aad []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformedEnvelope
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrMalformedKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
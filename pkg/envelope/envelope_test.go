@@ -0,0 +1,202 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package envelope_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mainflux/mainflux/pkg/envelope"
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	encChan   = "1"
+	otherChan = "2"
+	plainChan = "3"
+	value     = "personal data"
+)
+
+var (
+	key1 = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", envelope.KeySize)))
+	key2 = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("b", envelope.KeySize)))
+)
+
+func newProvider(t *testing.T, content string) (envelope.KeyProvider, error) {
+	f, err := ioutil.TempFile("", "keys")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(content)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	require.Nil(t, f.Close(), "failed to close keys file")
+
+	return envelope.NewFileProvider(f.Name())
+}
+
+func keysFile(current string) string {
+	return fmt.Sprintf(`{"channels": {
+		"%s": {"current": "%s", "keys": {"1": "%s", "2": "%s"}},
+		"%s": {"current": "1", "keys": {"1": "%s"}}
+	}}`, encChan, current, key1, key2, otherChan, key2)
+}
+
+// tamper changes a character in the middle of the sealed value.
+func tamper(sealed string) string {
+	i := len(sealed) - 10
+	c := byte('A')
+	if sealed[i] == c {
+		c = 'B'
+	}
+	return sealed[:i] + string(c) + sealed[i+1:]
+}
+
+func TestNewFileProvider(t *testing.T) {
+	cases := []struct {
+		desc    string
+		content string
+		err     error
+	}{
+		{
+			desc:    "load valid keys",
+			content: keysFile("1"),
+			err:     nil,
+		},
+		{
+			desc:    "load keys with missing current key",
+			content: keysFile("3"),
+			err:     envelope.ErrKeyNotFound,
+		},
+		{
+			desc:    "load key of invalid size",
+			content: fmt.Sprintf(`{"channels": {"1": {"current": "1", "keys": {"1": "%s"}}}}`, base64.StdEncoding.EncodeToString([]byte("short"))),
+			err:     envelope.ErrMalformedKey,
+		},
+		{
+			desc:    "load key with invalid ID",
+			content: fmt.Sprintf(`{"channels": {"1": {"current": "a:b", "keys": {"a:b": "%s"}}}}`, key1),
+			err:     envelope.ErrMalformedKey,
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := newProvider(t, tc.content)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	keys, err := newProvider(t, keysFile("1"))
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	rotated, err := newProvider(t, keysFile("2"))
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	sealer := envelope.New(keys)
+	sealed, err := sealer.Seal(encChan, value)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.NotContains(t, sealed, value, "expected value to be encrypted")
+
+	plain, err := sealer.Seal(plainChan, value)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.Equal(t, value, plain, "expected value of channel without key not to be encrypted")
+
+	cases := []struct {
+		desc   string
+		sealer envelope.Sealer
+		chanID string
+		value  string
+		opened string
+		err    error
+	}{
+		{
+			desc:   "open sealed value",
+			sealer: sealer,
+			chanID: encChan,
+			value:  sealed,
+			opened: value,
+			err:    nil,
+		},
+		{
+			desc:   "open sealed value after key rotation",
+			sealer: envelope.New(rotated),
+			chanID: encChan,
+			value:  sealed,
+			opened: value,
+			err:    nil,
+		},
+		{
+			desc:   "open value which is not sealed",
+			sealer: sealer,
+			chanID: encChan,
+			value:  value,
+			opened: value,
+			err:    nil,
+		},
+		{
+			desc:   "open value of channel without key",
+			sealer: sealer,
+			chanID: plainChan,
+			value:  sealed,
+			opened: sealed,
+			err:    nil,
+		},
+		{
+			desc:   "open value sealed for another channel",
+			sealer: sealer,
+			chanID: otherChan,
+			value:  sealed,
+			opened: "",
+			err:    envelope.ErrDecrypt,
+		},
+		{
+			desc:   "open tampered value",
+			sealer: sealer,
+			chanID: encChan,
+			value:  tamper(sealed),
+			opened: "",
+			err:    envelope.ErrDecrypt,
+		},
+		{
+			desc:   "open malformed value",
+			sealer: sealer,
+			chanID: encChan,
+			value:  "mfenc:v1:1:value",
+			opened: "",
+			err:    envelope.ErrMalformedEnvelope,
+		},
+	}
+
+	for _, tc := range cases {
+		opened, err := tc.sealer.Open(tc.chanID, tc.value)
+		assert.Equal(t, tc.opened, opened, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.opened, opened))
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+}
+
+func TestEncrypted(t *testing.T) {
+	keys, err := newProvider(t, keysFile("1"))
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	sealer := envelope.New(keys)
+
+	cases := []struct {
+		desc      string
+		chanID    string
+		encrypted bool
+	}{
+		{desc: "check channel with key", chanID: encChan, encrypted: true},
+		{desc: "check channel without key", chanID: plainChan, encrypted: false},
+	}
+
+	for _, tc := range cases {
+		encrypted, err := sealer.Encrypted(tc.chanID)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.encrypted, encrypted, fmt.Sprintf("%s: expected %t got %t", tc.desc, tc.encrypted, encrypted))
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package envelope
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/mainflux/mainflux/pkg/errors"
+)
+
+var errLoadKeys = errors.New("failed to load encryption keys")
+
+// keysFile is the content of the keys file, e.g.
+//
+//	{"channels": {"<channel_id>": {"current": "2", "keys": {"1": "<base64 key>", "2": "<base64 key>"}}}}
+type keysFile struct {
+	Channels map[string]channelKeys `json:"channels"`
+}
+
+type channelKeys struct {
+	Current string            `json:"current"`
+	Keys    map[string]string `json:"keys"`
+}
+
+var _ KeyProvider = (*fileProvider)(nil)
+
+type fileProvider struct {
+	current map[string]string
+	keys    map[string]map[string][]byte
+}
+
+// NewFileProvider returns key provider which loads the base64 encoded
+// channel keys from the JSON file. Only the channels listed in the file
+// are encrypted, using their current key.
+func NewFileProvider(path string) (KeyProvider, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(errLoadKeys, err)
+	}
+
+	var kf keysFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, errors.Wrap(errLoadKeys, err)
+	}
+
+	fp := fileProvider{
+		current: make(map[string]string, len(kf.Channels)),
+		keys:    make(map[string]map[string][]byte, len(kf.Channels)),
+	}
+	for chanID, ck := range kf.Channels {
+		if _, ok := ck.Keys[ck.Current]; !ok {
+			return nil, errors.Wrap(errLoadKeys, ErrKeyNotFound)
+		}
+
+		keys := make(map[string][]byte, len(ck.Keys))
+		for id, k := range ck.Keys {
+			secret, err := base64.StdEncoding.DecodeString(k)
+			if err != nil || len(secret) != KeySize || id == "" || strings.Contains(id, ":") {
+				return nil, errors.Wrap(errLoadKeys, ErrMalformedKey)
+			}
+			keys[id] = secret
+		}
+
+		fp.current[chanID] = ck.Current
+		fp.keys[chanID] = keys
+	}
+
+	return fp, nil
+}
+
+func (fp fileProvider) Key(chanID string) (Key, error) {
+	id, ok := fp.current[chanID]
+	if !ok {
+		return Key{}, ErrKeyNotFound
+	}
+	return fp.KeyByID(chanID, id)
+}
+
+func (fp fileProvider) KeyByID(chanID, keyID string) (Key, error) {
+	secret, ok := fp.keys[chanID][keyID]
+	if !ok {
+		return Key{}, ErrKeyNotFound
+	}
+	return Key{ID: keyID, Secret: secret}, nil
+}
@@ -0,0 +1,133 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package limits provides per-thing and per-channel publish rate limits
+// and daily message quotas shared by the protocol adapters.
+package limits
+
+import (
+	"math"
+
+	"github.com/mainflux/mainflux/pkg/errors"
+)
+
+// Kinds of the entities limits are set for.
+const (
+	Thing   = "thing"
+	Channel = "channel"
+)
+
+// metadataKey is the key of thing and channel metadata holding the limits.
+const metadataKey = "limits"
+
+var (
+	// ErrRateLimited indicates that the publish rate limit is exceeded.
+	ErrRateLimited = errors.New("publish rate limit exceeded")
+
+	// ErrQuotaExceeded indicates that the daily message quota is exceeded.
+	ErrQuotaExceeded = errors.New("daily message quota exceeded")
+
+	// ErrMalformedLimits indicates malformed limits in the metadata.
+	ErrMalformedLimits = errors.New("malformed limits")
+
+	// ErrCheckLimits indicates failure to check the limits.
+	ErrCheckLimits = errors.New("failed to check limits")
+)
+
+// Limits of the messages published by the thing or to the channel.
+// Zero value means no limit.
+type Limits struct {
+	// Rate is the number of messages per second.
+	Rate float64
+
+	// Burst is the number of messages that can be published at once,
+	// exceeding the rate. It defaults to the rate rounded up.
+	Burst int64
+
+	// DailyQuota is the number of messages per day (UTC).
+	DailyQuota int64
+}
+
+// Unlimited returns true if none of the limits is set.
+func (l Limits) Unlimited() bool {
+	return l.Rate == 0 && l.DailyQuota == 0
+}
+
+// FromMetadata returns the limits from the "limits" key of the thing or
+// channel metadata, e.g. {"limits": {"rate": 10, "burst": 20, "daily_quota": 100000}}.
+func FromMetadata(metadata map[string]interface{}) (Limits, error) {
+	v, ok := metadata[metadataKey]
+	if !ok {
+		return Limits{}, nil
+	}
+	md, ok := v.(map[string]interface{})
+	if !ok {
+		return Limits{}, ErrMalformedLimits
+	}
+
+	rate, err := number(md, "rate")
+	if err != nil {
+		return Limits{}, err
+	}
+	burst, err := number(md, "burst")
+	if err != nil {
+		return Limits{}, err
+	}
+	quota, err := number(md, "daily_quota")
+	if err != nil {
+		return Limits{}, err
+	}
+
+	l := Limits{
+		Rate:       rate,
+		Burst:      int64(burst),
+		DailyQuota: int64(quota),
+	}
+	if l.Rate > 0 && l.Burst == 0 {
+		l.Burst = int64(math.Ceil(l.Rate))
+	}
+	return l, nil
+}
+
+func number(md map[string]interface{}, key string) (float64, error) {
+	v, ok := md[key]
+	if !ok {
+		return 0, nil
+	}
+	n, ok := v.(float64)
+	if !ok || n < 0 {
+		return 0, ErrMalformedLimits
+	}
+	return n, nil
+}
+
+// Cache stores the limits of things and channels.
+type Cache interface {
+	// Save stores the limits of the entity of the given kind. Unlimited
+	// limits remove the stored ones.
+	Save(kind, id string, l Limits) error
+
+	// Remove removes the limits of the entity of the given kind.
+	Remove(kind, id string) error
+}
+
+// Limiter checks messages against the limits of their publisher and channel.
+type Limiter interface {
+	// Allow counts the message published by the thing to the channel. It
+	// returns ErrRateLimited or ErrQuotaExceeded if the message exceeds
+	// the limits of either of them, in which case the message is not counted.
+	Allow(thingID, chanID string) error
+}
+
+var _ Limiter = (*unlimited)(nil)
+
+type unlimited struct{}
+
+// NewUnlimited returns limiter which allows all the messages.
+func NewUnlimited() Limiter {
+	return unlimited{}
+}
+
+func (unlimited) Allow(thingID, chanID string) error {
+	return nil
+}
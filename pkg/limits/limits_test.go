@@ -0,0 +1,197 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package limits_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/mainflux/mainflux/pkg/limits"
+	"github.com/mainflux/mainflux/pkg/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromMetadata(t *testing.T) {
+	cases := []struct {
+		desc     string
+		metadata map[string]interface{}
+		limits   limits.Limits
+		err      error
+	}{
+		{
+			desc:     "metadata without limits",
+			metadata: map[string]interface{}{"key": "value"},
+			limits:   limits.Limits{},
+			err:      nil,
+		},
+		{
+			desc: "metadata with all the limits",
+			metadata: map[string]interface{}{
+				"limits": map[string]interface{}{"rate": 10.0, "burst": 20.0, "daily_quota": 1000.0},
+			},
+			limits: limits.Limits{Rate: 10, Burst: 20, DailyQuota: 1000},
+			err:    nil,
+		},
+		{
+			desc: "metadata with rate without burst",
+			metadata: map[string]interface{}{
+				"limits": map[string]interface{}{"rate": 0.5},
+			},
+			limits: limits.Limits{Rate: 0.5, Burst: 1},
+			err:    nil,
+		},
+		{
+			desc: "metadata with negative limit",
+			metadata: map[string]interface{}{
+				"limits": map[string]interface{}{"daily_quota": -1.0},
+			},
+			limits: limits.Limits{},
+			err:    limits.ErrMalformedLimits,
+		},
+		{
+			desc: "metadata with limit of invalid type",
+			metadata: map[string]interface{}{
+				"limits": map[string]interface{}{"rate": "10"},
+			},
+			limits: limits.Limits{},
+			err:    limits.ErrMalformedLimits,
+		},
+		{
+			desc:     "metadata with limits of invalid type",
+			metadata: map[string]interface{}{"limits": 10.0},
+			limits:   limits.Limits{},
+			err:      limits.ErrMalformedLimits,
+		},
+	}
+
+	for _, tc := range cases {
+		l, err := limits.FromMetadata(tc.metadata)
+		assert.Equal(t, tc.limits, l, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.limits, l))
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+}
+
+func TestAllow(t *testing.T) {
+	cache := limits.NewCache(redisClient)
+	limiter := limits.NewLimiter(redisClient)
+
+	ids := make([]string, 4)
+	for i := range ids {
+		id, err := uuid.New().ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		ids[i] = id
+	}
+	unlimitedThing, ratedThing, quotaThing, chanID := ids[0], ids[1], ids[2], ids[3]
+
+	err := cache.Save(limits.Thing, ratedThing, limits.Limits{Rate: 0.001, Burst: 2})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = cache.Save(limits.Thing, quotaThing, limits.Limits{DailyQuota: 1})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc    string
+		thingID string
+		chanID  string
+		err     error
+	}{
+		{
+			desc:    "allow message of unlimited thing",
+			thingID: unlimitedThing,
+			chanID:  chanID,
+			err:     nil,
+		},
+		{
+			desc:    "allow message within burst",
+			thingID: ratedThing,
+			chanID:  chanID,
+			err:     nil,
+		},
+		{
+			desc:    "allow last message within burst",
+			thingID: ratedThing,
+			chanID:  chanID,
+			err:     nil,
+		},
+		{
+			desc:    "allow message exceeding rate",
+			thingID: ratedThing,
+			chanID:  chanID,
+			err:     limits.ErrRateLimited,
+		},
+		{
+			desc:    "allow message within quota",
+			thingID: quotaThing,
+			chanID:  chanID,
+			err:     nil,
+		},
+		{
+			desc:    "allow message exceeding quota",
+			thingID: quotaThing,
+			chanID:  chanID,
+			err:     limits.ErrQuotaExceeded,
+		},
+	}
+
+	for _, tc := range cases {
+		err := limiter.Allow(tc.thingID, tc.chanID)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+}
+
+func TestAllowChannel(t *testing.T) {
+	cache := limits.NewCache(redisClient)
+	limiter := limits.NewLimiter(redisClient)
+
+	ids := make([]string, 3)
+	for i := range ids {
+		id, err := uuid.New().ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		ids[i] = id
+	}
+	thingID, otherThingID, chanID := ids[0], ids[1], ids[2]
+
+	err := cache.Save(limits.Channel, chanID, limits.Limits{DailyQuota: 2})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = cache.Save(limits.Thing, otherThingID, limits.Limits{DailyQuota: 1})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc    string
+		thingID string
+		err     error
+	}{
+		{
+			desc:    "allow message within thing and channel quota",
+			thingID: otherThingID,
+			err:     nil,
+		},
+		{
+			desc:    "allow message exceeding thing quota",
+			thingID: otherThingID,
+			err:     limits.ErrQuotaExceeded,
+		},
+		{
+			desc:    "allow message within channel quota not counting rejected message",
+			thingID: thingID,
+			err:     nil,
+		},
+		{
+			desc:    "allow message exceeding channel quota",
+			thingID: thingID,
+			err:     limits.ErrQuotaExceeded,
+		},
+	}
+
+	for _, tc := range cases {
+		err := limiter.Allow(tc.thingID, chanID)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+
+	err = cache.Remove(limits.Channel, chanID)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = limiter.Allow(thingID, chanID)
+	assert.Nil(t, err, fmt.Sprintf("allow message after removing channel limits: expected nil got %s", err))
+}
@@ -0,0 +1,23 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package limits
+
+var _ Limiter = (*limiterMock)(nil)
+
+type limiterMock struct {
+	errs map[string]error
+}
+
+// NewMock creates limiter mock which rejects messages published by the
+// things or to the channels with the given IDs with the mapped errors.
+func NewMock(errs map[string]error) Limiter {
+	return limiterMock{errs: errs}
+}
+
+func (lm limiterMock) Allow(thingID, chanID string) error {
+	if err, ok := lm.errs[thingID]; ok {
+		return err
+	}
+	return lm.errs[chanID]
+}
@@ -0,0 +1,150 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package limits
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/mainflux/mainflux/pkg/errors"
+)
+
+const (
+	keyPrefix    = "limits"
+	bucketPrefix = "limits:bucket"
+	quotaPrefix  = "limits:quota"
+
+	dayFormat = "20060102"
+
+	// Quota counters are kept a day longer than needed to tolerate clock
+	// differences between the adapters.
+	quotaTTL = 48 * time.Hour
+)
+
+// Result codes of the allow script.
+const (
+	allowed = iota
+	rateLimited
+	quotaExceeded
+)
+
+// allowScript checks the token bucket and the daily counter of each
+// limited entity given as a triplet of limits, bucket and counter keys.
+// State is updated only if the message is allowed by all of them, so
+// that rejected messages are not counted.
+var allowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local quotaTTL = tonumber(ARGV[2])
+local updates = {}
+for i = 1, #KEYS, 3 do
+	local l = redis.call('HMGET', KEYS[i], 'rate', 'burst', 'quota')
+	local rate = tonumber(l[1]) or 0
+	local burst = tonumber(l[2]) or 0
+	local quota = tonumber(l[3]) or 0
+	if rate > 0 then
+		local b = redis.call('HMGET', KEYS[i+1], 'tokens', 'ts')
+		local tokens = tonumber(b[1]) or burst
+		local ts = tonumber(b[2]) or now
+		if now > ts then
+			tokens = math.min(burst, tokens + (now - ts) * rate)
+		end
+		if tokens < 1 then
+			return 1
+		end
+		table.insert(updates, {KEYS[i+1], tostring(tokens - 1), math.ceil(burst / rate) + 1})
+	end
+	if quota > 0 then
+		local used = tonumber(redis.call('GET', KEYS[i+2])) or 0
+		if used >= quota then
+			return 2
+		end
+		table.insert(updates, {KEYS[i+2]})
+	end
+end
+for _, u in ipairs(updates) do
+	if #u == 1 then
+		redis.call('INCR', u[1])
+		redis.call('EXPIRE', u[1], quotaTTL)
+	else
+		redis.call('HMSET', u[1], 'tokens', u[2], 'ts', ARGV[1])
+		redis.call('EXPIRE', u[1], u[3])
+	end
+end
+return 0
+`)
+
+var _ Cache = (*cache)(nil)
+
+type cache struct {
+	client *redis.Client
+}
+
+// NewCache returns redis limits cache implementation.
+func NewCache(client *redis.Client) Cache {
+	return cache{client: client}
+}
+
+func (c cache) Save(kind, id string, l Limits) error {
+	if l.Unlimited() {
+		return c.Remove(kind, id)
+	}
+
+	key := fmt.Sprintf("%s:%s:%s", keyPrefix, kind, id)
+	fields := map[string]interface{}{
+		"rate":  strconv.FormatFloat(l.Rate, 'f', -1, 64),
+		"burst": l.Burst,
+		"quota": l.DailyQuota,
+	}
+	return c.client.HMSet(key, fields).Err()
+}
+
+func (c cache) Remove(kind, id string) error {
+	key := fmt.Sprintf("%s:%s:%s", keyPrefix, kind, id)
+	bucket := fmt.Sprintf("%s:%s:%s", bucketPrefix, kind, id)
+	return c.client.Del(key, bucket).Err()
+}
+
+var _ Limiter = (*limiter)(nil)
+
+type limiter struct {
+	client *redis.Client
+}
+
+// NewLimiter returns limiter which checks the limits stored in the redis
+// limits cache. Rate limits are enforced using a token bucket per thing
+// and channel, shared by all the adapters using the same redis instance.
+func NewLimiter(client *redis.Client) Limiter {
+	return limiter{client: client}
+}
+
+func (lm limiter) Allow(thingID, chanID string) error {
+	now := time.Now().UTC()
+	day := now.Format(dayFormat)
+
+	var keys []string
+	for _, e := range [][2]string{{Thing, thingID}, {Channel, chanID}} {
+		keys = append(keys,
+			fmt.Sprintf("%s:%s:%s", keyPrefix, e[0], e[1]),
+			fmt.Sprintf("%s:%s:%s", bucketPrefix, e[0], e[1]),
+			fmt.Sprintf("%s:%s:%s:%s", quotaPrefix, e[0], e[1], day),
+		)
+	}
+
+	ts := strconv.FormatFloat(float64(now.UnixNano())/float64(time.Second), 'f', 6, 64)
+	res, err := allowScript.Run(lm.client, keys, ts, int64(quotaTTL/time.Second)).Int()
+	if err != nil {
+		return errors.Wrap(ErrCheckLimits, err)
+	}
+
+	switch res {
+	case rateLimited:
+		return ErrRateLimited
+	case quotaExceeded:
+		return ErrQuotaExceeded
+	default:
+		return nil
+	}
+}
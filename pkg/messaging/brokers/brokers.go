@@ -0,0 +1,95 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package brokers creates the messaging PubSub of the message broker
+// selected in the service configuration.
+package brokers
+
+import (
+	"errors"
+
+	log "github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/jetstream"
+	"github.com/mainflux/mainflux/pkg/messaging/kafka"
+	"github.com/mainflux/mainflux/pkg/messaging/nats"
+	"github.com/mainflux/mainflux/pkg/messaging/rabbitmq"
+)
+
+const (
+	// NATS represents core NATS broker, which delivers messages
+	// at most once to the subscribers connected at the time.
+	NATS = "nats"
+
+	// JetStream represents NATS JetStream broker, which stores messages
+	// and delivers them at least once to the durable consumers.
+	JetStream = "jetstream"
+
+	// Kafka represents Kafka broker, which stores messages of all the
+	// channels in the single topic partitioned by the channel ID.
+	Kafka = "kafka"
+
+	// RabbitMQ represents RabbitMQ broker, which routes messages
+	// to the subscription queues through the topic exchange.
+	RabbitMQ = "rabbitmq"
+)
+
+var (
+	// ErrUnknownBroker indicates that the broker type is not supported.
+	ErrUnknownBroker = errors.New("unknown message broker type")
+
+	// ErrLeaseUnsupported indicates that the broker doesn't support leases.
+	ErrLeaseUnsupported = errors.New("message broker doesn't support leases")
+)
+
+// PubSub wraps messaging PubSub exposing
+// Close() method for broker connection.
+type PubSub interface {
+	messaging.PubSub
+	Close()
+}
+
+// Config contains message broker configuration.
+type Config struct {
+	// Type is the broker type, NATS by default.
+	Type string
+
+	// URL is the broker URL. Kafka URL is the comma separated
+	// list of the broker addresses.
+	URL string
+
+	// JetStream contains stream and consumers configuration,
+	// used if the broker type is JetStream.
+	JetStream jetstream.Config
+}
+
+// NewPubSub returns publisher/subscriber of the configured broker.
+// Queue specifies the queue subscribers of the service instances share.
+func NewPubSub(cfg Config, queue string, logger log.Logger) (PubSub, error) {
+	switch cfg.Type {
+	case "", NATS:
+		return nats.NewPubSub(cfg.URL, queue, logger)
+	case JetStream:
+		return jetstream.NewPubSub(cfg.URL, queue, cfg.JetStream, logger)
+	case Kafka:
+		return kafka.NewPubSub(cfg.URL, queue, logger)
+	case RabbitMQ:
+		return rabbitmq.NewPubSub(cfg.URL, queue, logger)
+	default:
+		return nil, ErrUnknownBroker
+	}
+}
+
+// NewLease returns the named lease shared by the service instances, which
+// is owned by a single instance at a time. Only NATS based brokers support
+// leases.
+func NewLease(cfg Config, name string) (*nats.Lease, error) {
+	switch cfg.Type {
+	case "", NATS, JetStream:
+		return nats.NewLease(cfg.URL, name)
+	case Kafka, RabbitMQ:
+		return nil, ErrLeaseUnsupported
+	default:
+		return nil, ErrUnknownBroker
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package messaging
+
+import "strings"
+
+// Well-known message headers used for request/response patterns. Devices
+// publish the request with the reply-to subtopic and the correlation ID,
+// and the response is published to the reply-to subtopic of the channel
+// with the same correlation ID.
+const (
+	// HeaderCorrelationID is the ID correlating the response with the request.
+	HeaderCorrelationID = "correlation-id"
+
+	// HeaderReplyTo is the subtopic of the channel the response is expected on.
+	HeaderReplyTo = "reply-to"
+)
+
+// HeaderName returns the canonical message header name. Header names
+// are case insensitive, so they're kept in lower case.
+func HeaderName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// SelectHeaders returns the headers with the given names, or nil if the
+// message has none of them.
+func SelectHeaders(headers map[string]string, names []string) map[string]string {
+	var ret map[string]string
+	for _, name := range names {
+		v, ok := headers[name]
+		if !ok {
+			continue
+		}
+		if ret == nil {
+			ret = make(map[string]string, len(names))
+		}
+		ret[name] = v
+	}
+	return ret
+}
+
+// ParseHeaderNames returns the canonical header names from the comma
+// separated list, skipping the empty ones.
+func ParseHeaderNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = HeaderName(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,86 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package messaging_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectHeaders(t *testing.T) {
+	headers := map[string]string{
+		messaging.HeaderCorrelationID: "42",
+		messaging.HeaderReplyTo:       "responses",
+		"uber-trace-id":               "trace",
+	}
+
+	cases := []struct {
+		desc     string
+		headers  map[string]string
+		names    []string
+		selected map[string]string
+	}{
+		{
+			desc:     "select existing headers",
+			headers:  headers,
+			names:    []string{messaging.HeaderCorrelationID, messaging.HeaderReplyTo},
+			selected: map[string]string{messaging.HeaderCorrelationID: "42", messaging.HeaderReplyTo: "responses"},
+		},
+		{
+			desc:     "select existing and missing headers",
+			headers:  headers,
+			names:    []string{messaging.HeaderCorrelationID, "missing"},
+			selected: map[string]string{messaging.HeaderCorrelationID: "42"},
+		},
+		{
+			desc:     "select missing headers",
+			headers:  headers,
+			names:    []string{"missing"},
+			selected: nil,
+		},
+		{
+			desc:     "select headers of message without headers",
+			headers:  nil,
+			names:    []string{messaging.HeaderCorrelationID},
+			selected: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		selected := messaging.SelectHeaders(tc.headers, tc.names)
+		assert.Equal(t, tc.selected, selected, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.selected, selected))
+	}
+}
+
+func TestParseHeaderNames(t *testing.T) {
+	cases := []struct {
+		desc  string
+		list  string
+		names []string
+	}{
+		{
+			desc:  "parse header names",
+			list:  "correlation-id,reply-to",
+			names: []string{messaging.HeaderCorrelationID, messaging.HeaderReplyTo},
+		},
+		{
+			desc:  "parse header names with spaces and capitals",
+			list:  " Correlation-ID , ,Reply-To",
+			names: []string{messaging.HeaderCorrelationID, messaging.HeaderReplyTo},
+		},
+		{
+			desc:  "parse empty header names",
+			list:  "",
+			names: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		names := messaging.ParseHeaderNames(tc.list)
+		assert.Equal(t, tc.names, names, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.names, names))
+	}
+}
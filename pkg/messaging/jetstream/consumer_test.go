@@ -0,0 +1,29 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package jetstream
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumerName(t *testing.T) {
+	cases := []struct {
+		desc     string
+		topic    string
+		consumer string
+	}{
+		{desc: "topic with dots", topic: "channels.a.b", consumer: "test-channels-a-b"},
+		{desc: "topic with dash", topic: "channels.a-b", consumer: "test-channels-a_2db"},
+		{desc: "topic with underscore", topic: "channels.a_b", consumer: "test-channels-a_5fb"},
+		{desc: "topic with wildcards", topic: "channels.*.>", consumer: "test-channels-_2a-_3e"},
+	}
+
+	for _, tc := range cases {
+		consumer := consumerName("test", tc.topic)
+		assert.Equal(t, tc.consumer, consumer, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.consumer, consumer))
+	}
+}
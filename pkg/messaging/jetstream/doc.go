@@ -0,0 +1,15 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package jetstream holds the implementation of the PubSub interface for
+// the NATS JetStream persistence layer. Unlike the core NATS PubSub,
+// messages are stored in the stream and delivered to the consumers at
+// least once: messages are acknowledged when the handler succeeds and
+// redelivered after the ack wait when it fails, until the maximum number
+// of deliveries is reached and the message is moved to the dead-letter
+// subject, stored in the dead-letter stream. Subscriptions
+// of the queue use durable consumers shared by the instances of the service,
+// which receive the messages stored while the service is down. The other
+// subscriptions use ephemeral consumers, deleted once unsubscribed, which
+// receive only the messages published after subscribing.
+package jetstream
@@ -0,0 +1,295 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package jetstream
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	log "github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	broker "github.com/nats-io/nats.go"
+)
+
+const (
+	chansPrefix = "channels"
+
+	// DeadLetterPrefix is the prefix of the subjects messages are moved to
+	// once the maximum number of deliveries is reached. Dead-letter subject
+	// is "<prefix>.<consumer>.<message subject>".
+	DeadLetterPrefix = "deadletter"
+
+	// DeadLetterStream is the name of the stream which stores the messages
+	// published to the dead-letter subjects of all the consumers.
+	DeadLetterStream = "deadletter"
+
+	defStream     = "mainflux"
+	defMaxDeliver = 5
+	defAckWait    = 30 * time.Second
+)
+
+var (
+	errAlreadySubscribed = errors.New("already subscribed to topic")
+	errNotSubscribed     = errors.New("not subscribed")
+	errEmptyTopic        = errors.New("empty topic")
+	errEmptyDurable      = errors.New("empty durable name")
+)
+
+// Config contains JetStream stream and consumers configuration.
+type Config struct {
+	// Stream is the name of the stream which stores channel messages.
+	Stream string
+
+	// Durable is the name consumers of the service are named after,
+	// shared by all the instances of the service.
+	Durable string
+
+	// MaxDeliver is the maximum number of message deliveries.
+	MaxDeliver int
+
+	// AckWait is the time after which the message is redelivered if it's
+	// not acknowledged. Messages the handler failed to handle are not
+	// acknowledged, so the consumer has the time to recover in between.
+	AckWait time.Duration
+}
+
+var _ messaging.PubSub = (*pubsub)(nil)
+
+// PubSub wraps messaging PubSub exposing
+// Close() method for NATS connection.
+type PubSub interface {
+	messaging.PubSub
+	Close()
+}
+
+type pubsub struct {
+	conn          *broker.Conn
+	js            broker.JetStreamContext
+	cfg           Config
+	queue         string
+	logger        log.Logger
+	mu            sync.Mutex
+	subscriptions map[string]*broker.Subscription
+}
+
+// NewPubSub returns JetStream message publisher/subscriber. The stream
+// storing messages of all the channels and the dead-letter stream are
+// created if they don't exist.
+// If queue is specified, consumers are shared by the service instances
+// subscribed to the same queue, and each message is delivered to only
+// one of them.
+func NewPubSub(url, queue string, cfg Config, logger log.Logger) (PubSub, error) {
+	if cfg.Durable == "" {
+		return nil, errEmptyDurable
+	}
+	if cfg.Stream == "" {
+		cfg.Stream = defStream
+	}
+	if cfg.MaxDeliver <= 0 {
+		cfg.MaxDeliver = defMaxDeliver
+	}
+	if cfg.AckWait <= 0 {
+		cfg.AckWait = defAckWait
+	}
+
+	conn, err := broker.Connect(url)
+	if err != nil {
+		return nil, err
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := createStream(js, cfg.Stream, chansPrefix); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := createStream(js, DeadLetterStream, DeadLetterPrefix); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	ret := &pubsub{
+		conn:          conn,
+		js:            js,
+		cfg:           cfg,
+		queue:         queue,
+		logger:        logger,
+		subscriptions: make(map[string]*broker.Subscription),
+	}
+	return ret, nil
+}
+
+// createStream creates the stream storing messages of all the subjects
+// with the given prefix.
+func createStream(js broker.JetStreamContext, name, prefix string) error {
+	if _, err := js.StreamInfo(name); err == nil {
+		return nil
+	}
+
+	_, err := js.AddStream(&broker.StreamConfig{
+		Name:     name,
+		Subjects: []string{fmt.Sprintf("%s.>", prefix)},
+		Storage:  broker.FileStorage,
+	})
+	return err
+}
+
+// Publish waits for the message to be stored in the stream.
+func (ps *pubsub) Publish(topic string, msg messaging.Message) error {
+	data, err := proto.Marshal(&msg)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("%s.%s", chansPrefix, topic)
+	if msg.Subtopic != "" {
+		subject = fmt.Sprintf("%s.%s", subject, msg.Subtopic)
+	}
+	_, err = ps.js.Publish(subject, data)
+	return err
+}
+
+func (ps *pubsub) Subscribe(topic string, handler messaging.MessageHandler) error {
+	if topic == "" {
+		return errEmptyTopic
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if _, ok := ps.subscriptions[topic]; ok {
+		return errAlreadySubscribed
+	}
+
+	consumer := consumerName(ps.cfg.Durable, topic)
+	nh := ps.jsHandler(consumer, handler)
+	opts := []broker.SubOpt{
+		broker.ManualAck(),
+		broker.AckExplicit(),
+		broker.MaxDeliver(ps.cfg.MaxDeliver),
+		broker.AckWait(ps.cfg.AckWait),
+	}
+
+	if ps.queue != "" {
+		opts = append(opts, broker.Durable(consumer), broker.DeliverAll())
+		sub, err := ps.js.QueueSubscribe(topic, ps.queue, nh, opts...)
+		if err != nil {
+			return err
+		}
+		ps.subscriptions[topic] = sub
+		return nil
+	}
+
+	// Subscriptions which aren't shared, such as the ones of the adapter
+	// clients, use ephemeral consumers receiving only the new messages.
+	opts = append(opts, broker.DeliverNew())
+	sub, err := ps.js.Subscribe(topic, nh, opts...)
+	if err != nil {
+		return err
+	}
+	ps.subscriptions[topic] = sub
+	return nil
+}
+
+// Unsubscribe drains the subscription of the queue, keeping the durable
+// consumer of the topic, since it's shared by all the instances of the
+// service. Ephemeral consumers of the other subscriptions are deleted.
+func (ps *pubsub) Unsubscribe(topic string) error {
+	if topic == "" {
+		return errEmptyTopic
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	sub, ok := ps.subscriptions[topic]
+	if !ok {
+		return errNotSubscribed
+	}
+
+	// Unlike Unsubscribe, Drain doesn't delete the durable consumer.
+	unsubscribe := sub.Unsubscribe
+	if ps.queue != "" {
+		unsubscribe = sub.Drain
+	}
+	if err := unsubscribe(); err != nil {
+		return err
+	}
+
+	delete(ps.subscriptions, topic)
+	return nil
+}
+
+// Close drains the subscriptions, keeping the durable consumers, so that
+// the messages received while the service is down are delivered once it
+// is up again.
+func (ps *pubsub) Close() {
+	if err := ps.conn.Drain(); err != nil {
+		ps.logger.Warn(fmt.Sprintf("Failed to drain NATS connection: %s", err))
+		ps.conn.Close()
+	}
+}
+
+func (ps *pubsub) jsHandler(consumer string, h messaging.MessageHandler) broker.MsgHandler {
+	return func(m *broker.Msg) {
+		var msg messaging.Message
+		if err := proto.Unmarshal(m.Data, &msg); err != nil {
+			ps.logger.Warn(fmt.Sprintf("Failed to unmarshal received message: %s", err))
+			// Message can't be handled no matter how many times it's delivered.
+			ps.deadLetter(consumer, m)
+			return
+		}
+
+		err := h(msg)
+		if err == nil {
+			if err := m.Ack(); err != nil {
+				ps.logger.Warn(fmt.Sprintf("Failed to acknowledge message: %s", err))
+			}
+			return
+		}
+		ps.logger.Warn(fmt.Sprintf("Failed to handle Mainflux message: %s", err))
+
+		// Message is redelivered once the ack wait expires.
+		meta, err := m.Metadata()
+		if err == nil && meta.NumDelivered >= uint64(ps.cfg.MaxDeliver) {
+			ps.deadLetter(consumer, m)
+		}
+	}
+}
+
+// deadLetter stores the message to the dead-letter stream and terminates
+// its delivery to the consumer. Delivery is terminated only once the
+// message is stored, so that it's kept in the channels stream otherwise.
+func (ps *pubsub) deadLetter(consumer string, m *broker.Msg) {
+	subject := fmt.Sprintf("%s.%s.%s", DeadLetterPrefix, consumer, m.Subject)
+	if _, err := ps.js.Publish(subject, m.Data); err != nil {
+		ps.logger.Error(fmt.Sprintf("Failed to publish message to dead-letter subject %s: %s", subject, err))
+		return
+	}
+	if err := m.Term(); err != nil {
+		ps.logger.Warn(fmt.Sprintf("Failed to terminate message delivery: %s", err))
+	}
+}
+
+// consumerName returns the consumer name of the topic. Consumer names can
+// contain only letters, digits, dashes and underscores, so dots are replaced
+// with dashes and the other characters are escaped as an underscore followed
+// by the hex code of the byte, which keeps the names of the topics distinct.
+func consumerName(durable, topic string) string {
+	var b strings.Builder
+	for i := 0; i < len(topic); i++ {
+		switch c := topic[i]; {
+		case c == '.':
+			b.WriteByte('-')
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "_%02x", c)
+		}
+	}
+	return fmt.Sprintf("%s-%s", durable, b.String())
+}
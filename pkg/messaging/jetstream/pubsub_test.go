@@ -0,0 +1,254 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package jetstream_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/gogo/protobuf/proto"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/jetstream"
+	"github.com/mainflux/mainflux/pkg/messaging/pubsubtest"
+	broker "github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	chansPrefix = "channels"
+	channel     = "9b7b1b3f-b1b0-46a8-a717-b8213f9eda3b"
+	subtopic    = "engine"
+	timeout     = 10 * time.Second
+)
+
+var (
+	data      = []byte("payload")
+	errHandle = errors.New("failed to handle message")
+)
+
+func TestPubsub(t *testing.T) {
+	topic := "topic"
+	msgChan := make(chan messaging.Message)
+	handler := func(msg messaging.Message) error {
+		msgChan <- msg
+		return nil
+	}
+
+	err := pubsub.Subscribe(fmt.Sprintf("%s.%s", chansPrefix, topic), handler)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = pubsub.Subscribe(fmt.Sprintf("%s.%s.%s", chansPrefix, topic, subtopic), handler)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc     string
+		channel  string
+		subtopic string
+		payload  []byte
+	}{
+		{
+			desc:    "publish message with nil payload",
+			payload: nil,
+		},
+		{
+			desc:    "publish message with string payload",
+			payload: data,
+		},
+		{
+			desc:    "publish message with channel",
+			payload: data,
+			channel: channel,
+		},
+		{
+			desc:     "publish message with subtopic",
+			payload:  data,
+			subtopic: subtopic,
+		},
+		{
+			desc:     "publish message with channel and subtopic",
+			payload:  data,
+			channel:  channel,
+			subtopic: subtopic,
+		},
+	}
+
+	for _, tc := range cases {
+		expectedMsg := messaging.Message{
+			Channel:  tc.channel,
+			Subtopic: tc.subtopic,
+			Payload:  tc.payload,
+		}
+
+		err = pubsub.Publish(topic, expectedMsg)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+		receivedMsg := <-msgChan
+		assert.Equal(t, expectedMsg, receivedMsg, fmt.Sprintf("%s: expected %+v got %+v\n", tc.desc, expectedMsg, receivedMsg))
+	}
+}
+
+func TestRedelivery(t *testing.T) {
+	topic := "redelivery"
+	msgChan := make(chan messaging.Message, maxDeliver)
+	attempts := 0
+	err := pubsub.Subscribe(fmt.Sprintf("%s.%s", chansPrefix, topic), func(msg messaging.Message) error {
+		attempts++
+		if attempts == 1 {
+			return errHandle
+		}
+		msgChan <- msg
+		return nil
+	})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	expectedMsg := messaging.Message{Channel: channel, Payload: data}
+	err = pubsub.Publish(topic, expectedMsg)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	select {
+	case receivedMsg := <-msgChan:
+		assert.Equal(t, expectedMsg, receivedMsg, fmt.Sprintf("expected %+v got %+v\n", expectedMsg, receivedMsg))
+		assert.Equal(t, 2, attempts, fmt.Sprintf("expected message to be delivered %d times got %d\n", 2, attempts))
+	case <-time.After(timeout):
+		assert.Fail(t, "expected message to be redelivered")
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	topic := "failing"
+	conn, err := broker.Connect(address)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	defer conn.Close()
+
+	subject := fmt.Sprintf("%s.%s", chansPrefix, topic)
+	dlChan := make(chan *broker.Msg, 1)
+	_, err = conn.ChanSubscribe(fmt.Sprintf("%s.>", jetstream.DeadLetterPrefix), dlChan)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	err = pubsub.Subscribe(subject, func(msg messaging.Message) error {
+		return errHandle
+	})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	expectedMsg := messaging.Message{Channel: channel, Payload: data}
+	err = pubsub.Publish(topic, expectedMsg)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	select {
+	case m := <-dlChan:
+		expectedSubject := fmt.Sprintf("%s.%s-%s-%s.%s", jetstream.DeadLetterPrefix, durable, chansPrefix, topic, subject)
+		assert.Equal(t, expectedSubject, m.Subject, fmt.Sprintf("expected dead-letter subject %s got %s\n", expectedSubject, m.Subject))
+		var receivedMsg messaging.Message
+		err := proto.Unmarshal(m.Data, &receivedMsg)
+		assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		assert.Equal(t, expectedMsg, receivedMsg, fmt.Sprintf("expected %+v got %+v\n", expectedMsg, receivedMsg))
+	case <-time.After(timeout):
+		assert.Fail(t, "expected message to be moved to dead-letter subject")
+	}
+
+	js, err := conn.JetStream()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	sub, err := js.SubscribeSync(fmt.Sprintf("%s.>", jetstream.DeadLetterPrefix), broker.BindStream(jetstream.DeadLetterStream), broker.DeliverAll())
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	defer sub.Unsubscribe()
+	m, err := sub.NextMsg(timeout)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	var storedMsg messaging.Message
+	err = proto.Unmarshal(m.Data, &storedMsg)
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.Equal(t, expectedMsg, storedMsg, fmt.Sprintf("expected dead-letter stream to store %+v got %+v\n", expectedMsg, storedMsg))
+}
+
+func TestUnsubscribe(t *testing.T) {
+	topic := "unsubscribe"
+	conn, err := broker.Connect(address)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	defer conn.Close()
+	js, err := conn.JetStream()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	queued, err := jetstream.NewPubSub(address, "queue", jetstream.Config{Durable: durable, MaxDeliver: maxDeliver}, testLog)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	defer queued.Close()
+
+	subject := fmt.Sprintf("%s.%s", chansPrefix, topic)
+	err = queued.Subscribe(subject, func(msg messaging.Message) error {
+		return nil
+	})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = queued.Unsubscribe(subject)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	// Durable consumer is shared by the instances of the service,
+	// so it's kept once one of them unsubscribes.
+	consumer := fmt.Sprintf("%s-%s-%s", durable, chansPrefix, topic)
+	_, err = js.ConsumerInfo("mainflux", consumer)
+	assert.Nil(t, err, fmt.Sprintf("unsubscribe queue: expected consumer %s to be kept got %s", consumer, err))
+
+	msgChan := make(chan messaging.Message, 1)
+	expectedMsg := messaging.Message{Channel: channel, Payload: data}
+	err = queued.Publish(topic, expectedMsg)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = queued.Subscribe(subject, func(msg messaging.Message) error {
+		msgChan <- msg
+		return nil
+	})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	select {
+	case receivedMsg := <-msgChan:
+		assert.Equal(t, expectedMsg, receivedMsg, fmt.Sprintf("expected message published while unsubscribed %+v got %+v\n", expectedMsg, receivedMsg))
+	case <-time.After(timeout):
+		assert.Fail(t, "expected message published while unsubscribed to be delivered")
+	}
+
+	// Ephemeral consumers of the subscriptions which aren't shared receive
+	// only the new messages and are deleted once unsubscribed.
+	info, err := js.StreamInfo("mainflux")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	consumers := info.State.Consumers
+
+	err = pubsub.Subscribe(subject, func(msg messaging.Message) error {
+		msgChan <- msg
+		return nil
+	})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	select {
+	case receivedMsg := <-msgChan:
+		assert.Fail(t, fmt.Sprintf("subscribe: expected stored messages not to be delivered got %+v", receivedMsg))
+	case <-time.After(time.Second):
+	}
+
+	err = pubsub.Unsubscribe(subject)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	info, err = js.StreamInfo("mainflux")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.Equal(t, consumers, info.State.Consumers, fmt.Sprintf("unsubscribe: expected %d consumers got %d", consumers, info.State.Consumers))
+}
+
+func TestConformance(t *testing.T) {
+	pubsubtest.Run(t, pubsubtest.Config{
+		New: func(queue string) (messaging.PubSub, error) {
+			// Subscribers share the durable consumer only
+			// if they're members of the same queue group.
+			durable := queue
+			if durable == "" {
+				id, err := uuid.NewV4()
+				if err != nil {
+					return nil, err
+				}
+				durable = id.String()
+			}
+			cfg := jetstream.Config{
+				Durable:    durable,
+				MaxDeliver: maxDeliver,
+				AckWait:    time.Second,
+			}
+			return jetstream.NewPubSub(address, queue, cfg, testLog)
+		},
+	})
+}
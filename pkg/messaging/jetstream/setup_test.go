@@ -0,0 +1,82 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package jetstream_test
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/messaging/jetstream"
+	dockertest "github.com/ory/dockertest/v3"
+)
+
+const (
+	durable    = "test"
+	maxDeliver = 3
+)
+
+var (
+	pubsub  jetstream.PubSub
+	address string
+	testLog logger.Logger
+)
+
+func TestMain(m *testing.M) {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		log.Fatalf("Could not connect to docker: %s", err)
+	}
+
+	container, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "nats",
+		Tag:        "2.2.6",
+		Cmd:        []string{"-js"},
+	})
+	if err != nil {
+		log.Fatalf("Could not start container: %s", err)
+	}
+	handleInterrupt(pool, container)
+
+	address = fmt.Sprintf("%s:%s", "localhost", container.GetPort("4222/tcp"))
+	testLog, err = logger.New(os.Stdout, "error")
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+	cfg := jetstream.Config{
+		Durable:    durable,
+		MaxDeliver: maxDeliver,
+		AckWait:    time.Second,
+	}
+	if err := pool.Retry(func() error {
+		pubsub, err = jetstream.NewPubSub(address, "", cfg, testLog)
+		return err
+	}); err != nil {
+		log.Fatalf("Could not connect to docker: %s", err)
+	}
+
+	code := m.Run()
+	if err := pool.Purge(container); err != nil {
+		log.Fatalf("Could not purge container: %s", err)
+	}
+
+	os.Exit(code)
+}
+
+func handleInterrupt(pool *dockertest.Pool, container *dockertest.Resource) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-c
+		if err := pool.Purge(container); err != nil {
+			log.Fatalf("Could not purge container: %s", err)
+		}
+		os.Exit(0)
+	}()
+}
@@ -0,0 +1,13 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package kafka holds the implementation of the Publisher and PubSub
+// interfaces for the Kafka messaging system. Messages of all the channels
+// are published to the single Kafka topic, keyed by the channel ID, so the
+// messages of the channel are stored in the same partition and consumed in
+// the order they are published. Subject of the message, made of the channel
+// and subtopic, is sent in the record header, and subscribers consume the
+// messages whose subject matches the subscribed NATS-like subject.
+// Messages are not redelivered if the handler fails to handle them, so
+// the services which can't afford to lose messages should use JetStream.
+package kafka
@@ -0,0 +1,391 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	log "github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	broker "github.com/segmentio/kafka-go"
+)
+
+const (
+	chansPrefix = "channels"
+
+	// Topic is the Kafka topic channel messages are published to.
+	Topic = "mainflux.channels"
+
+	subjectHeader = "subject"
+	batchTimeout  = 10 * time.Millisecond
+
+	// Number of attempts to read partitions of the topic, which is
+	// created by the broker once its metadata is requested.
+	partitionsAttempts = 10
+	partitionsDelay    = 100 * time.Millisecond
+)
+
+var (
+	errAlreadySubscribed = errors.New("already subscribed to topic")
+	errNotSubscribed     = errors.New("not subscribed")
+	errEmptyTopic        = errors.New("empty topic")
+	errNoPartitions      = errors.New("topic has no partitions")
+)
+
+var _ messaging.PubSub = (*pubsub)(nil)
+
+// PubSub wraps messaging PubSub exposing
+// Close() method for Kafka connections.
+type PubSub interface {
+	messaging.PubSub
+	Close()
+}
+
+// consumer reads the topic using a single consumer group reader,
+// or a reader per partition if it doesn't belong to a consumer group.
+type consumer struct {
+	ctx     context.Context
+	readers []*broker.Reader
+	cancel  context.CancelFunc
+	done    sync.WaitGroup
+}
+
+type pubsub struct {
+	brokers []string
+	writer  *broker.Writer
+	queue   string
+	logger  log.Logger
+	// mu guards the subscriptions management, while hmu guards
+	// the subscriptions handlers the messages are dispatched to.
+	mu            sync.Mutex
+	hmu           sync.RWMutex
+	subscriptions map[string]messaging.MessageHandler
+	groups        map[string]*consumer
+	partitions    *consumer
+}
+
+// NewPubSub returns Kafka message publisher/subscriber. URL is the comma
+// separated list of Kafka brokers addresses. If queue is specified, it's
+// used as the consumer group of the subscriptions, so that the service
+// instances subscribed to the same queue share the messages. Otherwise,
+// subscriptions share the readers of all the partitions of the topic,
+// which don't belong to a consumer group and start from the messages
+// published after the first subscription is made, so no consumer groups
+// are left behind once they're removed. Each message read is dispatched to
+// all the subscriptions it matches. Partitions added after the readers are
+// started are read once the next subscription is made. Topic is created by
+// the broker once it's first used, unless it's provisioned beforehand with
+// the required number of partitions.
+func NewPubSub(url, queue string, logger log.Logger) (PubSub, error) {
+	brokers := strings.Split(url, ",")
+	conn, err := broker.Dial("tcp", brokers[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Close(); err != nil {
+		return nil, err
+	}
+
+	w := &broker.Writer{
+		Addr:         broker.TCP(brokers...),
+		Topic:        Topic,
+		Balancer:     &broker.Hash{},
+		BatchTimeout: batchTimeout,
+		RequiredAcks: broker.RequireAll,
+	}
+
+	ret := &pubsub{
+		brokers:       brokers,
+		writer:        w,
+		queue:         queue,
+		logger:        logger,
+		subscriptions: make(map[string]messaging.MessageHandler),
+		groups:        make(map[string]*consumer),
+	}
+	return ret, nil
+}
+
+func (ps *pubsub) Publish(topic string, msg messaging.Message) error {
+	data, err := proto.Marshal(&msg)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("%s.%s", chansPrefix, topic)
+	if msg.Subtopic != "" {
+		subject = fmt.Sprintf("%s.%s", subject, msg.Subtopic)
+	}
+	km := broker.Message{
+		Key:     []byte(topic),
+		Value:   data,
+		Headers: []broker.Header{{Key: subjectHeader, Value: []byte(subject)}},
+	}
+
+	return ps.writer.WriteMessages(context.Background(), km)
+}
+
+func (ps *pubsub) Subscribe(topic string, handler messaging.MessageHandler) error {
+	if topic == "" {
+		return errEmptyTopic
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if _, ok := ps.subscriptions[topic]; ok {
+		return errAlreadySubscribed
+	}
+
+	if ps.queue != "" {
+		ps.groups[topic] = ps.group(topic, handler)
+	} else if err := ps.readPartitions(); err != nil {
+		return err
+	}
+
+	ps.hmu.Lock()
+	ps.subscriptions[topic] = handler
+	ps.hmu.Unlock()
+	return nil
+}
+
+func (ps *pubsub) Unsubscribe(topic string) error {
+	if topic == "" {
+		return errEmptyTopic
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if _, ok := ps.subscriptions[topic]; !ok {
+		return errNotSubscribed
+	}
+
+	if c, ok := ps.groups[topic]; ok {
+		if err := c.close(); err != nil {
+			return err
+		}
+		delete(ps.groups, topic)
+	}
+
+	ps.hmu.Lock()
+	delete(ps.subscriptions, topic)
+	ps.hmu.Unlock()
+
+	if len(ps.subscriptions) > 0 || ps.partitions == nil {
+		return nil
+	}
+	c := ps.partitions
+	ps.partitions = nil
+	return c.close()
+}
+
+func (ps *pubsub) Close() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for topic, c := range ps.groups {
+		if err := c.close(); err != nil {
+			ps.logger.Warn(fmt.Sprintf("Failed to close Kafka reader: %s", err))
+		}
+		delete(ps.groups, topic)
+	}
+	if ps.partitions != nil {
+		if err := ps.partitions.close(); err != nil {
+			ps.logger.Warn(fmt.Sprintf("Failed to close Kafka reader: %s", err))
+		}
+		ps.partitions = nil
+	}
+	ps.hmu.Lock()
+	ps.subscriptions = make(map[string]messaging.MessageHandler)
+	ps.hmu.Unlock()
+
+	if err := ps.writer.Close(); err != nil {
+		ps.logger.Warn(fmt.Sprintf("Failed to close Kafka writer: %s", err))
+	}
+}
+
+// group returns the consumer group consumer of the queue subscription.
+// Queue subscribers of different topics can't share the consumer group,
+// since each group receives the messages of the Kafka topic once.
+func (ps *pubsub) group(topic string, h messaging.MessageHandler) *consumer {
+	r := broker.NewReader(broker.ReaderConfig{
+		Brokers:     ps.brokers,
+		GroupID:     fmt.Sprintf("%s.%s", ps.queue, topic),
+		Topic:       Topic,
+		StartOffset: broker.LastOffset,
+	})
+
+	c := newConsumer()
+	ps.start(c, r, func(km broker.Message) {
+		if messaging.Matches(topic, subject(km)) {
+			ps.handle(km, h)
+		}
+	})
+	return c
+}
+
+// readPartitions starts the readers of the topic partitions which are not
+// read yet. Messages read are dispatched to the matching subscriptions.
+func (ps *pubsub) readPartitions() error {
+	partitions, err := ps.topicPartitions()
+	if err != nil {
+		return err
+	}
+
+	read := make(map[int]bool)
+	if ps.partitions != nil {
+		for _, r := range ps.partitions.readers {
+			read[r.Config().Partition] = true
+		}
+	}
+
+	var readers []*broker.Reader
+	for _, p := range partitions {
+		if read[p.ID] {
+			continue
+		}
+		r := broker.NewReader(broker.ReaderConfig{
+			Brokers:   ps.brokers,
+			Topic:     Topic,
+			Partition: p.ID,
+		})
+		if err := r.SetOffset(broker.LastOffset); err != nil {
+			for _, r := range append(readers, r) {
+				r.Close()
+			}
+			return err
+		}
+		readers = append(readers, r)
+	}
+
+	if ps.partitions == nil {
+		ps.partitions = newConsumer()
+	}
+	for _, r := range readers {
+		ps.start(ps.partitions, r, ps.dispatch)
+	}
+	return nil
+}
+
+// topicPartitions returns the partitions of the topic. Topic which doesn't
+// exist has no partitions until the broker creates it.
+func (ps *pubsub) topicPartitions() ([]broker.Partition, error) {
+	conn, err := broker.Dial("tcp", ps.brokers[0])
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	for i := 0; i < partitionsAttempts; i++ {
+		partitions, err := conn.ReadPartitions(Topic)
+		if err != nil {
+			return nil, err
+		}
+		if len(partitions) > 0 {
+			return partitions, nil
+		}
+		time.Sleep(partitionsDelay)
+	}
+	return nil, errNoPartitions
+}
+
+// start adds the reader to the consumer and handles its messages
+// until the consumer is closed.
+func (ps *pubsub) start(c *consumer, r *broker.Reader, handle func(broker.Message)) {
+	c.readers = append(c.readers, r)
+	c.done.Add(1)
+	go func() {
+		defer c.done.Done()
+		ps.consume(c.ctx, r, handle)
+	}()
+}
+
+// consume handles the messages read by the reader. Offsets of the consumer
+// group are committed once the message is handled, even if the handler
+// fails, so the messages the handler fails to handle are not redelivered.
+func (ps *pubsub) consume(ctx context.Context, r *broker.Reader, handle func(broker.Message)) {
+	commit := r.Config().GroupID != ""
+	for {
+		km, err := r.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				ps.logger.Error(fmt.Sprintf("Failed to fetch Kafka message: %s", err))
+			}
+			return
+		}
+
+		handle(km)
+		if !commit {
+			continue
+		}
+		if err := r.CommitMessages(ctx, km); err != nil && ctx.Err() == nil {
+			ps.logger.Warn(fmt.Sprintf("Failed to commit Kafka message: %s", err))
+		}
+	}
+}
+
+// dispatch handles the message read by the partition readers with the
+// handlers of all the subscriptions the message subject matches.
+func (ps *pubsub) dispatch(km broker.Message) {
+	subject := subject(km)
+
+	ps.hmu.RLock()
+	var handlers []messaging.MessageHandler
+	for topic, h := range ps.subscriptions {
+		if messaging.Matches(topic, subject) {
+			handlers = append(handlers, h)
+		}
+	}
+	ps.hmu.RUnlock()
+
+	if len(handlers) > 0 {
+		ps.handle(km, handlers...)
+	}
+}
+
+func (ps *pubsub) handle(km broker.Message, handlers ...messaging.MessageHandler) {
+	var msg messaging.Message
+	if err := proto.Unmarshal(km.Value, &msg); err != nil {
+		ps.logger.Warn(fmt.Sprintf("Failed to unmarshal received message: %s", err))
+		return
+	}
+	for _, h := range handlers {
+		if err := h(msg); err != nil {
+			ps.logger.Warn(fmt.Sprintf("Failed to handle Mainflux message: %s", err))
+		}
+	}
+}
+
+func newConsumer() *consumer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &consumer{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+func (c *consumer) close() error {
+	c.cancel()
+	c.done.Wait()
+
+	var err error
+	for _, r := range c.readers {
+		if e := r.Close(); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func subject(km broker.Message) string {
+	for _, h := range km.Headers {
+		if h.Key == subjectHeader {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
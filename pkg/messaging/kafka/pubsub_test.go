@@ -0,0 +1,172 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package kafka_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/kafka"
+	"github.com/mainflux/mainflux/pkg/messaging/pubsubtest"
+	broker "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	chansPrefix = "channels"
+	channel     = "9b7b1b3f-b1b0-46a8-a717-b8213f9eda3b"
+	subtopic    = "engine"
+	timeout     = 10 * time.Second
+
+	// Consumer group needs some time to join
+	// before the subscriber receives messages.
+	joinDelay = 10 * time.Second
+)
+
+var data = []byte("payload")
+
+func TestPubsub(t *testing.T) {
+	topic := "topic"
+	msgChan := make(chan messaging.Message)
+	handler := func(msg messaging.Message) error {
+		msgChan <- msg
+		return nil
+	}
+
+	err := pubsub.Subscribe(fmt.Sprintf("%s.%s", chansPrefix, topic), handler)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = pubsub.Subscribe(fmt.Sprintf("%s.%s.*", chansPrefix, topic), handler)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	time.Sleep(joinDelay)
+
+	cases := []struct {
+		desc     string
+		channel  string
+		subtopic string
+		payload  []byte
+	}{
+		{
+			desc:    "publish message with nil payload",
+			payload: nil,
+		},
+		{
+			desc:    "publish message with string payload",
+			payload: data,
+		},
+		{
+			desc:    "publish message with channel",
+			payload: data,
+			channel: channel,
+		},
+		{
+			desc:     "publish message with subtopic",
+			payload:  data,
+			subtopic: subtopic,
+		},
+		{
+			desc:     "publish message with channel and subtopic",
+			payload:  data,
+			channel:  channel,
+			subtopic: subtopic,
+		},
+	}
+
+	for _, tc := range cases {
+		expectedMsg := messaging.Message{
+			Channel:  tc.channel,
+			Subtopic: tc.subtopic,
+			Payload:  tc.payload,
+		}
+
+		err = pubsub.Publish(topic, expectedMsg)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+		select {
+		case receivedMsg := <-msgChan:
+			assert.Equal(t, expectedMsg, receivedMsg, fmt.Sprintf("%s: expected %+v got %+v\n", tc.desc, expectedMsg, receivedMsg))
+		case <-time.After(timeout):
+			assert.Fail(t, fmt.Sprintf("%s: expected message to be received", tc.desc))
+		}
+	}
+
+	err = pubsub.Unsubscribe(fmt.Sprintf("%s.%s", chansPrefix, topic))
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = pubsub.Unsubscribe(fmt.Sprintf("%s.%s.*", chansPrefix, topic))
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+}
+
+func TestQueue(t *testing.T) {
+	topic := fmt.Sprintf("%s.queue.>", chansPrefix)
+	msgChan := make(chan messaging.Message, 2)
+	handler := func(msg messaging.Message) error {
+		msgChan <- msg
+		return nil
+	}
+
+	for i := 0; i < 2; i++ {
+		ps, err := kafka.NewPubSub(address, queue, testLog)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		defer ps.Close()
+		err = ps.Subscribe(topic, handler)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	}
+	time.Sleep(joinDelay)
+
+	msgs := 10
+	for i := 0; i < msgs; i++ {
+		msg := messaging.Message{Channel: channel, Subtopic: subtopic, Payload: data}
+		err := pubsub.Publish(fmt.Sprintf("queue.%d", i), msg)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	}
+
+	received := 0
+	for received < msgs {
+		select {
+		case <-msgChan:
+			received++
+		case <-time.After(timeout):
+			assert.Fail(t, fmt.Sprintf("expected %d messages got %d", msgs, received))
+			return
+		}
+	}
+
+	select {
+	case <-msgChan:
+		assert.Fail(t, "expected queue subscribers to receive the message once")
+	case <-time.After(time.Second):
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	topic := fmt.Sprintf("%s.unsubscribe", chansPrefix)
+	err := pubsub.Subscribe(topic, func(msg messaging.Message) error {
+		return nil
+	})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = pubsub.Unsubscribe(topic)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	// Subscriptions without a queue don't use consumer groups,
+	// so no groups are left once they're removed.
+	client := broker.Client{Addr: broker.TCP(address)}
+	res, err := client.ListGroups(context.Background(), &broker.ListGroupsRequest{})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	for _, g := range res.Groups {
+		assert.False(t, strings.HasSuffix(g.GroupID, topic), fmt.Sprintf("unsubscribe: expected no consumer group of %s got %s", topic, g.GroupID))
+	}
+}
+
+func TestConformance(t *testing.T) {
+	pubsubtest.Run(t, pubsubtest.Config{
+		New: func(queue string) (messaging.PubSub, error) {
+			return kafka.NewPubSub(address, queue, testLog)
+		},
+		Delay: joinDelay,
+	})
+}
@@ -0,0 +1,91 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package kafka_test
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+
+	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/messaging/kafka"
+	dockertest "github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+)
+
+const (
+	port  = "9092"
+	queue = "test"
+)
+
+var (
+	pubsub  kafka.PubSub
+	address = "localhost:" + port
+	testLog logger.Logger
+)
+
+func TestMain(m *testing.M) {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		log.Fatalf("Could not connect to docker: %s", err)
+	}
+
+	// Broker advertises the listener address to the clients,
+	// so the container port is bound to the same host port.
+	container, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "bitnami/kafka",
+		Tag:        "3.4",
+		Env: []string{
+			"KAFKA_CFG_NODE_ID=0",
+			"KAFKA_CFG_PROCESS_ROLES=controller,broker",
+			"KAFKA_CFG_LISTENERS=PLAINTEXT://:9092,CONTROLLER://:9093",
+			"KAFKA_CFG_ADVERTISED_LISTENERS=PLAINTEXT://" + address,
+			"KAFKA_CFG_LISTENER_SECURITY_PROTOCOL_MAP=CONTROLLER:PLAINTEXT,PLAINTEXT:PLAINTEXT",
+			"KAFKA_CFG_CONTROLLER_QUORUM_VOTERS=0@localhost:9093",
+			"KAFKA_CFG_CONTROLLER_LISTENER_NAMES=CONTROLLER",
+			"KAFKA_CFG_NUM_PARTITIONS=2",
+			"KAFKA_CFG_OFFSETS_TOPIC_REPLICATION_FACTOR=1",
+		},
+		PortBindings: map[docker.Port][]docker.PortBinding{
+			port + "/tcp": {{HostIP: "localhost", HostPort: port}},
+		},
+	})
+	if err != nil {
+		log.Fatalf("Could not start container: %s", err)
+	}
+	handleInterrupt(pool, container)
+
+	testLog, err = logger.New(os.Stdout, "error")
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+	if err := pool.Retry(func() error {
+		pubsub, err = kafka.NewPubSub(address, "", testLog)
+		return err
+	}); err != nil {
+		log.Fatalf("Could not connect to docker: %s", err)
+	}
+
+	code := m.Run()
+	pubsub.Close()
+	if err := pool.Purge(container); err != nil {
+		log.Fatalf("Could not purge container: %s", err)
+	}
+
+	os.Exit(code)
+}
+
+func handleInterrupt(pool *dockertest.Pool, container *dockertest.Resource) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-c
+		if err := pool.Purge(container); err != nil {
+			log.Fatalf("Could not purge container: %s", err)
+		}
+		os.Exit(0)
+	}()
+}
@@ -0,0 +1,8 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package memory holds the in-memory implementation of the PubSub
+// interface. Messages are delivered synchronously to the subscribers
+// following the NATS subject and queue group semantics, which makes the
+// package suitable for the service tests that don't need a real broker.
+package memory
@@ -0,0 +1,180 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+)
+
+const chansPrefix = "channels"
+
+var (
+	errAlreadySubscribed = errors.New("already subscribed to topic")
+	errNotSubscribed     = errors.New("not subscribed")
+	errEmptyTopic        = errors.New("empty topic")
+)
+
+var _ messaging.PubSub = (*pubsub)(nil)
+
+// PubSub wraps messaging PubSub exposing Close() method,
+// which removes all the subscriptions of the PubSub.
+type PubSub interface {
+	messaging.PubSub
+	Close()
+}
+
+type subscription struct {
+	pubsub  *pubsub
+	topic   string
+	queue   string
+	handler messaging.MessageHandler
+}
+
+// Broker represents in-memory message broker. Messages published
+// by any of the PubSubs connected to the broker are delivered to the
+// subscribers of all of them.
+type Broker struct {
+	mu     sync.Mutex
+	subs   []subscription
+	cursor map[string]int
+}
+
+// NewBroker returns new in-memory message broker.
+func NewBroker() *Broker {
+	return &Broker{
+		cursor: make(map[string]int),
+	}
+}
+
+// Connect returns PubSub connected to the broker. Parameter queue has the
+// same meaning as for nats.NewPubSub: the message is delivered to a single
+// subscriber of the queue group, chosen in round-robin fashion among the
+// subscribers of the same topic and queue.
+func (b *Broker) Connect(queue string) PubSub {
+	return &pubsub{
+		broker: b,
+		queue:  queue,
+	}
+}
+
+// NewPubSub returns PubSub connected to the new in-memory broker.
+func NewPubSub() PubSub {
+	return NewBroker().Connect("")
+}
+
+type pubsub struct {
+	broker *Broker
+	queue  string
+}
+
+// Publish delivers the message to the subscribers before returning.
+// Errors returned by the handlers are not propagated to the publisher.
+func (ps *pubsub) Publish(topic string, msg messaging.Message) error {
+	subject := fmt.Sprintf("%s.%s", chansPrefix, topic)
+	if msg.Subtopic != "" {
+		subject = fmt.Sprintf("%s.%s", subject, msg.Subtopic)
+	}
+
+	for _, h := range ps.broker.handlers(subject) {
+		h(msg)
+	}
+	return nil
+}
+
+func (ps *pubsub) Subscribe(topic string, handler messaging.MessageHandler) error {
+	if topic == "" {
+		return errEmptyTopic
+	}
+	b := ps.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.find(ps, topic) >= 0 {
+		return errAlreadySubscribed
+	}
+	b.subs = append(b.subs, subscription{
+		pubsub:  ps,
+		topic:   topic,
+		queue:   ps.queue,
+		handler: handler,
+	})
+	return nil
+}
+
+func (ps *pubsub) Unsubscribe(topic string) error {
+	if topic == "" {
+		return errEmptyTopic
+	}
+	b := ps.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	i := b.find(ps, topic)
+	if i < 0 {
+		return errNotSubscribed
+	}
+	b.subs = append(b.subs[:i], b.subs[i+1:]...)
+	return nil
+}
+
+func (ps *pubsub) Close() {
+	b := ps.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs := b.subs[:0]
+	for _, s := range b.subs {
+		if s.pubsub != ps {
+			subs = append(subs, s)
+		}
+	}
+	b.subs = subs
+}
+
+func (b *Broker) find(ps *pubsub, topic string) int {
+	for i, s := range b.subs {
+		if s.pubsub == ps && s.topic == topic {
+			return i
+		}
+	}
+	return -1
+}
+
+// handlers returns the handlers the message published to the subject
+// is delivered to. Handlers are called without holding the lock, so
+// that they're able to publish and subscribe themselves.
+func (b *Broker) handlers(subject string) []messaging.MessageHandler {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var hs []messaging.MessageHandler
+	groups := make(map[string][]messaging.MessageHandler)
+	var keys []string
+	for _, s := range b.subs {
+		if !messaging.Matches(s.topic, subject) {
+			continue
+		}
+		if s.queue == "" {
+			hs = append(hs, s.handler)
+			continue
+		}
+		key := fmt.Sprintf("%s:%s", s.queue, s.topic)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], s.handler)
+	}
+
+	for _, key := range keys {
+		group := groups[key]
+		i := b.cursor[key] % len(group)
+		b.cursor[key] = i + 1
+		hs = append(hs, group[i])
+	}
+	return hs
+}
@@ -0,0 +1,24 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package memory_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/memory"
+	"github.com/mainflux/mainflux/pkg/messaging/pubsubtest"
+)
+
+func TestPubSub(t *testing.T) {
+	broker := memory.NewBroker()
+	pubsubtest.Run(t, pubsubtest.Config{
+		New: func(queue string) (messaging.PubSub, error) {
+			return broker.Connect(queue), nil
+		},
+		// Messages are delivered before Publish returns.
+		Quiet: 10 * time.Millisecond,
+	})
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package messaging
+
+// PublishFunc publishes the message to the topic.
+type PublishFunc func(topic string, msg Message) error
+
+// Middleware intercepts the messages published and received by the
+// service, e.g. to validate, limit or instrument them. Middlewares are
+// composed at the service startup using Chain functions.
+type Middleware interface {
+	// Publish wraps publishing of the messages.
+	Publish(next PublishFunc) PublishFunc
+
+	// Handle wraps the handler of the received messages.
+	Handle(next MessageHandler) MessageHandler
+}
+
+var (
+	_ Publisher = (*publisher)(nil)
+	_ PubSub    = (*pubsub)(nil)
+)
+
+type publisher struct {
+	publish PublishFunc
+}
+
+// ChainPublisher returns publisher which passes the messages through the
+// middlewares before publishing them. The first middleware is outermost,
+// i.e. it's the first one to intercept the message.
+func ChainPublisher(pub Publisher, mws ...Middleware) Publisher {
+	return publisher{
+		publish: chainPublish(pub.Publish, mws),
+	}
+}
+
+func (p publisher) Publish(topic string, msg Message) error {
+	return p.publish(topic, msg)
+}
+
+type pubsub struct {
+	publisher
+	sub Subscriber
+	mws []Middleware
+}
+
+// ChainPubSub returns PubSub which passes both published and received
+// messages through the middlewares, the first middleware being outermost.
+func ChainPubSub(ps PubSub, mws ...Middleware) PubSub {
+	return pubsub{
+		publisher: publisher{
+			publish: chainPublish(ps.Publish, mws),
+		},
+		sub: ps,
+		mws: mws,
+	}
+}
+
+func (ps pubsub) Subscribe(topic string, handler MessageHandler) error {
+	return ps.sub.Subscribe(topic, ChainHandler(handler, ps.mws...))
+}
+
+func (ps pubsub) Unsubscribe(topic string) error {
+	return ps.sub.Unsubscribe(topic)
+}
+
+// ChainHandler returns handler which passes the received messages
+// through the middlewares, the first middleware being outermost.
+func ChainHandler(h MessageHandler, mws ...Middleware) MessageHandler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i].Handle(h)
+	}
+	return h
+}
+
+func chainPublish(publish PublishFunc, mws []Middleware) PublishFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		publish = mws[i].Publish(publish)
+	}
+	return publish
+}
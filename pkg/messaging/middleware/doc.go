@@ -0,0 +1,7 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package middleware contains the messaging middlewares services compose
+// around their publishers and message handlers: payload size limit, rate
+// limit and OpenTracing span context propagation in the message headers.
+package middleware
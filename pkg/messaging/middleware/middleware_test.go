@@ -0,0 +1,140 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package middleware_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/pkg/limits"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/memory"
+	"github.com/mainflux/mainflux/pkg/messaging/middleware"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	channel     = "9b7b1b3f-b1b0-46a8-a717-b8213f9eda3b"
+	thing       = "3b9f8ab5-4b4a-4a6f-8d4a-7c2d9b0c6f41"
+	chansPrefix = "channels"
+	maxSize     = 8
+)
+
+func TestSizeLimit(t *testing.T) {
+	ps := messaging.ChainPubSub(memory.NewPubSub(), middleware.SizeLimit(maxSize))
+	msgs := make(chan messaging.Message, 1)
+	err := ps.Subscribe(fmt.Sprintf("%s.%s", chansPrefix, channel), handler(msgs))
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc    string
+		payload []byte
+		err     error
+	}{
+		{
+			desc:    "publish message with empty payload",
+			payload: nil,
+			err:     nil,
+		},
+		{
+			desc:    "publish message with payload of max size",
+			payload: make([]byte, maxSize),
+			err:     nil,
+		},
+		{
+			desc:    "publish message with too large payload",
+			payload: make([]byte, maxSize+1),
+			err:     middleware.ErrPayloadTooLarge,
+		},
+	}
+
+	for _, tc := range cases {
+		err := ps.Publish(channel, messaging.Message{Channel: channel, Payload: tc.payload})
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		if tc.err == nil {
+			<-msgs
+		}
+	}
+
+	h := messaging.ChainHandler(handler(msgs), middleware.SizeLimit(maxSize))
+	err = h(messaging.Message{Channel: channel, Payload: make([]byte, maxSize+1)})
+	assert.Equal(t, middleware.ErrPayloadTooLarge, err, fmt.Sprintf("handle message with too large payload: expected %s got %s", middleware.ErrPayloadTooLarge, err))
+	assert.Len(t, msgs, 0, "expected too large message to be dropped")
+}
+
+func TestRateLimit(t *testing.T) {
+	burst := 3
+	pub := messaging.ChainPublisher(memory.NewPubSub(), middleware.RateLimit(0.001, burst, middleware.ByPublisher))
+
+	cases := []struct {
+		desc      string
+		publisher string
+		count     int
+		err       error
+	}{
+		{
+			desc:      "publish burst of messages",
+			publisher: thing,
+			count:     burst,
+			err:       nil,
+		},
+		{
+			desc:      "publish message over the limit",
+			publisher: thing,
+			count:     1,
+			err:       limits.ErrRateLimited,
+		},
+		{
+			desc:      "publish message of another publisher",
+			publisher: "another-thing",
+			count:     1,
+			err:       nil,
+		},
+	}
+
+	for _, tc := range cases {
+		for i := 0; i < tc.count; i++ {
+			err := pub.Publish(channel, messaging.Message{Channel: channel, Publisher: tc.publisher})
+			assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		}
+	}
+}
+
+func TestTracing(t *testing.T) {
+	tracer := mocktracer.New()
+	ps := messaging.ChainPubSub(memory.NewPubSub(), middleware.Tracing(tracer))
+	msgs := make(chan messaging.Message, 1)
+	err := ps.Subscribe(fmt.Sprintf("%s.%s", chansPrefix, channel), handler(msgs))
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	parent := tracer.StartSpan("request")
+	headers := map[string]string{"correlation-id": "1"}
+	err = tracer.Inject(parent.Context(), opentracing.TextMap, opentracing.TextMapCarrier(headers))
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	err = ps.Publish(channel, messaging.Message{Channel: channel, Headers: headers})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	msg := <-msgs
+	assert.Equal(t, "1", msg.Headers["correlation-id"], "expected message headers to be preserved")
+	assert.Len(t, headers, 4, "expected headers of the published message not to be changed")
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 2, fmt.Sprintf("expected %d spans got %d", 2, len(spans)))
+	handle, publish := spans[0], spans[1]
+	assert.Equal(t, "handle", handle.OperationName, fmt.Sprintf("expected %s span got %s", "handle", handle.OperationName))
+	assert.Equal(t, "publish", publish.OperationName, fmt.Sprintf("expected %s span got %s", "publish", publish.OperationName))
+	assert.Equal(t, parent.(*mocktracer.MockSpan).SpanContext.SpanID, publish.ParentID, "expected publish span to be the child of the span message is published with")
+	assert.Equal(t, publish.SpanContext.SpanID, handle.ParentID, "expected handle span to be the child of publish span")
+	assert.Equal(t, publish.SpanContext.TraceID, handle.SpanContext.TraceID, "expected spans to belong to the same trace")
+}
+
+func handler(msgs chan<- messaging.Message) messaging.MessageHandler {
+	return func(msg messaging.Message) error {
+		msgs <- msg
+		return nil
+	}
+}
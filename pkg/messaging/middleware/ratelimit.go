@@ -0,0 +1,108 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mainflux/mainflux/pkg/limits"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"golang.org/x/time/rate"
+)
+
+// Minimum period of removing the idle limiters.
+const evictPeriod = time.Minute
+
+var _ messaging.Middleware = (*rateLimit)(nil)
+
+// KeyFunc returns the key messages are rate limited by.
+type KeyFunc func(topic string, msg messaging.Message) string
+
+// ByPublisher rate limits the messages of each publisher (thing) separately.
+func ByPublisher(_ string, msg messaging.Message) string {
+	return msg.Publisher
+}
+
+// ByChannel rate limits the messages of each channel separately.
+func ByChannel(_ string, msg messaging.Message) string {
+	return msg.Channel
+}
+
+type limiter struct {
+	*rate.Limiter
+	used time.Time
+}
+
+type rateLimit struct {
+	limit    rate.Limit
+	burst    int
+	key      KeyFunc
+	idle     time.Duration
+	mu       sync.Mutex
+	limiters map[string]*limiter
+	evicted  time.Time
+}
+
+// RateLimit returns middleware which allows publishing of up to limit
+// messages per second, with bursts of up to burst messages, for each key
+// returned by the key function. Messages exceeding the limit are rejected
+// with limits.ErrRateLimited. Limits apply to the service instance only;
+// received messages are not limited.
+func RateLimit(limit float64, burst int, key KeyFunc) messaging.Middleware {
+	rl := &rateLimit{
+		limit:    rate.Limit(limit),
+		burst:    burst,
+		key:      key,
+		limiters: make(map[string]*limiter),
+		evicted:  time.Now(),
+	}
+	// Limiter which is idle long enough to be refilled up to the burst
+	// is equivalent to the new one, so it's removed.
+	if limit > 0 {
+		rl.idle = time.Duration(float64(burst) / limit * float64(time.Second))
+	}
+	return rl
+}
+
+func (rl *rateLimit) Publish(next messaging.PublishFunc) messaging.PublishFunc {
+	return func(topic string, msg messaging.Message) error {
+		if !rl.limiter(rl.key(topic, msg), time.Now()).Allow() {
+			return limits.ErrRateLimited
+		}
+		return next(topic, msg)
+	}
+}
+
+func (rl *rateLimit) Handle(next messaging.MessageHandler) messaging.MessageHandler {
+	return next
+}
+
+func (rl *rateLimit) limiter(key string, now time.Time) *limiter {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if rl.idle > 0 && now.Sub(rl.evicted) > evictPeriod && now.Sub(rl.evicted) > rl.idle {
+		rl.evict(now)
+	}
+
+	l, ok := rl.limiters[key]
+	if !ok {
+		l = &limiter{Limiter: rate.NewLimiter(rl.limit, rl.burst)}
+		rl.limiters[key] = l
+	}
+	l.used = now
+	return l
+}
+
+// evict removes the limiters which are idle long enough to be refilled.
+// Caller must hold the lock.
+func (rl *rateLimit) evict(now time.Time) {
+	for key, l := range rl.limiters {
+		if now.Sub(l.used) > rl.idle {
+			delete(rl.limiters, key)
+		}
+	}
+	rl.evicted = now
+}
@@ -0,0 +1,30 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package middleware
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvict(t *testing.T) {
+	rl := RateLimit(10, 5, ByPublisher).(*rateLimit)
+	now := time.Now()
+
+	rl.limiter("idle", now.Add(-time.Second))
+	rl.limiter("active", now)
+	rl.evict(now)
+
+	_, ok := rl.limiters["idle"]
+	assert.False(t, ok, "evict limiters: expected idle limiter to be removed")
+	_, ok = rl.limiters["active"]
+	assert.True(t, ok, "evict limiters: expected active limiter to be kept")
+
+	// Limiters are evicted lazily on access once the evict period elapses.
+	rl.limiter("next", now.Add(2*evictPeriod))
+	assert.Equal(t, 1, len(rl.limiters), fmt.Sprintf("evict limiters on access: expected 1 limiter got %d", len(rl.limiters)))
+}
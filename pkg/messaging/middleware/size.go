@@ -0,0 +1,43 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package middleware
+
+import (
+	"errors"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+)
+
+// ErrPayloadTooLarge indicates that the message payload exceeds the limit.
+var ErrPayloadTooLarge = errors.New("message payload too large")
+
+var _ messaging.Middleware = (*sizeLimit)(nil)
+
+type sizeLimit struct {
+	max int
+}
+
+// SizeLimit returns middleware which rejects publishing and drops received
+// messages whose payload is larger than max bytes.
+func SizeLimit(max int) messaging.Middleware {
+	return sizeLimit{max: max}
+}
+
+func (sl sizeLimit) Publish(next messaging.PublishFunc) messaging.PublishFunc {
+	return func(topic string, msg messaging.Message) error {
+		if len(msg.Payload) > sl.max {
+			return ErrPayloadTooLarge
+		}
+		return next(topic, msg)
+	}
+}
+
+func (sl sizeLimit) Handle(next messaging.MessageHandler) messaging.MessageHandler {
+	return func(msg messaging.Message) error {
+		if len(msg.Payload) > sl.max {
+			return ErrPayloadTooLarge
+		}
+		return next(msg)
+	}
+}
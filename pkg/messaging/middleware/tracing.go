@@ -0,0 +1,81 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package middleware
+
+import (
+	"github.com/mainflux/mainflux/pkg/messaging"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+const (
+	publishOp = "publish"
+	handleOp  = "handle"
+)
+
+var _ messaging.Middleware = (*tracing)(nil)
+
+type tracing struct {
+	tracer opentracing.Tracer
+}
+
+// Tracing returns middleware which creates spans of publishing and
+// handling of the messages. Span context is propagated in the message
+// headers, so the handling span is the child of the publishing span, which
+// itself is the child of the span context the message is published with.
+func Tracing(tracer opentracing.Tracer) messaging.Middleware {
+	return tracing{tracer: tracer}
+}
+
+func (t tracing) Publish(next messaging.PublishFunc) messaging.PublishFunc {
+	return func(topic string, msg messaging.Message) error {
+		span := t.startSpan(publishOp, msg)
+		defer span.Finish()
+		ext.SpanKindProducer.Set(span)
+		span.SetTag("topic", topic)
+
+		// Headers are copied so that the message of the caller is not changed.
+		headers := make(map[string]string, len(msg.Headers)+1)
+		for k, v := range msg.Headers {
+			headers[k] = v
+		}
+		if err := t.tracer.Inject(span.Context(), opentracing.TextMap, opentracing.TextMapCarrier(headers)); err != nil {
+			ext.Error.Set(span, true)
+		}
+		msg.Headers = headers
+
+		err := next(topic, msg)
+		if err != nil {
+			ext.Error.Set(span, true)
+		}
+		return err
+	}
+}
+
+func (t tracing) Handle(next messaging.MessageHandler) messaging.MessageHandler {
+	return func(msg messaging.Message) error {
+		span := t.startSpan(handleOp, msg)
+		defer span.Finish()
+		ext.SpanKindConsumer.Set(span)
+
+		err := next(msg)
+		if err != nil {
+			ext.Error.Set(span, true)
+		}
+		return err
+	}
+}
+
+func (t tracing) startSpan(op string, msg messaging.Message) opentracing.Span {
+	var opts []opentracing.StartSpanOption
+	if parent, err := t.tracer.Extract(opentracing.TextMap, opentracing.TextMapCarrier(msg.Headers)); err == nil {
+		opts = append(opts, opentracing.ChildOf(parent))
+	}
+	span := t.tracer.StartSpan(op, opts...)
+	span.SetTag("channel", msg.Channel)
+	if msg.Subtopic != "" {
+		span.SetTag("subtopic", msg.Subtopic)
+	}
+	return span
+}
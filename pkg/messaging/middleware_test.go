@@ -0,0 +1,75 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package messaging_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	channel     = "9b7b1b3f-b1b0-46a8-a717-b8213f9eda3b"
+	chansPrefix = "channels"
+)
+
+// recorder appends its name to the publisher of the
+// message, recording the order middlewares are called in.
+type recorder string
+
+func (r recorder) Publish(next messaging.PublishFunc) messaging.PublishFunc {
+	return func(topic string, msg messaging.Message) error {
+		msg.Publisher += string(r)
+		return next(topic, msg)
+	}
+}
+
+func (r recorder) Handle(next messaging.MessageHandler) messaging.MessageHandler {
+	return func(msg messaging.Message) error {
+		msg.Protocol += string(r)
+		return next(msg)
+	}
+}
+
+func TestChainPubSub(t *testing.T) {
+	ps := messaging.ChainPubSub(memory.NewPubSub(), recorder("a"), recorder("b"))
+	msgs := make(chan messaging.Message, 1)
+	err := ps.Subscribe(fmt.Sprintf("%s.%s", chansPrefix, channel), func(msg messaging.Message) error {
+		msgs <- msg
+		return nil
+	})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	err = ps.Publish(channel, messaging.Message{Channel: channel})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	msg := <-msgs
+	assert.Equal(t, "ab", msg.Publisher, fmt.Sprintf("expected publish middlewares order %s got %s", "ab", msg.Publisher))
+	assert.Equal(t, "ab", msg.Protocol, fmt.Sprintf("expected handle middlewares order %s got %s", "ab", msg.Protocol))
+
+	err = ps.Unsubscribe(fmt.Sprintf("%s.%s", chansPrefix, channel))
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+}
+
+func TestChainPublisher(t *testing.T) {
+	ps := memory.NewPubSub()
+	msgs := make(chan messaging.Message, 1)
+	err := ps.Subscribe(fmt.Sprintf("%s.%s", chansPrefix, channel), func(msg messaging.Message) error {
+		msgs <- msg
+		return nil
+	})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	pub := messaging.ChainPublisher(ps, recorder("a"), recorder("b"), recorder("c"))
+	err = pub.Publish(channel, messaging.Message{Channel: channel})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	msg := <-msgs
+	assert.Equal(t, "abc", msg.Publisher, fmt.Sprintf("expected publish middlewares order %s got %s", "abc", msg.Publisher))
+	assert.Empty(t, msg.Protocol, "expected handler not to be wrapped")
+}
@@ -0,0 +1,10 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package mqtt holds the implementation of the Publisher and Subscriber
+// interfaces used to forward messages to the MQTT broker, and of the
+// PubSub interface for the MQTT messaging system. PubSub messages are
+// published to the channel topics and the NATS-like subscription subjects
+// are translated to the MQTT topic filters. Queue subscriptions are MQTT
+// shared subscriptions, so the broker has to support them.
+package mqtt
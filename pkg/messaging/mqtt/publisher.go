@@ -22,7 +22,7 @@ type publisher struct {
 
 // NewPublisher returns a new MQTT message publisher.
 func NewPublisher(address string, timeout time.Duration) (messaging.Publisher, error) {
-	client, err := newClient(address, timeout)
+	client, err := newClient(address, id, false, nil, timeout)
 	if err != nil {
 		return nil, err
 	}
@@ -5,26 +5,218 @@ package mqtt
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/gofrs/uuid"
+	"github.com/gogo/protobuf/proto"
+	log "github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/messaging"
 )
 
 const (
-	protocol = "mqtt"
-	id       = "mqtt-publisher"
-	qos      = 1
+	protocol    = "mqtt"
+	id          = "mqtt-publisher"
+	qos         = 1
+	chansPrefix = "channels"
+
+	// Time to wait for the in-flight messages on disconnect, in milliseconds.
+	disconnectQuiesce = 250
+)
+
+var (
+	errConnect           = errors.New("failed to connect to MQTT broker")
+	errAlreadySubscribed = errors.New("already subscribed to topic")
+	errNotSubscribed     = errors.New("not subscribed")
+	errEmptyTopic        = errors.New("empty topic")
 )
 
-var errConnect = errors.New("failed to connect to MQTT broker")
+var _ messaging.PubSub = (*pubsub)(nil)
+
+// PubSub wraps messaging PubSub exposing
+// Close() method for MQTT connection.
+type PubSub interface {
+	messaging.PubSub
+	Close()
+}
+
+type pubsub struct {
+	client        mqtt.Client
+	queue         string
+	timeout       time.Duration
+	logger        log.Logger
+	mu            sync.RWMutex
+	subscriptions map[string]messaging.MessageHandler
+}
+
+// NewPubSub returns MQTT message publisher/subscriber. Messages are
+// published encoded to the channel topics (channels/<channel_id>/<subtopic>)
+// and the subscription topics are translated to the MQTT topic filters, so
+// the subjects used across Mainflux services are supported without changes.
+// If the queue is specified, subscriptions are shared subscriptions of the
+// queue ($share/<queue>/<topic filter>), which are load balanced by the
+// broker among the subscribers of the queue.
+func NewPubSub(address, queue string, timeout time.Duration, logger log.Logger) (PubSub, error) {
+	clientID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &pubsub{
+		queue:         queue,
+		timeout:       timeout,
+		logger:        logger,
+		subscriptions: make(map[string]messaging.MessageHandler),
+	}
+	// Messages are dispatched to the subscriptions by the PubSub rather
+	// than by the client, since the client replaces the handlers of the
+	// overlapping topic filters.
+	client, err := newClient(address, fmt.Sprintf("%s-%s", protocol, clientID), true, ret.handle, timeout)
+	if err != nil {
+		return nil, err
+	}
+	ret.client = client
+
+	return ret, nil
+}
+
+func (ps *pubsub) Publish(topic string, msg messaging.Message) error {
+	data, err := proto.Marshal(&msg)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("%s.%s", chansPrefix, topic)
+	if msg.Subtopic != "" {
+		subject = fmt.Sprintf("%s.%s", subject, msg.Subtopic)
+	}
+
+	token := ps.client.Publish(mqttTopic(subject), qos, false, data)
+	return wait(token, ps.timeout, errPublishTimeout)
+}
+
+func (ps *pubsub) Subscribe(topic string, handler messaging.MessageHandler) error {
+	if topic == "" {
+		return errEmptyTopic
+	}
+
+	ps.mu.Lock()
+	if _, ok := ps.subscriptions[topic]; ok {
+		ps.mu.Unlock()
+		return errAlreadySubscribed
+	}
+	ps.subscriptions[topic] = handler
+	ps.mu.Unlock()
+
+	token := ps.client.Subscribe(ps.filter(topic), qos, nil)
+	if err := wait(token, ps.timeout, errSubscribeTimeout); err != nil {
+		ps.mu.Lock()
+		delete(ps.subscriptions, topic)
+		ps.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+func (ps *pubsub) Unsubscribe(topic string) error {
+	if topic == "" {
+		return errEmptyTopic
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if _, ok := ps.subscriptions[topic]; !ok {
+		return errNotSubscribed
+	}
 
-func newClient(address string, timeout time.Duration) (mqtt.Client, error) {
+	token := ps.client.Unsubscribe(ps.filter(topic))
+	if err := wait(token, ps.timeout, errUnsubscribeTimeout); err != nil {
+		return err
+	}
+	delete(ps.subscriptions, topic)
+
+	return nil
+}
+
+func (ps *pubsub) Close() {
+	ps.client.Disconnect(disconnectQuiesce)
+}
+
+// filter returns the MQTT topic filter of the subscription topic.
+func (ps *pubsub) filter(topic string) string {
+	filter := mqttTopic(topic)
+	if ps.queue != "" {
+		filter = fmt.Sprintf("$share/%s/%s", ps.queue, filter)
+	}
+	return filter
+}
+
+// handle dispatches the received message to the subscriptions the message
+// subject matches. Messages of the parent topic of the multi-level wildcard
+// topic, which are matched by the MQTT topic filter but not by the NATS
+// subject, are skipped.
+func (ps *pubsub) handle(c mqtt.Client, m mqtt.Message) {
+	subject := subject(m.Topic())
+
+	ps.mu.RLock()
+	var handlers []messaging.MessageHandler
+	for topic, h := range ps.subscriptions {
+		if messaging.Matches(topic, subject) {
+			handlers = append(handlers, h)
+		}
+	}
+	ps.mu.RUnlock()
+
+	for _, h := range handlers {
+		mqttHandler(ps.logger, h)(c, m)
+	}
+}
+
+func mqttHandler(logger log.Logger, h messaging.MessageHandler) mqtt.MessageHandler {
+	return func(c mqtt.Client, m mqtt.Message) {
+		var msg messaging.Message
+		if err := proto.Unmarshal(m.Payload(), &msg); err != nil {
+			logger.Warn(fmt.Sprintf("Failed to unmarshal received message: %s", err))
+			return
+		}
+		if err := h(msg); err != nil {
+			logger.Warn(fmt.Sprintf("Failed to handle Mainflux message: %s", err))
+		}
+	}
+}
+
+// mqttTopic translates NATS subject to the MQTT topic or topic filter.
+func mqttTopic(subject string) string {
+	tokens := strings.Split(subject, ".")
+	for i, t := range tokens {
+		switch t {
+		case "*":
+			tokens[i] = "+"
+		case ">":
+			tokens[i] = "#"
+		}
+	}
+	return strings.Join(tokens, "/")
+}
+
+// subject translates MQTT topic to the NATS subject.
+func subject(topic string) string {
+	return strings.ReplaceAll(topic, "/", ".")
+}
+
+func newClient(address, clientID string, cleanSession bool, handler mqtt.MessageHandler, timeout time.Duration) (mqtt.Client, error) {
 	opts := mqtt.NewClientOptions().
 		AddBroker(address).
 		SetUsername(id).
 		SetPassword(id).
-		SetClientID(id).
-		SetCleanSession(false)
+		SetClientID(clientID).
+		SetCleanSession(cleanSession).
+		SetDefaultPublishHandler(handler)
 	client := mqtt.NewClient(opts)
 	token := client.Connect()
 	if token.Error() != nil {
@@ -41,3 +233,19 @@ func newClient(address string, timeout time.Duration) (mqtt.Client, error) {
 
 	return client, nil
 }
+
+// wait waits for the token to complete, returning the given
+// error if it's not completed within the timeout.
+func wait(token mqtt.Token, timeout time.Duration, errTimeout error) error {
+	if token.Error() != nil {
+		return token.Error()
+	}
+	ok := token.WaitTimeout(timeout)
+	if ok && token.Error() != nil {
+		return token.Error()
+	}
+	if !ok {
+		return errTimeout
+	}
+	return nil
+}
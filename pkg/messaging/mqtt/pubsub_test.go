@@ -0,0 +1,20 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package mqtt_test
+
+import (
+	"testing"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/mqtt"
+	"github.com/mainflux/mainflux/pkg/messaging/pubsubtest"
+)
+
+func TestConformance(t *testing.T) {
+	pubsubtest.Run(t, pubsubtest.Config{
+		New: func(queue string) (messaging.PubSub, error) {
+			return mqtt.NewPubSub(address, queue, timeout, testLog)
+		},
+	})
+}
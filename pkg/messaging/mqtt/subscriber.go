@@ -4,11 +4,9 @@
 package mqtt
 
 import (
-	"fmt"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
-	"github.com/gogo/protobuf/proto"
 	log "github.com/mainflux/mainflux/logger"
 	"github.com/mainflux/mainflux/pkg/errors"
 	"github.com/mainflux/mainflux/pkg/messaging"
@@ -29,7 +27,7 @@ type subscriber struct {
 
 // NewSubscriber returns a new MQTT message subscriber.
 func NewSubscriber(address string, timeout time.Duration, logger log.Logger) (messaging.Subscriber, error) {
-	client, err := newClient(address, timeout)
+	client, err := newClient(address, id, false, nil, timeout)
 	if err != nil {
 		return nil, err
 	}
@@ -43,7 +41,7 @@ func NewSubscriber(address string, timeout time.Duration, logger log.Logger) (me
 }
 
 func (sub subscriber) Subscribe(topic string, handler messaging.MessageHandler) error {
-	token := sub.client.Subscribe(topic, qos, sub.mqttHandler(handler))
+	token := sub.client.Subscribe(topic, qos, mqttHandler(sub.logger, handler))
 	if token.Error() != nil {
 		return token.Error()
 	}
@@ -71,16 +69,3 @@ func (sub subscriber) Unsubscribe(topic string) error {
 	}
 	return nil
 }
-
-func (sub subscriber) mqttHandler(h messaging.MessageHandler) mqtt.MessageHandler {
-	return func(c mqtt.Client, m mqtt.Message) {
-		var msg messaging.Message
-		if err := proto.Unmarshal(m.Payload(), &msg); err != nil {
-			sub.logger.Warn(fmt.Sprintf("Failed to unmarshal received message: %s", err))
-			return
-		}
-		if err := h(msg); err != nil {
-			sub.logger.Warn(fmt.Sprintf("Failed to handle Mainflux message: %s", err))
-		}
-	}
-}
@@ -0,0 +1,172 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package nats
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	broker "github.com/nats-io/nats.go"
+)
+
+const (
+	leasesPrefix = "leases"
+
+	// Period of checking the lease owners.
+	leasePeriod = 5 * time.Second
+
+	// Maximum duration of waiting for the lease owners to respond.
+	leaseTimeout = time.Second
+)
+
+// ErrLeaseClosed indicates that the lease is closed.
+var ErrLeaseClosed = errors.New("lease closed")
+
+// Lease represents the named lease owned by a single holder at a time.
+// Holders find the owners by the request sent to the lease subject, which
+// the owners respond to with their IDs. The lease is acquired if no owner
+// responds. Holders which acquire the lease at the same time find each
+// other on the next check, and all of them except the one with the lowest
+// ID release the lease.
+type Lease struct {
+	conn    *broker.Conn
+	subject string
+	id      string
+	closed  chan struct{}
+	once    sync.Once
+}
+
+// NewLease returns the named lease shared by the holders connected
+// to the NATS at the given URL.
+func NewLease(url, name string) (*Lease, error) {
+	conn, err := broker.Connect(url)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &Lease{
+		conn:    conn,
+		subject: fmt.Sprintf("%s.%s", leasesPrefix, name),
+		id:      id.String(),
+		closed:  make(chan struct{}),
+	}, nil
+}
+
+// Acquire blocks until the lease is owned by the holder. The returned
+// channel is closed once the lease is released.
+func (l *Lease) Acquire() (<-chan struct{}, error) {
+	for {
+		select {
+		case <-l.closed:
+			return nil, ErrLeaseClosed
+		default:
+		}
+
+		owners, err := l.owners()
+		if err != nil {
+			return nil, err
+		}
+		if len(owners) == 0 {
+			break
+		}
+
+		select {
+		case <-time.After(leasePeriod):
+		case <-l.closed:
+			return nil, ErrLeaseClosed
+		}
+	}
+
+	sub, err := l.conn.Subscribe(l.subject, func(msg *broker.Msg) {
+		if string(msg.Data) == l.id {
+			return
+		}
+		msg.Respond([]byte(l.id))
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Make sure that the holders checking the owners find the new owner.
+	if err := l.conn.Flush(); err != nil {
+		sub.Unsubscribe()
+		return nil, err
+	}
+
+	released := make(chan struct{})
+	go l.hold(sub, released)
+
+	return released, nil
+}
+
+// Close releases the lease and closes the NATS connection.
+func (l *Lease) Close() {
+	l.once.Do(func() {
+		close(l.closed)
+		l.conn.Close()
+	})
+}
+
+// hold keeps the lease until the owner with the lower ID is found,
+// the owners can't be checked or the lease is closed.
+func (l *Lease) hold(sub *broker.Subscription, released chan struct{}) {
+	defer close(released)
+	defer sub.Unsubscribe()
+
+	t := time.NewTicker(leasePeriod)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			owners, err := l.owners()
+			if err != nil {
+				return
+			}
+			for _, id := range owners {
+				if id < l.id {
+					return
+				}
+			}
+		case <-l.closed:
+			return
+		}
+	}
+}
+
+// owners returns IDs of the other holders which own the lease.
+func (l *Lease) owners() ([]string, error) {
+	inbox := broker.NewInbox()
+	sub, err := l.conn.SubscribeSync(inbox)
+	if err != nil {
+		return nil, err
+	}
+	defer sub.Unsubscribe()
+
+	if err := l.conn.PublishRequest(l.subject, inbox, []byte(l.id)); err != nil {
+		return nil, err
+	}
+
+	var owners []string
+	deadline := time.Now().Add(leaseTimeout)
+	for {
+		msg, err := sub.NextMsg(time.Until(deadline))
+		switch err {
+		case nil:
+			owners = append(owners, string(msg.Data))
+		case broker.ErrTimeout:
+			return owners, nil
+		default:
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package nats_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux/pkg/messaging/nats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const leaseWait = 15 * time.Second
+
+func TestLease(t *testing.T) {
+	owner, err := nats.NewLease(address, "test-lease")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	standby, err := nats.NewLease(address, "test-lease")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	defer standby.Close()
+	other, err := nats.NewLease(address, "other-lease")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	defer other.Close()
+
+	released, err := owner.Acquire()
+	require.Nil(t, err, fmt.Sprintf("acquire free lease: got unexpected error: %s", err))
+
+	_, err = other.Acquire()
+	assert.Nil(t, err, fmt.Sprintf("acquire another free lease: got unexpected error: %s", err))
+
+	acquired := make(chan error, 1)
+	go func() {
+		_, err := standby.Acquire()
+		acquired <- err
+	}()
+
+	select {
+	case err := <-acquired:
+		assert.Fail(t, fmt.Sprintf("acquire owned lease: expected to block got %v", err))
+	case <-time.After(2 * time.Second):
+	}
+
+	owner.Close()
+	select {
+	case <-released:
+	case <-time.After(leaseWait):
+		assert.Fail(t, "close lease: expected lease to be released")
+	}
+
+	select {
+	case err := <-acquired:
+		assert.Nil(t, err, fmt.Sprintf("acquire released lease: got unexpected error: %s", err))
+	case <-time.After(leaseWait):
+		assert.Fail(t, "acquire released lease: expected lease to be acquired")
+	}
+
+	_, err = owner.Acquire()
+	assert.Equal(t, nats.ErrLeaseClosed, err, fmt.Sprintf("acquire closed lease: expected %s got %s", nats.ErrLeaseClosed, err))
+}
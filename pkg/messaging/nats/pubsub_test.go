@@ -8,6 +8,8 @@ import (
 	"testing"
 
 	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/nats"
+	"github.com/mainflux/mainflux/pkg/messaging/pubsubtest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -78,6 +80,14 @@ func TestPubsub(t *testing.T) {
 	}
 }
 
+func TestConformance(t *testing.T) {
+	pubsubtest.Run(t, pubsubtest.Config{
+		New: func(queue string) (messaging.PubSub, error) {
+			return nats.NewPubSub(address, queue, testLog)
+		},
+	})
+}
+
 func handler(msg messaging.Message) error {
 	msgChan <- msg
 	return nil
@@ -20,6 +20,8 @@ import (
 var (
 	publisher messaging.Publisher
 	pubsub    messaging.PubSub
+	address   string
+	testLog   logger.Logger
 )
 
 func TestMain(m *testing.M) {
@@ -34,7 +36,7 @@ func TestMain(m *testing.M) {
 	}
 	handleInterrupt(pool, container)
 
-	address := fmt.Sprintf("%s:%s", "localhost", container.GetPort("4222/tcp"))
+	address = fmt.Sprintf("%s:%s", "localhost", container.GetPort("4222/tcp"))
 	if err := pool.Retry(func() error {
 		publisher, err = nats.NewPublisher(address)
 		return err
@@ -42,12 +44,12 @@ func TestMain(m *testing.M) {
 		log.Fatalf("Could not connect to docker: %s", err)
 	}
 
-	logger, err := logger.New(os.Stdout, "error")
+	testLog, err = logger.New(os.Stdout, "error")
 	if err != nil {
 		log.Fatalf(err.Error())
 	}
 	if err := pool.Retry(func() error {
-		pubsub, err = nats.NewPubSub(address, "", logger)
+		pubsub, err = nats.NewPubSub(address, "", testLog)
 		return err
 	}); err != nil {
 		log.Fatalf("Could not connect to docker: %s", err)
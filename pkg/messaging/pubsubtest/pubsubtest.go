@@ -0,0 +1,379 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package pubsubtest contains the conformance test suite of the messaging
+// PubSub implementations. The suite checks publishing and subscribing,
+// subject wildcards, queue groups, unsubscribing and concurrent subscribing
+// against the semantics of the NATS implementation.
+package pubsubtest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	chansPrefix    = "channels"
+	subtopic       = "engine"
+	defTimeout     = 5 * time.Second
+	defQuiet       = 500 * time.Millisecond
+	msgsCount      = 10
+	subsCount      = 10
+	queueGroupSize = 2
+)
+
+var data = []byte("payload")
+
+// Factory returns PubSub connected to the broker under test. Parameter
+// queue is the queue group the subscriptions of the PubSub join, as in
+// nats.NewPubSub. PubSubs with the Close() method are closed at the end
+// of each test.
+type Factory func(queue string) (messaging.PubSub, error)
+
+// Config contains the conformance suite configuration.
+type Config struct {
+	// New creates the PubSubs under test.
+	New Factory
+
+	// Delay is the period the subscriptions need to become active,
+	// e.g. for the consumer group to be joined.
+	Delay time.Duration
+
+	// Timeout is the maximum period of the message delivery.
+	// Defaults to 5 seconds.
+	Timeout time.Duration
+
+	// Quiet is the period no messages are expected to be delivered
+	// in, once all the expected messages are received.
+	// Defaults to 500 milliseconds.
+	Quiet time.Duration
+}
+
+// Run runs the conformance suite against the PubSub implementation.
+func Run(t *testing.T, cfg Config) {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defTimeout
+	}
+	if cfg.Quiet == 0 {
+		cfg.Quiet = defQuiet
+	}
+	s := suite{cfg}
+
+	t.Run("publish and subscribe", s.testPubSub)
+	t.Run("subject wildcards", s.testWildcards)
+	t.Run("queue groups", s.testQueueGroups)
+	t.Run("unsubscribe", s.testUnsubscribe)
+	t.Run("concurrent subscribe", s.testConcurrentSubscribe)
+}
+
+type suite struct {
+	Config
+}
+
+func (s suite) testPubSub(t *testing.T) {
+	ps := s.pubsub(t, "")
+	channel := newID(t)
+	msgs := make(chan messaging.Message, msgsCount)
+
+	err := ps.Subscribe(fmt.Sprintf("%s.%s", chansPrefix, channel), handler(msgs))
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = ps.Subscribe(fmt.Sprintf("%s.%s.%s", chansPrefix, channel, subtopic), handler(msgs))
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = ps.Subscribe(fmt.Sprintf("%s.%s", chansPrefix, channel), handler(msgs))
+	assert.NotNil(t, err, "expected error subscribing to the same topic twice")
+	time.Sleep(s.Delay)
+
+	cases := []struct {
+		desc     string
+		subtopic string
+		payload  []byte
+	}{
+		{
+			desc:    "publish message with nil payload",
+			payload: nil,
+		},
+		{
+			desc:    "publish message with string payload",
+			payload: data,
+		},
+		{
+			desc:     "publish message with subtopic",
+			payload:  data,
+			subtopic: subtopic,
+		},
+	}
+
+	for _, tc := range cases {
+		expectedMsg := messaging.Message{
+			Channel:  channel,
+			Subtopic: tc.subtopic,
+			Payload:  tc.payload,
+		}
+		err := ps.Publish(channel, expectedMsg)
+		require.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+
+		received := s.receive(t, msgs, 1)
+		if assert.Len(t, received, 1, fmt.Sprintf("%s: expected message to be received", tc.desc)) {
+			assert.Equal(t, expectedMsg, received[0], fmt.Sprintf("%s: expected %+v got %+v\n", tc.desc, expectedMsg, received[0]))
+		}
+	}
+	s.assertQuiet(t, msgs, "publish and subscribe")
+}
+
+func (s suite) testWildcards(t *testing.T) {
+	ps := s.pubsub(t, "")
+	channel := newID(t)
+	single := make(chan messaging.Message, msgsCount)
+	multi := make(chan messaging.Message, msgsCount)
+
+	err := ps.Subscribe(fmt.Sprintf("%s.%s.*", chansPrefix, channel), handler(single))
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = ps.Subscribe(fmt.Sprintf("%s.%s.>", chansPrefix, channel), handler(multi))
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	time.Sleep(s.Delay)
+
+	cases := []struct {
+		desc     string
+		subtopic string
+		single   int
+		multi    int
+	}{
+		{
+			desc:     "publish message without subtopic",
+			subtopic: "",
+			single:   0,
+			multi:    0,
+		},
+		{
+			desc:     "publish message with single token subtopic",
+			subtopic: subtopic,
+			single:   1,
+			multi:    1,
+		},
+		{
+			desc:     "publish message with multiple tokens subtopic",
+			subtopic: fmt.Sprintf("%s.temperature", subtopic),
+			single:   0,
+			multi:    1,
+		},
+	}
+
+	for _, tc := range cases {
+		msg := messaging.Message{Channel: channel, Subtopic: tc.subtopic, Payload: data}
+		err := ps.Publish(channel, msg)
+		require.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+
+		recvSingle := s.receive(t, single, tc.single)
+		recvMulti := s.receive(t, multi, tc.multi)
+		assert.Len(t, recvSingle, tc.single, fmt.Sprintf("%s: expected %d messages on single token wildcard got %d", tc.desc, tc.single, len(recvSingle)))
+		assert.Len(t, recvMulti, tc.multi, fmt.Sprintf("%s: expected %d messages on multiple tokens wildcard got %d", tc.desc, tc.multi, len(recvMulti)))
+		s.assertQuiet(t, single, tc.desc)
+		s.assertQuiet(t, multi, tc.desc)
+	}
+}
+
+func (s suite) testQueueGroups(t *testing.T) {
+	channel := newID(t)
+	topic := fmt.Sprintf("%s.%s", chansPrefix, channel)
+
+	cases := []struct {
+		desc     string
+		queue    string
+		expected int
+	}{
+		{
+			desc:     "publish messages to the queue group",
+			queue:    fmt.Sprintf("queue-%s", channel),
+			expected: msgsCount,
+		},
+		{
+			desc:     "publish messages to the subscribers without queue",
+			queue:    "",
+			expected: queueGroupSize * msgsCount,
+		},
+	}
+
+	for _, tc := range cases {
+		msgs := make(chan messaging.Message, queueGroupSize*msgsCount)
+		for i := 0; i < queueGroupSize; i++ {
+			ps := s.pubsub(t, tc.queue)
+			err := ps.Subscribe(topic, handler(msgs))
+			require.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+		}
+		time.Sleep(s.Delay)
+
+		pub := s.pubsub(t, "")
+		for i := 0; i < msgsCount; i++ {
+			msg := messaging.Message{Channel: channel, Payload: []byte(fmt.Sprintf("%d", i))}
+			err := pub.Publish(channel, msg)
+			require.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+		}
+
+		received := s.receive(t, msgs, tc.expected)
+		assert.Len(t, received, tc.expected, fmt.Sprintf("%s: expected %d messages got %d", tc.desc, tc.expected, len(received)))
+		s.assertQuiet(t, msgs, tc.desc)
+
+		channel = newID(t)
+		topic = fmt.Sprintf("%s.%s", chansPrefix, channel)
+	}
+}
+
+func (s suite) testUnsubscribe(t *testing.T) {
+	ps := s.pubsub(t, "")
+	channel := newID(t)
+	topic := fmt.Sprintf("%s.%s", chansPrefix, channel)
+	msgs := make(chan messaging.Message, msgsCount)
+
+	err := ps.Subscribe(topic, handler(msgs))
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	time.Sleep(s.Delay)
+
+	msg := messaging.Message{Channel: channel, Payload: data}
+	err = ps.Publish(channel, msg)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	received := s.receive(t, msgs, 1)
+	assert.Len(t, received, 1, "expected message to be received before unsubscribing")
+
+	cases := []struct {
+		desc  string
+		topic string
+		err   bool
+	}{
+		{
+			desc:  "unsubscribe from subscribed topic",
+			topic: topic,
+			err:   false,
+		},
+		{
+			desc:  "unsubscribe from the topic twice",
+			topic: topic,
+			err:   true,
+		},
+		{
+			desc:  "unsubscribe from not subscribed topic",
+			topic: fmt.Sprintf("%s.%s", chansPrefix, newID(t)),
+			err:   true,
+		},
+		{
+			desc:  "unsubscribe from empty topic",
+			topic: "",
+			err:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		err := ps.Unsubscribe(tc.topic)
+		assert.Equal(t, tc.err, err != nil, fmt.Sprintf("%s: expected error %t got %s", tc.desc, tc.err, err))
+	}
+
+	err = ps.Publish(channel, msg)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	s.assertQuiet(t, msgs, "publish message after unsubscribing")
+
+	err = ps.Subscribe(topic, handler(msgs))
+	assert.Nil(t, err, fmt.Sprintf("subscribe again: got unexpected error: %s", err))
+}
+
+func (s suite) testConcurrentSubscribe(t *testing.T) {
+	ps := s.pubsub(t, "")
+	channel := newID(t)
+	msgs := make(chan messaging.Message, subsCount)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, subsCount)
+	for i := 0; i < subsCount; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- ps.Subscribe(fmt.Sprintf("%s.%s.%d", chansPrefix, channel, i), handler(msgs))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.Nil(t, err, fmt.Sprintf("subscribe to different topics: got unexpected error: %s", err))
+	}
+
+	topic := fmt.Sprintf("%s.%s", chansPrefix, channel)
+	dups := make(chan error, subsCount)
+	for i := 0; i < subsCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dups <- ps.Subscribe(topic, handler(msgs))
+		}()
+	}
+	wg.Wait()
+	close(dups)
+	succeeded := 0
+	for err := range dups {
+		if err == nil {
+			succeeded++
+		}
+	}
+	assert.Equal(t, 1, succeeded, fmt.Sprintf("subscribe to the same topic: expected %d subscription got %d", 1, succeeded))
+	time.Sleep(s.Delay)
+
+	for i := 0; i < subsCount; i++ {
+		msg := messaging.Message{Channel: channel, Subtopic: fmt.Sprintf("%d", i), Payload: data}
+		err := ps.Publish(channel, msg)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	}
+
+	received := s.receive(t, msgs, subsCount)
+	assert.Len(t, received, subsCount, fmt.Sprintf("expected %d messages got %d", subsCount, len(received)))
+	s.assertQuiet(t, msgs, "concurrent subscribe")
+}
+
+// pubsub creates PubSub closed once the test is done.
+func (s suite) pubsub(t *testing.T, queue string) messaging.PubSub {
+	ps, err := s.New(queue)
+	require.Nil(t, err, fmt.Sprintf("failed to create PubSub: %s", err))
+	if c, ok := ps.(interface{ Close() }); ok {
+		t.Cleanup(c.Close)
+	}
+	return ps
+}
+
+// receive waits for n messages or until the timeout expires.
+func (s suite) receive(t *testing.T, msgs <-chan messaging.Message, n int) []messaging.Message {
+	var received []messaging.Message
+	timeout := time.After(s.Timeout)
+	for len(received) < n {
+		select {
+		case msg := <-msgs:
+			received = append(received, msg)
+		case <-timeout:
+			return received
+		}
+	}
+	return received
+}
+
+func (s suite) assertQuiet(t *testing.T, msgs <-chan messaging.Message, desc string) {
+	select {
+	case msg := <-msgs:
+		assert.Fail(t, fmt.Sprintf("%s: got unexpected message %+v", desc, msg))
+	case <-time.After(s.Quiet):
+	}
+}
+
+func handler(msgs chan<- messaging.Message) messaging.MessageHandler {
+	return func(msg messaging.Message) error {
+		msgs <- msg
+		return nil
+	}
+}
+
+func newID(t *testing.T) string {
+	id, err := uuid.NewV4()
+	require.Nil(t, err, fmt.Sprintf("failed to generate ID: %s", err))
+	return id.String()
+}
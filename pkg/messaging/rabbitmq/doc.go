@@ -0,0 +1,9 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+// Package rabbitmq holds the implementation of the PubSub interface for
+// the RabbitMQ (AMQP 0.9.1) messaging system. Messages are published to
+// the topic exchange with the NATS-like subject as the routing key, and
+// the subscription topics are translated to the AMQP binding keys, so the
+// subjects used across Mainflux services are supported without changes.
+package rabbitmq
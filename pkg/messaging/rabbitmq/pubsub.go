@@ -0,0 +1,382 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package rabbitmq
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	log "github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	broker "github.com/streadway/amqp"
+)
+
+const (
+	chansPrefix = "channels"
+
+	// Exchange is the name of the topic exchange messages are published to.
+	Exchange = "mainflux"
+
+	exchangeKind = "topic"
+	contentType  = "application/octet-stream"
+
+	// Maximum number of unacknowledged messages delivered to the subscription.
+	prefetchCount = 100
+
+	// Delay between the attempts to reconnect to the broker.
+	reconnectDelay = time.Second
+)
+
+var (
+	errAlreadySubscribed = errors.New("already subscribed to topic")
+	errNotSubscribed     = errors.New("not subscribed")
+	errEmptyTopic        = errors.New("empty topic")
+	errNotConfirmed      = errors.New("message not confirmed by the broker")
+)
+
+var _ messaging.PubSub = (*pubsub)(nil)
+
+// PubSub wraps messaging PubSub exposing
+// Close() method for RabbitMQ connection.
+type PubSub interface {
+	messaging.PubSub
+	Close()
+}
+
+type subscription struct {
+	ch      *broker.Channel
+	handler messaging.MessageHandler
+}
+
+type pubsub struct {
+	url           string
+	logger        log.Logger
+	queue         string
+	pubMu         sync.Mutex
+	pubCh         *broker.Channel
+	confirms      chan broker.Confirmation
+	mu            sync.Mutex
+	conn          *broker.Connection
+	subscriptions map[string]*subscription
+	closed        chan struct{}
+	once          sync.Once
+}
+
+// NewPubSub returns RabbitMQ message publisher/subscriber. Messages are
+// published in confirm mode, so Publish returns once the broker confirms
+// the message. If queue is specified, subscribers of the same topic
+// consume from the shared queue named after the queue and the topic, so
+// that each message is handled by a single service instance. Otherwise,
+// each subscription consumes from its own exclusive queue. If the
+// connection is lost, the publisher/subscriber reconnects to the broker
+// and restores the subscriptions.
+func NewPubSub(url, queue string, logger log.Logger) (PubSub, error) {
+	ps := &pubsub{
+		url:           url,
+		logger:        logger,
+		queue:         queue,
+		subscriptions: make(map[string]*subscription),
+		closed:        make(chan struct{}),
+	}
+	closes, err := ps.connect()
+	if err != nil {
+		return nil, err
+	}
+	if err := ps.openPublisher(); err != nil {
+		ps.Close()
+		return nil, err
+	}
+	go ps.watch(closes)
+
+	return ps, nil
+}
+
+// connect connects to the broker and declares the exchange. It returns
+// the channel notified once the connection is closed.
+func (ps *pubsub) connect() (chan *broker.Error, error) {
+	conn, err := broker.Dial(ps.url)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := ch.ExchangeDeclare(Exchange, exchangeKind, true, false, false, false, nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := ch.Close(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	closes := conn.NotifyClose(make(chan *broker.Error, 1))
+
+	ps.mu.Lock()
+	ps.conn = conn
+	ps.mu.Unlock()
+
+	return closes, nil
+}
+
+// openPublisher opens the channel messages are published to in confirm mode.
+func (ps *pubsub) openPublisher() error {
+	ps.mu.Lock()
+	ch, err := ps.conn.Channel()
+	ps.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return err
+	}
+
+	ps.pubMu.Lock()
+	defer ps.pubMu.Unlock()
+
+	ps.pubCh = ch
+	ps.confirms = ch.NotifyPublish(make(chan broker.Confirmation, 1))
+	return nil
+}
+
+// watch reconnects to the broker once the connection is lost. Connection
+// closed by Close is not notified with the error, so it's not restored.
+func (ps *pubsub) watch(closes chan *broker.Error) {
+	for {
+		err, ok := <-closes
+		if !ok || err == nil {
+			return
+		}
+		ps.logger.Warn(fmt.Sprintf("RabbitMQ connection lost: %s", err))
+
+		if closes = ps.reconnect(); closes == nil {
+			return
+		}
+		ps.logger.Info("Reconnected to RabbitMQ")
+	}
+}
+
+// reconnect connects to the broker and restores the subscriptions, retrying
+// until it succeeds or the publisher/subscriber is closed. Publishing is
+// restored once the subscriptions are, so that the subscribers don't miss
+// the messages published by the same publisher/subscriber.
+func (ps *pubsub) reconnect() chan *broker.Error {
+	for {
+		select {
+		case <-ps.closed:
+			return nil
+		case <-time.After(reconnectDelay):
+		}
+
+		closes, err := ps.connect()
+		if err != nil {
+			ps.logger.Warn(fmt.Sprintf("Failed to reconnect to RabbitMQ: %s", err))
+			continue
+		}
+		if err := ps.resubscribe(); err != nil {
+			ps.logger.Warn(fmt.Sprintf("Failed to restore RabbitMQ subscriptions: %s", err))
+			ps.mu.Lock()
+			ps.conn.Close()
+			ps.mu.Unlock()
+			continue
+		}
+		if err := ps.openPublisher(); err != nil {
+			ps.logger.Warn(fmt.Sprintf("Failed to restore RabbitMQ publishing: %s", err))
+			ps.mu.Lock()
+			ps.conn.Close()
+			ps.mu.Unlock()
+			continue
+		}
+
+		// Connection made while closing is closed as well.
+		select {
+		case <-ps.closed:
+			ps.mu.Lock()
+			ps.conn.Close()
+			ps.mu.Unlock()
+			return nil
+		default:
+			return closes
+		}
+	}
+}
+
+func (ps *pubsub) resubscribe() error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for topic, sub := range ps.subscriptions {
+		ch, err := ps.subscribe(topic, sub.handler)
+		if err != nil {
+			return err
+		}
+		sub.ch = ch
+	}
+	return nil
+}
+
+func (ps *pubsub) Publish(topic string, msg messaging.Message) error {
+	data, err := proto.Marshal(&msg)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("%s.%s", chansPrefix, topic)
+	if msg.Subtopic != "" {
+		subject = fmt.Sprintf("%s.%s", subject, msg.Subtopic)
+	}
+
+	// Confirmations are delivered in the publishing order,
+	// so publishing is serialized to match the confirmation.
+	ps.pubMu.Lock()
+	defer ps.pubMu.Unlock()
+
+	pm := broker.Publishing{
+		ContentType: contentType,
+		Body:        data,
+	}
+	if err := ps.pubCh.Publish(Exchange, subject, false, false, pm); err != nil {
+		return err
+	}
+
+	conf, ok := <-ps.confirms
+	if !ok || !conf.Ack {
+		return errNotConfirmed
+	}
+	return nil
+}
+
+func (ps *pubsub) Subscribe(topic string, handler messaging.MessageHandler) error {
+	if topic == "" {
+		return errEmptyTopic
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if _, ok := ps.subscriptions[topic]; ok {
+		return errAlreadySubscribed
+	}
+
+	ch, err := ps.subscribe(topic, handler)
+	if err != nil {
+		return err
+	}
+
+	ps.subscriptions[topic] = &subscription{ch: ch, handler: handler}
+	return nil
+}
+
+// subscribe opens the channel of the subscription and starts handling the
+// messages. Caller must hold the lock.
+func (ps *pubsub) subscribe(topic string, handler messaging.MessageHandler) (*broker.Channel, error) {
+	ch, err := ps.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	// Prefetch limits the messages buffered by the slow subscriber.
+	if err := ch.Qos(prefetchCount, 0, false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	deliveries, err := ps.consume(ch, topic)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+	go ps.handle(deliveries, topic, handler)
+
+	return ch, nil
+}
+
+func (ps *pubsub) Unsubscribe(topic string) error {
+	if topic == "" {
+		return errEmptyTopic
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	sub, ok := ps.subscriptions[topic]
+	if !ok {
+		return errNotSubscribed
+	}
+
+	// Closing the channel cancels the consumer and deletes the exclusive
+	// queue of the subscription. Channel of the lost connection is already
+	// closed, and the subscription is not restored once it's removed.
+	if err := sub.ch.Close(); err != nil && err != broker.ErrClosed {
+		return err
+	}
+
+	delete(ps.subscriptions, topic)
+	return nil
+}
+
+func (ps *pubsub) Close() {
+	ps.once.Do(func() {
+		close(ps.closed)
+	})
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.conn.Close()
+}
+
+// consume declares the subscription queue bound to the
+// exchange with the topic binding key and starts consuming.
+func (ps *pubsub) consume(ch *broker.Channel, topic string) (<-chan broker.Delivery, error) {
+	name, durable, exclusive := "", false, true
+	if ps.queue != "" {
+		name, durable, exclusive = fmt.Sprintf("%s.%s", ps.queue, topic), true, false
+	}
+	q, err := ch.QueueDeclare(name, durable, !durable, exclusive, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.QueueBind(q.Name, bindingKey(topic), Exchange, false, nil); err != nil {
+		return nil, err
+	}
+
+	return ch.Consume(q.Name, "", false, exclusive, false, false, nil)
+}
+
+func (ps *pubsub) handle(deliveries <-chan broker.Delivery, topic string, h messaging.MessageHandler) {
+	// Unlike the NATS ">" wildcard, the AMQP "#" wildcard matches
+	// zero tokens, so the messages published to the subject the
+	// topic wildcard is appended to are skipped.
+	parent := strings.TrimSuffix(topic, ".>")
+	for d := range deliveries {
+		if parent != topic && d.RoutingKey == parent {
+			d.Ack(false)
+			continue
+		}
+		var msg messaging.Message
+		if err := proto.Unmarshal(d.Body, &msg); err != nil {
+			ps.logger.Warn(fmt.Sprintf("Failed to unmarshal received message: %s", err))
+			d.Reject(false)
+			continue
+		}
+		if err := h(msg); err != nil {
+			ps.logger.Warn(fmt.Sprintf("Failed to handle Mainflux message: %s", err))
+		}
+		if err := d.Ack(false); err != nil {
+			ps.logger.Warn(fmt.Sprintf("Failed to ack RabbitMQ message: %s", err))
+		}
+	}
+}
+
+// bindingKey translates NATS-like subject to the AMQP binding key. Both
+// use "." as the token separator and "*" to match a single token, while
+// the NATS ">" wildcard corresponds to the AMQP "#" wildcard.
+func bindingKey(topic string) string {
+	tokens := strings.Split(topic, ".")
+	if last := len(tokens) - 1; tokens[last] == ">" {
+		tokens[last] = "#"
+	}
+	return strings.Join(tokens, ".")
+}
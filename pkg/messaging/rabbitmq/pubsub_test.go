@@ -0,0 +1,133 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package rabbitmq_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/pubsubtest"
+	"github.com/mainflux/mainflux/pkg/messaging/rabbitmq"
+	dockertest "github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	topic       = "topic"
+	chansPrefix = "channels"
+	channel     = "9b7b1b3f-b1b0-46a8-a717-b8213f9eda3b"
+	subtopic    = "engine"
+	timeout     = 10 * time.Second
+)
+
+var (
+	msgChan = make(chan messaging.Message)
+	data    = []byte("payload")
+)
+
+func TestPubsub(t *testing.T) {
+	err := pubsub.Subscribe(fmt.Sprintf("%s.%s", chansPrefix, topic), handler)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	err = pubsub.Subscribe(fmt.Sprintf("%s.%s.%s", chansPrefix, topic, subtopic), handler)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc     string
+		channel  string
+		subtopic string
+		payload  []byte
+	}{
+		{
+			desc:    "publish message with nil payload",
+			payload: nil,
+		},
+		{
+			desc:    "publish message with string payload",
+			payload: data,
+		},
+		{
+			desc:    "publish message with channel",
+			payload: data,
+			channel: channel,
+		},
+		{
+			desc:     "publish message with subtopic",
+			payload:  data,
+			subtopic: subtopic,
+		},
+		{
+			desc:     "publish message with channel and subtopic",
+			payload:  data,
+			channel:  channel,
+			subtopic: subtopic,
+		},
+	}
+
+	for _, tc := range cases {
+		expectedMsg := messaging.Message{
+			Channel:  tc.channel,
+			Subtopic: tc.subtopic,
+			Payload:  tc.payload,
+		}
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+		err = pubsub.Publish(topic, expectedMsg)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+		receivedMsg := <-msgChan
+		assert.Equal(t, expectedMsg, receivedMsg, fmt.Sprintf("%s: expected %+v got %+v\n", tc.desc, expectedMsg, receivedMsg))
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	ps, err := rabbitmq.NewPubSub(address, "", testLog)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	defer ps.Close()
+
+	msgs := make(chan messaging.Message, 1)
+	err = ps.Subscribe(fmt.Sprintf("%s.reconnect", chansPrefix), func(msg messaging.Message) error {
+		msgs <- msg
+		return nil
+	})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	code, err := container.Exec([]string{"rabbitmqctl", "close_all_connections", "test"}, dockertest.ExecOptions{})
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	require.Equal(t, 0, code, fmt.Sprintf("close connections: expected exit code 0 got %d", code))
+
+	// Messages are published and received once the connection is restored.
+	expectedMsg := messaging.Message{Channel: channel, Payload: data}
+	deadline := time.Now().Add(timeout)
+	for {
+		err = ps.Publish("reconnect", expectedMsg)
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.Nil(t, err, fmt.Sprintf("publish after reconnect: got unexpected error: %s", err))
+
+	select {
+	case receivedMsg := <-msgs:
+		assert.Equal(t, expectedMsg, receivedMsg, fmt.Sprintf("receive after reconnect: expected %+v got %+v\n", expectedMsg, receivedMsg))
+	case <-time.After(timeout):
+		assert.Fail(t, "receive after reconnect: expected message to be received")
+	}
+}
+
+func TestConformance(t *testing.T) {
+	pubsubtest.Run(t, pubsubtest.Config{
+		New: func(queue string) (messaging.PubSub, error) {
+			return rabbitmq.NewPubSub(address, queue, testLog)
+		},
+	})
+}
+
+func handler(msg messaging.Message) error {
+	msgChan <- msg
+	return nil
+}
@@ -0,0 +1,27 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package messaging
+
+import "strings"
+
+// Matches returns true if the subject matches the NATS-like subscription
+// topic, where "*" matches a single token and ">" matches one or more
+// trailing tokens. Brokers without NATS-like subjects use it to filter
+// the received messages.
+func Matches(topic, subject string) bool {
+	if subject == "" {
+		return false
+	}
+	tt := strings.Split(topic, ".")
+	st := strings.Split(subject, ".")
+	for i, t := range tt {
+		if t == ">" {
+			return len(st) > i
+		}
+		if i >= len(st) || (t != "*" && t != st[i]) {
+			return false
+		}
+	}
+	return len(tt) == len(st)
+}
@@ -0,0 +1,81 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package messaging_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatches(t *testing.T) {
+	cases := []struct {
+		desc    string
+		topic   string
+		subject string
+		matches bool
+	}{
+		{
+			desc:    "match the same subject",
+			topic:   "channels.1",
+			subject: "channels.1",
+			matches: true,
+		},
+		{
+			desc:    "match subject of another channel",
+			topic:   "channels.1",
+			subject: "channels.2",
+			matches: false,
+		},
+		{
+			desc:    "match subject with subtopic",
+			topic:   "channels.1",
+			subject: "channels.1.temperature",
+			matches: false,
+		},
+		{
+			desc:    "match single token wildcard",
+			topic:   "channels.*",
+			subject: "channels.1",
+			matches: true,
+		},
+		{
+			desc:    "match single token wildcard with subtopic",
+			topic:   "channels.*",
+			subject: "channels.1.temperature",
+			matches: false,
+		},
+		{
+			desc:    "match trailing tokens wildcard",
+			topic:   "channels.>",
+			subject: "channels.1.temperature.room",
+			matches: true,
+		},
+		{
+			desc:    "match trailing tokens wildcard without trailing tokens",
+			topic:   "channels.1.>",
+			subject: "channels.1",
+			matches: false,
+		},
+		{
+			desc:    "match subject shorter than the topic",
+			topic:   "channels.1.temperature",
+			subject: "channels.1",
+			matches: false,
+		},
+		{
+			desc:    "match empty subject",
+			topic:   "channels.>",
+			subject: "",
+			matches: false,
+		},
+	}
+
+	for _, tc := range cases {
+		matches := messaging.Matches(tc.topic, tc.subject)
+		assert.Equal(t, tc.matches, matches, fmt.Sprintf("%s: expected %t got %t", tc.desc, tc.matches, matches))
+	}
+}
@@ -5,6 +5,7 @@ package sdk
 
 import (
 	"bytes"
+	"crypto"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -12,18 +13,23 @@ import (
 	"strings"
 
 	"github.com/mainflux/mainflux/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
 )
 
 const configsEndpoint = "configs"
 const bootstrapEndpoint = "bootstrap"
 const whitelistEndpoint = "state"
 const bootstrapCertsEndpoint = "configs/certs"
+const signatureHeader = "X-JWS-Signature"
+
+var errMissingSignature = errors.New("missing signature")
 
 // BootstrapConfig represents Configuration entity. It wraps information about external entity
 // as well as info about corresponding Mainflux entities.
 // MFThing represents corresponding Mainflux Thing ID.
 // MFKey is key of corresponding Mainflux Thing.
 // MFChannels is a list of Mainflux Channels corresponding Mainflux Thing connects to.
+// Control is ID of the Mainflux Channel the Thing is notified about Config changes over.
 type BootstrapConfig struct {
 	ThingID     string    `json:"thing_id,omitempty"`
 	Channels    []string  `json:"channels,omitempty"`
@@ -38,6 +44,7 @@ type BootstrapConfig struct {
 	CACert      string    `json:"ca_cert,omitempty"`
 	Content     string    `json:"content,omitempty"`
 	State       int       `json:"state,omitempty"`
+	Control     string    `json:"control_channel,omitempty"`
 }
 
 type ConfigUpdateCertReq struct {
@@ -236,6 +243,12 @@ func (sdk mfSDK) Bootstrap(externalKey, externalID string) (BootstrapConfig, err
 		return BootstrapConfig{}, errors.Wrap(ErrFailedFetch, errors.New(resp.Status))
 	}
 
+	if sdk.bootstrapKey != nil {
+		if err := verify(resp.Header.Get(signatureHeader), body, sdk.bootstrapKey); err != nil {
+			return BootstrapConfig{}, errors.Wrap(ErrInvalidSignature, err)
+		}
+	}
+
 	var bc BootstrapConfig
 	if err := json.Unmarshal(body, &bc); err != nil {
 		return BootstrapConfig{}, err
@@ -243,3 +256,18 @@ func (sdk mfSDK) Bootstrap(externalKey, externalID string) (BootstrapConfig, err
 
 	return bc, nil
 }
+
+// verify checks detached JWS signature of the response body.
+func verify(signature string, body []byte, key crypto.PublicKey) error {
+	if signature == "" {
+		return errMissingSignature
+	}
+
+	jws, err := jose.ParseDetached(signature, body)
+	if err != nil {
+		return err
+	}
+
+	_, err = jws.Verify(key)
+	return err
+}
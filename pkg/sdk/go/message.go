@@ -4,6 +4,7 @@
 package sdk
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -41,6 +42,38 @@ func (sdk mfSDK) SendMessage(chanName, msg, token string) error {
 	return nil
 }
 
+func (sdk mfSDK) SendMessages(chanID string, msgs []BatchMessage, token string) ([]MessageResult, error) {
+	data, err := json.Marshal(map[string][]BatchMessage{"messages": msgs})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("channels/%s/batch", chanID)
+	url := createURL(sdk.baseURL, sdk.httpAdapterPrefix, endpoint)
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := sdk.sendRequest(req, token, string(CTJSON))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, errors.Wrap(ErrFailedPublish, errors.New(resp.Status))
+	}
+
+	var res sendMessagesRes
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+
+	return res.Results, nil
+}
+
 func (sdk mfSDK) ReadMessages(chanName, token string) (MessagesPage, error) {
 	chanNameParts := strings.SplitN(chanName, ".", 2)
 	chanID := chanNameParts[0]
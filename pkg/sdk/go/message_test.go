@@ -13,14 +13,15 @@ import (
 	adapter "github.com/mainflux/mainflux/http"
 	"github.com/mainflux/mainflux/http/api"
 	"github.com/mainflux/mainflux/http/mocks"
+	"github.com/mainflux/mainflux/pkg/limits"
 	sdk "github.com/mainflux/mainflux/pkg/sdk/go"
 	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/assert"
 )
 
 func newMessageService(cc mainflux.ThingsServiceClient) adapter.Service {
-	pub := mocks.NewPublisher()
-	return adapter.New(pub, cc)
+	pubSub := mocks.NewPubSub()
+	return adapter.New(pubSub, cc, limits.NewUnlimited())
 }
 
 func newMessageServer(svc adapter.Service) *httptest.Server {
@@ -98,6 +99,79 @@ func TestSendMessage(t *testing.T) {
 	}
 }
 
+func TestSendMessages(t *testing.T) {
+	chanID := "1"
+	atoken := "auth_token"
+	invalidToken := "invalid_token"
+	thingsClient := mocks.NewThingsClient(map[string]string{atoken: chanID})
+	pub := newMessageService(thingsClient)
+	ts := newMessageServer(pub)
+	defer ts.Close()
+	sdkConf := sdk.Config{
+		BaseURL:           ts.URL,
+		UsersPrefix:       "",
+		GroupsPrefix:      "",
+		ThingsPrefix:      "",
+		HTTPAdapterPrefix: "",
+		MsgContentType:    contentType,
+		TLSVerification:   false,
+	}
+
+	mainfluxSDK := sdk.NewSDK(sdkConf)
+
+	msgs := []sdk.BatchMessage{
+		{Payload: `[{"n":"current","t":-1,"v":1.6}]`},
+		{Subtopic: "temperature.room", Payload: "21.5", Created: 1600000000000000000},
+		{Subtopic: "temp*", Payload: "21.5"},
+	}
+
+	cases := map[string]struct {
+		chanID  string
+		msgs    []sdk.BatchMessage
+		auth    string
+		results []sdk.MessageResult
+		err     error
+	}{
+		"publish messages": {
+			chanID: chanID,
+			msgs:   msgs,
+			auth:   atoken,
+			results: []sdk.MessageResult{
+				{Accepted: true},
+				{Accepted: true},
+				{Accepted: false, Error: "malformed subtopic"},
+			},
+			err: nil,
+		},
+		"publish no messages": {
+			chanID:  chanID,
+			msgs:    []sdk.BatchMessage{},
+			auth:    atoken,
+			results: nil,
+			err:     createError(sdk.ErrFailedPublish, http.StatusBadRequest),
+		},
+		"publish messages with invalid authorization token": {
+			chanID:  chanID,
+			msgs:    msgs,
+			auth:    invalidToken,
+			results: nil,
+			err:     createError(sdk.ErrFailedPublish, http.StatusForbidden),
+		},
+		"publish messages unable to authorize": {
+			chanID:  chanID,
+			msgs:    msgs,
+			auth:    mocks.ServiceErrToken,
+			results: nil,
+			err:     createError(sdk.ErrFailedPublish, http.StatusServiceUnavailable),
+		},
+	}
+	for desc, tc := range cases {
+		results, err := mainfluxSDK.SendMessages(tc.chanID, tc.msgs, tc.auth)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s, got %s", desc, tc.err, err))
+		assert.Equal(t, tc.results, results, fmt.Sprintf("%s: expected results %v, got %v", desc, tc.results, results))
+	}
+}
+
 func TestSetContentType(t *testing.T) {
 	chanID := "1"
 	atoken := "auth_token"
@@ -41,6 +41,16 @@ type MessagesPage struct {
 	pageRes
 }
 
+// MessageResult contains result of publishing the batch message.
+type MessageResult struct {
+	Accepted bool   `json:"accepted"`
+	Error    string `json:"error,omitempty"`
+}
+
+type sendMessagesRes struct {
+	Results []MessageResult `json:"results"`
+}
+
 type GroupsPage struct {
 	Groups []Group `json:"groups"`
 	pageRes
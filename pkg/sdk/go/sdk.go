@@ -4,6 +4,7 @@
 package sdk
 
 import (
+	"crypto"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -72,6 +73,10 @@ var (
 
 	// ErrFailedUserAdd failed to add user to a group.
 	ErrFailedUserAdd = errors.New("failed to add user to group")
+
+	// ErrInvalidSignature indicates that bootstrap response signature is
+	// missing or can't be verified.
+	ErrInvalidSignature = errors.New("invalid bootstrap response signature")
 )
 
 // ContentType represents all possible content types.
@@ -112,6 +117,15 @@ type Channel struct {
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// BatchMessage represents message published in batch. Subtopic is given
+// in the dot separated form, and Created is the message creation time in
+// nanoseconds since the epoch, or zero for the time of publishing.
+type BatchMessage struct {
+	Subtopic string `json:"subtopic,omitempty"`
+	Payload  string `json:"payload"`
+	Created  int64  `json:"created,omitempty"`
+}
+
 // SDK contains Mainflux API.
 type SDK interface {
 	// CreateUser registers mainflux user.
@@ -209,6 +223,10 @@ type SDK interface {
 	// SendMessage send message to specified channel.
 	SendMessage(chanID, msg, token string) error
 
+	// SendMessages sends batch of messages to specified channel. Returned
+	// results correspond to the sent messages.
+	SendMessages(chanID string, msgs []BatchMessage, token string) ([]MessageResult, error)
+
 	// ReadMessages read messages of specified channel.
 	ReadMessages(chanID, token string) (MessagesPage, error)
 
@@ -234,6 +252,7 @@ type SDK interface {
 	RemoveBootstrap(token, id string) error
 
 	// Bootstrap returns Config to the Thing with provided external ID using external key.
+	// If the bootstrap public key is configured, response signature is verified.
 	Bootstrap(externalKey, externalID string) (BootstrapConfig, error)
 
 	// Whitelist updates Thing state Config with given ID belonging to the user identified by the given token.
@@ -263,6 +282,7 @@ type mfSDK struct {
 	httpAdapterPrefix string
 	bootstrapPrefix   string
 	msgContentType    ContentType
+	bootstrapKey      crypto.PublicKey
 	client            *http.Client
 }
 
@@ -280,6 +300,10 @@ type Config struct {
 	BootstrapPrefix   string
 	MsgContentType    ContentType
 	TLSVerification   bool
+
+	// BootstrapKey is the public key of the Bootstrap service used
+	// to verify bootstrap responses. Verification is skipped if not set.
+	BootstrapKey crypto.PublicKey
 }
 
 // NewSDK returns new mainflux SDK instance.
@@ -296,6 +320,7 @@ func NewSDK(conf Config) SDK {
 		httpAdapterPrefix: conf.HTTPAdapterPrefix,
 		bootstrapPrefix:   conf.BootstrapPrefix,
 		msgContentType:    conf.MsgContentType,
+		bootstrapKey:      conf.BootstrapKey,
 		client: &http.Client{
 			Transport: &http.Transport{
 				TLSClientConfig: &tls.Config{
@@ -0,0 +1,67 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package senml
+
+import (
+	"math"
+	"time"
+
+	"github.com/mainflux/mainflux/pkg/errors"
+)
+
+// TimePolicy decides which time the transformed message is stored with.
+type TimePolicy string
+
+const (
+	// DeviceTime policy keeps the time sent by the device.
+	DeviceTime TimePolicy = "device"
+	// ServerTime policy always replaces the device time with the time
+	// the message is received by the platform.
+	ServerTime TimePolicy = "server"
+	// SkewWindow policy keeps the device time only if it differs from
+	// the server time less than the maximum skew and replaces it otherwise.
+	SkewWindow TimePolicy = "skew"
+)
+
+// ErrInvalidTimestamping indicates unknown time policy or negative skew.
+var ErrInvalidTimestamping = errors.New("invalid timestamping configuration")
+
+// Timestamping configures the time policy of the transformer.
+type Timestamping struct {
+	Policy  TimePolicy
+	MaxSkew time.Duration
+}
+
+// Validate returns an error if the time policy is unknown or the
+// maximum skew is negative.
+func (ts Timestamping) Validate() error {
+	switch ts.Policy {
+	case DeviceTime, ServerTime, SkewWindow:
+	default:
+		return ErrInvalidTimestamping
+	}
+	if ts.MaxSkew < 0 {
+		return ErrInvalidTimestamping
+	}
+	return nil
+}
+
+// resolve returns the time the message is stored with and whether the
+// device time is replaced. Time values are in seconds since the epoch.
+func (ts Timestamping) resolve(device, server float64) (float64, bool) {
+	// Use reception timestamp if SenML messsage Time is missing.
+	if device == 0 {
+		return server, false
+	}
+
+	switch ts.Policy {
+	case ServerTime:
+		return server, true
+	case SkewWindow:
+		if math.Abs(device-server) > ts.MaxSkew.Seconds() {
+			return server, true
+		}
+	}
+	return device, false
+}
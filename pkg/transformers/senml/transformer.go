@@ -29,10 +29,20 @@ var formats = map[string]senml.Format{
 
 type transformer struct {
 	format senml.Format
+	ts     Timestamping
 }
 
-// New returns transformer service implementation for SenML messages.
+// New returns transformer service implementation for SenML messages
+// which keeps the time sent by the device.
 func New(contentFormat string) transformers.Transformer {
+	return NewWithTimestamping(contentFormat, Timestamping{Policy: DeviceTime})
+}
+
+// NewWithTimestamping returns transformer service implementation for
+// SenML messages which stores messages with the time decided by the
+// given timestamping configuration. The original device time of the
+// message is kept in the DeviceTime field if it's replaced.
+func NewWithTimestamping(contentFormat string, ts Timestamping) transformers.Transformer {
 	format, ok := formats[contentFormat]
 	if !ok {
 		format = formats[JSON]
@@ -40,6 +50,7 @@ func New(contentFormat string) transformers.Transformer {
 
 	return transformer{
 		format: format,
+		ts:     ts,
 	}
 }
 
@@ -54,13 +65,15 @@ func (t transformer) Transform(msg messaging.Message) (interface{}, error) {
 		return nil, errors.Wrap(errNormalize, err)
 	}
 
+	// Convert the Unix timestamp in nanoseconds to float64
+	received := float64(msg.Created) / float64(1e9)
+
 	msgs := make([]Message, len(normalized.Records))
 	for i, v := range normalized.Records {
-		// Use reception timestamp if SenML messsage Time is missing
-		t := v.Time
-		if t == 0 {
-			// Convert the Unix timestamp in nanoseconds to float64
-			t = float64(msg.Created) / float64(1e9)
+		tm, replaced := t.ts.resolve(v.Time, received)
+		var deviceTime float64
+		if replaced {
+			deviceTime = v.Time
 		}
 
 		msgs[i] = Message{
@@ -70,13 +83,15 @@ func (t transformer) Transform(msg messaging.Message) (interface{}, error) {
 			Protocol:    msg.Protocol,
 			Name:        v.Name,
 			Unit:        v.Unit,
-			Time:        t,
+			Time:        tm,
+			DeviceTime:  deviceTime,
 			UpdateTime:  v.UpdateTime,
 			Value:       v.Value,
 			BoolValue:   v.BoolValue,
 			DataValue:   v.DataValue,
 			StringValue: v.StringValue,
 			Sum:         v.Sum,
+			Headers:     msg.Headers,
 		}
 	}
 
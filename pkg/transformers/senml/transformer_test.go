@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/mainflux/mainflux/pkg/errors"
 	"github.com/mainflux/mainflux/pkg/messaging"
@@ -150,3 +151,113 @@ func TestTransformCBOR(t *testing.T) {
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s expected %s, got %s", tc.desc, tc.err, err))
 	}
 }
+
+func TestTransformTimestamping(t *testing.T) {
+	received := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := float64(received.Unix())
+	skewed := server - 30
+	future := server + 3600
+
+	payload := func(tm float64) []byte {
+		if tm == 0 {
+			return []byte(`[{"n":"temperature","v":21}]`)
+		}
+		return []byte(fmt.Sprintf(`[{"n":"temperature","v":21,"t":%f}]`, tm))
+	}
+
+	cases := []struct {
+		desc       string
+		ts         senml.Timestamping
+		time       float64
+		expected   float64
+		deviceTime float64
+	}{
+		{
+			desc:       "trust device time",
+			ts:         senml.Timestamping{Policy: senml.DeviceTime},
+			time:       future,
+			expected:   future,
+			deviceTime: 0,
+		},
+		{
+			desc:       "trust missing device time",
+			ts:         senml.Timestamping{Policy: senml.DeviceTime},
+			time:       0,
+			expected:   server,
+			deviceTime: 0,
+		},
+		{
+			desc:       "replace device time with server time",
+			ts:         senml.Timestamping{Policy: senml.ServerTime},
+			time:       skewed,
+			expected:   server,
+			deviceTime: skewed,
+		},
+		{
+			desc:       "replace missing device time with server time",
+			ts:         senml.Timestamping{Policy: senml.ServerTime},
+			time:       0,
+			expected:   server,
+			deviceTime: 0,
+		},
+		{
+			desc:       "keep device time within skew window",
+			ts:         senml.Timestamping{Policy: senml.SkewWindow, MaxSkew: time.Minute},
+			time:       skewed,
+			expected:   skewed,
+			deviceTime: 0,
+		},
+		{
+			desc:       "replace device time out of skew window",
+			ts:         senml.Timestamping{Policy: senml.SkewWindow, MaxSkew: time.Minute},
+			time:       future,
+			expected:   server,
+			deviceTime: future,
+		},
+	}
+
+	for _, tc := range cases {
+		tr := senml.NewWithTimestamping(senml.JSON, tc.ts)
+		msg := messaging.Message{Channel: "channel", Payload: payload(tc.time), Created: received.UnixNano()}
+		res, err := tr.Transform(msg)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		msgs, ok := res.([]senml.Message)
+		require.True(t, ok && len(msgs) == 1, fmt.Sprintf("%s: expected a single message", tc.desc))
+		assert.Equal(t, tc.expected, msgs[0].Time, fmt.Sprintf("%s: expected time %f got %f", tc.desc, tc.expected, msgs[0].Time))
+		assert.Equal(t, tc.deviceTime, msgs[0].DeviceTime, fmt.Sprintf("%s: expected device time %f got %f", tc.desc, tc.deviceTime, msgs[0].DeviceTime))
+	}
+}
+
+func TestTimestampingValidate(t *testing.T) {
+	cases := []struct {
+		desc string
+		ts   senml.Timestamping
+		err  error
+	}{
+		{
+			desc: "validate device time policy",
+			ts:   senml.Timestamping{Policy: senml.DeviceTime},
+			err:  nil,
+		},
+		{
+			desc: "validate skew window policy",
+			ts:   senml.Timestamping{Policy: senml.SkewWindow, MaxSkew: time.Minute},
+			err:  nil,
+		},
+		{
+			desc: "validate unknown policy",
+			ts:   senml.Timestamping{Policy: "unknown"},
+			err:  senml.ErrInvalidTimestamping,
+		},
+		{
+			desc: "validate negative skew",
+			ts:   senml.Timestamping{Policy: senml.SkewWindow, MaxSkew: -time.Minute},
+			err:  senml.ErrInvalidTimestamping,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.ts.Validate()
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+}
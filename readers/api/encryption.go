@@ -0,0 +1,91 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"github.com/mainflux/mainflux/pkg/envelope"
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/mainflux/mainflux/pkg/transformers/senml"
+	"github.com/mainflux/mainflux/readers"
+)
+
+var _ readers.MessageRepository = (*decryptionMiddleware)(nil)
+
+// errEncryptedFilter indicates filtering by the encrypted values.
+var errEncryptedFilter = errors.New("encrypted values can't be filtered")
+
+// Filters of the string and data values, which are stored encrypted and
+// can't be compared with the given ones.
+var encryptedFilters = []string{"vs", "vd"}
+
+type decryptionMiddleware struct {
+	sealer envelope.Sealer
+	svc    readers.MessageRepository
+}
+
+// DecryptionMiddleware decrypts the string and data values of the messages
+// encrypted by the writers. Since the channel is authorized before reading
+// the messages, values are decrypted only for the authorized callers.
+// Filtering the messages of the encrypted channels by the encrypted values
+// is rejected with the bad request error.
+func DecryptionMiddleware(svc readers.MessageRepository, sealer envelope.Sealer) readers.MessageRepository {
+	return &decryptionMiddleware{
+		sealer: sealer,
+		svc:    svc,
+	}
+}
+
+func (dm *decryptionMiddleware) ReadAll(chanID string, offset, limit uint64, query map[string]string) (readers.MessagesPage, error) {
+	if err := dm.checkFilters(chanID, query); err != nil {
+		return readers.MessagesPage{}, err
+	}
+
+	page, err := dm.svc.ReadAll(chanID, offset, limit, query)
+	if err != nil {
+		return page, err
+	}
+
+	msgs := make([]senml.Message, len(page.Messages))
+	for i, msg := range page.Messages {
+		if msg.StringValue != nil {
+			v, err := dm.sealer.Open(chanID, *msg.StringValue)
+			if err != nil {
+				return readers.MessagesPage{}, err
+			}
+			msg.StringValue = &v
+		}
+		if msg.DataValue != nil {
+			v, err := dm.sealer.Open(chanID, *msg.DataValue)
+			if err != nil {
+				return readers.MessagesPage{}, err
+			}
+			msg.DataValue = &v
+		}
+		msgs[i] = msg
+	}
+	page.Messages = msgs
+
+	return page, nil
+}
+
+func (dm *decryptionMiddleware) checkFilters(chanID string, query map[string]string) error {
+	var filtered bool
+	for _, name := range encryptedFilters {
+		if _, ok := query[name]; ok {
+			filtered = true
+		}
+	}
+	if !filtered {
+		return nil
+	}
+
+	encrypted, err := dm.sealer.Encrypted(chanID)
+	if err != nil {
+		return err
+	}
+	if encrypted {
+		return errEncryptedFilter
+	}
+	return nil
+}
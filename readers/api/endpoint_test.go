@@ -4,17 +4,24 @@
 package api_test
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/mainflux/mainflux"
+	"github.com/mainflux/mainflux/pkg/envelope"
 	"github.com/mainflux/mainflux/pkg/transformers/senml"
 	"github.com/mainflux/mainflux/readers"
 	"github.com/mainflux/mainflux/readers/api"
 	"github.com/mainflux/mainflux/readers/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -180,3 +187,100 @@ func TestReadAll(t *testing.T) {
 		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
 	}
 }
+
+// newSealer returns sealer encrypting the values of the given channel.
+func newSealer(t *testing.T, chanID string) envelope.Sealer {
+	f, err := ioutil.TempFile("", "keys")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	defer os.Remove(f.Name())
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", envelope.KeySize)))
+	_, err = fmt.Fprintf(f, `{"channels": {"%s": {"current": "1", "keys": {"1": "%s"}}}}`, chanID, key)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	require.Nil(t, f.Close(), "failed to close keys file")
+
+	keys, err := envelope.NewFileProvider(f.Name())
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	return envelope.New(keys)
+}
+
+func TestReadAllEncrypted(t *testing.T) {
+	sealer := newSealer(t, chanID)
+
+	sealedString, err := sealer.Seal(chanID, stringV)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	sealedData, err := sealer.Seal(chanID, dataV)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	svc := mocks.NewMessageRepository(map[string][]senml.Message{
+		chanID: {
+			{Channel: chanID, StringValue: &sealedString},
+			{Channel: chanID, DataValue: &sealedData},
+		},
+	})
+	ts := newServer(api.DecryptionMiddleware(svc, sealer), mocks.NewThingsService())
+	defer ts.Close()
+
+	req := testRequest{
+		client: ts.Client(),
+		method: http.MethodGet,
+		url:    fmt.Sprintf("%s/channels/%s/messages", ts.URL, chanID),
+		token:  token,
+	}
+	res, err := req.make()
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode, fmt.Sprintf("expected %d got %d", http.StatusOK, res.StatusCode))
+
+	var page struct {
+		Messages []senml.Message `json:"messages"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&page)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	require.Len(t, page.Messages, 2, "expected two messages")
+	assert.Equal(t, &stringV, page.Messages[0].StringValue, "expected decrypted string value")
+	assert.Equal(t, &dataV, page.Messages[1].DataValue, "expected decrypted data value")
+}
+
+func TestReadAllEncryptedFilters(t *testing.T) {
+	plainChan := "2"
+	svc := mocks.NewMessageRepository(map[string][]senml.Message{
+		chanID:    {{Channel: chanID}},
+		plainChan: {{Channel: plainChan, StringValue: &stringV}},
+	})
+	ts := newServer(api.DecryptionMiddleware(svc, newSealer(t, chanID)), mocks.NewThingsService())
+	defer ts.Close()
+
+	cases := map[string]struct {
+		url    string
+		status int
+	}{
+		"read encrypted messages without value filters": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?v=%f", ts.URL, chanID, v),
+			status: http.StatusOK,
+		},
+		"read encrypted messages filtered by string value": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?vs=%s", ts.URL, chanID, stringV),
+			status: http.StatusBadRequest,
+		},
+		"read encrypted messages filtered by data value": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?vd=%s", ts.URL, chanID, dataV),
+			status: http.StatusBadRequest,
+		},
+		"read plain messages filtered by string value": {
+			url:    fmt.Sprintf("%s/channels/%s/messages?vs=%s", ts.URL, plainChan, stringV),
+			status: http.StatusOK,
+		},
+	}
+
+	for desc, tc := range cases {
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  token,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", desc, tc.status, res.StatusCode))
+	}
+}
@@ -60,7 +60,7 @@ func (cr cassandraRepository) ReadAll(chanID string, offset, limit uint64, query
 		var msg senml.Message
 		err := scanner.Scan(&msg.Channel, &msg.Subtopic, &msg.Publisher, &msg.Protocol,
 			&msg.Name, &msg.Unit, &msg.Value, &msg.StringValue, &msg.BoolValue,
-			&msg.DataValue, &msg.Sum, &msg.Time, &msg.UpdateTime)
+			&msg.DataValue, &msg.Sum, &msg.Time, &msg.UpdateTime, &msg.DeviceTime, &msg.Headers)
 		if err != nil {
 			return readers.MessagesPage{}, errors.Wrap(errReadMessages, err)
 		}
@@ -78,7 +78,7 @@ func buildSelectQuery(chanID string, offset, limit uint64, names []string) strin
 	var condCQL string
 	cql := `SELECT channel, subtopic, publisher, protocol, name, unit,
 	        value, string_value, bool_value, data_value, sum, time,
-			update_time FROM messages WHERE channel = ? %s LIMIT ?
+			update_time, device_time, headers FROM messages WHERE channel = ? %s LIMIT ?
 			ALLOW FILTERING`
 
 	for _, name := range names {
@@ -140,6 +140,13 @@ func fmtCondition(chanID string, query map[string]string) string {
 // results in form of rows and columns, this obscure message conversion is needed
 // to return actual []broker.Message from the query result.
 func parseValues(value interface{}, name string, msg *senml.Message) {
+	if name == "headers" {
+		if h, ok := value.(string); ok {
+			json.Unmarshal([]byte(h), &msg.Headers)
+		}
+		return
+	}
+
 	if name == "sum" && value != nil {
 		if valSum, ok := value.(json.Number); ok {
 			sum, err := valSum.Float64()
@@ -206,7 +213,13 @@ func parseMessage(names []string, fields []interface{}) senml.Message {
 				continue
 			}
 
-			val, _ := strconv.ParseFloat(fields[i].(string), 64)
+			// Fields missing in some points, such as the device time,
+			// are nil in the rows of these points.
+			s, ok := fields[i].(string)
+			if !ok {
+				continue
+			}
+			val, _ := strconv.ParseFloat(s, 64)
 			msgField.SetFloat(val)
 		}
 	}
@@ -70,8 +70,10 @@ func TestReadAll(t *testing.T) {
 			msg.Value = &v
 		case 1:
 			msg.BoolValue = &boolV
+			msg.Headers = map[string]string{"correlation-id": "42"}
 		case 2:
 			msg.StringValue = &stringV
+			msg.DeviceTime = 1600000000
 		case 3:
 			msg.DataValue = &dataV
 		case 4:
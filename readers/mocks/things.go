@@ -45,3 +45,7 @@ func (svc thingsServiceMock) CanAccessByID(context.Context, *mainflux.AccessByID
 func (svc thingsServiceMock) Identify(context.Context, *mainflux.Token, ...grpc.CallOption) (*mainflux.ThingID, error) {
 	panic("not implemented")
 }
+
+func (svc thingsServiceMock) Key(context.Context, *mainflux.ThingID, ...grpc.CallOption) (*mainflux.Token, error) {
+	panic("not implemented")
+}
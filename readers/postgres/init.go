@@ -70,6 +70,18 @@ func migrateDB(db *sqlx.DB) error {
 					"DROP TABLE messages",
 				},
 			},
+			{
+				Id: "messages_2",
+				Up: []string{
+					`ALTER TABLE IF EXISTS messages ADD COLUMN IF NOT EXISTS headers JSONB`,
+				},
+			},
+			{
+				Id: "messages_3",
+				Up: []string{
+					`ALTER TABLE IF EXISTS messages ADD COLUMN IF NOT EXISTS device_time FLOAT`,
+				},
+			},
 		},
 	}
 
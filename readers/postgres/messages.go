@@ -4,6 +4,7 @@
 package postgres
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/jmoiron/sqlx" // required for DB access
@@ -61,7 +62,10 @@ func (tr postgresRepository) ReadAll(chanID string, offset, limit uint64, query
 			return readers.MessagesPage{}, errors.Wrap(errReadMessages, err)
 		}
 
-		msg := toMessage(dbm)
+		msg, err := toMessage(dbm)
+		if err != nil {
+			return readers.MessagesPage{}, errors.Wrap(errReadMessages, err)
+		}
 		page.Messages = append(page.Messages, msg)
 	}
 
@@ -110,9 +114,11 @@ type dbMessage struct {
 	Sum         *float64 `db:"sum"`
 	Time        float64  `db:"time"`
 	UpdateTime  float64  `db:"update_time"`
+	DeviceTime  *float64 `db:"device_time"`
+	Headers     []byte   `db:"headers"`
 }
 
-func toMessage(dbm dbMessage) senml.Message {
+func toMessage(dbm dbMessage) (senml.Message, error) {
 	msg := senml.Message{
 		Channel:    dbm.Channel,
 		Subtopic:   dbm.Subtopic,
@@ -136,5 +142,15 @@ func toMessage(dbm dbMessage) senml.Message {
 		msg.BoolValue = dbm.BoolValue
 	}
 
-	return msg
+	if dbm.DeviceTime != nil {
+		msg.DeviceTime = *dbm.DeviceTime
+	}
+
+	if len(dbm.Headers) > 0 {
+		if err := json.Unmarshal(dbm.Headers, &msg.Headers); err != nil {
+			return senml.Message{}, err
+		}
+	}
+
+	return msg, nil
 }
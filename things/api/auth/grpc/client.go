@@ -23,6 +23,7 @@ type grpcClient struct {
 	canAccessByKey endpoint.Endpoint
 	canAccessByID  endpoint.Endpoint
 	identify       endpoint.Endpoint
+	key            endpoint.Endpoint
 }
 
 // NewClient returns new gRPC client instance.
@@ -55,6 +56,14 @@ func NewClient(conn *grpc.ClientConn, tracer opentracing.Tracer, timeout time.Du
 			decodeIdentityResponse,
 			mainflux.ThingID{},
 		).Endpoint()),
+		key: kitot.TraceClient(tracer, "key")(kitgrpc.NewClient(
+			conn,
+			svcName,
+			"Key",
+			encodeKeyRequest,
+			decodeKeyResponse,
+			mainflux.Token{},
+		).Endpoint()),
 	}
 }
 
@@ -99,6 +108,19 @@ func (client grpcClient) Identify(ctx context.Context, req *mainflux.Token, _ ..
 	return &mainflux.ThingID{Value: ir.id}, ir.err
 }
 
+func (client grpcClient) Key(ctx context.Context, req *mainflux.ThingID, _ ...grpc.CallOption) (*mainflux.Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, client.timeout)
+	defer cancel()
+
+	res, err := client.key(ctx, keyReq{id: req.GetValue()})
+	if err != nil {
+		return nil, err
+	}
+
+	kr := res.(keyRes)
+	return &mainflux.Token{Value: kr.key}, kr.err
+}
+
 func encodeCanAccessByKeyRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
 	req := grpcReq.(AccessByKeyReq)
 	return &mainflux.AccessByKeyReq{Token: req.thingKey, ChanID: req.chanID}, nil
@@ -114,11 +136,21 @@ func encodeIdentifyRequest(_ context.Context, grpcReq interface{}) (interface{},
 	return &mainflux.Token{Value: req.key}, nil
 }
 
+func encodeKeyRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(keyReq)
+	return &mainflux.ThingID{Value: req.id}, nil
+}
+
 func decodeIdentityResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
 	res := grpcRes.(*mainflux.ThingID)
 	return identityRes{id: res.GetValue(), err: nil}, nil
 }
 
+func decodeKeyResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(*mainflux.Token)
+	return keyRes{key: res.GetValue(), err: nil}, nil
+}
+
 func decodeEmptyResponse(_ context.Context, _ interface{}) (interface{}, error) {
 	return emptyRes{}, nil
 }
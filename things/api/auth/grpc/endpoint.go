@@ -47,3 +47,18 @@ func identifyEndpoint(svc things.Service) endpoint.Endpoint {
 		return identityRes{id: id, err: nil}, nil
 	}
 }
+
+func keyEndpoint(svc things.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(keyReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		key, err := svc.Key(ctx, req.id)
+		if err != nil {
+			return keyRes{err: err}, err
+		}
+		return keyRes{key: key, err: nil}, nil
+	}
+}
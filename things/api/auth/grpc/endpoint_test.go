@@ -185,3 +185,46 @@ func TestIdentify(t *testing.T) {
 		assert.Equal(t, tc.code, e.Code(), fmt.Sprintf("%s: expected %s got %s", desc, tc.code, e.Code()))
 	}
 }
+
+func TestKey(t *testing.T) {
+	ths, err := svc.CreateThings(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	sth := ths[0]
+
+	usersAddr := fmt.Sprintf("localhost:%d", port)
+	conn, err := grpc.Dial(usersAddr, grpc.WithInsecure())
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	cli := grpcapi.NewClient(conn, mocktracer.New(), time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	cases := map[string]struct {
+		id   string
+		key  string
+		code codes.Code
+	}{
+		"retrieve key of existing thing": {
+			id:   sth.ID,
+			key:  sth.Key,
+			code: codes.OK,
+		},
+		"retrieve key of non-existent thing": {
+			id:   wrong,
+			key:  "",
+			code: codes.NotFound,
+		},
+		"retrieve key with empty thing ID": {
+			id:   wrongID,
+			key:  "",
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for desc, tc := range cases {
+		key, err := cli.Key(ctx, &mainflux.ThingID{Value: tc.id})
+		e, ok := status.FromError(err)
+		assert.True(t, ok, "OK expected to be true")
+		assert.Equal(t, tc.key, key.GetValue(), fmt.Sprintf("%s: expected %s got %s", desc, tc.key, key.GetValue()))
+		assert.Equal(t, tc.code, e.Code(), fmt.Sprintf("%s: expected %s got %s", desc, tc.code, e.Code()))
+	}
+}
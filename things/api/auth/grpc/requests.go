@@ -42,3 +42,15 @@ func (req identifyReq) validate() error {
 
 	return nil
 }
+
+type keyReq struct {
+	id string
+}
+
+func (req keyReq) validate() error {
+	if req.id == "" {
+		return things.ErrMalformedEntity
+	}
+
+	return nil
+}
@@ -8,6 +8,11 @@ type identityRes struct {
 	err error
 }
 
+type keyRes struct {
+	key string
+	err error
+}
+
 type emptyRes struct {
 	err error
 }
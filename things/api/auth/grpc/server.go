@@ -22,6 +22,7 @@ type grpcServer struct {
 	canAccessByKey kitgrpc.Handler
 	canAccessByID  kitgrpc.Handler
 	identify       kitgrpc.Handler
+	key            kitgrpc.Handler
 }
 
 // NewServer returns new ThingsServiceServer instance.
@@ -42,6 +43,11 @@ func NewServer(tracer opentracing.Tracer, svc things.Service) mainflux.ThingsSer
 			decodeIdentifyRequest,
 			encodeIdentityResponse,
 		),
+		key: kitgrpc.NewServer(
+			kitot.TraceServer(tracer, "key")(keyEndpoint(svc)),
+			decodeKeyRequest,
+			encodeKeyResponse,
+		),
 	}
 }
 
@@ -72,6 +78,15 @@ func (gs *grpcServer) Identify(ctx context.Context, req *mainflux.Token) (*mainf
 	return res.(*mainflux.ThingID), nil
 }
 
+func (gs *grpcServer) Key(ctx context.Context, req *mainflux.ThingID) (*mainflux.Token, error) {
+	_, res, err := gs.key.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+
+	return res.(*mainflux.Token), nil
+}
+
 func decodeCanAccessByKeyRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
 	req := grpcReq.(*mainflux.AccessByKeyReq)
 	return AccessByKeyReq{thingKey: req.GetToken(), chanID: req.GetChanID()}, nil
@@ -87,11 +102,21 @@ func decodeIdentifyRequest(_ context.Context, grpcReq interface{}) (interface{},
 	return identifyReq{key: req.GetValue()}, nil
 }
 
+func decodeKeyRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*mainflux.ThingID)
+	return keyReq{id: req.GetValue()}, nil
+}
+
 func encodeIdentityResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
 	res := grpcRes.(identityRes)
 	return &mainflux.ThingID{Value: res.id}, encodeError(res.err)
 }
 
+func encodeKeyResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(keyRes)
+	return &mainflux.Token{Value: res.key}, encodeError(res.err)
+}
+
 func encodeEmptyResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
 	res := grpcRes.(emptyRes)
 	return &empty.Empty{}, encodeError(res.err)
@@ -194,6 +194,15 @@ func (ms *metricsMiddleware) Identify(ctx context.Context, key string) (string,
 	return ms.svc.Identify(ctx, key)
 }
 
+func (ms *metricsMiddleware) Key(ctx context.Context, id string) (string, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "key").Add(1)
+		ms.latency.With("method", "key").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.Key(ctx, id)
+}
+
 func (ms *metricsMiddleware) CreateGroup(ctx context.Context, token string, g groups.Group) (id string, err error) {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "create_group").Add(1)
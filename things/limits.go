@@ -0,0 +1,16 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package things
+
+import "context"
+
+// LimitsRepository specifies the retrieval of things and channels which have
+// publish limits set in their metadata, regardless of their owners.
+type LimitsRepository interface {
+	// RetrieveThings retrieves metadata of the things with limits by their IDs.
+	RetrieveThings(ctx context.Context) (map[string]Metadata, error)
+
+	// RetrieveChannels retrieves metadata of the channels with limits by their IDs.
+	RetrieveChannels(ctx context.Context) (map[string]Metadata, error)
+}
@@ -0,0 +1,34 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package mocks
+
+import (
+	"context"
+
+	"github.com/mainflux/mainflux/things"
+)
+
+var _ things.LimitsRepository = (*limitsRepositoryMock)(nil)
+
+type limitsRepositoryMock struct {
+	things   map[string]things.Metadata
+	channels map[string]things.Metadata
+}
+
+// NewLimitsRepository creates in-memory limits repository holding metadata
+// of the given things and channels.
+func NewLimitsRepository(ths, chs map[string]things.Metadata) things.LimitsRepository {
+	return &limitsRepositoryMock{
+		things:   ths,
+		channels: chs,
+	}
+}
+
+func (lrm *limitsRepositoryMock) RetrieveThings(_ context.Context) (map[string]things.Metadata, error) {
+	return lrm.things, nil
+}
+
+func (lrm *limitsRepositoryMock) RetrieveChannels(_ context.Context) (map[string]things.Metadata, error) {
+	return lrm.channels, nil
+}
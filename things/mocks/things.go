@@ -228,6 +228,19 @@ func (trm *thingRepositoryMock) RetrieveByKey(_ context.Context, key string) (st
 	return "", things.ErrNotFound
 }
 
+func (trm *thingRepositoryMock) RetrieveKey(_ context.Context, id string) (string, error) {
+	trm.mu.Lock()
+	defer trm.mu.Unlock()
+
+	for _, thing := range trm.things {
+		if thing.ID == id {
+			return thing.Key, nil
+		}
+	}
+
+	return "", things.ErrNotFound
+}
+
 func (trm *thingRepositoryMock) connect(conn Connection) {
 	trm.mu.Lock()
 	defer trm.mu.Unlock()
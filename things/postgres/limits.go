@@ -0,0 +1,64 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/mainflux/mainflux/things"
+)
+
+var _ things.LimitsRepository = (*limitsRepository)(nil)
+
+type limitsRepository struct {
+	db Database
+}
+
+// NewLimitsRepository instantiates a PostgreSQL implementation of limits
+// repository.
+func NewLimitsRepository(db Database) things.LimitsRepository {
+	return &limitsRepository{
+		db: db,
+	}
+}
+
+func (lr limitsRepository) RetrieveThings(ctx context.Context) (map[string]things.Metadata, error) {
+	return lr.retrieve(ctx, "things")
+}
+
+func (lr limitsRepository) RetrieveChannels(ctx context.Context) (map[string]things.Metadata, error) {
+	return lr.retrieve(ctx, "channels")
+}
+
+func (lr limitsRepository) retrieve(ctx context.Context, table string) (map[string]things.Metadata, error) {
+	q := fmt.Sprintf(`SELECT id, metadata FROM %s WHERE metadata->'limits' IS NOT NULL;`, table)
+
+	rows, err := lr.db.NamedQueryContext(ctx, q, map[string]interface{}{})
+	if err != nil {
+		return nil, errors.Wrap(things.ErrSelectEntity, err)
+	}
+	defer rows.Close()
+
+	items := make(map[string]things.Metadata)
+	for rows.Next() {
+		var row struct {
+			ID       string `db:"id"`
+			Metadata []byte `db:"metadata"`
+		}
+		if err := rows.StructScan(&row); err != nil {
+			return nil, errors.Wrap(things.ErrSelectEntity, err)
+		}
+
+		var metadata things.Metadata
+		if err := json.Unmarshal(row.Metadata, &metadata); err != nil {
+			return nil, errors.Wrap(things.ErrMalformedEntity, err)
+		}
+		items[row.ID] = metadata
+	}
+
+	return items, nil
+}
@@ -0,0 +1,72 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	uuidProvider "github.com/mainflux/mainflux/pkg/uuid"
+	"github.com/mainflux/mainflux/things"
+	"github.com/mainflux/mainflux/things/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitsRetrieve(t *testing.T) {
+	dbMiddleware := postgres.NewDatabase(db)
+	thingRepo := postgres.NewThingRepository(dbMiddleware)
+	channelRepo := postgres.NewChannelRepository(dbMiddleware)
+	limitsRepo := postgres.NewLimitsRepository(dbMiddleware)
+
+	limited := things.Metadata{"limits": map[string]interface{}{"rate": 5.0}}
+	unlimited := things.Metadata{"test": "test"}
+
+	var ths []things.Thing
+	var chs []things.Channel
+	for i, owner := range []string{"limits-a@example.com", "limits-b@example.com"} {
+		for _, metadata := range []things.Metadata{limited, unlimited} {
+			thid, err := uuidProvider.New().ID()
+			require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+			thkey, err := uuidProvider.New().ID()
+			require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+			ths = append(ths, things.Thing{ID: thid, Owner: owner, Key: thkey, Name: fmt.Sprintf("thing-%d", i), Metadata: metadata})
+
+			chid, err := uuidProvider.New().ID()
+			require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+			chs = append(chs, things.Channel{ID: chid, Owner: owner, Name: fmt.Sprintf("channel-%d", i), Metadata: metadata})
+		}
+	}
+	_, err := thingRepo.Save(context.Background(), ths...)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	_, err = channelRepo.Save(context.Background(), chs...)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	thsMetadata, err := limitsRepo.RetrieveThings(context.Background())
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	chsMetadata, err := limitsRepo.RetrieveChannels(context.Background())
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	for i, th := range ths {
+		desc := "retrieve thing with limits"
+		if i%2 == 1 {
+			desc = "retrieve thing without limits"
+		}
+		_, ok := thsMetadata[th.ID]
+		assert.Equal(t, i%2 == 0, ok, fmt.Sprintf("%s: expected retrieved %t got %t", desc, i%2 == 0, ok))
+		if ok {
+			assert.Equal(t, th.Metadata, thsMetadata[th.ID], fmt.Sprintf("%s: expected %v got %v", desc, th.Metadata, thsMetadata[th.ID]))
+		}
+	}
+
+	for i, ch := range chs {
+		desc := "retrieve channel with limits"
+		if i%2 == 1 {
+			desc = "retrieve channel without limits"
+		}
+		_, ok := chsMetadata[ch.ID]
+		assert.Equal(t, i%2 == 0, ok, fmt.Sprintf("%s: expected retrieved %t got %t", desc, i%2 == 0, ok))
+	}
+}
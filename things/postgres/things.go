@@ -181,6 +181,21 @@ func (tr thingRepository) RetrieveByKey(ctx context.Context, key string) (string
 	return id, nil
 }
 
+func (tr thingRepository) RetrieveKey(ctx context.Context, id string) (string, error) {
+	q := `SELECT key FROM things WHERE id = $1;`
+
+	var key string
+	if err := tr.db.QueryRowxContext(ctx, q, id).Scan(&key); err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if err == sql.ErrNoRows || ok && errInvalid == pqErr.Code.Name() {
+			return "", errors.Wrap(things.ErrNotFound, err)
+		}
+		return "", errors.Wrap(things.ErrSelectEntity, err)
+	}
+
+	return key, nil
+}
+
 func (tr thingRepository) RetrieveAll(ctx context.Context, owner string, pm things.PageMetadata) (things.Page, error) {
 	nq, name := getNameQuery(pm.Name)
 	oq := getOrderQuery(pm.Order)
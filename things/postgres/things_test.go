@@ -368,6 +368,50 @@ func TestThingRetrieveByKey(t *testing.T) {
 	}
 }
 
+func TestThingRetrieveKey(t *testing.T) {
+	email := "thing-retrieved-key@example.com"
+	dbMiddleware := postgres.NewDatabase(db)
+	thingRepo := postgres.NewThingRepository(dbMiddleware)
+
+	id, err := uuidProvider.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	key, err := uuidProvider.New().ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	th := things.Thing{
+		ID:    id,
+		Owner: email,
+		Key:   key,
+	}
+
+	ths, err := thingRepo.Save(context.Background(), th)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	th.ID = ths[0].ID
+
+	cases := map[string]struct {
+		ID  string
+		key string
+		err error
+	}{
+		"retrieve key of existing thing": {
+			ID:  th.ID,
+			key: th.Key,
+			err: nil,
+		},
+		"retrieve key of non-existent thing": {
+			ID:  wrongValue,
+			key: "",
+			err: things.ErrNotFound,
+		},
+	}
+
+	for desc, tc := range cases {
+		key, err := thingRepo.RetrieveKey(context.Background(), tc.ID)
+		assert.Equal(t, tc.key, key, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.key, key))
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
+	}
+}
+
 func TestMultiThingRetrieval(t *testing.T) {
 	dbMiddleware := postgres.NewDatabase(db)
 	thingRepo := postgres.NewThingRepository(dbMiddleware)
@@ -0,0 +1,258 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package redis
+
+import (
+	"context"
+
+	"github.com/mainflux/mainflux/internal/groups"
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/mainflux/mainflux/pkg/limits"
+	"github.com/mainflux/mainflux/things"
+)
+
+var _ things.Service = (*limitsMiddleware)(nil)
+
+type limitsMiddleware struct {
+	svc   things.Service
+	cache limits.Cache
+}
+
+// NewLimitsMiddleware returns wrapper around things service that stores
+// the limits set in things and channels metadata to the limits cache used
+// by the protocol adapters.
+func NewLimitsMiddleware(svc things.Service, cache limits.Cache) things.Service {
+	return limitsMiddleware{
+		svc:   svc,
+		cache: cache,
+	}
+}
+
+// WarmLimits stores the limits of all the things and channels to the limits
+// cache. It's called on startup, since the middleware saves the limits only
+// when things and channels are changed, so that the limits set before the
+// cache was flushed or the middleware was enabled are enforced too. Malformed
+// limits, which could be stored before the limits were validated, are skipped.
+func WarmLimits(ctx context.Context, repo things.LimitsRepository, cache limits.Cache) error {
+	ths, err := repo.RetrieveThings(ctx)
+	if err != nil {
+		return err
+	}
+	if err := saveLimits(cache, limits.Thing, ths); err != nil {
+		return err
+	}
+
+	chs, err := repo.RetrieveChannels(ctx)
+	if err != nil {
+		return err
+	}
+	return saveLimits(cache, limits.Channel, chs)
+}
+
+func saveLimits(cache limits.Cache, kind string, entities map[string]things.Metadata) error {
+	for id, metadata := range entities {
+		l, err := limits.FromMetadata(metadata)
+		if err != nil {
+			continue
+		}
+		if err := cache.Save(kind, id, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (lm limitsMiddleware) CreateThings(ctx context.Context, token string, ths ...things.Thing) ([]things.Thing, error) {
+	for _, thing := range ths {
+		if _, err := limits.FromMetadata(thing.Metadata); err != nil {
+			return nil, errors.Wrap(things.ErrMalformedEntity, err)
+		}
+	}
+
+	sths, err := lm.svc.CreateThings(ctx, token, ths...)
+	if err != nil {
+		return sths, err
+	}
+
+	for _, thing := range sths {
+		l, _ := limits.FromMetadata(thing.Metadata)
+		if err := lm.cache.Save(limits.Thing, thing.ID, l); err != nil {
+			return sths, errors.Wrap(things.ErrCreateEntity, err)
+		}
+	}
+
+	return sths, nil
+}
+
+func (lm limitsMiddleware) UpdateThing(ctx context.Context, token string, thing things.Thing) error {
+	l, err := limits.FromMetadata(thing.Metadata)
+	if err != nil {
+		return errors.Wrap(things.ErrMalformedEntity, err)
+	}
+
+	if err := lm.svc.UpdateThing(ctx, token, thing); err != nil {
+		return err
+	}
+
+	if err := lm.cache.Save(limits.Thing, thing.ID, l); err != nil {
+		return errors.Wrap(things.ErrUpdateEntity, err)
+	}
+	return nil
+}
+
+func (lm limitsMiddleware) RemoveThing(ctx context.Context, token, id string) error {
+	if err := lm.svc.RemoveThing(ctx, token, id); err != nil {
+		return err
+	}
+
+	if err := lm.cache.Remove(limits.Thing, id); err != nil {
+		return errors.Wrap(things.ErrRemoveEntity, err)
+	}
+	return nil
+}
+
+func (lm limitsMiddleware) CreateChannels(ctx context.Context, token string, channels ...things.Channel) ([]things.Channel, error) {
+	for _, channel := range channels {
+		if _, err := limits.FromMetadata(channel.Metadata); err != nil {
+			return nil, errors.Wrap(things.ErrMalformedEntity, err)
+		}
+	}
+
+	schs, err := lm.svc.CreateChannels(ctx, token, channels...)
+	if err != nil {
+		return schs, err
+	}
+
+	for _, channel := range schs {
+		l, _ := limits.FromMetadata(channel.Metadata)
+		if err := lm.cache.Save(limits.Channel, channel.ID, l); err != nil {
+			return schs, errors.Wrap(things.ErrCreateEntity, err)
+		}
+	}
+
+	return schs, nil
+}
+
+func (lm limitsMiddleware) UpdateChannel(ctx context.Context, token string, channel things.Channel) error {
+	l, err := limits.FromMetadata(channel.Metadata)
+	if err != nil {
+		return errors.Wrap(things.ErrMalformedEntity, err)
+	}
+
+	if err := lm.svc.UpdateChannel(ctx, token, channel); err != nil {
+		return err
+	}
+
+	if err := lm.cache.Save(limits.Channel, channel.ID, l); err != nil {
+		return errors.Wrap(things.ErrUpdateEntity, err)
+	}
+	return nil
+}
+
+func (lm limitsMiddleware) RemoveChannel(ctx context.Context, token, id string) error {
+	if err := lm.svc.RemoveChannel(ctx, token, id); err != nil {
+		return err
+	}
+
+	if err := lm.cache.Remove(limits.Channel, id); err != nil {
+		return errors.Wrap(things.ErrRemoveEntity, err)
+	}
+	return nil
+}
+
+func (lm limitsMiddleware) UpdateKey(ctx context.Context, token, id, key string) error {
+	return lm.svc.UpdateKey(ctx, token, id, key)
+}
+
+func (lm limitsMiddleware) ViewThing(ctx context.Context, token, id string) (things.Thing, error) {
+	return lm.svc.ViewThing(ctx, token, id)
+}
+
+func (lm limitsMiddleware) ListThings(ctx context.Context, token string, pm things.PageMetadata) (things.Page, error) {
+	return lm.svc.ListThings(ctx, token, pm)
+}
+
+func (lm limitsMiddleware) ListThingsByChannel(ctx context.Context, token, id string, offset, limit uint64, connected bool) (things.Page, error) {
+	return lm.svc.ListThingsByChannel(ctx, token, id, offset, limit, connected)
+}
+
+func (lm limitsMiddleware) ViewChannel(ctx context.Context, token, id string) (things.Channel, error) {
+	return lm.svc.ViewChannel(ctx, token, id)
+}
+
+func (lm limitsMiddleware) ListChannels(ctx context.Context, token string, pm things.PageMetadata) (things.ChannelsPage, error) {
+	return lm.svc.ListChannels(ctx, token, pm)
+}
+
+func (lm limitsMiddleware) ListChannelsByThing(ctx context.Context, token, id string, offset, limit uint64, connected bool) (things.ChannelsPage, error) {
+	return lm.svc.ListChannelsByThing(ctx, token, id, offset, limit, connected)
+}
+
+func (lm limitsMiddleware) Connect(ctx context.Context, token string, chIDs, thIDs []string) error {
+	return lm.svc.Connect(ctx, token, chIDs, thIDs)
+}
+
+func (lm limitsMiddleware) Disconnect(ctx context.Context, token, chanID, thingID string) error {
+	return lm.svc.Disconnect(ctx, token, chanID, thingID)
+}
+
+func (lm limitsMiddleware) CanAccessByKey(ctx context.Context, chanID string, key string) (string, error) {
+	return lm.svc.CanAccessByKey(ctx, chanID, key)
+}
+
+func (lm limitsMiddleware) CanAccessByID(ctx context.Context, chanID string, thingID string) error {
+	return lm.svc.CanAccessByID(ctx, chanID, thingID)
+}
+
+func (lm limitsMiddleware) Identify(ctx context.Context, key string) (string, error) {
+	return lm.svc.Identify(ctx, key)
+}
+
+func (lm limitsMiddleware) Key(ctx context.Context, id string) (string, error) {
+	return lm.svc.Key(ctx, id)
+}
+
+func (lm limitsMiddleware) CreateGroup(ctx context.Context, token string, g groups.Group) (string, error) {
+	return lm.svc.CreateGroup(ctx, token, g)
+}
+
+func (lm limitsMiddleware) ViewGroup(ctx context.Context, token, id string) (groups.Group, error) {
+	return lm.svc.ViewGroup(ctx, token, id)
+}
+
+func (lm limitsMiddleware) ListGroups(ctx context.Context, token string, level uint64, gm groups.Metadata) (groups.GroupPage, error) {
+	return lm.svc.ListGroups(ctx, token, level, gm)
+}
+
+func (lm limitsMiddleware) ListParents(ctx context.Context, token, childID string, level uint64, gm groups.Metadata) (groups.GroupPage, error) {
+	return lm.svc.ListParents(ctx, token, childID, level, gm)
+}
+
+func (lm limitsMiddleware) ListChildren(ctx context.Context, token, parentID string, level uint64, gm groups.Metadata) (groups.GroupPage, error) {
+	return lm.svc.ListChildren(ctx, token, parentID, level, gm)
+}
+
+func (lm limitsMiddleware) ListMembers(ctx context.Context, token, groupID string, offset, limit uint64, gm groups.Metadata) (groups.MemberPage, error) {
+	return lm.svc.ListMembers(ctx, token, groupID, offset, limit, gm)
+}
+
+func (lm limitsMiddleware) RemoveGroup(ctx context.Context, token, id string) error {
+	return lm.svc.RemoveGroup(ctx, token, id)
+}
+
+func (lm limitsMiddleware) Unassign(ctx context.Context, token, memberID, groupID string) error {
+	return lm.svc.Unassign(ctx, token, memberID, groupID)
+}
+
+func (lm limitsMiddleware) UpdateGroup(ctx context.Context, token string, g groups.Group) (groups.Group, error) {
+	return lm.svc.UpdateGroup(ctx, token, g)
+}
+
+func (lm limitsMiddleware) Assign(ctx context.Context, token, memberID, groupID string) error {
+	return lm.svc.Assign(ctx, token, memberID, groupID)
+}
+
+func (lm limitsMiddleware) ListMemberships(ctx context.Context, token, memberID string, offset, limit uint64, gm groups.Metadata) (groups.GroupPage, error) {
+	return lm.svc.ListMemberships(ctx, token, memberID, offset, limit, gm)
+}
@@ -0,0 +1,140 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package redis_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/mainflux/mainflux/pkg/errors"
+	"github.com/mainflux/mainflux/pkg/limits"
+	"github.com/mainflux/mainflux/things"
+	"github.com/mainflux/mainflux/things/mocks"
+	"github.com/mainflux/mainflux/things/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThingLimits(t *testing.T) {
+	redisClient.FlushAll().Err()
+
+	svc := newService(map[string]string{token: email})
+	svc = redis.NewLimitsMiddleware(svc, limits.NewCache(redisClient))
+
+	ths, err := svc.CreateThings(context.Background(), token, things.Thing{
+		Name:     "a",
+		Metadata: map[string]interface{}{"limits": map[string]interface{}{"rate": 5.0, "daily_quota": 100.0}},
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	th := ths[0]
+	key := fmt.Sprintf("limits:thing:%s", th.ID)
+
+	saved := map[string]string{"rate": "5", "burst": "5", "quota": "100"}
+	assert.Equal(t, saved, redisClient.HGetAll(key).Val(), fmt.Sprintf("create thing with limits: expected %v saved", saved))
+
+	_, err = svc.CreateThings(context.Background(), token, things.Thing{
+		Name:     "b",
+		Metadata: map[string]interface{}{"limits": map[string]interface{}{"rate": -1.0}},
+	})
+	assert.True(t, errors.Contains(err, things.ErrMalformedEntity), fmt.Sprintf("create thing with malformed limits: expected %s got %s", things.ErrMalformedEntity, err))
+
+	cases := []struct {
+		desc   string
+		thing  things.Thing
+		err    error
+		limits map[string]string
+	}{
+		{
+			desc:   "update thing limits",
+			thing:  things.Thing{ID: th.ID, Name: "a", Metadata: map[string]interface{}{"limits": map[string]interface{}{"daily_quota": 10.0}}},
+			err:    nil,
+			limits: map[string]string{"rate": "0", "burst": "0", "quota": "10"},
+		},
+		{
+			desc:   "update thing with malformed limits",
+			thing:  things.Thing{ID: th.ID, Name: "a", Metadata: map[string]interface{}{"limits": "10"}},
+			err:    things.ErrMalformedEntity,
+			limits: map[string]string{"rate": "0", "burst": "0", "quota": "10"},
+		},
+		{
+			desc:   "update thing without limits",
+			thing:  things.Thing{ID: th.ID, Name: "a", Metadata: map[string]interface{}{"test": "test"}},
+			err:    nil,
+			limits: map[string]string{},
+		},
+	}
+
+	for _, tc := range cases {
+		err := svc.UpdateThing(context.Background(), token, tc.thing)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		l := redisClient.HGetAll(key).Val()
+		assert.Equal(t, tc.limits, l, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.limits, l))
+	}
+}
+
+func TestChannelLimits(t *testing.T) {
+	redisClient.FlushAll().Err()
+
+	svc := newService(map[string]string{token: email})
+	svc = redis.NewLimitsMiddleware(svc, limits.NewCache(redisClient))
+
+	chs, err := svc.CreateChannels(context.Background(), token, things.Channel{
+		Name:     "a",
+		Metadata: map[string]interface{}{"limits": map[string]interface{}{"rate": 0.5, "burst": 10.0}},
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	ch := chs[0]
+	key := fmt.Sprintf("limits:channel:%s", ch.ID)
+
+	saved := map[string]string{"rate": "0.5", "burst": "10", "quota": "0"}
+	assert.Equal(t, saved, redisClient.HGetAll(key).Val(), fmt.Sprintf("create channel with limits: expected %v saved", saved))
+
+	err = svc.RemoveChannel(context.Background(), token, ch.ID)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, map[string]string{}, redisClient.HGetAll(key).Val(), "remove channel: expected limits removed")
+}
+
+func TestWarmLimits(t *testing.T) {
+	redisClient.FlushAll().Err()
+
+	repo := mocks.NewLimitsRepository(
+		map[string]things.Metadata{
+			"thing":     {"limits": map[string]interface{}{"rate": 5.0}},
+			"malformed": {"limits": "5"},
+		},
+		map[string]things.Metadata{
+			"channel": {"limits": map[string]interface{}{"daily_quota": 100.0}},
+		},
+	)
+	err := redis.WarmLimits(context.Background(), repo, limits.NewCache(redisClient))
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc   string
+		key    string
+		limits map[string]string
+	}{
+		{
+			desc:   "warm thing limits",
+			key:    "limits:thing:thing",
+			limits: map[string]string{"rate": "5", "burst": "5", "quota": "0"},
+		},
+		{
+			desc:   "warm malformed thing limits",
+			key:    "limits:thing:malformed",
+			limits: map[string]string{},
+		},
+		{
+			desc:   "warm channel limits",
+			key:    "limits:channel:channel",
+			limits: map[string]string{"rate": "0", "burst": "0", "quota": "100"},
+		},
+	}
+
+	for _, tc := range cases {
+		l := redisClient.HGetAll(tc.key).Val()
+		assert.Equal(t, tc.limits, l, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.limits, l))
+	}
+}
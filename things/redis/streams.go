@@ -241,6 +241,10 @@ func (es eventStore) Identify(ctx context.Context, key string) (string, error) {
 	return es.svc.Identify(ctx, key)
 }
 
+func (es eventStore) Key(ctx context.Context, id string) (string, error) {
+	return es.svc.Key(ctx, id)
+}
+
 func (es eventStore) CreateGroup(ctx context.Context, token string, g groups.Group) (string, error) {
 	return es.svc.CreateGroup(ctx, token, g)
 }
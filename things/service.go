@@ -121,6 +121,10 @@ type Service interface {
 	// Identify returns thing ID for given thing key.
 	Identify(ctx context.Context, key string) (string, error)
 
+	// Key returns thing key for given thing ID. It is used by the adapters
+	// which authenticate things using the key as a shared secret.
+	Key(ctx context.Context, id string) (string, error)
+
 	groups.Service
 }
 
@@ -388,6 +392,10 @@ func (ts *thingsService) Identify(ctx context.Context, key string) (string, erro
 	return id, nil
 }
 
+func (ts *thingsService) Key(ctx context.Context, id string) (string, error) {
+	return ts.things.RetrieveKey(ctx, id)
+}
+
 func (ts *thingsService) hasThing(ctx context.Context, chanID, thingKey string) (string, error) {
 	thingID, err := ts.thingCache.ID(ctx, thingKey)
 	if err != nil {
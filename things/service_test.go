@@ -1054,3 +1054,34 @@ func TestIdentify(t *testing.T) {
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
 	}
 }
+
+func TestKey(t *testing.T) {
+	svc := newService(map[string]string{token: email})
+
+	ths, err := svc.CreateThings(context.Background(), token, thing)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	th := ths[0]
+
+	cases := map[string]struct {
+		id  string
+		key string
+		err error
+	}{
+		"retrieve key of existing thing": {
+			id:  th.ID,
+			key: th.Key,
+			err: nil,
+		},
+		"retrieve key of non-existing thing": {
+			id:  wrongID,
+			key: "",
+			err: things.ErrNotFound,
+		},
+	}
+
+	for desc, tc := range cases {
+		key, err := svc.Key(context.Background(), tc.id)
+		assert.Equal(t, tc.key, key, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.key, key))
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
+	}
+}
@@ -17,6 +17,7 @@ const (
 	updateThingKeyOp          = "update_thing_by_key"
 	retrieveThingByIDOp       = "retrieve_thing_by_id"
 	retrieveThingByKeyOp      = "retrieve_thing_by_key"
+	retrieveThingKeyOp        = "retrieve_thing_key"
 	retrieveAllThingsOp       = "retrieve_all_things"
 	retrieveThingsByChannelOp = "retrieve_things_by_chan"
 	removeThingOp             = "remove_thing"
@@ -82,6 +83,14 @@ func (trm thingRepositoryMiddleware) RetrieveByKey(ctx context.Context, key stri
 	return trm.repo.RetrieveByKey(ctx, key)
 }
 
+func (trm thingRepositoryMiddleware) RetrieveKey(ctx context.Context, id string) (string, error) {
+	span := createSpan(ctx, trm.tracer, retrieveThingKeyOp)
+	defer span.Finish()
+	ctx = opentracing.ContextWithSpan(ctx, span)
+
+	return trm.repo.RetrieveKey(ctx, id)
+}
+
 func (trm thingRepositoryMiddleware) RetrieveAll(ctx context.Context, owner string, pm things.PageMetadata) (things.Page, error) {
 	span := createSpan(ctx, trm.tracer, retrieveAllThingsOp)
 	defer span.Finish()
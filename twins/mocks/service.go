@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/memory"
 	"github.com/mainflux/mainflux/pkg/uuid"
 	"github.com/mainflux/mainflux/twins"
 	"github.com/mainflux/senml"
@@ -22,9 +23,7 @@ func NewService(tokens map[string]string) twins.Service {
 	twinCache := NewTwinCache()
 	statesRepo := NewStateRepository()
 	uuidProvider := uuid.NewMock()
-	subs := map[string]string{"chanID": "chanID"}
-	broker := NewBroker(subs)
-	return twins.New(broker, auth, twinsRepo, twinCache, statesRepo, uuidProvider, "chanID", nil)
+	return twins.New(memory.NewPubSub(), auth, twinsRepo, twinCache, statesRepo, uuidProvider, "chanID", nil)
 }
 
 // CreateDefinition creates twin definition
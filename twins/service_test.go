@@ -6,8 +6,13 @@ package twins_test
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"testing"
 
+	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/memory"
+	"github.com/mainflux/mainflux/pkg/uuid"
 	"github.com/mainflux/mainflux/twins"
 	"github.com/mainflux/mainflux/twins/mocks"
 	"github.com/mainflux/senml"
@@ -21,7 +26,7 @@ const (
 	token      = "token"
 	wrongToken = "wrong-token"
 	email      = "user@example.com"
-	natsURL    = "nats://localhost:4222"
+	notifsChan = "notifications"
 	numRecs    = 100
 )
 
@@ -61,6 +66,47 @@ func TestAddTwin(t *testing.T) {
 	}
 }
 
+func TestTwinNotifications(t *testing.T) {
+	l, err := logger.New(ioutil.Discard, "error")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	ps := memory.NewPubSub()
+	var notifs []messaging.Message
+	err = ps.Subscribe(fmt.Sprintf("channels.%s.>", notifsChan), func(msg messaging.Message) error {
+		notifs = append(notifs, msg)
+		return nil
+	})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	auth := mocks.NewAuthNServiceClient(map[string]string{token: email})
+	svc := twins.New(ps, auth, mocks.NewTwinRepository(), mocks.NewTwinCache(), mocks.NewStateRepository(), uuid.NewMock(), notifsChan, l)
+
+	cases := []struct {
+		desc     string
+		token    string
+		subtopic string
+	}{
+		{
+			desc:     "add new twin",
+			token:    token,
+			subtopic: "create.success",
+		},
+		{
+			desc:     "add twin with wrong credentials",
+			token:    wrongToken,
+			subtopic: "create.failure",
+		},
+	}
+
+	for _, tc := range cases {
+		notifs = nil
+		svc.AddTwin(context.Background(), tc.token, twins.Twin{}, twins.Definition{})
+		require.Len(t, notifs, 1, fmt.Sprintf("%s: expected single notification got %d\n", tc.desc, len(notifs)))
+		assert.Equal(t, notifsChan, notifs[0].Channel, fmt.Sprintf("%s: expected channel %s got %s\n", tc.desc, notifsChan, notifs[0].Channel))
+		assert.Equal(t, tc.subtopic, notifs[0].Subtopic, fmt.Sprintf("%s: expected subtopic %s got %s\n", tc.desc, tc.subtopic, notifs[0].Subtopic))
+	}
+}
+
 func TestUpdateTwin(t *testing.T) {
 	svc := mocks.NewService(map[string]string{token: email})
 	twin := twins.Twin{}
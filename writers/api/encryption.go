@@ -0,0 +1,50 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"github.com/mainflux/mainflux/pkg/envelope"
+	"github.com/mainflux/mainflux/pkg/transformers/senml"
+	"github.com/mainflux/mainflux/writers"
+)
+
+var _ writers.MessageRepository = (*encryptionMiddleware)(nil)
+
+type encryptionMiddleware struct {
+	sealer envelope.Sealer
+	repo   writers.MessageRepository
+}
+
+// EncryptionMiddleware returns new message repository which encrypts
+// the string and data values of the messages of the channels with
+// the encryption key before storing them.
+func EncryptionMiddleware(repo writers.MessageRepository, sealer envelope.Sealer) writers.MessageRepository {
+	return &encryptionMiddleware{
+		sealer: sealer,
+		repo:   repo,
+	}
+}
+
+func (em *encryptionMiddleware) Save(msgs ...senml.Message) error {
+	sealed := make([]senml.Message, len(msgs))
+	for i, msg := range msgs {
+		if msg.StringValue != nil {
+			v, err := em.sealer.Seal(msg.Channel, *msg.StringValue)
+			if err != nil {
+				return err
+			}
+			msg.StringValue = &v
+		}
+		if msg.DataValue != nil {
+			v, err := em.sealer.Seal(msg.Channel, *msg.DataValue)
+			if err != nil {
+				return err
+			}
+			msg.DataValue = &v
+		}
+		sealed[i] = msg
+	}
+
+	return em.repo.Save(sealed...)
+}
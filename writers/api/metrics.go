@@ -4,6 +4,7 @@
 package api
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/go-kit/kit/metrics"
@@ -12,18 +13,23 @@ import (
 )
 
 type metricsMiddleware struct {
-	counter metrics.Counter
-	latency metrics.Histogram
-	repo    writers.MessageRepository
+	counter   metrics.Counter
+	latency   metrics.Histogram
+	corrected metrics.Counter
+	repo      writers.MessageRepository
 }
 
 // MetricsMiddleware returns new message repository
-// with Save method wrapped to expose metrics.
-func MetricsMiddleware(repo writers.MessageRepository, counter metrics.Counter, latency metrics.Histogram) writers.MessageRepository {
+// with Save method wrapped to expose metrics. Messages
+// with the device time replaced by the transformer
+// time policy are counted by the corrected counter
+// once they're saved.
+func MetricsMiddleware(repo writers.MessageRepository, counter metrics.Counter, latency metrics.Histogram, corrected metrics.Counter) writers.MessageRepository {
 	return &metricsMiddleware{
-		counter: counter,
-		latency: latency,
-		repo:    repo,
+		counter:   counter,
+		latency:   latency,
+		corrected: corrected,
+		repo:      repo,
 	}
 }
 
@@ -32,5 +38,29 @@ func (mm *metricsMiddleware) Save(msgs ...senml.Message) error {
 		mm.counter.With("method", "handle_message").Add(1)
 		mm.latency.With("method", "handle_message").Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	return mm.repo.Save(msgs...)
+
+	if err := mm.repo.Save(msgs...); err != nil {
+		return err
+	}
+
+	var corrected int
+	for _, msg := range msgs {
+		if msg.DeviceTime != 0 {
+			corrected++
+		}
+	}
+	if corrected > 0 {
+		mm.corrected.Add(float64(corrected))
+	}
+	return nil
+}
+
+// PartitionMetrics exposes the queue group and the partition assignment
+// of the writer replica as the gauge set to 1.
+func PartitionMetrics(gauge metrics.Gauge, queue string, p writers.Partition) {
+	gauge.With(
+		"queue", queue,
+		"partition", strconv.Itoa(p.ID),
+		"partitions", strconv.Itoa(p.Count),
+	).Set(1)
 }
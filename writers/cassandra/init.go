@@ -3,7 +3,11 @@
 
 package cassandra
 
-import "github.com/gocql/gocql"
+import (
+	"fmt"
+
+	"github.com/gocql/gocql"
+)
 
 const table = `CREATE TABLE IF NOT EXISTS messages (
         id uuid,
@@ -20,9 +24,25 @@ const table = `CREATE TABLE IF NOT EXISTS messages (
     	sum double,
     	time double,
     	update_time double,
+        device_time double,
+        headers map<text, text>,
         PRIMARY KEY (channel, time, id)
 	) WITH CLUSTERING ORDER BY (time DESC)`
 
+// Cassandra doesn't support ADD IF NOT EXISTS, so the columns added after
+// the messages table was created are added to the existing tables only if
+// they're missing.
+const (
+	columnQuery = `SELECT column_name FROM system_schema.columns
+        WHERE keyspace_name = ? AND table_name = 'messages' AND column_name = ?`
+	addColumn = `ALTER TABLE messages ADD %s %s`
+)
+
+var addedColumns = [][2]string{
+	{"headers", "map<text, text>"},
+	{"device_time", "double"},
+}
+
 // DBConfig contains Cassandra DB specific parameters.
 type DBConfig struct {
 	Hosts    []string
@@ -52,5 +72,19 @@ func Connect(cfg DBConfig) (*gocql.Session, error) {
 		return nil, err
 	}
 
+	for _, c := range addedColumns {
+		var column string
+		err := session.Query(columnQuery, cfg.Keyspace, c[0]).Scan(&column)
+		if err == nil {
+			continue
+		}
+		if err != gocql.ErrNotFound {
+			return nil, err
+		}
+		if err := session.Query(fmt.Sprintf(addColumn, c[0], c[1])).Exec(); err != nil {
+			return nil, err
+		}
+	}
+
 	return session, nil
 }
@@ -26,14 +26,14 @@ func New(session *gocql.Session) writers.MessageRepository {
 func (cr *cassandraRepository) Save(messages ...senml.Message) error {
 	cql := `INSERT INTO messages (id, channel, subtopic, publisher, protocol,
 			name, unit, value, string_value, bool_value, data_value, sum,
-			time, update_time)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+			time, update_time, device_time, headers)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	id := gocql.TimeUUID()
 
 	for _, msg := range messages {
 		err := cr.session.Query(cql, id, msg.Channel, msg.Subtopic, msg.Publisher,
 			msg.Protocol, msg.Name, msg.Unit, msg.Value, msg.StringValue,
-			msg.BoolValue, msg.DataValue, msg.Sum, msg.Time, msg.UpdateTime).Exec()
+			msg.BoolValue, msg.DataValue, msg.Sum, msg.Time, msg.UpdateTime, msg.DeviceTime, msg.Headers).Exec()
 		if err != nil {
 			return errors.Wrap(errSaveMessage, err)
 		}
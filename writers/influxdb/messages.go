@@ -4,6 +4,7 @@
 package influxdb
 
 import (
+	"encoding/json"
 	"math"
 	"strconv"
 	"time"
@@ -47,6 +48,14 @@ func (repo *influxRepo) Save(messages ...senml.Message) error {
 
 	for _, msg := range messages {
 		tgs, flds := repo.tagsOf(&msg), repo.fieldsOf(&msg)
+		if len(msg.Headers) > 0 {
+			// InfluxDB fields are scalar, so the headers are stored as JSON.
+			headers, err := json.Marshal(msg.Headers)
+			if err != nil {
+				return errors.Wrap(errSaveMessage, err)
+			}
+			flds["headers"] = string(headers)
+		}
 
 		sec, dec := math.Modf(msg.Time)
 		t := time.Unix(int64(sec), int64(dec*(1e9)))
@@ -95,5 +104,9 @@ func (repo *influxRepo) fieldsOf(msg *senml.Message) fields {
 		ret["sum"] = *msg.Sum
 	}
 
+	if msg.DeviceTime != 0 {
+		ret["deviceTime"] = strconv.FormatFloat(msg.DeviceTime, 'f', -1, 64)
+	}
+
 	return ret
 }
@@ -0,0 +1,48 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package mocks
+
+import (
+	"sync"
+
+	"github.com/mainflux/mainflux/writers"
+)
+
+var _ writers.Lease = (*Lease)(nil)
+
+// Lease is the partition lease shared by the replicas in memory.
+type Lease struct {
+	free chan struct{}
+	mu   sync.Mutex
+	lost chan struct{}
+}
+
+// NewLease returns mock of the partition lease shared by the replicas
+// the lease is passed to.
+func NewLease() *Lease {
+	l := &Lease{free: make(chan struct{}, 1)}
+	l.free <- struct{}{}
+	return l
+}
+
+// Acquire blocks until the lease is free.
+func (l *Lease) Acquire() (<-chan struct{}, error) {
+	<-l.free
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lost = make(chan struct{})
+	return l.lost, nil
+}
+
+// Release takes the lease from its owner, so that another
+// replica is able to acquire it.
+func (l *Lease) Release() {
+	l.mu.Lock()
+	close(l.lost)
+	l.mu.Unlock()
+
+	l.free <- struct{}{}
+}
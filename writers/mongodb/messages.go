@@ -25,19 +25,21 @@ type mongoRepo struct {
 
 // Message struct is used as a MongoDB representation of Mainflux message.
 type message struct {
-	Channel     string   `bson:"channel,omitempty"`
-	Subtopic    string   `bson:"subtopic,omitempty"`
-	Publisher   string   `bson:"publisher,omitempty"`
-	Protocol    string   `bson:"protocol,omitempty"`
-	Name        string   `bson:"name,omitempty"`
-	Unit        string   `bson:"unit,omitempty"`
-	Value       *float64 `bson:"value,omitempty"`
-	StringValue *string  `bson:"stringValue,omitempty"`
-	BoolValue   *bool    `bson:"boolValue,omitempty"`
-	DataValue   *string  `bson:"dataValue,omitempty"`
-	Sum         *float64 `bson:"sum,omitempty"`
-	Time        float64  `bson:"time,omitempty"`
-	UpdateTime  float64  `bson:"updateTime,omitempty"`
+	Channel     string            `bson:"channel,omitempty"`
+	Subtopic    string            `bson:"subtopic,omitempty"`
+	Publisher   string            `bson:"publisher,omitempty"`
+	Protocol    string            `bson:"protocol,omitempty"`
+	Name        string            `bson:"name,omitempty"`
+	Unit        string            `bson:"unit,omitempty"`
+	Value       *float64          `bson:"value,omitempty"`
+	StringValue *string           `bson:"stringValue,omitempty"`
+	BoolValue   *bool             `bson:"boolValue,omitempty"`
+	DataValue   *string           `bson:"dataValue,omitempty"`
+	Sum         *float64          `bson:"sum,omitempty"`
+	Time        float64           `bson:"time,omitempty"`
+	UpdateTime  float64           `bson:"updateTime,omitempty"`
+	DeviceTime  float64           `bson:"deviceTime,omitempty"`
+	Headers     map[string]string `bson:"headers,omitempty"`
 }
 
 // New returns new MongoDB writer.
@@ -58,6 +60,8 @@ func (repo *mongoRepo) Save(messages ...senml.Message) error {
 			Unit:       msg.Unit,
 			Time:       msg.Time,
 			UpdateTime: msg.UpdateTime,
+			DeviceTime: msg.DeviceTime,
+			Headers:    msg.Headers,
 		}
 
 		switch {
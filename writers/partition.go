@@ -0,0 +1,79 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package writers
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/mainflux/mainflux/pkg/errors"
+)
+
+var (
+	// ErrInvalidPartition indicates that the partition is out of range.
+	ErrInvalidPartition = errors.New("invalid partition")
+
+	// ErrNoLease indicates that the partition has no lease.
+	ErrNoLease = errors.New("partition lease not set")
+)
+
+// Lease represents the ownership of the partition shared by the replicas
+// of the partition, which is owned by a single replica at a time.
+type Lease interface {
+	// Acquire blocks until the partition is owned by the replica. The
+	// returned channel is closed once the ownership is lost.
+	Acquire() (<-chan struct{}, error)
+}
+
+// Partition represents the share of the channels stored by the writer
+// replica. Channels are assigned to the partitions by the channel ID
+// hash, so the messages of the channel are always stored by the same
+// partition. Messages are stored in the order they are received only by
+// the replica which owns the partition lease, while the other replicas of
+// the partition stand by and take over once the lease is released. Since
+// partitions are not mapped to subjects, the partition owner receives the
+// messages of all the channels and skips the ones of other partitions.
+type Partition struct {
+	// ID is the partition of the replica, from 0 to Count - 1.
+	ID int
+
+	// Count is the number of partitions channels are split into.
+	// Partitioning is disabled if the count is 0 or 1.
+	Count int
+
+	// Lease is the ownership of the partition, required if the
+	// partitioning is enabled.
+	Lease Lease
+}
+
+// Validate returns an error if partition is not valid.
+func (p Partition) Validate() error {
+	if p.Count < 0 || p.ID < 0 || (p.Count > 1 && p.ID >= p.Count) || (p.Count <= 1 && p.ID != 0) {
+		return ErrInvalidPartition
+	}
+	return nil
+}
+
+// Queue returns the queue group the writer replica joins. Replicas of the
+// same writer kind share the queue group, so each message is stored once,
+// regardless of the number of replicas. Replicas of the same partition
+// share the queue group too, but since only the partition owner
+// subscribes, it only prevents storing the messages twice while the
+// replica which lost the lease unsubscribes.
+func (p Partition) Queue(svcName string) string {
+	if p.Count <= 1 {
+		return svcName
+	}
+	return fmt.Sprintf("%s-%d", svcName, p.ID)
+}
+
+// Owns returns true if the channel is assigned to the partition.
+func (p Partition) Owns(chanID string) bool {
+	if p.Count <= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(chanID))
+	return int(h.Sum32()%uint32(p.Count)) == p.ID
+}
@@ -5,6 +5,7 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/gofrs/uuid"
 	"github.com/jmoiron/sqlx"
@@ -38,10 +39,10 @@ func New(db *sqlx.DB) writers.MessageRepository {
 func (pr postgresRepo) Save(messages ...senml.Message) (err error) {
 	q := `INSERT INTO messages (id, channel, subtopic, publisher, protocol,
     name, unit, value, string_value, bool_value, data_value, sum,
-    time, update_time)
+    time, update_time, device_time, headers)
     VALUES (:id, :channel, :subtopic, :publisher, :protocol, :name, :unit,
     :value, :string_value, :bool_value, :data_value, :sum,
-    :time, :update_time);`
+    :time, :update_time, :device_time, :headers);`
 
 	tx, err := pr.db.BeginTxx(context.Background(), nil)
 	if err != nil {
@@ -97,6 +98,8 @@ type dbMessage struct {
 	Sum         *float64 `db:"sum"`
 	Time        float64  `db:"time"`
 	UpdateTime  float64  `db:"update_time"`
+	DeviceTime  *float64 `db:"device_time"`
+	Headers     []byte   `db:"headers"`
 }
 
 func toDBMessage(msg senml.Message) (dbMessage, error) {
@@ -118,6 +121,18 @@ func toDBMessage(msg senml.Message) (dbMessage, error) {
 		Sum:        msg.Sum,
 	}
 
+	if msg.DeviceTime != 0 {
+		m.DeviceTime = &msg.DeviceTime
+	}
+
+	if len(msg.Headers) > 0 {
+		headers, err := json.Marshal(msg.Headers)
+		if err != nil {
+			return dbMessage{}, err
+		}
+		m.Headers = headers
+	}
+
 	switch {
 	case msg.Value != nil:
 		m.Value = msg.Value
@@ -25,16 +25,27 @@ var (
 type consumer struct {
 	repo        MessageRepository
 	transformer transformers.Transformer
+	partition   Partition
+	headers     []string
 	logger      logger.Logger
 }
 
 // Start method starts consuming messages received from NATS.
 // This method transforms messages to SenML format before
-// using MessageRepository to store them.
-func Start(sub messaging.Subscriber, repo MessageRepository, transformer transformers.Transformer, queue string, subjectsCfgPath string, logger logger.Logger) error {
+// using MessageRepository to store them. If partitioning is
+// enabled, messages are consumed only while the replica owns
+// the partition lease, and the messages of the channels not
+// assigned to the partition are skipped. Only the message
+// headers with the given names are stored.
+func Start(sub messaging.Subscriber, repo MessageRepository, transformer transformers.Transformer, partition Partition, headers []string, subjectsCfgPath string, logger logger.Logger) error {
+	if err := partition.Validate(); err != nil {
+		return err
+	}
 	c := consumer{
 		repo:        repo,
 		transformer: transformer,
+		partition:   partition,
+		headers:     headers,
 		logger:      logger,
 	}
 
@@ -43,6 +54,40 @@ func Start(sub messaging.Subscriber, repo MessageRepository, transformer transfo
 		logger.Warn(fmt.Sprintf("Failed to load subjects: %s", err))
 	}
 
+	if partition.Count <= 1 {
+		return c.subscribe(sub, subjects)
+	}
+	if partition.Lease == nil {
+		return ErrNoLease
+	}
+	go c.own(sub, subjects)
+
+	return nil
+}
+
+// own consumes messages while the replica owns the partition,
+// waiting for the partition to be released by the other replica
+// whenever the ownership is lost.
+func (c *consumer) own(sub messaging.Subscriber, subjects []string) {
+	for {
+		lost, err := c.partition.Lease.Acquire()
+		if err != nil {
+			c.logger.Error(fmt.Sprintf("Failed to acquire partition %d: %s", c.partition.ID, err))
+			return
+		}
+		c.logger.Info(fmt.Sprintf("Acquired partition %d of %d", c.partition.ID, c.partition.Count))
+
+		if err := c.subscribe(sub, subjects); err != nil {
+			c.logger.Error(fmt.Sprintf("Failed to subscribe to partition %d: %s", c.partition.ID, err))
+		}
+		<-lost
+
+		c.logger.Warn(fmt.Sprintf("Lost partition %d of %d", c.partition.ID, c.partition.Count))
+		c.unsubscribe(sub, subjects)
+	}
+}
+
+func (c *consumer) subscribe(sub messaging.Subscriber, subjects []string) error {
 	for _, subject := range subjects {
 		if err := sub.Subscribe(subject, c.handler); err != nil {
 			return err
@@ -51,7 +96,19 @@ func Start(sub messaging.Subscriber, repo MessageRepository, transformer transfo
 	return nil
 }
 
+func (c *consumer) unsubscribe(sub messaging.Subscriber, subjects []string) {
+	for _, subject := range subjects {
+		if err := sub.Unsubscribe(subject); err != nil {
+			c.logger.Warn(fmt.Sprintf("Failed to unsubscribe from %s: %s", subject, err))
+		}
+	}
+}
+
 func (c *consumer) handler(msg messaging.Message) error {
+	if !c.partition.Owns(msg.Channel) {
+		return nil
+	}
+	msg.Headers = messaging.SelectHeaders(msg.Headers, c.headers)
 	t, err := c.transformer.Transform(msg)
 	if err != nil {
 		return err
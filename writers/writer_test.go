@@ -0,0 +1,298 @@
+// Copyright (c) Mainflux
+// SPDX-License-Identifier: Apache-2.0
+
+package writers_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/mainflux/mainflux/logger"
+	"github.com/mainflux/mainflux/pkg/messaging"
+	"github.com/mainflux/mainflux/pkg/messaging/memory"
+	"github.com/mainflux/mainflux/pkg/transformers/senml"
+	"github.com/mainflux/mainflux/writers"
+	"github.com/mainflux/mainflux/writers/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	svcName  = "test-writer"
+	chansNum = 20
+	timeout  = 5 * time.Second
+	tick     = 10 * time.Millisecond
+)
+
+var payload = []byte(`[{"bn":"base-name","n":"temperature","v":17}]`)
+
+type repo struct {
+	mu       sync.Mutex
+	channels map[string]int
+	headers  []map[string]string
+}
+
+func (r *repo) Save(msgs ...senml.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, msg := range msgs {
+		r.channels[msg.Channel]++
+		r.headers = append(r.headers, msg.Headers)
+	}
+	return nil
+}
+
+// subscriber counts the active subscriptions of the writer replica.
+type subscriber struct {
+	messaging.PubSub
+	mu   sync.Mutex
+	subs int
+}
+
+func (s *subscriber) Subscribe(topic string, handler messaging.MessageHandler) error {
+	if err := s.PubSub.Subscribe(topic, handler); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs++
+	return nil
+}
+
+func (s *subscriber) Unsubscribe(topic string) error {
+	if err := s.PubSub.Unsubscribe(topic); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs--
+	return nil
+}
+
+func (s *subscriber) active() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.subs > 0
+}
+
+func activeReplicas(subs []*subscriber) int {
+	n := 0
+	for _, s := range subs {
+		if s.active() {
+			n++
+		}
+	}
+	return n
+}
+
+func TestStart(t *testing.T) {
+	logger, err := logger.New(ioutil.Discard, "error")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc       string
+		partitions int
+		replicas   int
+	}{
+		{
+			desc:       "store messages with partitioning disabled",
+			partitions: 1,
+			replicas:   2,
+		},
+		{
+			desc:       "store messages of partitioned channels",
+			partitions: 2,
+			replicas:   2,
+		},
+		{
+			desc:       "store messages of partitioned channels with two replicas per partition",
+			partitions: 2,
+			replicas:   4,
+		},
+	}
+
+	for _, tc := range cases {
+		broker := memory.NewBroker()
+		leases := make([]*mocks.Lease, tc.partitions)
+		for i := range leases {
+			leases[i] = mocks.NewLease()
+		}
+		repos := make([]*repo, tc.replicas)
+		subs := make([]*subscriber, tc.replicas)
+		for i := range repos {
+			partition := writers.Partition{ID: i % tc.partitions, Count: tc.partitions, Lease: leases[i%tc.partitions]}
+			repos[i] = &repo{channels: make(map[string]int)}
+			subs[i] = &subscriber{PubSub: broker.Connect(partition.Queue(svcName))}
+			err := writers.Start(subs[i], repos[i], senml.New(senml.JSON), partition, []string{messaging.HeaderCorrelationID}, "", logger)
+			require.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+		}
+
+		// Partitions are owned by a single replica each.
+		active := tc.replicas
+		if tc.partitions > 1 {
+			active = tc.partitions
+		}
+		assert.Eventually(t, func() bool { return activeReplicas(subs) == active }, timeout, tick, fmt.Sprintf("%s: expected %d active replicas", tc.desc, active))
+		time.Sleep(10 * tick)
+		assert.Equal(t, active, activeReplicas(subs), fmt.Sprintf("%s: expected %d active replicas got %d", tc.desc, active, activeReplicas(subs)))
+
+		pub := broker.Connect("")
+		for i := 0; i < chansNum; i++ {
+			id, err := uuid.NewV4()
+			require.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+			msg := messaging.Message{Channel: id.String(), Payload: payload}
+			err = pub.Publish(msg.Channel, msg)
+			require.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+		}
+
+		stored := make(map[string]int)
+		partitions := make(map[string]int)
+		for i, r := range repos {
+			for ch, n := range r.channels {
+				stored[ch] += n
+				p, ok := partitions[ch]
+				assert.False(t, ok && p != i%tc.partitions, fmt.Sprintf("%s: expected channel %s to be stored by a single partition", tc.desc, ch))
+				partitions[ch] = i % tc.partitions
+			}
+		}
+		assert.Len(t, stored, chansNum, fmt.Sprintf("%s: expected %d channels got %d", tc.desc, chansNum, len(stored)))
+		for ch, n := range stored {
+			assert.Equal(t, 1, n, fmt.Sprintf("%s: expected message of channel %s to be stored once got %d", tc.desc, ch, n))
+		}
+	}
+}
+
+func TestStartFailover(t *testing.T) {
+	logger, err := logger.New(ioutil.Discard, "error")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	broker := memory.NewBroker()
+	partition := writers.Partition{ID: 0, Count: 2}
+	err = writers.Start(broker.Connect(partition.Queue(svcName)), &repo{channels: make(map[string]int)}, senml.New(senml.JSON), partition, nil, "", logger)
+	assert.Equal(t, writers.ErrNoLease, err, fmt.Sprintf("start partition without lease: expected %s got %s", writers.ErrNoLease, err))
+
+	lease := mocks.NewLease()
+	partition.Lease = lease
+	repos := []*repo{{channels: make(map[string]int)}, {channels: make(map[string]int)}}
+	subs := make([]*subscriber, len(repos))
+	for i := range repos {
+		subs[i] = &subscriber{PubSub: broker.Connect(partition.Queue(svcName))}
+		err := writers.Start(subs[i], repos[i], senml.New(senml.JSON), partition, nil, "", logger)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	}
+	require.Eventually(t, func() bool { return activeReplicas(subs) == 1 }, timeout, tick, "expected single active replica")
+
+	owner, standby := 0, 1
+	if subs[standby].active() {
+		owner, standby = standby, owner
+	}
+
+	var chanID string
+	for chanID == "" || !partition.Owns(chanID) {
+		id, err := uuid.NewV4()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		chanID = id.String()
+	}
+	pub := broker.Connect("")
+	msg := messaging.Message{Channel: chanID, Payload: payload}
+
+	err = pub.Publish(msg.Channel, msg)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.Equal(t, 1, repos[owner].channels[chanID], "store message: expected message to be stored by partition owner")
+	assert.Equal(t, 0, repos[standby].channels[chanID], "store message: expected message not to be stored by standby replica")
+
+	lease.Release()
+	require.Eventually(t, func() bool { return subs[standby].active() && !subs[owner].active() }, timeout, tick, "release partition: expected standby replica to take over")
+
+	err = pub.Publish(msg.Channel, msg)
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.Equal(t, 1, repos[owner].channels[chanID], "store message after failover: expected message not to be stored by former owner")
+	assert.Equal(t, 1, repos[standby].channels[chanID], "store message after failover: expected message to be stored by new owner")
+}
+
+func TestStartHeaders(t *testing.T) {
+	logger, err := logger.New(ioutil.Discard, "error")
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc     string
+		names    []string
+		headers  map[string]string
+		expected map[string]string
+	}{
+		{
+			desc:     "store selected message headers",
+			names:    []string{messaging.HeaderCorrelationID, messaging.HeaderReplyTo},
+			headers:  map[string]string{messaging.HeaderCorrelationID: "42", "uber-trace-id": "trace"},
+			expected: map[string]string{messaging.HeaderCorrelationID: "42"},
+		},
+		{
+			desc:     "store message without selected headers",
+			names:    []string{messaging.HeaderCorrelationID},
+			headers:  map[string]string{"uber-trace-id": "trace"},
+			expected: nil,
+		},
+		{
+			desc:     "store message with header selection disabled",
+			names:    nil,
+			headers:  map[string]string{messaging.HeaderCorrelationID: "42"},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		broker := memory.NewBroker()
+		r := &repo{channels: make(map[string]int)}
+		err := writers.Start(broker.Connect(""), r, senml.New(senml.JSON), writers.Partition{}, tc.names, "", logger)
+		require.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+
+		msg := messaging.Message{Channel: "1", Payload: payload, Headers: tc.headers}
+		err = broker.Connect("").Publish(msg.Channel, msg)
+		require.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+		require.Len(t, r.headers, 1, fmt.Sprintf("%s: expected message to be stored", tc.desc))
+		assert.Equal(t, tc.expected, r.headers[0], fmt.Sprintf("%s: expected headers %v got %v", tc.desc, tc.expected, r.headers[0]))
+	}
+}
+
+func TestPartitionValidate(t *testing.T) {
+	cases := []struct {
+		desc      string
+		partition writers.Partition
+		err       error
+	}{
+		{
+			desc:      "validate disabled partitioning",
+			partition: writers.Partition{},
+			err:       nil,
+		},
+		{
+			desc:      "validate partition in range",
+			partition: writers.Partition{ID: 3, Count: 4},
+			err:       nil,
+		},
+		{
+			desc:      "validate partition out of range",
+			partition: writers.Partition{ID: 4, Count: 4},
+			err:       writers.ErrInvalidPartition,
+		},
+		{
+			desc:      "validate negative partition",
+			partition: writers.Partition{ID: -1, Count: 4},
+			err:       writers.ErrInvalidPartition,
+		},
+		{
+			desc:      "validate partition with partitioning disabled",
+			partition: writers.Partition{ID: 1, Count: 1},
+			err:       writers.ErrInvalidPartition,
+		},
+	}
+
+	for _, tc := range cases {
+		err := tc.partition.Validate()
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+	}
+}